@@ -0,0 +1,134 @@
+// Package anonymize masks the values of a dump with fake data derived from a
+// secret: the same input always gives the same fake output for the same
+// secret, across tables and runs, so anonymized datasets keep joining on the
+// masked columns.
+//
+//	a := anonymize.New([]byte(os.Getenv("MASK_SECRET")))
+//	data.Masks = map[string]mysqldump.Masker{
+//		"*.email":      a.Email(),
+//		"users.name":   a.Name(),
+//		"orders.phone": a.Phone(),
+//	}
+//
+// Different inputs may still get the same fake value. Wrap the maskers of key
+// columns in a mysqldump.MaskMapping, shared by all columns with the same keys,
+// to keep unique indexes and joins intact.
+//
+// The fake values also depend on the gofakeit version, upgrading it changes
+// them.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jamf/go-mysqldump"
+)
+
+// Anonymizer derives the fake values from its secret.
+type Anonymizer struct {
+	secret []byte
+}
+
+// New returns an Anonymizer keyed by secret.
+func New(secret []byte) *Anonymizer {
+	return &Anonymizer{secret: append([]byte(nil), secret...)}
+}
+
+// Masker returns a masker calling fake with a faker seeded by the value and
+// kind. Values masked with the same kind get the same fake value wherever they
+// are, different kinds keep equal inputs from being linked.
+func (a *Anonymizer) Masker(kind string, fake func(f *gofakeit.Faker) string) mysqldump.Masker {
+	return mysqldump.MaskFunc(func(value string) string {
+		return fake(a.faker(kind, value))
+	})
+}
+
+// faker returns a faker seeded with the HMAC of kind and value
+func (a *Anonymizer) faker(kind, value string) *gofakeit.Faker {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	sum := mac.Sum(nil)
+	return gofakeit.NewCustom(&source{state: binary.BigEndian.Uint64(sum)})
+}
+
+// Email masks email addresses.
+func (a *Anonymizer) Email() mysqldump.Masker {
+	return a.Masker("email", (*gofakeit.Faker).Email)
+}
+
+// Name masks full names.
+func (a *Anonymizer) Name() mysqldump.Masker {
+	return a.Masker("name", (*gofakeit.Faker).Name)
+}
+
+// FirstName masks first names.
+func (a *Anonymizer) FirstName() mysqldump.Masker {
+	return a.Masker("first_name", (*gofakeit.Faker).FirstName)
+}
+
+// LastName masks last names.
+func (a *Anonymizer) LastName() mysqldump.Masker {
+	return a.Masker("last_name", (*gofakeit.Faker).LastName)
+}
+
+// Username masks user names.
+func (a *Anonymizer) Username() mysqldump.Masker {
+	return a.Masker("username", (*gofakeit.Faker).Username)
+}
+
+// Phone masks phone numbers.
+func (a *Anonymizer) Phone() mysqldump.Masker {
+	return a.Masker("phone", (*gofakeit.Faker).Phone)
+}
+
+// Street masks street addresses.
+func (a *Anonymizer) Street() mysqldump.Masker {
+	return a.Masker("street", (*gofakeit.Faker).Street)
+}
+
+// City masks city names.
+func (a *Anonymizer) City() mysqldump.Masker {
+	return a.Masker("city", (*gofakeit.Faker).City)
+}
+
+// Company masks company names.
+func (a *Anonymizer) Company() mysqldump.Masker {
+	return a.Masker("company", (*gofakeit.Faker).Company)
+}
+
+// IPv4 masks IPv4 addresses.
+func (a *Anonymizer) IPv4() mysqldump.Masker {
+	return a.Masker("ipv4", (*gofakeit.Faker).IPv4Address)
+}
+
+// UUID masks UUIDs.
+func (a *Anonymizer) UUID() mysqldump.Masker {
+	return a.Masker("uuid", (*gofakeit.Faker).UUID)
+}
+
+// source is a splitmix64 generator, cheap to seed for every value unlike the
+// source of math/rand
+type source struct {
+	state uint64
+}
+
+func (s *source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
@@ -0,0 +1,58 @@
+package anonymize_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/jamf/go-mysqldump/anonymize"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministic(t *testing.T) {
+	a := anonymize.New([]byte("secret"))
+	email := a.Email().Mask("jane@example.com")
+
+	assert.NotEqual(t, "jane@example.com", email)
+	assert.Contains(t, email, "@")
+	assert.Equal(t, email, anonymize.New([]byte("secret")).Email().Mask("jane@example.com"))
+	assert.NotEqual(t, email, a.Email().Mask("john@example.com"))
+	assert.NotEqual(t, email, anonymize.New([]byte("other")).Email().Mask("jane@example.com"))
+	assert.NotEqual(t, a.FirstName().Mask("Jane"), a.LastName().Mask("Jane"))
+}
+
+func TestDumpMasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	a := anonymize.New([]byte("secret"))
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &buf,
+		Masks: map[string]mysqldump.Masker{
+			"*.email": a.Email(),
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("users", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `users`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("users", "CREATE TABLE `users` (`id` int, `email` varchar(255))"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `users`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `users`$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", 0).Nullable(true),
+		sqlmock.NewColumn("email").OfType("VARCHAR", "").Nullable(true),
+	).AddRow(1, "jane@example.com").AddRow(2, nil))
+	mock.ExpectRollback()
+
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	masked := a.Email().Mask("jane@example.com")
+	assert.Contains(t, buf.String(), "VALUES (1,'"+strings.Replace(masked, "'", "\\'", -1)+"'),(2,NULL);")
+	assert.NotContains(t, buf.String(), "jane@example.com")
+}
@@ -0,0 +1,19 @@
+module github.com/jamf/go-mysqldump/anonymize
+
+go 1.22
+
+replace github.com/jamf/go-mysqldump => ../
+
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
+	github.com/brianvoe/gofakeit/v6 v6.28.0
+	github.com/jamf/go-mysqldump v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
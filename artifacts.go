@@ -0,0 +1,35 @@
+package mysqldump
+
+import "strings"
+
+// artifactSuffixes end the names of the tables online schema change tools
+// work on: _t_new and _t_old of pt-online-schema-change and _t_gho, _t_ghc
+// and _t_del of gh-ost
+var artifactSuffixes = []string{"_new", "_old", "_gho", "_ghc", "_del"}
+
+// isToolArtifact reports whether the table is left by an online schema change
+// in progress or an interrupted ALTER TABLE, like #sql-1a2b_3 on MySQL or
+// #sql2-1a2b-3 on older versions
+func isToolArtifact(name string) bool {
+	if strings.HasPrefix(name, "#sql") {
+		return true
+	}
+	if !strings.HasPrefix(name, "_") {
+		return false
+	}
+	for _, suffix := range artifactSuffixes {
+		if len(name) > len("_")+len(suffix) && strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSkippedArtifact reports whether the table is left out by SkipToolArtifacts
+func (data *Data) isSkippedArtifact(name string) bool {
+	if !data.SkipToolArtifacts || !isToolArtifact(name) {
+		return false
+	}
+	data.warn("table " + name + " skipped, it looks like the work table of an online schema change")
+	return true
+}
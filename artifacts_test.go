@@ -0,0 +1,57 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsToolArtifact(t *testing.T) {
+	for _, name := range []string{"_users_new", "_users_old", "_users_gho", "_users_ghc", "_users_del", "_users_20240101120000_del", "#sql-1a2b_3", "#sql2-1a2b-3", "#sql-ib1234"} {
+		assert.True(t, isToolArtifact(name), name)
+	}
+	for _, name := range []string{"users", "users_new", "_new", "_users", "news", "sql-users"} {
+		assert.False(t, isToolArtifact(name), name)
+	}
+}
+
+func artifactTables(t *testing.T, configure func(*Data)) ([]string, []string) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	configure(data)
+
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("users", "BASE TABLE").
+		AddRow("_users_new", "BASE TABLE").
+		AddRow("_orders_gho", "BASE TABLE").
+		AddRow("#sql-1a2b_3", "BASE TABLE"))
+
+	result, err := data.getTables()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	names := []string{}
+	for _, table := range result {
+		names = append(names, table.Name)
+	}
+	return names, data.warnings
+}
+
+func TestGetTablesSkipToolArtifacts(t *testing.T) {
+	names, warnings := artifactTables(t, func(data *Data) {})
+	assert.Equal(t, []string{"users", "_users_new", "_orders_gho", "#sql-1a2b_3"}, names)
+	assert.Empty(t, warnings)
+
+	names, warnings = artifactTables(t, func(data *Data) { data.SkipToolArtifacts = true })
+	assert.Equal(t, []string{"users"}, names)
+	assert.Len(t, warnings, 3)
+	assert.Contains(t, warnings[0], "_users_new")
+
+	// Tables listed in IncludeTables are dumped anyway
+	names, _ = artifactTables(t, func(data *Data) {
+		data.SkipToolArtifacts = true
+		data.IncludeTables = []string{"users", "_users_new"}
+	})
+	assert.Equal(t, []string{"users", "_users_new"}, names)
+}
@@ -0,0 +1,59 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBarrierTimeout is the error of a dump whose Barrier did not return
+// within BarrierTimeout.
+var ErrBarrierTimeout = errors.New("barrier did not return in time")
+
+// defaultBarrierTimeout bounds the Barrier when BarrierTimeout is not set
+const defaultBarrierTimeout = 30 * time.Second
+
+// barrierError is the failure of the Barrier of a dump
+type barrierError struct {
+	err error
+}
+
+func (e *barrierError) Error() string {
+	return "barrier: " + e.err.Error()
+}
+
+func (e *barrierError) Unwrap() error {
+	return e.err
+}
+
+// runBarrier calls the Barrier, if any, once the snapshot is taken and before
+// any row is read. The dump fails without waiting any longer for a Barrier
+// that outlives its timeout, the context of the Barrier is canceled by then.
+func (data *Data) runBarrier() error {
+	if data.Barrier == nil {
+		return nil
+	}
+	timeout := data.BarrierTimeout
+	if timeout <= 0 {
+		timeout = defaultBarrierTimeout
+	}
+	ctx, cancel := context.WithTimeout(data.context(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- data.Barrier(ctx, data.binlog)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return &barrierError{err}
+		}
+		return nil
+	case <-ctx.Done():
+		if err := data.checkContext(); err != nil {
+			return err
+		}
+		return &barrierError{ErrBarrierTimeout}
+	}
+}
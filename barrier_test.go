@@ -0,0 +1,107 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockBarrierBegin expects the start of a dump with a Barrier, which takes the
+// snapshot with the transaction
+func mockBarrierBegin(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Version()", "")).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).AddRow("Test_Table", "BASE TABLE"))
+}
+
+func TestDumpBarrier(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockBarrierBegin(mock)
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Table", ""), c("Create Table", "")).
+			AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int(11) NOT NULL)"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(mockColumnRows())
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).AddRow(1, nil, "Test Name 1"))
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var buf bytes.Buffer
+	called := false
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &buf,
+		Barrier: func(ctx context.Context, binlog *mysqldump.BinlogCoordinates) error {
+			called = true
+			assert.Nil(t, binlog)
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			// Nothing but the tables has been read yet
+			assert.NotContains(t, buf.String(), "CREATE TABLE")
+			return nil
+		},
+	}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.True(t, called)
+	assert.Contains(t, buf.String(), "INSERT INTO `Test_Table`")
+}
+
+func TestDumpBarrierFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockBarrierBegin(mock)
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	failure := errors.New("cache flush failed")
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &bytes.Buffer{},
+		Barrier: func(context.Context, *mysqldump.BinlogCoordinates) error {
+			return failure
+		},
+	}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, failure), "unexpected error %v", err)
+	assert.Equal(t, mysqldump.CauseBarrier, mysqldump.CauseOf(err))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpBarrierTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockBarrierBegin(mock)
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	release := make(chan struct{})
+	defer close(release)
+	data := &mysqldump.Data{
+		Connection:     db,
+		Out:            &bytes.Buffer{},
+		BarrierTimeout: 10 * time.Millisecond,
+		Barrier: func(context.Context, *mysqldump.BinlogCoordinates) error {
+			// Ignores its context
+			<-release
+			return nil
+		},
+	}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, mysqldump.ErrBarrierTimeout), "unexpected error %v", err)
+	assert.Equal(t, mysqldump.CauseBarrier, mysqldump.CauseOf(err))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
@@ -0,0 +1,62 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// isBinaryType reports whether the database type name is one of a binary
+// string, whose bytes are dumped as they are whatever the driver scans them
+// into. The trailing zero bytes MySQL pads BINARY(n) values with are part of
+// the value, a key compared against them would no longer match without.
+func isBinaryType(name string) bool {
+	switch name {
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return true
+	}
+	return false
+}
+
+// binaryScanner scans a binary value into its raw bytes, nil for NULL only.
+// database/sql leaves an empty value nil when scanning into sql.RawBytes
+// directly, which would dump an empty string as NULL.
+type binaryScanner struct {
+	b *sql.RawBytes
+}
+
+func (s binaryScanner) Scan(src interface{}) error {
+	var value []byte
+	switch v := src.(type) {
+	case nil:
+		*s.b = nil
+		return nil
+	case []byte:
+		value = v
+	case string:
+		value = []byte(v)
+	default:
+		value = []byte(fmt.Sprint(v))
+	}
+	// The buffer of the previous row is reused, it is only valid until the
+	// next one like the bytes of the driver
+	b := (*s.b)[:0]
+	if b == nil {
+		b = sql.RawBytes{}
+	}
+	*s.b = append(b, value...)
+	return nil
+}
+
+// scanDestinations returns what the columns of the rows are scanned into, the
+// values but for the raw bytes going through a binaryScanner
+func scanDestinations(values []interface{}) []interface{} {
+	dest := make([]interface{}, len(values))
+	for i, value := range values {
+		if b, ok := value.(*sql.RawBytes); ok {
+			dest[i] = binaryScanner{b}
+		} else {
+			dest[i] = value
+		}
+	}
+	return dest
+}
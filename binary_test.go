@@ -0,0 +1,80 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamBinary streams the rows of a BINARY(4) and a VARBINARY column, which
+// the mocked driver scans as strings
+func streamBinary(t *testing.T, hexBlob bool) string {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("k", "").AddRow("v", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0),
+			sqlmock.NewColumn("k").OfType("BINARY", "").Nullable(true),
+			sqlmock.NewColumn("v").OfType("VARBINARY", "").Nullable(true)).
+			AddRow(0, "", "").
+			AddRow(1, "ab\x00\x00", "").
+			AddRow(2, nil, nil).
+			AddRow(3, "\x00\x00\x00\x00", ""))
+	data.MaxAllowedPacket = 4096
+	data.HexBlob = hexBlob
+
+	var statements []string
+	for statement := range data.createTable("test", false).Stream() {
+		statements = append(statements, statement)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	return strings.Join(statements, "\n")
+}
+
+func TestStreamBinaryPadding(t *testing.T) {
+	assert.Equal(t, "INSERT INTO `test` (`id`, `k`, `v`) VALUES "+
+		"(0,_binary '',_binary ''),"+
+		"(1,_binary 'ab\\0\\0',_binary ''),"+
+		"(2,NULL,NULL),"+
+		"(3,_binary '\\0\\0\\0\\0',_binary '');", streamBinary(t, false))
+}
+
+func TestStreamBinaryPaddingHex(t *testing.T) {
+	assert.Equal(t, "INSERT INTO `test` (`id`, `k`, `v`) VALUES "+
+		"(0,X'',X''),"+
+		"(1,0x61620000,X''),"+
+		"(2,NULL,NULL),"+
+		"(3,0x00000000,X'');", streamBinary(t, true))
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, hexBlob := range []bool{false, true} {
+		d := NewDecoder(strings.NewReader(streamBinary(t, hexBlob) + "\n"))
+		event, err := d.Next()
+		assert.NoError(t, err)
+		rows, ok := event.(*InsertRows)
+		if !assert.True(t, ok, "expected the INSERT, got %#v", event) {
+			continue
+		}
+		assert.Equal(t, [][]interface{}{
+			{int64(0), []byte{}, []byte{}},
+			{int64(1), []byte("ab\x00\x00"), []byte{}},
+			{int64(2), nil, nil},
+			{int64(3), []byte{0, 0, 0, 0}, []byte{}},
+		}, rows.Rows, "HexBlob %v", hexBlob)
+	}
+}
+
+func TestIsBinaryType(t *testing.T) {
+	for _, name := range []string{"BINARY", "VARBINARY", "BLOB", "LONGBLOB"} {
+		assert.True(t, isBinaryType(name), name)
+	}
+	for _, name := range []string{"CHAR", "VARCHAR", "TEXT", "JSON"} {
+		assert.False(t, isBinaryType(name), name)
+	}
+}
@@ -0,0 +1,367 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// BinlogCoordinates is the position in the binary log the snapshot of a dump
+// matches, where a replica restored from the dump starts replicating.
+type BinlogCoordinates struct {
+	File     string `json:"file"`
+	Position int64  `json:"position"`
+	GTIDSet  string `json:"gtidSet,omitempty"`
+}
+
+// ErrNoBinlog is returned with BinlogCoordinates when the server does not
+// write a binary log.
+var ErrNoBinlog = errors.New("binary logging is not enabled")
+
+// transaction is what a dump reads through, a *sql.Tx or a transaction
+// started by hand on a connection
+type transaction interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+	Rollback() error
+}
+
+// connTx is a transaction on a connection that is given back to the pool on
+// Rollback
+type connTx struct {
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+func (tx *connTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.conn.ExecContext(tx.ctx, query, args...)
+}
+
+func (tx *connTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.conn.QueryContext(tx.ctx, query, args...)
+}
+
+func (tx *connTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.conn.QueryContext(ctx, query, args...)
+}
+
+func (tx *connTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.conn.QueryRowContext(tx.ctx, query, args...)
+}
+
+func (tx *connTx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.conn.PrepareContext(tx.ctx, query)
+}
+
+func (tx *connTx) Rollback() error {
+	_, err := tx.conn.ExecContext(context.Background(), "ROLLBACK")
+	if cerr := tx.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Binlog returns the binary log coordinates of the last dump, or nil unless
+// BinlogCoordinates is set.
+func (data *Data) Binlog() *BinlogCoordinates {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.binlog
+}
+
+// backupStatements hold off writes while the snapshot and the coordinates
+// are taken
+type backupStatements struct {
+	name      string
+	lock      []string
+	unlock    string
+	logStatus bool
+}
+
+// backupLock returns the statements that hold off writes while the snapshot
+// and the coordinates are taken. MariaDB 10.4 and later have BACKUP STAGE,
+// where BLOCK_COMMIT only waits for running commits and leaves the tables
+// open; the server runs the stages in between START and BLOCK_COMMIT itself.
+// LOCK INSTANCE FOR BACKUP lets transactions commit, it is not taken with
+// Concurrency as the snapshots of the workers could differ under it, and the
+// coordinates are only kept under it when no commit came in while the
+// snapshot was taken.
+func (data *Data) backupLock(version serverVersion) backupStatements {
+	switch {
+	case data.BackupLock && version.MariaDB && version.atLeast(10, 4):
+		return backupStatements{
+			name:   "BACKUP STAGE",
+			lock:   []string{"BACKUP STAGE START", "BACKUP STAGE BLOCK_COMMIT"},
+			unlock: "BACKUP STAGE END",
+		}
+	case data.BackupLock && !version.MariaDB && version.atLeast(8, 0) && data.Concurrency <= 1:
+		return backupStatements{
+			name:      "LOCK INSTANCE FOR BACKUP",
+			lock:      []string{"LOCK INSTANCE FOR BACKUP"},
+			unlock:    "UNLOCK INSTANCE",
+			logStatus: true,
+		}
+	}
+	return flushTablesLock
+}
+
+var flushTablesLock = backupStatements{
+	name:   "FLUSH TABLES WITH READ LOCK",
+	lock:   []string{"FLUSH TABLES WITH READ LOCK"},
+	unlock: "UNLOCK TABLES",
+}
+
+// logStatusAttempts is the number of snapshots taken under LOCK INSTANCE FOR
+// BACKUP for one without a commit coming in, before writes are blocked with
+// FLUSH TABLES WITH READ LOCK instead
+const logStatusAttempts = 3
+
+// beginWithCoordinates starts the transaction of the dump on a connection of
+// its own while writes are locked, so the binary log coordinates read before
+// unlocking match the snapshot, and so do the snapshots of the workers of
+// Concurrency. Under LOCK INSTANCE FOR BACKUP DML goes on, the coordinates are
+// then read from performance_schema.log_status before and after the snapshot
+// is taken and only kept when they are the same.
+func (data *Data) beginWithCoordinates() error {
+	// The locks are released and the transaction rolled back even once ctx
+	// is done, the connection goes back to the pool with them otherwise
+	ctx := data.context()
+	release := context.Background()
+	conn, err := data.Connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	var v string
+	if err := conn.QueryRowContext(ctx, data.hinted("SELECT version()")).Scan(&v); err != nil {
+		conn.Close()
+		return err
+	}
+	version := parseServerVersion(v)
+	backup := data.backupLock(version)
+
+	locked, err := data.lockBackup(ctx, conn, backup)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	taken := false
+	if locked && backup.logStatus && data.BinlogCoordinates {
+		var matched bool
+		data.binlog, matched, err = data.snapshotAtLogStatus(ctx, conn)
+		taken = err != nil || matched
+		if !taken {
+			conn.ExecContext(release, backup.unlock)
+			data.warn("commits went on under " + backup.name + " while the snapshot was taken, writes blocked with " + flushTablesLock.name + " instead")
+			backup = flushTablesLock
+			if locked, err = data.lockBackup(ctx, conn, backup); err != nil {
+				conn.Close()
+				return err
+			}
+		}
+	}
+
+	if !locked && data.BinlogCoordinates {
+		data.warn("binary log coordinates not recorded without " + backup.name)
+	}
+	if !locked && data.Concurrency > 1 {
+		data.warn("tables read one at a time without " + backup.name + ", the snapshots of their connections could differ")
+	}
+	if !taken {
+		err = data.startSnapshot(ctx, conn)
+		if err == nil && locked && data.Concurrency > 1 {
+			err = data.startWorkers(ctx)
+		}
+		if err == nil && locked && data.BinlogCoordinates {
+			data.binlog, err = readBinlogStatus(ctx, conn, version)
+		}
+	}
+	if locked {
+		if _, uerr := conn.ExecContext(release, backup.unlock); err == nil {
+			err = uerr
+		}
+	}
+	if err != nil {
+		data.stopWorkers()
+		conn.ExecContext(release, "ROLLBACK")
+		conn.Close()
+		return err
+	}
+	data.tx = &connTx{ctx: ctx, conn: conn}
+	if data.binlog != nil && data.binlog.GTIDSet != "" {
+		data.gtidQuery = "SELECT @@GLOBAL.gtid_executed"
+		if version.MariaDB {
+			data.gtidQuery = "SELECT @@GLOBAL.gtid_binlog_pos"
+		}
+	}
+	return nil
+}
+
+// position returns the time and the executed GTID set of the server, the
+// latter only once the binlog coordinates of the dump include one
+func (data *Data) position(tx transaction) (*TablePosition, error) {
+	p := &TablePosition{Time: time.Now().UTC()}
+	if data.gtidQuery == "" {
+		return p, nil
+	}
+	var gtids sql.NullString
+	if err := tx.QueryRow(data.hinted(data.gtidQuery)).Scan(&gtids); err != nil {
+		return nil, err
+	}
+	p.GTIDSet = gtids.String
+	return p, nil
+}
+
+// readData records the positions around read, which reads the rows of the
+// table, and lets SkipCurrentTable stop it
+func (table *table) readData(read func() error) (err error) {
+	if table.dataStart, err = table.data.position(table.txn()); err != nil {
+		return err
+	}
+	untrack := table.data.track(table)
+	err = read()
+	untrack()
+	if err != nil {
+		return err
+	}
+	table.warnSkipped()
+	table.dataEnd, err = table.data.position(table.txn())
+	return err
+}
+
+// lockBackup takes the statements of backup on conn, it reports whether they
+// are held
+func (data *Data) lockBackup(ctx context.Context, conn *sql.Conn, backup backupStatements) (bool, error) {
+	locked := false
+	err := data.privileged(backup.name, func() error {
+		for _, lock := range backup.lock {
+			if _, err := conn.ExecContext(ctx, data.hinted(lock)); err != nil {
+				if locked {
+					conn.ExecContext(context.Background(), backup.unlock)
+					locked = false
+				}
+				return err
+			}
+			locked = true
+		}
+		return nil
+	})
+	return locked, err
+}
+
+// snapshotAtLogStatus takes the snapshot between two reads of
+// performance_schema.log_status, up to logStatusAttempts times until no commit
+// comes in between. It reports whether the coordinates match the snapshot,
+// which is rolled back otherwise.
+func (data *Data) snapshotAtLogStatus(ctx context.Context, conn *sql.Conn) (*BinlogCoordinates, bool, error) {
+	for i := 0; i < logStatusAttempts; i++ {
+		before, err := readLogStatus(ctx, conn)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := data.startSnapshot(ctx, conn); err != nil {
+			return nil, false, err
+		}
+		after, err := readLogStatus(ctx, conn)
+		if err != nil {
+			return nil, false, err
+		}
+		if *before == *after {
+			return after, true, nil
+		}
+		if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (data *Data) startSnapshot(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, data.hinted("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ")); err != nil {
+		return err
+	}
+	_, err := conn.ExecContext(ctx, data.hinted("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY"))
+	return err
+}
+
+// readBinlogStatus reads the coordinates with SHOW MASTER STATUS, called SHOW
+// BINARY LOG STATUS since MySQL 8.2. MariaDB keeps its GTID position in
+// gtid_binlog_pos instead.
+func readBinlogStatus(ctx context.Context, conn *sql.Conn, version serverVersion) (*BinlogCoordinates, error) {
+	query := "SHOW MASTER STATUS"
+	if !version.MariaDB && version.atLeast(8, 2) {
+		query = "SHOW BINARY LOG STATUS"
+	}
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoBinlog
+	}
+	values := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+	if err := rows.Scan(scans...); err != nil {
+		return nil, err
+	}
+
+	coordinates := &BinlogCoordinates{}
+	for i, col := range cols {
+		switch col {
+		case "File":
+			coordinates.File = values[i].String
+		case "Position":
+			var pos sql.NullInt64
+			if err := pos.Scan(values[i].String); err != nil {
+				return nil, err
+			}
+			coordinates.Position = pos.Int64
+		case "Executed_Gtid_Set":
+			coordinates.GTIDSet = values[i].String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if version.MariaDB {
+		var gtids sql.NullString
+		if err := conn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_binlog_pos").Scan(&gtids); err != nil {
+			return nil, err
+		}
+		coordinates.GTIDSet = gtids.String
+	}
+	return coordinates, nil
+}
+
+// readLogStatus reads the coordinates from performance_schema.log_status,
+// which needs BACKUP_ADMIN like the backup lock
+func readLogStatus(ctx context.Context, conn *sql.Conn) (*BinlogCoordinates, error) {
+	var file, gtids sql.NullString
+	var pos sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT LOCAL->>'$.binary_log_file', LOCAL->>'$.binary_log_position', LOCAL->>'$.gtid_executed' FROM performance_schema.log_status").Scan(&file, &pos, &gtids)
+	if err != nil {
+		return nil, err
+	}
+	if !file.Valid || file.String == "" {
+		return nil, ErrNoBinlog
+	}
+	return &BinlogCoordinates{File: file.String, Position: pos.Int64, GTIDSet: gtids.String}, nil
+}
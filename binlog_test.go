@@ -0,0 +1,219 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeginWithCoordinates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("5.7.44-log"))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW MASTER STATUS$`).WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+			AddRow("mysql-bin.000003", "157", "", "", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5"))
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}))
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	tables, err := data.getTables()
+	assert.NoError(t, err)
+	assert.Empty(t, tables)
+	assert.NoError(t, data.rollback())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, &BinlogCoordinates{
+		File:     "mysql-bin.000003",
+		Position: 157,
+		GTIDSet:  "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5",
+	}, data.binlog)
+
+	assert.NoError(t, data.getTemplates())
+	var buf bytes.Buffer
+	assert.NoError(t, data.headerTmpl.Execute(&buf, &metaData{ServerVersion: "5.7.44-log", Binlog: data.binlog}))
+	assert.Contains(t, buf.String(), `-- Server version	5.7.44-log
+-- Binlog file	mysql-bin.000003
+-- Binlog position	157
+-- GTID set	3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5
+--
+-- CHANGE MASTER TO MASTER_LOG_FILE='mysql-bin.000003', MASTER_LOG_POS=157;
+`)
+}
+
+func TestBeginWithBackupLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true, BackupLock: true}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectExec(`^LOCK INSTANCE FOR BACKUP$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM performance_schema.log_status$`).WillReturnRows(
+		sqlmock.NewRows([]string{"file", "position", "gtids"}).AddRow("binlog.000007", 4711, ""))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM performance_schema.log_status$`).WillReturnRows(
+		sqlmock.NewRows([]string{"file", "position", "gtids"}).AddRow("binlog.000007", 4711, ""))
+	mock.ExpectExec(`^UNLOCK INSTANCE$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, &BinlogCoordinates{File: "binlog.000007", Position: 4711}, data.binlog)
+
+	// MySQL 5.7 has no backup lock
+	backup := data.backupLock(parseServerVersion("5.7.44"))
+	assert.Equal(t, []string{"FLUSH TABLES WITH READ LOCK"}, backup.lock)
+	assert.Equal(t, "UNLOCK TABLES", backup.unlock)
+
+	// MariaDB before 10.4 has no BACKUP STAGE
+	backup = data.backupLock(parseServerVersion("10.3.39-MariaDB"))
+	assert.Equal(t, []string{"FLUSH TABLES WITH READ LOCK"}, backup.lock)
+}
+
+func TestBeginWithBackupLockCommits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true, BackupLock: true}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectExec(`^LOCK INSTANCE FOR BACKUP$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	// A commit comes in while every snapshot is taken
+	for i := 0; i < logStatusAttempts; i++ {
+		mock.ExpectQuery(`FROM performance_schema.log_status$`).WillReturnRows(
+			sqlmock.NewRows([]string{"file", "position", "gtids"}).AddRow("binlog.000007", 4711+i, "uuid:1-"+strconv.Itoa(i+1)))
+		mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FROM performance_schema.log_status$`).WillReturnRows(
+			sqlmock.NewRows([]string{"file", "position", "gtids"}).AddRow("binlog.000007", 4800+i, "uuid:1-"+strconv.Itoa(i+2)))
+		mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`^UNLOCK INSTANCE$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW MASTER STATUS$`).WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+			AddRow("binlog.000007", "4900", "", "", "uuid:1-9"))
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, &BinlogCoordinates{File: "binlog.000007", Position: 4900, GTIDSet: "uuid:1-9"}, data.binlog)
+	assert.Equal(t, []string{"commits went on under LOCK INSTANCE FOR BACKUP while the snapshot was taken, writes blocked with FLUSH TABLES WITH READ LOCK instead"}, data.warnings)
+}
+
+func TestBeginWithBackupStage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true, BackupLock: true}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("10.6.16-MariaDB-log"))
+	mock.ExpectExec(`^BACKUP STAGE START$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^BACKUP STAGE BLOCK_COMMIT$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW MASTER STATUS$`).WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB"}).
+			AddRow("mariadb-bin.000012", "342", "", ""))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.gtid_binlog_pos$`).WillReturnRows(sqlmock.NewRows([]string{"gtid_binlog_pos"}).AddRow("0-1-42"))
+	mock.ExpectExec(`^BACKUP STAGE END$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, &BinlogCoordinates{File: "mariadb-bin.000012", Position: 342, GTIDSet: "0-1-42"}, data.binlog)
+}
+
+func TestTablePositions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("5.7.44-log"))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW MASTER STATUS$`).WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+			AddRow("mysql-bin.000003", "157", "", "", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5"))
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.gtid_executed$`).WillReturnRows(
+		sqlmock.NewRows([]string{"gtid_executed"}).AddRow("3E11FA47-71CA-11E1-9E33-C80AA9429562:1-6"))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.gtid_executed$`).WillReturnRows(
+		sqlmock.NewRows([]string{"gtid_executed"}).AddRow("3E11FA47-71CA-11E1-9E33-C80AA9429562:1-9"))
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	table := data.createTable("Test_Table", false)
+	read := false
+	assert.NoError(t, table.readData(func() error {
+		read = true
+		return nil
+	}))
+	assert.NoError(t, data.rollback())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.True(t, read)
+	assert.Equal(t, "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-6", table.dataStart.GTIDSet)
+	assert.Equal(t, "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-9", table.dataEnd.GTIDSet)
+	assert.False(t, table.dataEnd.Time.Before(table.dataStart.Time))
+}
+
+func TestBeginWithBackupStageFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true, BackupLock: true, Grants: GrantsAuto}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("10.11.6-MariaDB"))
+	mock.ExpectExec(`^BACKUP STAGE START$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^BACKUP STAGE BLOCK_COMMIT$`).WillReturnError(errors.New("Error 1227 (42000): Access denied; you need (at least one of) the RELOAD privilege(s) for this operation"))
+	mock.ExpectExec(`^BACKUP STAGE END$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Nil(t, data.binlog)
+	assert.Len(t, data.warnings, 2)
+}
+
+func TestBeginWithCoordinatesMinimalGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, BinlogCoordinates: true, Grants: GrantsMinimal}
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, data.begin())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Nil(t, data.binlog)
+	assert.Equal(t, []string{
+		"FLUSH TABLES WITH READ LOCK skipped to work with SELECT privileges only",
+		"binary log coordinates not recorded without FLUSH TABLES WITH READ LOCK",
+	}, data.warnings)
+}
@@ -0,0 +1,130 @@
+package mysqldump
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BlobMode selects how binary values moved out of the dump by BlobThreshold
+// are referenced from the emitted INSERT statements.
+type BlobMode int
+
+const (
+	// BlobLoadFile references the side file with LOAD_FILE() so the value is
+	// restored by the server, provided BlobDir is readable by it.
+	BlobLoadFile BlobMode = iota
+	// BlobPlaceholder writes NULL with a comment naming the side file, leaving
+	// it to the restore tooling to fill the value in from the manifest.
+	BlobPlaceholder
+)
+
+// externalizeBlob writes the value of column key of the current row to its own
+// file in BlobDir and returns the SQL expression that takes its place
+func (table *table) externalizeBlob(key int, value []byte) (string, error) {
+	name := filepath.Join(safeFileName(table.Name), table.rowKey()+"."+safeFileName(table.cols[key])+".bin")
+	p := filepath.Join(table.data.BlobDir, name)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nullType, err
+	}
+	if err := ioutil.WriteFile(p, value, 0644); err != nil {
+		return nullType, err
+	}
+
+	sum := sha256.Sum256(value)
+	table.data.manifest.addBlob(ManifestBlob{
+		Table:  table.Name,
+		Column: table.cols[key],
+		Key:    table.rowKey(),
+		File:   filepath.ToSlash(name),
+		Size:   int64(len(value)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+
+	if table.data.BlobMode == BlobPlaceholder {
+		return nullType + " /* blob:" + filepath.ToSlash(name) + " */", nil
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return nullType, err
+	}
+	return "LOAD_FILE('" + sanitize(filepath.ToSlash(abs)) + "')", nil
+}
+
+// rowKey identifies the current row by its primary key values, or by its
+// position in the result when the table has no primary key. Keys of values
+// that are not plain file names, or with dashes or dots that would have them
+// read apart differently, end with ~ and the hash of the values.
+func (table *table) rowKey() string {
+	if len(table.pk) == 0 {
+		return strconv.Itoa(table.row)
+	}
+	parts := make([]string, len(table.pk))
+	var tuple strings.Builder
+	plain := true
+	for i, index := range table.pk {
+		value := plainValue(table.values[index])
+		parts[i] = value
+		tuple.WriteString(strconv.Itoa(len(value)) + ":" + value)
+		if value == "" || mapFileName(value) != value || strings.ContainsAny(value, "-.") {
+			plain = false
+		}
+	}
+	if plain {
+		return strings.Join(parts, "-")
+	}
+	return mapFileName(strings.Join(parts, "-")) + "~" + nameHash(tuple.String())
+}
+
+// plainValue formats a scanned value without any SQL quoting
+func plainValue(value interface{}) string {
+	switch s := value.(type) {
+	case *sql.NullString:
+		return s.String
+	case *sql.NullInt64:
+		return strconv.FormatInt(s.Int64, 10)
+	case *sql.NullFloat64:
+		return strconv.FormatFloat(s.Float64, 'f', -1, 64)
+	case *sql.RawBytes:
+		return hex.EncodeToString(*s)
+	}
+	return fmt.Sprint(value)
+}
+
+// safeFileName replaces everything but letters, digits, dots, dashes and
+// underscores so that any identifier or value can be used as a file name. The
+// names it changes, and the ones starting with a dot, end with ~ and the hash
+// of the name, so that distinct names never get the same file.
+func safeFileName(name string) string {
+	safe := mapFileName(name)
+	if safe == name && name != "" && !strings.HasPrefix(name, ".") {
+		return name
+	}
+	return safe + "~" + nameHash(name)
+}
+
+// mapFileName replaces everything but letters, digits, dots, dashes and
+// underscores with underscores
+func mapFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// nameHash returns the first 16 bytes of the SHA-256 of name in hex
+func nameHash(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,136 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockBlobSelect(mock sqlmock.Sqlmock, name string) {
+	cols := sqlmock.NewRows([]string{"Field", "Key", "Extra"}).
+		AddRow("id", "PRI", "").
+		AddRow("data", "", "")
+
+	rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0), sqlmock.NewColumn("data").OfType("BLOB", []byte{}).Nullable(true)).
+		AddRow(1, []byte("tiny")).
+		AddRow(2, []byte("a much larger value"))
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `" + name + "`$").WillReturnRows(cols)
+	mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`$").WillReturnRows(rows)
+}
+
+func TestBlobLoadFile(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	dir, err := ioutil.TempDir("", "blobs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mockBlobSelect(mock, "test")
+
+	data.MaxAllowedPacket = 4096
+	data.BlobThreshold = 8
+	data.BlobDir = dir
+	data.manifest = &Manifest{}
+
+	table := data.createTable("test", false)
+
+	abs, err := filepath.Abs(filepath.Join(dir, "test", "2.data.bin"))
+	assert.NoError(t, err)
+
+	s := table.Stream()
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `data`) VALUES (1,_binary 'tiny'),(2,LOAD_FILE('"+filepath.ToSlash(abs)+"'));", <-s)
+	assert.NoError(t, table.Err)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	content, err := ioutil.ReadFile(abs)
+	assert.NoError(t, err)
+	assert.Equal(t, "a much larger value", string(content))
+
+	assert.Len(t, data.manifest.Blobs, 1)
+	assert.Equal(t, ManifestBlob{
+		Table:  "test",
+		Column: "data",
+		Key:    "2",
+		File:   "test/2.data.bin",
+		Size:   19,
+		SHA256: "9e623da2bd311c37277d109927b629a3339415f9c9b61400f850c193d3705658",
+	}, data.manifest.Blobs[0])
+}
+
+func TestBlobPlaceholder(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	dir, err := ioutil.TempDir("", "blobs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mockBlobSelect(mock, "test")
+
+	data.MaxAllowedPacket = 4096
+	data.BlobThreshold = 8
+	data.BlobDir = dir
+	data.BlobMode = BlobPlaceholder
+	data.manifest = &Manifest{}
+
+	table := data.createTable("test", false)
+
+	s := table.Stream()
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `data`) VALUES (1,_binary 'tiny'),(2,NULL /* blob:test/2.data.bin */);", <-s)
+	assert.NoError(t, table.Err)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.NoError(t, data.manifest.writeFile(dir))
+	content, err := ioutil.ReadFile(filepath.Join(dir, manifestFileName))
+	assert.NoError(t, err)
+
+	var m Manifest
+	assert.NoError(t, json.Unmarshal(content, &m))
+	assert.Len(t, m.Blobs, 1)
+	assert.Equal(t, "test/2.data.bin", m.Blobs[0].File)
+}
+
+func TestSafeFileName(t *testing.T) {
+	assert.Equal(t, "Test_Table.v2", safeFileName("Test_Table.v2"))
+	assert.Equal(t, "a_b_.._c.txt~"+nameHash("a/b ..\\c.txt"), safeFileName("a/b ..\\c.txt"))
+	assert.Equal(t, "..~"+nameHash(".."), safeFileName(".."))
+
+	// Names mapped to the same characters get files of their own
+	assert.NotEqual(t, safeFileName("a_b"), safeFileName("a b"))
+	assert.NotEqual(t, safeFileName("a b"), safeFileName("a/b"))
+}
+
+func TestRowKey(t *testing.T) {
+	key := func(values ...string) string {
+		table := &table{}
+		for i, value := range values {
+			table.pk = append(table.pk, i)
+			table.values = append(table.values, &sql.NullString{String: value, Valid: true})
+		}
+		return table.rowKey()
+	}
+	assert.Equal(t, "2", key("2"))
+	assert.Equal(t, "1-2", key("1", "2"))
+	assert.Equal(t, "a_b", key("a_b"))
+
+	keys := map[string]bool{}
+	for _, k := range []string{key("a b"), key("a_b"), key("a-b", "c"), key("a", "b-c"), key("a", "b", "c"), key("1.x")} {
+		assert.False(t, keys[k], k)
+		keys[k] = true
+	}
+	assert.Equal(t, "a-b-c~"+nameHash("3:a-b1:c"), key("a-b", "c"))
+}
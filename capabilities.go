@@ -0,0 +1,220 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Feature is something of the server a dump makes use of.
+type Feature string
+
+// Features reported by Capabilities
+const (
+	// FeatureMariaDB is a MariaDB server, as opposed to MySQL.
+	FeatureMariaDB Feature = "mariadb"
+	// FeatureProxy is a proxy like ProxySQL in front of the server.
+	FeatureProxy Feature = "proxy"
+	// FeatureBinlog is binary logging, which binlog coordinates need.
+	FeatureBinlog Feature = "binlog"
+	// FeatureGTID is a GTID set along with the binlog coordinates.
+	FeatureGTID Feature = "gtid"
+	// FeatureBackupStage is BACKUP STAGE of MariaDB 10.4 and later.
+	FeatureBackupStage Feature = "backup-stage"
+	// FeatureLockInstance is LOCK INSTANCE FOR BACKUP of MySQL 8.0 and
+	// later.
+	FeatureLockInstance Feature = "lock-instance"
+	// FeatureBinaryLogStatus is SHOW BINARY LOG STATUS, which replaced SHOW
+	// MASTER STATUS in MySQL 8.2.
+	FeatureBinaryLogStatus Feature = "binary-log-status"
+	// FeatureInvisibleColumns is the INVISIBLE column attribute of MySQL
+	// 8.0.23 and MariaDB 10.3.
+	FeatureInvisibleColumns Feature = "invisible-columns"
+)
+
+// Capabilities describes what a dump with the options of a Data does on a
+// given server: the features found on the server, the statements the dump
+// runs without their arguments, in the order it runs them, and what it can't
+// do there.
+type Capabilities struct {
+	Version       string
+	ServerVersion string
+	Features      []Feature
+	Statements    []string
+	Limitations   []string
+}
+
+// Has reports whether the server has the feature.
+func (c *Capabilities) Has(feature Feature) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities asks the server what a dump with the options of data would
+// make use of, without starting one.
+func (data *Data) Capabilities() (*Capabilities, error) {
+	if err := data.checkProxyMode(); err != nil {
+		return nil, err
+	}
+	var version sql.NullString
+	if err := data.Connection.QueryRow("SELECT version()").Scan(&version); err != nil {
+		return nil, err
+	}
+	v := parseServerVersion(version.String)
+	c := &Capabilities{Version: Version, ServerVersion: version.String}
+
+	proxied := data.Proxy == ProxyAlways
+	if data.Proxy == ProxyAuto {
+		var comment sql.NullString
+		if err := data.Connection.QueryRow("SELECT @@version_comment LIMIT 1").Scan(&comment); err != nil {
+			return nil, err
+		}
+		proxied = strings.Contains(strings.ToLower(comment.String), "proxysql")
+	}
+
+	if v.MariaDB {
+		c.Features = append(c.Features, FeatureMariaDB)
+	}
+	if proxied {
+		c.Features = append(c.Features, FeatureProxy)
+	}
+	var logBin sql.NullInt64
+	if err := data.Connection.QueryRow("SELECT @@GLOBAL.log_bin").Scan(&logBin); err != nil {
+		return nil, err
+	}
+	if logBin.Int64 == 1 {
+		c.Features = append(c.Features, FeatureBinlog)
+		gtid := v.MariaDB
+		if !v.MariaDB {
+			// gtid_mode does not exist before MySQL 5.6
+			var mode sql.NullString
+			gtid = data.Connection.QueryRow("SELECT @@GLOBAL.gtid_mode").Scan(&mode) == nil && mode.String == "ON"
+		}
+		if gtid {
+			c.Features = append(c.Features, FeatureGTID)
+		}
+	}
+	switch {
+	case v.MariaDB && v.atLeast(10, 4):
+		c.Features = append(c.Features, FeatureBackupStage)
+	case !v.MariaDB && v.atLeast(8, 0):
+		c.Features = append(c.Features, FeatureLockInstance)
+	}
+	if !v.MariaDB && v.atLeast(8, 2) {
+		c.Features = append(c.Features, FeatureBinaryLogStatus)
+	}
+	if v.MariaDB && v.atLeast(10, 3) || !v.MariaDB && !v.less(serverVersion{Major: 8, Patch: 23}) {
+		c.Features = append(c.Features, FeatureInvisibleColumns)
+	}
+
+	c.statements(data, v, proxied)
+	return c, nil
+}
+
+// statements lists the statements of a dump of data and the limitations
+// they run into
+func (c *Capabilities) statements(data *Data, v serverVersion, proxied bool) {
+	add := func(statements ...string) {
+		for _, s := range statements {
+			c.Statements = append(c.Statements, data.hinted(s))
+		}
+	}
+	limit := func(s string) {
+		c.Limitations = append(c.Limitations, s)
+	}
+
+	minimal := data.Grants == GrantsMinimal
+	if minimal {
+		limit("statements that need more than SELECT are skipped")
+	}
+
+	switch {
+	case data.BinlogCoordinates || data.Concurrency > 1:
+		backup := data.backupLock(v)
+		if data.BackupLock && backup.name == "FLUSH TABLES WITH READ LOCK" {
+			if data.Concurrency > 1 && !v.MariaDB && v.atLeast(8, 0) {
+				limit("BackupLock lets transactions commit on MySQL, FLUSH TABLES WITH READ LOCK is taken instead with Concurrency")
+			} else {
+				limit("BackupLock needs MySQL 8.0 or MariaDB 10.4, FLUSH TABLES WITH READ LOCK is taken instead")
+			}
+		}
+		if data.BinlogCoordinates && !c.Has(FeatureBinlog) {
+			limit("binary logging is off, there are no binlog coordinates to record")
+		}
+		if minimal {
+			if data.BinlogCoordinates {
+				limit("binlog coordinates are not recorded without " + backup.name)
+			}
+			if data.Concurrency > 1 {
+				limit("tables are read one at a time without " + backup.name)
+			}
+		} else {
+			add(backup.lock...)
+		}
+		add("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY")
+		switch {
+		case minimal, !data.BinlogCoordinates:
+		case backup.logStatus:
+			add("SELECT FROM performance_schema.log_status")
+		case c.Has(FeatureBinaryLogStatus):
+			add("SHOW BINARY LOG STATUS")
+		default:
+			add("SHOW MASTER STATUS")
+		}
+		if !minimal {
+			add(backup.unlock)
+		}
+	case data.ProxyHint != "" || data.Barrier != nil:
+		add("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY")
+	default:
+		add("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION READ ONLY")
+	}
+
+	schema := data.UseInformationSchema || proxied
+	if schema {
+		add("SELECT FROM information_schema.TABLES", "SELECT FROM information_schema.COLUMNS")
+	} else {
+		add("SHOW FULL TABLES")
+	}
+	switch {
+	case data.LockTables && proxied:
+		limit("LOCK TABLES is skipped behind a proxy, it could reach another server than the transaction")
+	case data.LockTables && !minimal:
+		add("LOCK TABLES")
+	}
+	if data.NonTransactional != NonTransactionalIgnore && (!data.LockTables || proxied) {
+		add("SELECT FROM information_schema.ENGINES")
+		switch {
+		case data.NonTransactional == NonTransactionalLock && proxied:
+			limit("LOCK TABLES on the tables without transactions is skipped behind a proxy")
+		case data.NonTransactional == NonTransactionalLock && !minimal:
+			add("LOCK TABLES")
+		}
+	}
+	if len(data.MaterializeViews) > 0 && !minimal {
+		add("CREATE TEMPORARY TABLE", "DROP TEMPORARY TABLE")
+	}
+	add("SHOW CREATE TABLE")
+	if !schema {
+		add("SHOW COLUMNS")
+	}
+	add("SELECT")
+	if data.Checksums {
+		add("CHECKSUM TABLE")
+	}
+	if data.Routines {
+		add("SHOW CREATE PROCEDURE", "SHOW CREATE FUNCTION")
+	}
+	if data.Triggers {
+		add("SHOW CREATE TRIGGER")
+	}
+	if data.Events {
+		add("SHOW CREATE EVENT")
+	}
+	if data.IncludeGrants {
+		add("SELECT FROM mysql.user", "SHOW CREATE USER", "SHOW GRANTS")
+	}
+}
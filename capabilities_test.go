@@ -0,0 +1,97 @@
+package mysqldump_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesMySQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.4.0"))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.log_bin$`).WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.log_bin"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.gtid_mode$`).WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.gtid_mode"}).AddRow("ON"))
+
+	data := &mysqldump.Data{Connection: db, BinlogCoordinates: true, BackupLock: true, LockTables: true, Checksums: true}
+	c, err := data.Capabilities()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, mysqldump.Version, c.Version)
+	assert.Equal(t, "8.4.0", c.ServerVersion)
+	assert.Equal(t, []mysqldump.Feature{
+		mysqldump.FeatureBinlog,
+		mysqldump.FeatureGTID,
+		mysqldump.FeatureLockInstance,
+		mysqldump.FeatureBinaryLogStatus,
+		mysqldump.FeatureInvisibleColumns,
+	}, c.Features)
+	assert.True(t, c.Has(mysqldump.FeatureGTID))
+	assert.False(t, c.Has(mysqldump.FeatureMariaDB))
+	assert.Equal(t, []string{
+		"LOCK INSTANCE FOR BACKUP",
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+		"SELECT FROM performance_schema.log_status",
+		"UNLOCK INSTANCE",
+		"SHOW FULL TABLES",
+		"LOCK TABLES",
+		"SHOW CREATE TABLE",
+		"SHOW COLUMNS",
+		"SELECT",
+		"CHECKSUM TABLE",
+	}, c.Statements)
+	assert.Empty(t, c.Limitations)
+}
+
+func TestCapabilitiesProxy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("5.5.30"))
+	mock.ExpectQuery(`^SELECT @@version_comment LIMIT 1$`).WillReturnRows(sqlmock.NewRows([]string{"@@version_comment"}).AddRow("(ProxySQL)"))
+	mock.ExpectQuery(`^SELECT @@GLOBAL.log_bin$`).WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.log_bin"}).AddRow(0))
+
+	data := &mysqldump.Data{
+		Connection:        db,
+		Proxy:             mysqldump.ProxyAuto,
+		ProxyHint:         "/* hostgroup=1 */",
+		BinlogCoordinates: true,
+		BackupLock:        true,
+		LockTables:        true,
+	}
+	c, err := data.Capabilities()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []mysqldump.Feature{mysqldump.FeatureProxy}, c.Features)
+	assert.Equal(t, "/* hostgroup=1 */ FLUSH TABLES WITH READ LOCK", c.Statements[0])
+	assert.Contains(t, c.Statements, "/* hostgroup=1 */ SELECT FROM information_schema.COLUMNS")
+	assert.NotContains(t, c.Statements, "/* hostgroup=1 */ LOCK TABLES")
+	assert.Equal(t, []string{
+		"BackupLock needs MySQL 8.0 or MariaDB 10.4, FLUSH TABLES WITH READ LOCK is taken instead",
+		"binary logging is off, there are no binlog coordinates to record",
+		"LOCK TABLES is skipped behind a proxy, it could reach another server than the transaction",
+	}, c.Limitations)
+}
+
+func TestCapabilitiesError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnError(errors.New("connection refused"))
+
+	_, err = (&mysqldump.Data{Connection: db}).Capabilities()
+	assert.EqualError(t, err, "connection refused")
+
+	_, err = (&mysqldump.Data{Connection: db, Proxy: "sometimes"}).Capabilities()
+	assert.Equal(t, mysqldump.ErrUnknownProxyMode, err)
+}
@@ -0,0 +1,189 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// Cause tells why a dump failed, so the caller can choose between retrying
+// and alerting.
+type Cause string
+
+const (
+	// CauseCanceled is a dump stopped by the cancellation of its Context.
+	CauseCanceled Cause = "canceled"
+	// CauseDeadline is a dump stopped by the deadline of its Context.
+	CauseDeadline Cause = "deadline"
+	// CauseServer is a dump failed by the server or the connection to it.
+	CauseServer Cause = "server"
+	// CauseSink is a dump failed by the writer or the files it writes to.
+	CauseSink Cause = "sink"
+	// CauseInvalidOutput is a dump stopped by ValidateOutput on a malformed
+	// statement.
+	CauseInvalidOutput Cause = "invalid-output"
+	// CauseBarrier is a dump failed by its Barrier or its BarrierTimeout.
+	CauseBarrier Cause = "barrier"
+)
+
+// DumpError is the error of a dump that failed once it had started, invalid
+// options are reported as they are. Partial tells whether output was written
+// before the failure, in which case the output is incomplete and must not be
+// restored. The message is the one of Err.
+type DumpError struct {
+	Cause   Cause
+	Partial bool
+	Err     error
+}
+
+func (e *DumpError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DumpError) Unwrap() error {
+	return e.Err
+}
+
+// CauseOf returns the cause of err, a DumpError or a context error, and an
+// empty Cause for other errors.
+func CauseOf(err error) Cause {
+	var dumpErr *DumpError
+	switch {
+	case errors.As(err, &dumpErr):
+		return dumpErr.Cause
+	case errors.Is(err, context.Canceled):
+		return CauseCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CauseDeadline
+	}
+	return ""
+}
+
+// context returns the Context of the dump
+func (data *Data) context() context.Context {
+	if data.Context == nil {
+		return context.Background()
+	}
+	return data.Context
+}
+
+// checkContext returns the error of the Context once it is done
+func (data *Data) checkContext() error {
+	return data.context().Err()
+}
+
+// failed turns err, the failure of a started dump, into a DumpError
+func (data *Data) failed(err error, out *sink) error {
+	if err == nil {
+		return nil
+	}
+	var dumpErr *DumpError
+	if errors.As(err, &dumpErr) {
+		return err
+	}
+
+	cause := CauseServer
+	var sinkErr *sinkError
+	var barrierErr *barrierError
+	ctxErr := data.checkContext()
+	switch {
+	case errors.Is(err, context.Canceled) || ctxErr == context.Canceled:
+		cause = CauseCanceled
+	case errors.Is(err, context.DeadlineExceeded) || ctxErr == context.DeadlineExceeded:
+		cause = CauseDeadline
+	case errors.As(err, &barrierErr):
+		cause = CauseBarrier
+	case errors.Is(err, ErrInvalidOutput):
+		cause = CauseInvalidOutput
+	case errors.As(err, &sinkErr):
+		cause = CauseSink
+	}
+	return &DumpError{Cause: cause, Partial: out.bytes() > 0 || atomic.LoadInt64(&out.files) > 0, Err: err}
+}
+
+// sink keeps track of the output of a dump and marks the errors of writing it
+type sink struct {
+	written int64 // accessed atomically
+	files   int64 // accessed atomically
+}
+
+// bytes returns the bytes written so far, 0 without a sink
+func (s *sink) bytes() int64 {
+	if s == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&s.written)
+}
+
+// sinkError is a failed write or create of the output
+type sinkError struct {
+	err error
+}
+
+func (e *sinkError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sinkError) Unwrap() error {
+	return e.err
+}
+
+// writer wraps w, counting the bytes written if count is set
+func (s *sink) writer(w io.Writer, count bool) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return &sinkWriter{w: w, s: s, count: count}
+}
+
+type sinkWriter struct {
+	w     io.Writer
+	s     *sink
+	count bool
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if w.count {
+		atomic.AddInt64(&w.s.written, int64(n))
+	}
+	if err != nil {
+		return n, &sinkError{err}
+	}
+	return n, nil
+}
+
+// factory wraps the files created by f
+func (s *sink) factory(f WriterFactory) WriterFactory {
+	if f == nil {
+		return nil
+	}
+	return sinkFactory{f: f, s: s}
+}
+
+type sinkFactory struct {
+	f WriterFactory
+	s *sink
+}
+
+func (f sinkFactory) Create(name string) (io.WriteCloser, error) {
+	w, err := f.f.Create(name)
+	if err != nil {
+		return nil, &sinkError{err}
+	}
+	atomic.AddInt64(&f.s.files, 1)
+	return &sinkFile{sinkWriter: sinkWriter{w: w, s: f.s, count: true}, c: w}, nil
+}
+
+type sinkFile struct {
+	sinkWriter
+	c io.Closer
+}
+
+func (f *sinkFile) Close() error {
+	if err := f.c.Close(); err != nil {
+		return &sinkError{err}
+	}
+	return nil
+}
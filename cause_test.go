@@ -0,0 +1,123 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+// cancelingWriter cancels the dump on its first write
+type cancelingWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.Buffer.Write(p)
+}
+
+func dumpCause(t *testing.T, err error) *mysqldump.DumpError {
+	var dumpErr *mysqldump.DumpError
+	if assert.True(t, errors.As(err, &dumpErr), "expected a DumpError, got %v", err) {
+		assert.Equal(t, dumpErr.Cause, mysqldump.CauseOf(err))
+	}
+	return dumpErr
+}
+
+func TestDumpErrorServer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	lost := errors.New("Error 2013: Lost connection to MySQL server during query")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnError(lost)
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, lost))
+	assert.EqualError(t, err, lost.Error())
+	dumpErr := dumpCause(t, err)
+	assert.Equal(t, mysqldump.CauseServer, dumpErr.Cause)
+	assert.False(t, dumpErr.Partial)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpErrorSink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}))
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Connection: db, Out: failingWriter{}}
+	err = data.Dump()
+	assert.EqualError(t, err, "no space left on device")
+	dumpErr := dumpCause(t, err)
+	assert.Equal(t, mysqldump.CauseSink, dumpErr.Cause)
+	assert.False(t, dumpErr.Partial)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpErrorCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &cancelingWriter{cancel: cancel}
+	data := &mysqldump.Data{Connection: db, Out: out, Context: ctx}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, context.Canceled))
+	dumpErr := dumpCause(t, err)
+	assert.Equal(t, mysqldump.CauseCanceled, dumpErr.Cause)
+	assert.True(t, dumpErr.Partial)
+	assert.NotZero(t, out.Len())
+}
+
+func TestDumpErrorDeadline(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}, Context: ctx}
+	err = data.Dump()
+	dumpErr := dumpCause(t, err)
+	assert.Equal(t, mysqldump.CauseDeadline, dumpErr.Cause)
+	assert.False(t, dumpErr.Partial)
+	assert.True(t, mysqldump.IsTransient(err))
+}
+
+func TestDumpErrorInvalidOptions(t *testing.T) {
+	data := &mysqldump.Data{Grants: "none"}
+	err := data.Dump()
+	assert.Equal(t, mysqldump.ErrUnknownGrantMode, err)
+	assert.Equal(t, mysqldump.Cause(""), mysqldump.CauseOf(err))
+}
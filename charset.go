@@ -0,0 +1,131 @@
+package mysqldump
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	legacyCharsetRe    = regexp.MustCompile(`(?i)\b(CHARSET|CHARACTER SET|COLLATE)(\s*=\s*|\s+)utf8(?:mb3)?(_\w+)?\b`)
+	legacyIntroducerRe = regexp.MustCompile(`(?i)\b_utf8(?:mb3)?'`)
+	uca900CollationRe  = regexp.MustCompile(`(?i)\butf8mb4_(?:(\w+?)_)?0900_(\w+)\b`)
+)
+
+// utf8mb4Collations maps the utf8mb3 collations without a utf8mb4 counterpart
+// of the same name
+var utf8mb4Collations = map[string]string{
+	"_general_mysql500_ci": "_general_ci",
+}
+
+// uca900Languages maps the language codes of the MySQL 8 collations to the
+// names of their 5.7 counterparts
+var uca900Languages = map[string]string{
+	"cs":      "czech",
+	"da":      "danish",
+	"de_pb":   "german2",
+	"eo":      "esperanto",
+	"es":      "spanish",
+	"es_trad": "spanish2",
+	"et":      "estonian",
+	"hr":      "croatian",
+	"hu":      "hungarian",
+	"is":      "icelandic",
+	"la":      "roman",
+	"lt":      "lithuanian",
+	"lv":      "latvian",
+	"pl":      "polish",
+	"ro":      "romanian",
+	"sk":      "slovak",
+	"sl":      "slovenian",
+	"sv":      "swedish",
+	"tr":      "turkish",
+	"vi":      "vietnamese",
+}
+
+// rewriteDDL applies the charset conversions configured on data to a CREATE
+// statement
+func (data *Data) rewriteDDL(create string) string {
+	if data.CharsetConvert {
+		create = convertCharset(create)
+	}
+	if data.TargetVersion != "" {
+		target := parseServerVersion(data.TargetVersion)
+		if target.MariaDB || !target.atLeast(8, 0) {
+			create = downgradeCollations(create)
+		}
+	}
+	return create
+}
+
+// downgradeCollations replaces the UCA 9.0.0 collations introduced with
+// MySQL 8.0 by the closest collation older servers know
+func downgradeCollations(create string) string {
+	return rewriteOutsideQuotes(create, func(s string) string {
+		return uca900CollationRe.ReplaceAllStringFunc(s, func(match string) string {
+			m := uca900CollationRe.FindStringSubmatch(match)
+			if name, ok := uca900Languages[strings.ToLower(m[1])]; ok {
+				return "utf8mb4_" + name + "_ci"
+			}
+			switch strings.ToLower(m[2]) {
+			case "bin", "as_cs":
+				if m[1] == "" {
+					return "utf8mb4_bin"
+				}
+			}
+			return "utf8mb4_general_ci"
+		})
+	})
+}
+
+// convertCharset rewrites the utf8 and utf8mb3 character sets and collations
+// of a CREATE statement to utf8mb4
+func convertCharset(create string) string {
+	return rewriteOutsideQuotes(create, func(s string) string {
+		s = legacyCharsetRe.ReplaceAllStringFunc(s, func(match string) string {
+			m := legacyCharsetRe.FindStringSubmatch(match)
+			collation := strings.ToLower(m[3])
+			if mapped, ok := utf8mb4Collations[collation]; ok {
+				collation = mapped
+			}
+			return m[1] + m[2] + "utf8mb4" + collation
+		})
+		return legacyIntroducerRe.ReplaceAllString(s, "_utf8mb4'")
+	})
+}
+
+// rewriteOutsideQuotes applies rewrite to the parts of a statement that are
+// not string literals or quoted identifiers, so defaults and comments keep
+// their text. The opening quote of a literal stays with the text in front of
+// it for introducers like _utf8'...'.
+func rewriteOutsideQuotes(statement string, rewrite func(string) string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(statement); i++ {
+		quote := statement[i]
+		if quote != '\'' && quote != '"' && quote != '`' {
+			continue
+		}
+		b.WriteString(rewrite(statement[start : i+1]))
+		end := i + 1
+		for ; end < len(statement); end++ {
+			if statement[end] == '\\' && quote != '`' {
+				end++
+			} else if statement[end] == quote {
+				if end+1 < len(statement) && statement[end+1] == quote {
+					end++
+					continue
+				}
+				break
+			}
+		}
+		if end >= len(statement) {
+			b.WriteString(statement[i+1:])
+			return b.String()
+		}
+		b.WriteString(statement[i+1 : end+1])
+		start = end + 1
+		i = end
+	}
+	b.WriteString(rewrite(statement[start:]))
+	return b.String()
+}
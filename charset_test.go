@@ -0,0 +1,80 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertCharset(t *testing.T) {
+	for in, out := range map[string]string{
+		"ENGINE=InnoDB DEFAULT CHARSET=utf8":                                      "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"DEFAULT CHARSET=utf8mb3 COLLATE=utf8mb3_unicode_ci":                      "DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+		"`s` varchar(10) CHARACTER SET utf8 COLLATE utf8_bin":                     "`s` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+		"`s` text COLLATE utf8_general_mysql500_ci":                               "`s` text COLLATE utf8mb4_general_ci",
+		"`s` varchar(10) DEFAULT _utf8mb3'x'":                                     "`s` varchar(10) DEFAULT _utf8mb4'x'",
+		"DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci":                      "DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci",
+		"`s` varchar(10) DEFAULT 'CHARSET utf8' COMMENT 'it''s COLLATE utf8_bin'": "`s` varchar(10) DEFAULT 'CHARSET utf8' COMMENT 'it''s COLLATE utf8_bin'",
+		"`CHARSET utf8` int COLLATE utf8_bin":                                     "`CHARSET utf8` int COLLATE utf8mb4_bin",
+	} {
+		assert.Equal(t, out, convertCharset(in))
+	}
+}
+
+func TestCreateSQLCharsetConvert(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	rows := sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("Test_Table", "CREATE TABLE `Test_Table` (`s` char(60) CHARACTER SET utf8 DEFAULT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3")
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(rows)
+
+	data.CharsetConvert = true
+	result, err := data.createTable("Test_Table", false).CreateSQL()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, "CREATE TABLE `Test_Table` (`s` char(60) CHARACTER SET utf8mb4 DEFAULT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", result)
+}
+
+func TestDowngradeCollations(t *testing.T) {
+	for in, out := range map[string]string{
+		"DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci": "DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci",
+		"`s` text COLLATE utf8mb4_0900_as_cs":                "`s` text COLLATE utf8mb4_bin",
+		"`s` text COLLATE utf8mb4_0900_bin":                  "`s` text COLLATE utf8mb4_bin",
+		"`s` text COLLATE utf8mb4_de_pb_0900_ai_ci":          "`s` text COLLATE utf8mb4_german2_ci",
+		"`s` text COLLATE utf8mb4_es_trad_0900_as_cs":        "`s` text COLLATE utf8mb4_spanish2_ci",
+		"`s` text COLLATE utf8mb4_ja_0900_as_cs_ks":          "`s` text COLLATE utf8mb4_general_ci",
+		"`s` text COLLATE utf8mb4_unicode_ci":                "`s` text COLLATE utf8mb4_unicode_ci",
+		"`s` varchar(10) DEFAULT 'utf8mb4_0900_ai_ci'":       "`s` varchar(10) DEFAULT 'utf8mb4_0900_ai_ci'",
+		"select 'a' COLLATE utf8mb4_0900_ai_ci AS `utf8mb4`": "select 'a' COLLATE utf8mb4_general_ci AS `utf8mb4`",
+	} {
+		assert.Equal(t, out, downgradeCollations(in))
+	}
+}
+
+func TestRewriteDDLTargetVersion(t *testing.T) {
+	create := "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"
+	downgraded := "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci"
+
+	assert.Equal(t, create, (&Data{}).rewriteDDL(create))
+	assert.Equal(t, create, (&Data{TargetVersion: "8.0.36"}).rewriteDDL(create))
+	assert.Equal(t, downgraded, (&Data{TargetVersion: "5.7"}).rewriteDDL(create))
+	assert.Equal(t, downgraded, (&Data{TargetVersion: "10.11.6-MariaDB"}).rewriteDDL(create))
+	assert.Equal(t, downgraded, (&Data{TargetVersion: "5.7", CharsetConvert: true}).rewriteDDL("ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8mb4_0900_ai_ci"))
+}
+
+func TestHeaderTargetVersion(t *testing.T) {
+	data := &Data{}
+	assert.NoError(t, data.getTemplates())
+
+	var buf bytes.Buffer
+	assert.NoError(t, data.headerTmpl.Execute(&buf, &metaData{DumpVersion: Version, ServerVersion: "8.0.36", TargetVersion: "5.7"}))
+	assert.Contains(t, buf.String(), "-- Server version\t8.0.36\n-- Target version\t5.7\n\n/*!40101")
+
+	comments := []string{"Go SQL Dump " + Version, "Server version\t8.0.36", "Target version\t5.7"}
+	assert.Equal(t, "5.7", dumpServerVersion(comments))
+	assert.Equal(t, "8.0.36", dumpServerVersion(comments[:2]))
+}
@@ -0,0 +1,121 @@
+package mysqldump
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Checkpoint stores the last table a restore completed.
+type Checkpoint interface {
+	// Load returns the last completed table, or an empty string if there is
+	// nothing to resume.
+	Load() (string, error)
+	// Save records table as completed. An empty name marks the restore as
+	// finished.
+	Save(table string) error
+}
+
+// FileCheckpoint keeps the checkpoint of a restore in the file at path.
+type FileCheckpoint string
+
+// Load implements Checkpoint, a missing file means there is nothing to resume.
+func (p FileCheckpoint) Load() (string, error) {
+	b, err := ioutil.ReadFile(string(p))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Save implements Checkpoint. The file is replaced atomically so an
+// interruption never leaves a truncated checkpoint behind.
+func (p FileCheckpoint) Save(table string) error {
+	if table == "" {
+		err := os.Remove(string(p))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	tmp := string(p) + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(table+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, string(p))
+}
+
+// resumer decides which table sections of a dump are skipped
+type resumer struct {
+	from     string
+	after    string
+	seen     bool
+	skipping bool
+}
+
+// newResumer skips everything in front of the section of table
+func newResumer(table string) *resumer {
+	return &resumer{from: table, skipping: table != ""}
+}
+
+// newResumerAfter skips everything up to and including the section of table
+func newResumerAfter(table string) *resumer {
+	return &resumer{after: table, from: table, skipping: table != ""}
+}
+
+// enter is called whenever the section of another table begins
+func (r *resumer) enter(table string) {
+	if !r.skipping {
+		return
+	}
+	if r.after == "" {
+		r.skipping = sectionBase(table) != r.from
+		return
+	}
+	if r.seen {
+		r.skipping = false
+	} else if table == r.after {
+		r.seen = true
+	}
+}
+
+// missing reports whether the table to start from never showed up
+func (r *resumer) missing() bool {
+	if r.after != "" {
+		return !r.seen
+	}
+	return r.skipping
+}
+
+// sectionTable extracts the table from the comments introducing a table or
+// view section, like "Table structure for table `name`". The data, deferred
+// index and foreign key sections are named like "name/data" and
+// "name/indexes" so resuming does not confuse them with the structure of the
+// table, which they can be far from with a SectionOrder putting the schema
+// first, the routines and events of the database "/routines" and "/events"
+// and the accounts of the server "/grants".
+func sectionTable(comments []string) string {
+	for _, comment := range comments {
+		switch comment {
+		case "Dumping routines":
+			return "/routines"
+		case "Dumping events":
+			return "/events"
+		case "Dumping users and grants":
+			return "/grants"
+		}
+		for prefix, suffix := range map[string]string{"Dumping data for table ": "/data", "Indexes for table ": "/indexes", "Constraints for table ": "/constraints", "Triggers for table ": "/triggers"} {
+			if strings.HasPrefix(comment, prefix) {
+				return strings.Trim(strings.TrimPrefix(comment, prefix), "`") + suffix
+			}
+		}
+		for _, prefix := range []string{"Table structure for table ", "View structure for view "} {
+			if strings.HasPrefix(comment, prefix) {
+				return strings.Trim(strings.TrimPrefix(comment, prefix), "`")
+			}
+		}
+	}
+	return ""
+}
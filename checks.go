@@ -0,0 +1,93 @@
+package mysqldump
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// CheckConstraints is what becomes of the CHECK constraints of the tables,
+// which MySQL enforces from 8.0.16 on. Rows the constraints reject, dumped
+// from an older server or loaded while they were not enforced, fail the
+// restore of a dump keeping them.
+type CheckConstraints string
+
+const (
+	// CheckConstraintsKeep writes the CHECK constraints as SHOW CREATE TABLE
+	// returns them.
+	CheckConstraintsKeep CheckConstraints = ""
+	// CheckConstraintsNotEnforced writes the enforced CHECK constraints as NOT
+	// ENFORCED, they are kept for the target to enforce once the data is
+	// fixed with ALTER TABLE ... ALTER CHECK ... ENFORCED.
+	CheckConstraintsNotEnforced CheckConstraints = "not-enforced"
+	// CheckConstraintsStrip leaves the CHECK constraints out, for targets
+	// knowing no NOT ENFORCED like MariaDB.
+	CheckConstraintsStrip CheckConstraints = "strip"
+)
+
+// ErrUnknownCheckConstraints is returned for CheckConstraints that don't
+// exist.
+var ErrUnknownCheckConstraints = errors.New("unknown CHECK constraints handling")
+
+var (
+	checkConstraintRe = regexp.MustCompile("(?i)^(?:CONSTRAINT\\s+(`(?:[^`]|``)+`)\\s+)?CHECK\\s*\\(")
+	notEnforcedRe     = regexp.MustCompile(`(?i)(?:/\*!\d+\s*)?\bNOT\s+ENFORCED\b(?:\s*\*/)?$`)
+)
+
+func (data *Data) checkCheckConstraints() error {
+	switch data.CheckConstraints {
+	case CheckConstraintsKeep, CheckConstraintsNotEnforced, CheckConstraintsStrip:
+		return nil
+	}
+	return ErrUnknownCheckConstraints
+}
+
+// rewriteChecks applies CheckConstraints to the CHECK constraints of the
+// output of SHOW CREATE TABLE, warning about the tables it changes
+func (table *table) rewriteChecks(create string) string {
+	if table.data.CheckConstraints == CheckConstraintsKeep {
+		return create
+	}
+	lines := strings.Split(create, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], ")") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return create
+	}
+
+	var defs, changed []string
+	for _, line := range lines[1:end] {
+		def := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		m := checkConstraintRe.FindStringSubmatch(def)
+		switch {
+		case m == nil:
+			defs = append(defs, def)
+			continue
+		case table.data.CheckConstraints == CheckConstraintsStrip:
+		case notEnforcedRe.MatchString(def):
+			defs = append(defs, def)
+			continue
+		default:
+			defs = append(defs, def+" /*!80016 NOT ENFORCED */")
+		}
+		name := m[1]
+		if name == "" {
+			name = "CHECK"
+		}
+		changed = append(changed, name)
+	}
+	if len(changed) == 0 {
+		return create
+	}
+	if table.data.CheckConstraints == CheckConstraintsStrip {
+		table.data.warn("CHECK constraints of table " + table.NameEsc() + " left out: " + strings.Join(changed, ", "))
+	} else {
+		table.data.warn("CHECK constraints of table " + table.NameEsc() + " not enforced: " + strings.Join(changed, ", "))
+	}
+	return lines[0] + "\n  " + strings.Join(defs, ",\n  ") + "\n" + strings.Join(lines[end:], "\n")
+}
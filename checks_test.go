@@ -0,0 +1,56 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const checksDDL = "CREATE TABLE `t` (\n" +
+	"  `id` int NOT NULL,\n" +
+	"  `a` int DEFAULT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  CONSTRAINT `t_chk_1` CHECK ((`a` > 0)),\n" +
+	"  CONSTRAINT `t_chk_2` CHECK ((`a` < 100)) /*!80016 NOT ENFORCED */\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='CONSTRAINT `x` CHECK (1)'"
+
+func rewriteChecks(checks CheckConstraints) (string, []string) {
+	data := &Data{CheckConstraints: checks}
+	create := data.createTable("t", false).rewriteChecks(checksDDL)
+	return create, data.warnings
+}
+
+func TestRewriteChecks(t *testing.T) {
+	create, warnings := rewriteChecks(CheckConstraintsKeep)
+	assert.Equal(t, checksDDL, create)
+	assert.Empty(t, warnings)
+
+	// The constraints not enforced already stay as they are
+	create, warnings = rewriteChecks(CheckConstraintsNotEnforced)
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `a` int DEFAULT NULL,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  CONSTRAINT `t_chk_1` CHECK ((`a` > 0)) /*!80016 NOT ENFORCED */,\n"+
+		"  CONSTRAINT `t_chk_2` CHECK ((`a` < 100)) /*!80016 NOT ENFORCED */\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='CONSTRAINT `x` CHECK (1)'", create)
+	assert.Equal(t, []string{"CHECK constraints of table `t` not enforced: `t_chk_1`"}, warnings)
+
+	create, warnings = rewriteChecks(CheckConstraintsStrip)
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `a` int DEFAULT NULL,\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='CONSTRAINT `x` CHECK (1)'", create)
+	assert.Equal(t, []string{"CHECK constraints of table `t` left out: `t_chk_1`, `t_chk_2`"}, warnings)
+}
+
+func TestRewriteChecksNone(t *testing.T) {
+	data := &Data{CheckConstraints: CheckConstraintsStrip}
+	assert.Equal(t, tableOptionsDDL, data.createTable("t", false).rewriteChecks(tableOptionsDDL))
+	assert.Empty(t, data.warnings)
+}
+
+func TestDumpUnknownCheckConstraints(t *testing.T) {
+	assert.Equal(t, ErrUnknownCheckConstraints, (&Data{CheckConstraints: "drop"}).Dump())
+}
@@ -0,0 +1,125 @@
+/*
+Command go-mysqldump dumps and restores MySQL databases without the mysqldump
+and mysql clients.
+
+	go-mysqldump dump [-config runner.json] [-progress json]
+	go-mysqldump restore [flags] dump.sql
+
+dump runs a mysqldump.Runner configured from the JSON file and the MYSQLDUMP_*
+environment variables and exits with its exit code. With -progress=json it
+writes the progress to stdout as JSON lines for wrappers and UIs, a progress
+event with the table, rows, bytes and eta in seconds every -progress-interval
+and a done event with the exit code.
+
+restore replays a dump into the database of -dsn, or MYSQLDUMP_DSN, and asks
+before the dump drops tables, views or databases that exist in the target.
+
+	-dry-run:            Parse the dump and report what would be restored, and dropped if -dsn is set
+	-only-tables:        Comma separated list of the only tables and views to restore
+	-target-db:          Restore into this schema instead of the database of the dump
+	-force:              Restore into a schema that already contains tables
+	-yes:                Drop existing objects without asking
+	-skip-version-check: Restore even if the target server is older than the source
+	-checkpoint:         File recording completed tables so an interrupted restore resumes
+	-defer-triggers:     Create the triggers only once all rows are restored
+	-trigger-guard:      User variable set to 1 during the restore for triggers checking it, e.g. DISABLE_TRIGGERS
+	-visible-indexes:    Create the INVISIBLE indexes as visible ones, for a target that rejects the attribute
+	-engine:             Create the tables with this storage engine, e.g. InnoDB for the MyISAM tables of old dumps
+	-row-format:         Create the tables with this ROW_FORMAT, e.g. DYNAMIC
+	-charset:            Replace the character sets of the databases, tables and columns, e.g. utf8mb4
+	-collation:          Replace the collations by this one of -charset, dropped for its default collation otherwise
+*/
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jamf/go-mysqldump"
+)
+
+const usage = `usage: go-mysqldump <command> [flags]
+
+commands:
+  dump     dump a database as configured by a runner config
+  restore  restore a dump into a database
+`
+
+// cli is the environment the commands run in
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	open   func(dsn string) (*sql.DB, error)
+	// terminal reports whether stdin can answer prompts
+	terminal bool
+}
+
+func main() {
+	c := &cli{
+		stdin:    os.Stdin,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		open:     func(dsn string) (*sql.DB, error) { return sql.Open("mysql", dsn) },
+		terminal: isTerminal(os.Stdin),
+	}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "dump":
+		return c.dump(args[1:])
+	case "restore":
+		return c.restore(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.stdout, usage)
+		return 0
+	}
+	fmt.Fprintf(c.stderr, "unknown command %q\n%s", args[0], usage)
+	return 2
+}
+
+func (c *cli) dump(args []string) int {
+	flags := c.flags("dump")
+	path := flags.String("config", os.Getenv("MYSQLDUMP_CONFIG"), "JSON runner config, the MYSQLDUMP_* environment variables override it")
+	progress := flags.String("progress", "", "write the progress to stdout, as JSON lines with json")
+	interval := flags.Duration("progress-interval", 5*time.Second, "time between the progress events")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *progress != "" && *progress != "json" {
+		fmt.Fprintf(c.stderr, "unknown progress format %q\n", *progress)
+		return 2
+	}
+
+	config, err := mysqldump.LoadRunnerConfig(*path)
+	if err != nil {
+		fmt.Fprintln(c.stderr, "loading config:", err)
+		return mysqldump.ExitPermanent
+	}
+	runner := &mysqldump.Runner{Config: config, Log: c.stderr}
+	if *progress == "" {
+		return runner.Run(context.Background())
+	}
+	p := newProgressWriter(c.stdout)
+	runner.Progress = p.progress
+	runner.ProgressInterval = *interval
+	code := runner.Run(context.Background())
+	p.done(code)
+	return code
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
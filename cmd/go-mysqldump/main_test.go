@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDump = `-- Go SQL Dump 0.7.0
+--
+-- ------------------------------------------------------
+-- Server version	8.0.34
+
+--
+-- Table structure for table ` + "`a`" + `
+--
+
+DROP TABLE IF EXISTS ` + "`a`" + `;
+CREATE TABLE ` + "`a`" + ` (id int);
+
+--
+-- Table structure for table ` + "`b`" + `
+--
+
+DROP TABLE IF EXISTS ` + "`b`" + `;
+CREATE TABLE ` + "`b`" + ` (id int);
+`
+
+func writeDump(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "go-mysqldump")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "dump.sql")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testDump), 0644))
+	return path
+}
+
+func newCLI(t *testing.T, stdin string) (*cli, sqlmock.Sqlmock, *bytes.Buffer, *bytes.Buffer) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	t.Cleanup(func() { db.Close() })
+
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdin:    strings.NewReader(stdin),
+		stdout:   &stdout,
+		stderr:   &stderr,
+		open:     func(string) (*sql.DB, error) { return db, nil },
+		terminal: true,
+	}
+	return c, mock, &stdout, &stderr
+}
+
+func TestUnknownCommand(t *testing.T) {
+	c, _, _, stderr := newCLI(t, "")
+	assert.Equal(t, 2, c.run([]string{"load"}))
+	assert.Contains(t, stderr.String(), `unknown command "load"`)
+}
+
+func TestRestoreDryRun(t *testing.T) {
+	c, _, stdout, _ := newCLI(t, "")
+	assert.Equal(t, 0, c.run([]string{"restore", "-dry-run", "-only-tables", "b", writeDump(t)}))
+	assert.Equal(t, "2 statements\n1 tables and views: b\ndrops table `b`\n", stdout.String())
+}
+
+func TestRestoreDryRunCompressed(t *testing.T) {
+	path := writeDump(t)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(testDump))
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, ioutil.WriteFile(path+".gz", buf.Bytes(), 0644))
+
+	c, _, stdout, _ := newCLI(t, "")
+	assert.Equal(t, 0, c.run([]string{"restore", "-dry-run", path + ".gz"}))
+	assert.Equal(t, "4 statements\n2 tables and views: a, b\ndrops table `a`\ndrops table `b`\n", stdout.String())
+}
+
+func TestRestoreConfirmed(t *testing.T) {
+	c, mock, _, stderr := newCLI(t, "y\n")
+
+	mock.ExpectExec("^USE `dst`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT TABLE_NAME FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("a"))
+	mock.ExpectExec("^USE `dst`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec("^DROP TABLE IF EXISTS `a`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `a` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^DROP TABLE IF EXISTS `b`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `b` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Equal(t, 0, c.run([]string{"restore", "-dsn", "test", "-target-db", "dst", "-skip-version-check", writeDump(t)}))
+	assert.Contains(t, stderr.String(), "  table `a`\nContinue? [y/N] ")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreDeclined(t *testing.T) {
+	c, mock, _, stderr := newCLI(t, "\n")
+
+	mock.ExpectQuery(`^SELECT TABLE_NAME FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("b"))
+
+	assert.Equal(t, 1, c.run([]string{"restore", "-dsn", "test", writeDump(t)}))
+	assert.Contains(t, stderr.String(), "restore: restore aborted\n")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreNotInteractive(t *testing.T) {
+	c, mock, _, stderr := newCLI(t, "")
+	c.terminal = false
+
+	mock.ExpectQuery(`^SELECT TABLE_NAME FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("b"))
+
+	assert.Equal(t, 1, c.run([]string{"restore", "-dsn", "test", writeDump(t)}))
+	assert.Contains(t, stderr.String(), "confirm with -yes")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
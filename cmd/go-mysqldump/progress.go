@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jamf/go-mysqldump"
+)
+
+// progressEvent is a line of the NDJSON progress of -progress=json
+type progressEvent struct {
+	Event         string   `json:"event"`
+	Time          string   `json:"time"`
+	Table         string   `json:"table,omitempty"`
+	Rows          int64    `json:"rows"`
+	TotalRows     int64    `json:"totalRows"`
+	EstimatedRows int64    `json:"estimatedRows,omitempty"`
+	Bytes         int64    `json:"bytes"`
+	Elapsed       float64  `json:"elapsed"`
+	ETA           *float64 `json:"eta,omitempty"`
+	ExitCode      *int     `json:"exitCode,omitempty"`
+}
+
+// progressWriter writes the progress of a dump as JSON lines, one progress
+// event for every heartbeat and a done event with the exit code
+type progressWriter struct {
+	mu   sync.Mutex
+	enc  *json.Encoder
+	last mysqldump.Heartbeat
+}
+
+func newProgressWriter(w io.Writer) *progressWriter {
+	return &progressWriter{enc: json.NewEncoder(w)}
+}
+
+func (p *progressWriter) progress(h mysqldump.Heartbeat) {
+	event := progressEvent{
+		Event:         "progress",
+		Time:          h.Time.UTC().Format(time.RFC3339Nano),
+		Table:         h.Table,
+		Rows:          h.Rows,
+		TotalRows:     h.TotalRows,
+		EstimatedRows: h.EstimatedRows,
+		Bytes:         h.Bytes,
+		Elapsed:       h.Elapsed.Seconds(),
+	}
+	if eta := h.ETA(); eta > 0 {
+		seconds := eta.Seconds()
+		event.ETA = &seconds
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = h
+	p.enc.Encode(event)
+}
+
+// done ends the progress with the totals of the last heartbeat
+func (p *progressWriter) done(code int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enc.Encode(progressEvent{
+		Event:     "done",
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		TotalRows: p.last.TotalRows,
+		Bytes:     p.last.Bytes,
+		Elapsed:   p.last.Elapsed.Seconds(),
+		ExitCode:  &code,
+	})
+}
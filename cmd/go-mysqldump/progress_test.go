@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeEvents(t *testing.T, out string) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var event map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestProgressWriter(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgressWriter(&buf)
+	p.progress(mysqldump.Heartbeat{
+		Time:          time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Elapsed:       10 * time.Second,
+		Table:         "users",
+		Rows:          40,
+		TotalRows:     100,
+		EstimatedRows: 400,
+		Bytes:         2048,
+	})
+	p.progress(mysqldump.Heartbeat{Elapsed: 20 * time.Second, Table: "users", Rows: 80, TotalRows: 140, Bytes: 4096})
+	p.done(0)
+
+	events := decodeEvents(t, buf.String())
+	if !assert.Len(t, events, 3) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{
+		"event":         "progress",
+		"time":          "2024-01-02T03:04:05Z",
+		"table":         "users",
+		"rows":          40.0,
+		"totalRows":     100.0,
+		"estimatedRows": 400.0,
+		"bytes":         2048.0,
+		"elapsed":       10.0,
+		"eta":           30.0,
+	}, events[0])
+	assert.NotContains(t, events[1], "eta")
+	assert.Equal(t, "done", events[2]["event"])
+	assert.Equal(t, 0.0, events[2]["exitCode"])
+	assert.Equal(t, 140.0, events[2]["totalRows"])
+	assert.Equal(t, 4096.0, events[2]["bytes"])
+}
+
+func TestDumpProgressDone(t *testing.T) {
+	t.Setenv("MYSQLDUMP_DSN", "")
+	t.Setenv("MYSQLDUMP_DSNS", "")
+	c, _, stdout, _ := newCLI(t, "")
+	assert.Equal(t, mysqldump.ExitPermanent, c.run([]string{"dump", "-progress", "json"}))
+
+	events := decodeEvents(t, stdout.String())
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "done", events[0]["event"])
+		assert.EqualValues(t, mysqldump.ExitPermanent, events[0]["exitCode"])
+	}
+}
+
+func TestDumpUnknownProgress(t *testing.T) {
+	c, _, stdout, stderr := newCLI(t, "")
+	assert.Equal(t, 2, c.run([]string{"dump", "-progress", "text"}))
+	assert.Contains(t, stderr.String(), `unknown progress format "text"`)
+	assert.Empty(t, stdout.String())
+}
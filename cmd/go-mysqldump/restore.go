@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamf/go-mysqldump"
+)
+
+// errAborted is returned when the drop of existing objects is not confirmed
+var errAborted = errors.New("restore aborted")
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	return flags
+}
+
+func (c *cli) restore(args []string) int {
+	flags := c.flags("restore")
+	dsn := flags.String("dsn", os.Getenv("MYSQLDUMP_DSN"), "data source name of the target database")
+	dryRun := flags.Bool("dry-run", false, "parse the dump and report what would be restored")
+	onlyTables := flags.String("only-tables", "", "comma separated list of the only tables and views to restore")
+	targetDB := flags.String("target-db", "", "restore into this schema instead of the database of the dump")
+	force := flags.Bool("force", false, "restore into a schema that already contains tables")
+	yes := flags.Bool("yes", false, "drop existing objects without asking")
+	skipVersionCheck := flags.Bool("skip-version-check", false, "restore even if the target server is older than the source")
+	checkpoint := flags.String("checkpoint", "", "file recording completed tables so an interrupted restore resumes")
+	deferTriggers := flags.Bool("defer-triggers", false, "create the triggers only once all rows are restored")
+	triggerGuard := flags.String("trigger-guard", "", "user variable set to 1 during the restore for triggers checking it, e.g. DISABLE_TRIGGERS")
+	visibleIndexes := flags.Bool("visible-indexes", false, "create the INVISIBLE indexes as visible ones")
+	engine := flags.String("engine", "", "create the tables with this storage engine, e.g. InnoDB")
+	rowFormat := flags.String("row-format", "", "create the tables with this ROW_FORMAT, e.g. DYNAMIC")
+	charset := flags.String("charset", "", "replace the character sets of the databases, tables and columns")
+	collation := flags.String("collation", "", "replace the collations by this one of -charset")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "usage: go-mysqldump restore [flags] <dump.sql|->")
+		return 2
+	}
+	path := flags.Arg(0)
+
+	r := &mysqldump.Restorer{
+		Force:            *force,
+		SkipVersionCheck: *skipVersionCheck,
+		Database:         *targetDB,
+		DeferTriggers:    *deferTriggers,
+		TriggerGuard:     *triggerGuard,
+		VisibleIndexes:   *visibleIndexes,
+		Engine:           *engine,
+		RowFormat:        *rowFormat,
+		Charset:          *charset,
+		Collation:        *collation,
+	}
+	if *onlyTables != "" {
+		r.Tables = strings.Split(*onlyTables, ",")
+	}
+	if *checkpoint != "" {
+		r.Checkpoint = mysqldump.FileCheckpoint(*checkpoint)
+	}
+	if *dsn != "" {
+		db, err := c.open(*dsn)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return 1
+		}
+		defer db.Close()
+		r.Connection = db
+	} else if !*dryRun {
+		fmt.Fprintln(c.stderr, "restore: -dsn or MYSQLDUMP_DSN is required")
+		return 2
+	}
+
+	if err := c.restoreDump(r, path, *dryRun, *yes); err != nil {
+		fmt.Fprintln(c.stderr, "restore:", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) restoreDump(r *mysqldump.Restorer, path string, dryRun, yes bool) error {
+	// A dump on stdin can only be read once, the restore itself refuses to
+	// overwrite tables without -force then
+	if path == "-" {
+		if dryRun {
+			return c.report(r, c.stdin)
+		}
+		return r.Restore(c.stdin)
+	}
+
+	f, err := openDump(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if dryRun {
+		return c.report(r, f)
+	}
+
+	plan, err := r.Plan(f)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	conflicts, err := r.Conflicts(plan)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 && !yes {
+		if err := c.confirm(conflicts); err != nil {
+			return err
+		}
+	}
+	// Dropping what is there is confirmed, the rest of the schema may stay
+	if len(conflicts) > 0 {
+		r.Force = true
+	}
+
+	if f, err = openDump(path); err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Restore(f)
+}
+
+// openDump opens the dump at path, decoded by the codec named by its
+// extension if there is one
+func openDump(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := mysqldump.LookupCodec(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return f, nil
+	}
+	r, err := codec.Unwrap(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &dumpFile{ReadCloser: r, f: f}, nil
+}
+
+// dumpFile closes both the decoder and the file
+type dumpFile struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (d *dumpFile) Close() error {
+	err := d.ReadCloser.Close()
+	if ferr := d.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// report prints the plan of the restore and, with a connection, the existing
+// objects it would drop
+func (c *cli) report(r *mysqldump.Restorer, in io.Reader) error {
+	plan, err := r.Plan(in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "%d statements\n", plan.Statements)
+	fmt.Fprintf(c.stdout, "%d tables and views: %s\n", len(plan.Tables), strings.Join(plan.Tables, ", "))
+	for _, drop := range plan.Drops {
+		fmt.Fprintf(c.stdout, "drops %s\n", drop)
+	}
+	if r.Connection == nil {
+		return nil
+	}
+	conflicts, err := r.Conflicts(plan)
+	if err != nil {
+		return err
+	}
+	for _, drop := range conflicts {
+		fmt.Fprintf(c.stdout, "would drop existing %s\n", drop)
+	}
+	return nil
+}
+
+// confirm asks whether the existing objects may be dropped
+func (c *cli) confirm(conflicts []mysqldump.RestoreDrop) error {
+	if !c.terminal {
+		return fmt.Errorf("%w: the dump drops %d existing objects, confirm with -yes", errAborted, len(conflicts))
+	}
+	fmt.Fprintln(c.stderr, "The dump drops these existing objects:")
+	for _, drop := range conflicts {
+		fmt.Fprintln(c.stderr, "  "+drop.String())
+	}
+	fmt.Fprint(c.stderr, "Continue? [y/N] ")
+	answer, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errAborted
+}
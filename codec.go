@@ -0,0 +1,134 @@
+package mysqldump
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Codec compresses or otherwise encodes the output of a dump. Its name is the
+// file extension, without the dot, of the files it writes, like "gz" for
+// "dump.sql.gz".
+type Codec interface {
+	Name() string
+	// Wrap returns a writer encoding to w, closing it flushes the encoding
+	// but does not close w
+	Wrap(w io.Writer) (io.WriteCloser, error)
+	// Unwrap returns a reader decoding r
+	Unwrap(r io.Reader) (io.ReadCloser, error)
+}
+
+// ErrUnknownCodec is returned for codecs that are not registered.
+var ErrUnknownCodec = errors.New("unknown codec")
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec(gzipCodec{level: gzip.DefaultCompression})
+	RegisterCodec(frameCodec{})
+}
+
+// RegisterCodec makes codec available by its name, to the formats of the
+// Handler and the Runner among others. Like database/sql.Register it panics if
+// a codec of that name is already registered.
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	name := codec.Name()
+	if name == "" || strings.ContainsAny(name, "./") {
+		panic("invalid codec name " + name)
+	}
+	if _, dup := codecs[name]; dup {
+		panic("RegisterCodec called twice for codec " + name)
+	}
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered under name.
+func LookupCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, ErrUnknownCodec
+	}
+	return codec, nil
+}
+
+// Codecs returns the sorted names of the registered codecs.
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseFormat splits formats like sql, tar or sql.gz into the container, sql
+// or tar, and the codec applied to it, nil if there is none
+func parseFormat(format string) (string, Codec, error) {
+	base, name := format, ""
+	if i := strings.IndexByte(format, '.'); i >= 0 {
+		base, name = format[:i], format[i+1:]
+	}
+	if base != "sql" && base != "tar" && base != "zip" || base == "zip" && name != "" {
+		return "", nil, errors.New("unknown format " + format)
+	}
+	if name == "" {
+		return base, nil, nil
+	}
+	codec, err := LookupCodec(name)
+	if err != nil {
+		return "", nil, errors.New("unknown format " + format)
+	}
+	return base, codec, nil
+}
+
+// ErrInvalidCompressLevel is returned for gzip levels outside of
+// gzip.HuffmanOnly to gzip.BestCompression.
+var ErrInvalidCompressLevel = errors.New("compression level must be between -2 and 9")
+
+// NewGzipCodec returns the gz codec compressing at a level of compress/gzip
+// instead of the default one, like gzip.BestSpeed for large dumps.
+func NewGzipCodec(level int) (Codec, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, ErrInvalidCompressLevel
+	}
+	return gzipCodec{level: level}, nil
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func (gzipCodec) Name() string { return "gz" }
+
+func (c gzipCodec) Wrap(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCodec) Unwrap(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type frameCodec struct{}
+
+func (frameCodec) Name() string { return "frame" }
+
+func (frameCodec) Wrap(w io.Writer) (io.WriteCloser, error) {
+	return NewFrameWriter(w), nil
+}
+
+func (frameCodec) Unwrap(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(NewFrameReader(r)), nil
+}
@@ -0,0 +1,104 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+type base64Codec struct{}
+
+func (base64Codec) Name() string { return "b64" }
+
+func (base64Codec) Wrap(w io.Writer) (io.WriteCloser, error) {
+	return base64.NewEncoder(base64.StdEncoding, w), nil
+}
+
+func (base64Codec) Unwrap(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+}
+
+func init() {
+	mysqldump.RegisterCodec(base64Codec{})
+}
+
+func unwrap(t *testing.T, codec mysqldump.Codec, s string) string {
+	r, err := codec.Unwrap(strings.NewReader(s))
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestCodecRegistry(t *testing.T) {
+	assert.Equal(t, []string{"b64", "frame", "gz"}, mysqldump.Codecs())
+
+	codec, err := mysqldump.LookupCodec("b64")
+	assert.NoError(t, err)
+	assert.Equal(t, base64Codec{}, codec)
+
+	_, err = mysqldump.LookupCodec("zst")
+	assert.True(t, errors.Is(err, mysqldump.ErrUnknownCodec))
+
+	assert.Panics(t, func() { mysqldump.RegisterCodec(base64Codec{}) })
+}
+
+func TestDumpFilesCodec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	archive := mysqldump.NewTarWriter(&buf, false)
+	data := &mysqldump.Data{Connection: db, Files: archive, Codec: base64Codec{}}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, archive.Close())
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	names, files := readTar(t, &buf)
+	assert.Equal(t, []string{"schema.sql.b64", "data/Test_Table.sql.b64", "manifest.json", "SHA256SUMS"}, names)
+	assert.Contains(t, unwrap(t, base64Codec{}, files["schema.sql.b64"]), "CREATE TABLE 'Test_Table'")
+	assert.Contains(t, unwrap(t, base64Codec{}, files["data/Test_Table.sql.b64"]), "INSERT INTO `Test_Table`")
+
+	var manifest mysqldump.Manifest
+	assert.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	assert.Len(t, manifest.Files, 2)
+	assert.Equal(t, "data/Test_Table.sql.b64", manifest.Files[1].Name)
+	assert.Equal(t, "b64", manifest.Files[1].Codec)
+	assert.Equal(t, int64(len(files["data/Test_Table.sql.b64"])), manifest.Files[1].Size)
+}
+
+func TestHandlerCodec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?format=sql.b64", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `.sql.b64"`)
+	assert.Contains(t, unwrap(t, base64Codec{}, rec.Body.String()), "INSERT INTO `Test_Table`")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?format=sql.zst", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
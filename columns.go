@@ -0,0 +1,100 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// isSkippedColumn reports whether SkipColumns leaves the column out of the
+// rows of the table, listed for the table or for * for any table
+func (table *table) isSkippedColumn(column string) bool {
+	return table.data.isListed(table.data.SkipColumns[table.Name], column) || table.data.isListed(table.data.SkipColumns["*"], column)
+}
+
+// addColumn adds a dumped column to the table unless it is skipped. Without
+// a column of the primary key the rows can't be paged or sampled by it, the
+// table is then read like one without a key.
+func (table *table) addColumn(column, columnType string, key bool) {
+	if table.isSkippedColumn(column) {
+		if key {
+			table.pk = nil
+			table.keySkipped = true
+		}
+		return
+	}
+	if key && !table.keySkipped {
+		table.pk = append(table.pk, len(table.cols))
+	}
+	table.cols = append(table.cols, column)
+	table.colTypes = append(table.colTypes, columnType)
+}
+
+// orderKey puts the primary key in the order of columns, the columns of the
+// key in the order it was declared with. SHOW COLUMNS and COLUMNS list them
+// in the order of the table, which pages and samples by another order than
+// the index of the key once it has several columns. The order of the table
+// is kept if columns does not name the same columns.
+func (table *table) orderKey(columns []string) {
+	if len(columns) != len(table.pk) {
+		return
+	}
+	index := make(map[string]int, len(table.cols))
+	for _, i := range table.pk {
+		index[table.cols[i]] = i
+	}
+	pk := make([]int, 0, len(columns))
+	for _, column := range columns {
+		i, ok := index[column]
+		if !ok {
+			return
+		}
+		pk = append(pk, i)
+	}
+	table.pk = pk
+}
+
+// loadKey reads the order of the columns of a primary key of several columns
+// from SHOW KEYS, which lists the columns of an index by Seq_in_index
+func (table *table) loadKey() error {
+	if len(table.pk) < 2 {
+		return nil
+	}
+	keyInfo, err := table.metadataQuery("SHOW KEYS FROM " + table.NameEsc() + " WHERE Key_name = 'PRIMARY'")
+	if err != nil {
+		return err
+	}
+	defer keyInfo.Close()
+
+	cols, err := keyInfo.Columns()
+	if err != nil {
+		return err
+	}
+	columnIndex := -1
+	for i, col := range cols {
+		switch col {
+		case "Column_name", "column_name":
+			columnIndex = i
+		}
+	}
+	if columnIndex < 0 {
+		return errors.New("database key information is malformed")
+	}
+
+	info := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range info {
+		scans[i] = &info[i]
+	}
+	var columns []string
+	for keyInfo.Next() {
+		if err := keyInfo.Scan(scans...); err != nil {
+			return err
+		}
+		columns = append(columns, info[columnIndex].String)
+	}
+	if err := keyInfo.Err(); err != nil {
+		return err
+	}
+	table.orderKey(columns)
+	return nil
+}
@@ -0,0 +1,101 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockUserColumns(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("^SHOW COLUMNS FROM `users`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).
+		AddRow("id", "int", "NO", "PRI", nil, "").
+		AddRow("email", "varchar(255)", "YES", "", nil, "").
+		AddRow("password_hash", "char(60)", "YES", "", nil, "").
+		AddRow("avatar", "longblob", "YES", "", nil, ""))
+}
+
+func TestSkipColumns(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.SkipColumns = map[string][]string{"users": {"password_hash"}, "*": {"avatar"}}
+
+	mockUserColumns(mock)
+	mock.ExpectQuery("^SELECT `id`, `email` FROM `users`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", "")).AddRow(1, "a@example.com"))
+
+	table := data.createTable("users", false)
+	assert.True(t, table.Next())
+	assert.NoError(t, table.Err)
+	assert.Equal(t, []string{"id", "email"}, table.cols)
+	assert.Equal(t, []string{"int", "varchar(255)"}, table.colTypes)
+	assert.Equal(t, []int{0}, table.pk)
+	assert.Equal(t, "(1,'a@example.com')", table.RowBuffer().String())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestSkipColumnsOfKey(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.SkipColumns = map[string][]string{"users": {"id"}}
+	data.FetchSize = 10
+
+	mockUserColumns(mock)
+	// Without its key the table is not paged
+	mock.ExpectQuery("^SELECT `email`, `password_hash`, `avatar` FROM `users`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("email", ""), c("password_hash", ""), c("avatar", "")))
+
+	table := data.createTable("users", false)
+	assert.False(t, table.Next())
+	assert.NoError(t, table.Err)
+	assert.Empty(t, table.pk)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestSkipColumnsInformationSchema(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.SkipColumns = map[string][]string{"users": {"password_hash"}}
+
+	mock.ExpectQuery("^SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_KEY", "EXTRA"}).
+			AddRow("users", "id", "int", "PRI", "").
+			AddRow("users", "password_hash", "char(60)", "", "").
+			AddRow("logs", "password_hash", "text", "", ""))
+
+	users, logs := data.createTable("users", false), data.createTable("logs", false)
+	assert.NoError(t, data.loadColumns([]*table{users, logs}))
+	assert.Equal(t, []string{"id"}, users.cols)
+	assert.Equal(t, []int{0}, users.pk)
+	assert.Equal(t, []string{"password_hash"}, logs.cols)
+}
+
+func TestCompositeKeyOrderInformationSchema(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_KEY", "EXTRA"}).
+			AddRow("lines", "line", "int", "PRI", "").
+			AddRow("lines", "order_id", "int", "PRI", "").
+			AddRow("lines", "sku", "text", "", "").
+			AddRow("users", "id", "int", "PRI", ""))
+	mock.ExpectQuery("^SELECT TABLE_NAME, COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE\\(\\) AND INDEX_NAME = 'PRIMARY' ORDER BY TABLE_NAME, SEQ_IN_INDEX$").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME"}).
+			AddRow("lines", "order_id").
+			AddRow("lines", "line").
+			AddRow("users", "id"))
+
+	lines, users := data.createTable("lines", false), data.createTable("users", false)
+	tables := []*table{lines, users}
+	assert.NoError(t, data.loadColumns(tables))
+	assert.NoError(t, data.loadKeys(tables))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []int{1, 0}, lines.pk)
+	assert.Equal(t, "`order_id`, `line`", lines.orderBy())
+	assert.Equal(t, []int{0}, users.pk)
+}
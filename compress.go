@@ -0,0 +1,57 @@
+package mysqldump
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// compressLevel returns the gzip level of Compress
+func (data *Data) compressLevel() int {
+	if data.CompressLevel == 0 {
+		return gzip.DefaultCompression
+	}
+	return data.CompressLevel
+}
+
+func (data *Data) checkCompress() error {
+	if !data.Compress {
+		return nil
+	}
+	_, err := data.compressCodec()
+	return err
+}
+
+// compressCodec returns the codec Out is encoded with by Compress, Codec if
+// set or gzip at CompressLevel
+func (data *Data) compressCodec() (Codec, error) {
+	if data.Codec != nil {
+		return data.Codec, nil
+	}
+	return NewGzipCodec(data.compressLevel())
+}
+
+// compressOut wraps Out in the stream of Compress, encoded by the compress
+// stage. The returned function flushes and closes the stream, which has to
+// happen whether the dump succeeds or not for what was written to be readable.
+func (data *Data) compressOut() (func() error, error) {
+	if !data.Compress || data.Out == nil {
+		return func() error { return nil }, nil
+	}
+	codec, err := data.compressCodec()
+	if err != nil {
+		return nil, err
+	}
+	var zw io.WriteCloser
+	if zw, err = codec.Wrap(data.Out); err != nil {
+		return nil, err
+	}
+	stage := newCompressStage(zw)
+	data.Out = stage
+	return func() error {
+		err := stage.Close()
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
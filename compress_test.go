@@ -0,0 +1,88 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpCompress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Out: &buf, Connection: db, Compress: true, CompressLevel: gzip.BestSpeed}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	result := strings.Replace(strings.Split(string(b), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
+
+func TestDumpCompressFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnError(errors.New("gone away"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Out: &buf, Connection: db, Compress: true}
+	assert.Error(t, data.Dump())
+
+	// The stream is closed even though nothing was dumped
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestDumpCompressLevel(t *testing.T) {
+	data := &mysqldump.Data{Compress: true, CompressLevel: 12}
+	assert.Equal(t, mysqldump.ErrInvalidCompressLevel, data.Dump())
+
+	_, err := mysqldump.NewGzipCodec(-3)
+	assert.Equal(t, mysqldump.ErrInvalidCompressLevel, err)
+	codec, err := mysqldump.NewGzipCodec(gzip.BestCompression)
+	assert.NoError(t, err)
+	assert.Equal(t, "gz", codec.Name())
+}
+
+func TestDumpCompressCodec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+
+	codec, err := mysqldump.LookupCodec("frame")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Out: &buf, Connection: db, Compress: true, Codec: codec}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	// Out is encoded with the codec instead of gzip
+	r, err := codec.Unwrap(&buf)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	result := strings.Replace(strings.Split(string(b), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,210 @@
+package mysqldump
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// startWorkers starts the transactions of the Concurrency-1 connections that
+// read rows along with the one of the dump. It is called while writes are
+// locked, for their snapshots to be the one of the dump.
+func (data *Data) startWorkers(ctx context.Context) error {
+	for i := 1; i < data.Concurrency; i++ {
+		conn, err := data.Connection.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		if err := data.startSnapshot(ctx, conn); err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+			conn.Close()
+			return err
+		}
+		data.workers = append(data.workers, &connTx{ctx: ctx, conn: conn})
+	}
+	return nil
+}
+
+// stopWorkers rolls back the transactions of the workers, the dump then reads
+// every table on its own transaction
+func (data *Data) stopWorkers() error {
+	var err error
+	for _, worker := range data.workers {
+		if rerr := worker.Rollback(); err == nil {
+			err = rerr
+		}
+	}
+	data.workers = nil
+	return err
+}
+
+// txn returns the transaction the table is read through
+func (table *table) txn() transaction {
+	if table.worker != nil {
+		return table.worker
+	}
+	return table.data.tx
+}
+
+// txPool hands out the transactions of the dump to the tables read at the
+// same time
+type txPool struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	main transaction
+	free []transaction
+}
+
+func newTxPool(main transaction, workers []transaction) *txPool {
+	p := &txPool{main: main, free: append([]transaction{main}, workers...)}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// get waits for a free transaction, the one of the dump for a pinned table
+// reading a temporary table of its session. The others are handed out first
+// so it stays free for those.
+func (p *txPool) get(pinned bool) transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if i := p.pick(pinned); i >= 0 {
+			tx := p.free[i]
+			p.free = append(p.free[:i], p.free[i+1:]...)
+			return tx
+		}
+		p.cond.Wait()
+	}
+}
+
+// pick returns the index of the free transaction to hand out, -1 if none
+func (p *txPool) pick(pinned bool) int {
+	main := -1
+	for i, tx := range p.free {
+		if tx == p.main {
+			main = i
+		} else if !pinned {
+			return i
+		}
+	}
+	return main
+}
+
+func (p *txPool) put(tx transaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.free = append(p.free, tx)
+	p.cond.Broadcast()
+}
+
+// readTables reads the tables with read and records them in their order,
+// read writing to out unless it is nil. With workers up to Concurrency tables
+// are read at the same time, each on a transaction of its own, and what they
+// write reaches out in the order of the tables: the first table not written
+// yet writes through while the others are buffered up to MergeBuffer. A table
+// keeps its transaction until its output is written, so no more than
+// Concurrency buffers are kept.
+func (data *Data) readTables(out io.Writer, tables []*table, read func(io.Writer, *table) error) error {
+	if len(data.workers) == 0 {
+		for _, table := range tables {
+			if data.err != nil {
+				return data.err
+			}
+			data.waitWhilePaused()
+			if err := data.checkContext(); err != nil {
+				return err
+			}
+			start := data.offset(out)
+			if err := data.readTable(out, table, read); err != nil {
+				return err
+			}
+			if start >= 0 {
+				table.addSection(start, data.streamed.n)
+			}
+			if err := data.recordTable(table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	limit := data.MergeBuffer
+	if limit <= 0 {
+		limit = defaultMergeBuffer
+	}
+	merged := out
+	if merged == nil {
+		merged = ioutil.Discard
+	}
+	m := newOrderedMerger(merged, len(tables), limit)
+	m.budget = data.budget
+	if data.offset(out) >= 0 {
+		m.track(data.streamed)
+	}
+	pool := newTxPool(data.tx, data.workers)
+	var wg sync.WaitGroup
+	recorded := make(chan struct{})
+	close(recorded)
+	for i, t := range tables {
+		data.waitWhilePaused()
+		if err := data.checkContext(); err != nil {
+			m.close(i, err)
+			break
+		}
+		tx := pool.get(t.source != "")
+		if m.failed() {
+			pool.put(tx)
+			break
+		}
+		var w io.Writer
+		if out != nil {
+			w = m.part(i)
+		}
+
+		previous, done := recorded, make(chan struct{})
+		recorded = done
+		wg.Add(1)
+		go func(i int, t *table) {
+			defer wg.Done()
+			defer close(done)
+			t.worker = tx
+			t.atHead = func() bool { return m.atHead(i) }
+			err := data.readTable(w, t, read)
+			var checksum string
+			if err == nil {
+				checksum, err = data.tableChecksum(t)
+			}
+			t.worker = nil
+			m.close(i, err)
+			m.wait(i)
+			pool.put(tx)
+
+			// The tables are recorded in their order, one at a time
+			<-previous
+			if start, end, ok := m.section(i); ok {
+				t.addSection(start, end)
+			}
+			if !m.failed() {
+				data.addTable(t, checksum)
+			}
+		}(i, t)
+	}
+	wg.Wait()
+	return m.err
+}
+
+// readTable reads a table with read, counting the bytes it writes to out
+// unless out is nil
+func (data *Data) readTable(out io.Writer, table *table, read func(io.Writer, *table) error) error {
+	table.start = time.Now()
+	data.heartbeat.enter(table.Name)
+	if out == nil {
+		return read(nil, table)
+	}
+	counter := &countWriter{w: out}
+	err := read(counter, table)
+	table.bytes = counter.n
+	return err
+}
@@ -0,0 +1,94 @@
+package mysqldump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockConcurrentDump expects the queries of a dump of the tables a, b and c
+// by two workers, a taking the longest
+func mockConcurrentDump(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+			AddRow("a", "BASE TABLE").AddRow("b", "BASE TABLE").AddRow("c", "BASE TABLE"))
+	for _, name := range []string{"a", "b", "c"} {
+		mockCreateTable(mock, name)
+		mock.ExpectQuery("^SHOW COLUMNS FROM `" + name + "`$").WillReturnRows(
+			sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int(11)", "NO", "PRI", nil, ""))
+		rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0))
+		for id := 1; id <= 3; id++ {
+			rows.AddRow(id)
+		}
+		// The first table is the slowest, the others wait for it
+		if name == "a" {
+			mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`").WillDelayFor(20 * time.Millisecond).WillReturnRows(rows)
+		} else {
+			mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`").WillReturnRows(rows)
+		}
+	}
+}
+
+func TestDumpConcurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	// The workers read the tables in any order
+	mock.MatchExpectationsInOrder(false)
+	mockConcurrentDump(mock)
+
+	var buf bytes.Buffer
+	data := &Data{Connection: db, Out: &buf, Concurrency: 2, MaxAllowedPacket: 4096}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	positions := []int{}
+	for _, name := range []string{"a", "b", "c"} {
+		i := strings.Index(out, "INSERT INTO `"+name+"` (`id`) VALUES (1),(2),(3);")
+		assert.True(t, i >= 0, out)
+		positions = append(positions, i)
+	}
+	assert.True(t, positions[0] < positions[1] && positions[1] < positions[2], out)
+
+	var names []string
+	for _, stats := range data.Report() {
+		names = append(names, stats.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Empty(t, data.Warnings())
+}
+
+func TestTxPool(t *testing.T) {
+	main, worker := &connTx{}, &connTx{}
+	p := newTxPool(main, []transaction{worker})
+
+	// The workers are handed out first, pinned tables get the main one
+	assert.True(t, p.get(false) == worker)
+	assert.True(t, p.get(true) == main)
+
+	got := make(chan transaction)
+	go func() { got <- p.get(true) }()
+	p.put(worker)
+	select {
+	case <-got:
+		t.Fatal("a pinned table got a worker")
+	case <-time.After(10 * time.Millisecond):
+	}
+	p.put(main)
+	assert.True(t, <-got == main)
+	assert.True(t, p.get(false) == worker)
+}
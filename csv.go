@@ -0,0 +1,238 @@
+package mysqldump
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DataFormat is the format of the data files of a multi-file dump.
+type DataFormat string
+
+const (
+	// DataFormatSQL writes the rows of the tables as INSERT statements.
+	DataFormatSQL DataFormat = ""
+	// DataFormatCSV writes the rows of every table as RFC 4180 CSV to a
+	// .csv file instead, for analytics pipelines rather than for restoring
+	// with RestoreFiles. Binary values are written as they are.
+	DataFormatCSV DataFormat = "csv"
+	// DataFormatTSV writes the rows of every table to a .txt file escaped
+	// like SELECT ... INTO OUTFILE and mysqldump --tab, and the LOAD DATA
+	// statements restoring them to load.sql. A Codec leaves the files to be
+	// decompressed before running it.
+	DataFormatTSV DataFormat = "tsv"
+	// DataFormatJSON writes the rows of every table as JSON Lines to a .jsonl
+	// file, one object keyed by the column names per row, for log pipelines
+	// and document stores.
+	DataFormatJSON DataFormat = "jsonl"
+)
+
+var (
+	// ErrUnknownDataFormat is returned for data formats that don't exist.
+	ErrUnknownDataFormat = errors.New("unknown data format")
+	// ErrDataFormatFiles is returned for CSV, TSV or JSON data without Files
+	// to write the file of every table to.
+	ErrDataFormatFiles = errors.New("CSV, TSV and JSON data is written to Files")
+	// ErrInvalidCSVSyntax is returned when the CSV delimiter or quote is a
+	// line break or they are the same.
+	ErrInvalidCSVSyntax = errors.New("invalid CSV delimiter or quote")
+)
+
+func (data *Data) checkDataFormat() error {
+	switch data.DataFormat {
+	case DataFormatSQL:
+		return nil
+	case DataFormatCSV, DataFormatTSV, DataFormatJSON:
+	default:
+		return ErrUnknownDataFormat
+	}
+	if data.Files == nil {
+		return ErrDataFormatFiles
+	}
+	if data.DataFormat != DataFormatCSV {
+		return nil
+	}
+	delimiter, quote := data.csvDelimiter(), data.csvQuote()
+	if delimiter == quote || delimiter == '\r' || delimiter == '\n' || quote == '\r' || quote == '\n' {
+		return ErrInvalidCSVSyntax
+	}
+	return nil
+}
+
+func (data *Data) csvDelimiter() rune {
+	if data.CSVDelimiter == 0 {
+		return ','
+	}
+	return data.CSVDelimiter
+}
+
+func (data *Data) csvQuote() rune {
+	if data.CSVQuote == 0 {
+		return '"'
+	}
+	return data.CSVQuote
+}
+
+// textFileName returns the name of a data file with the extension of a text
+// format
+func textFileName(name, ext string) string {
+	return strings.TrimSuffix(name, ".sql") + ext
+}
+
+// csvWriter writes the fields of CSV records
+type csvWriter struct {
+	w         *bufio.Writer
+	delimiter string
+	quote     string
+	quotes    string
+	special   string
+	null      string
+	eol       string
+}
+
+func (data *Data) newCSVWriter(w io.Writer) *csvWriter {
+	delimiter, quote := string(data.csvDelimiter()), string(data.csvQuote())
+	c := &csvWriter{
+		w:         bufio.NewWriter(w),
+		delimiter: delimiter,
+		quote:     quote,
+		quotes:    quote + quote,
+		special:   delimiter + quote + "\r\n",
+		null:      data.CSVNull,
+		eol:       "\n",
+	}
+	if data.LineEnding == LineEndingCRLF {
+		c.eol = "\r\n"
+	}
+	return c
+}
+
+// write writes a record, nil fields being NULL. Fields that could be read as
+// NULL or contain the delimiter, the quote or a line break are quoted.
+func (c *csvWriter) write(fields []*string) error {
+	for i, field := range fields {
+		if i > 0 {
+			c.w.WriteString(c.delimiter)
+		}
+		switch {
+		case field == nil:
+			c.w.WriteString(c.null)
+		case *field == c.null || strings.ContainsAny(*field, c.special):
+			c.w.WriteString(c.quote)
+			c.w.WriteString(strings.Replace(*field, c.quote, c.quotes, -1))
+			c.w.WriteString(c.quote)
+		default:
+			c.w.WriteString(*field)
+		}
+	}
+	_, err := c.w.WriteString(c.eol)
+	return err
+}
+
+func (c *csvWriter) flush() error {
+	return c.w.Flush()
+}
+
+// csvField returns a value of the current row as a CSV field, nil for NULL.
+// Transforms and Masks apply like to the INSERT statements.
+func (table *table) csvField(column int, value interface{}) *string {
+	value = table.transformed(column, value)
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *sql.NullInt64:
+		if !v.Valid {
+			return nil
+		}
+		s = strconv.FormatInt(v.Int64, 10)
+	case *sql.NullFloat64:
+		if !v.Valid {
+			return nil
+		}
+		s = formatFloat(v.Float64, table.floatBits(column))
+	default:
+		var ok bool
+		if s, ok = textValue(value); !ok {
+			return nil
+		}
+	}
+	if m := table.masker(column); m != nil {
+		s = m.Mask(s)
+	}
+	return &s
+}
+
+// recordWriter writes the records of the data files of the text formats
+type recordWriter interface {
+	write(fields []*string) error
+	flush() error
+}
+
+// writeTableRecords writes the rows of the table with rw, after the names of
+// the columns with header
+func (data *Data) writeTableRecords(rw recordWriter, table *table, header bool) error {
+	var fields []*string
+	return table.writeRows(func() error {
+		if header && len(table.cols) > 0 {
+			names := make([]*string, len(table.cols))
+			for i := range table.cols {
+				names[i] = &table.cols[i]
+			}
+			if err := rw.write(names); err != nil {
+				return err
+			}
+		}
+		fields = make([]*string, len(table.values))
+		return nil
+	}, func() error {
+		for i, value := range table.values {
+			fields[i] = table.csvField(i, value)
+		}
+		return rw.write(fields)
+	}, rw.flush)
+}
+
+// writeRows reads the rows of the table, calling start once the columns are
+// known, row for every row and end after the last one
+func (table *table) writeRows(start, row, end func() error) error {
+	if err := table.checkMerge(); err != nil {
+		return err
+	}
+	return table.readData(func() error {
+		defer table.stopReading()
+		if err := table.Init(); err != nil {
+			return err
+		}
+		if err := start(); err != nil {
+			return err
+		}
+		for table.Next() {
+			if err := row(); err != nil {
+				return err
+			}
+		}
+		if table.Err != nil {
+			return table.Err
+		}
+		return end()
+	})
+}
+
+// writeCSVFile writes the rows of table to its own CSV file
+func (data *Data) writeCSVFile(meta *metaData, table *table) error {
+	f, err := data.createFile(textFileName(data.dataFileName(meta, table), ".csv"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := data.writeTableRecords(data.newCSVWriter(f), table, data.CSVHeader); err != nil {
+		return err
+	}
+	table.bytes = f.written
+	return f.Close()
+}
@@ -0,0 +1,80 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// dumpCSV dumps a table of awkward values as CSV, or the DataFormat of data, to
+// a zip
+func dumpCSV(t *testing.T, data *mysqldump.Data) map[string]string {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int(11) NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(mockColumnRows())
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).
+		AddRow(1, nil, "plain").
+		AddRow(2, "", "with, comma").
+		AddRow(3, "say \"hi\"", "two\nlines"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	archive := mysqldump.NewZipWriter(&buf)
+	data.Connection = db
+	data.Files = archive
+	if data.DataFormat == mysqldump.DataFormatSQL {
+		data.DataFormat = mysqldump.DataFormatCSV
+	}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, archive.Close())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	_, files := readZip(t, buf.Bytes())
+	return files
+}
+
+func TestDumpCSV(t *testing.T) {
+	files := dumpCSV(t, &mysqldump.Data{CSVHeader: true})
+	assert.Contains(t, files["schema.sql"], "CREATE TABLE `Test_Table`")
+	assert.NotContains(t, files, "data/Test_Table.sql")
+	assert.Equal(t, "id,email,name\n"+
+		"1,,plain\n"+
+		"2,\"\",\"with, comma\"\n"+
+		"3,\"say \"\"hi\"\"\",\"two\nlines\"\n", files["data/Test_Table.csv"])
+}
+
+func TestDumpCSVSyntax(t *testing.T) {
+	files := dumpCSV(t, &mysqldump.Data{CSVDelimiter: ';', CSVQuote: '\'', CSVNull: `\N`, LineEnding: mysqldump.LineEndingCRLF})
+	assert.Equal(t, "1;\\N;plain\r\n"+
+		"2;;with, comma\r\n"+
+		"3;say \"hi\";'two\nlines'\r\n", files["data/Test_Table.csv"])
+}
+
+func TestDumpTSV(t *testing.T) {
+	files := dumpCSV(t, &mysqldump.Data{DataFormat: mysqldump.DataFormatTSV})
+	assert.NotContains(t, files, "data/Test_Table.csv")
+	assert.Equal(t, "1\t\\N\tplain\n"+
+		"2\t\twith, comma\n"+
+		"3\tsay \"hi\"\ttwo\\\nlines\n", files["data/Test_Table.txt"])
+	assert.Contains(t, files["load.sql"], "LOAD DATA LOCAL INFILE 'data/Test_Table.txt' INTO TABLE `Test_Table` CHARACTER SET utf8mb4 "+
+		"FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (`id`, `email`, `name`);\n")
+}
+
+func TestDumpCSVInvalid(t *testing.T) {
+	archive := mysqldump.NewZipWriter(&bytes.Buffer{})
+	assert.Equal(t, mysqldump.ErrDataFormatFiles, (&mysqldump.Data{DataFormat: mysqldump.DataFormatCSV}).Dump())
+	assert.Equal(t, mysqldump.ErrDataFormatFiles, (&mysqldump.Data{DataFormat: mysqldump.DataFormatTSV}).Dump())
+	assert.Equal(t, mysqldump.ErrUnknownDataFormat, (&mysqldump.Data{DataFormat: "parquet", Files: archive}).Dump())
+	assert.Equal(t, mysqldump.ErrInvalidCSVSyntax, (&mysqldump.Data{DataFormat: mysqldump.DataFormatCSV, Files: archive, CSVQuote: ','}).Dump())
+}
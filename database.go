@@ -0,0 +1,85 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Takes a *database
+const databaseTmpl = `
+--
+-- Current Database: {{ .NameEsc }}
+--
+{{ if .Drop }}
+/*!40000 DROP DATABASE IF EXISTS {{ .NameEsc }}*/;
+{{ end }}
+{{ terminate .CreateSQL }}
+
+USE {{ .NameEsc }};
+`
+
+// database is the database level DDL written in front of the tables
+type database struct {
+	Name      string
+	CreateSQL string
+	Drop      bool
+}
+
+func (db *database) NameEsc() string {
+	return "`" + db.Name + "`"
+}
+
+// getDatabase reads the definition of the named or current database
+func (data *Data) getDatabase(name string) (*database, error) {
+	if name == "" {
+		var current sql.NullString
+		if err := data.tx.QueryRow("SELECT DATABASE()").Scan(&current); err != nil {
+			return nil, err
+		}
+		if !current.Valid {
+			return nil, errors.New("no database selected")
+		}
+		name = current.String
+	}
+
+	db := &database{Name: name, Drop: data.AddDropDatabase}
+	var returned, create sql.NullString
+	if err := data.tx.QueryRow("SHOW CREATE DATABASE "+db.NameEsc()).Scan(&returned, &create); err != nil {
+		return nil, err
+	}
+
+	db.CreateSQL = data.rewriteDDL(create.String)
+	if !db.Drop {
+		db.CreateSQL = strings.Replace(db.CreateSQL, "CREATE DATABASE ", "CREATE DATABASE /*!32312 IF NOT EXISTS*/ ", 1)
+	}
+	return db, nil
+}
+
+// writeDatabase writes the database level DDL if the dump includes it
+func (data *Data) writeDatabase(w io.Writer, meta *metaData) error {
+	if meta.database == nil {
+		return nil
+	}
+	return data.databaseTmpl.Execute(w, meta.database)
+}
+
+// isDropDatabase reports whether statement drops a database
+func isDropDatabase(statement string) bool {
+	s := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(statement), "/*!40000"))
+	return len(s) >= 13 && strings.EqualFold(s[:13], "DROP DATABASE")
+}
+
+// isDatabaseStatement reports whether statement drops, creates or selects a
+// database
+func isDatabaseStatement(statement string) bool {
+	s := strings.TrimSpace(statement)
+	if isDropDatabase(s) {
+		return true
+	}
+	if len(s) >= 15 && strings.EqualFold(s[:15], "CREATE DATABASE") {
+		return true
+	}
+	return len(s) >= 4 && strings.EqualFold(s[:4], "USE ")
+}
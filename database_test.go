@@ -0,0 +1,95 @@
+package mysqldump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const createDatabase = "CREATE DATABASE `Testdb` /*!40100 DEFAULT CHARACTER SET utf8mb3 */"
+
+func TestGetDatabaseCurrent(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT DATABASE\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("Testdb"))
+	mock.ExpectQuery("^SHOW CREATE DATABASE `Testdb`$").WillReturnRows(sqlmock.NewRows([]string{"Database", "Create Database"}).AddRow("Testdb", createDatabase))
+
+	data.CreateDatabase = true
+	data.CharsetConvert = true
+	db, err := data.getDatabase("")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, &database{
+		Name:      "Testdb",
+		CreateSQL: "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `Testdb` /*!40100 DEFAULT CHARACTER SET utf8mb4 */",
+	}, db)
+}
+
+func TestGetDatabaseNoneSelected(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT DATABASE\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow(nil))
+
+	_, err = data.getDatabase("")
+	assert.EqualError(t, err, "no database selected")
+}
+
+func TestWriteDatabaseDrop(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE DATABASE `Testdb`$").WillReturnRows(sqlmock.NewRows([]string{"Database", "Create Database"}).AddRow("Testdb", createDatabase))
+
+	data.AddDropDatabase = true
+	assert.NoError(t, data.getTemplates())
+	meta := &metaData{}
+	meta.database, err = data.getDatabase("Testdb")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, data.writeDatabase(&buf, meta))
+
+	expected := `
+--
+-- Current Database: ~Testdb~
+--
+
+/*!40000 DROP DATABASE IF EXISTS ~Testdb~*/;
+
+CREATE DATABASE ~Testdb~ /*!40100 DEFAULT CHARACTER SET utf8mb3 */;
+
+USE ~Testdb~;
+`
+	assert.Equal(t, expected, strings.Replace(buf.String(), "`", "~", -1))
+}
+
+func TestRestoreResumeKeepsDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dump := "-- Server version\t8.0.34\n\n" +
+		"/*!40000 DROP DATABASE IF EXISTS `Testdb`*/;\n" +
+		"CREATE DATABASE `Testdb`;\n" +
+		"USE `Testdb`;\n" +
+		strings.SplitN(resumeDump, "\n", 2)[1]
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec("^CREATE DATABASE `Testdb`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^USE `Testdb`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE b \(id int\)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO b VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, (&Restorer{Connection: db, SkipVersionCheck: true, FromTable: "b"}).Restore(strings.NewReader(dump)))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
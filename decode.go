@@ -0,0 +1,389 @@
+package mysqldump
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Event is a decoded statement of a dump, one of *CreateTable, *CreateView,
+// *InsertRows or *Statement.
+type Event interface {
+	event()
+}
+
+// CreateTable is the structure of a table.
+type CreateTable struct {
+	Name string
+	SQL  string
+}
+
+// CreateView is the definition of a view.
+type CreateView struct {
+	Name string
+	SQL  string
+}
+
+// InsertRows holds the rows of one INSERT statement. Values are nil for NULL,
+// int64 or float64 for numbers, bool for TRUE and FALSE, string for strings,
+// []byte for binary and hex literals and RawValue for anything else.
+type InsertRows struct {
+	Table   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Statement is any other statement, like the session settings of the header.
+type Statement struct {
+	SQL string
+}
+
+// RawValue is a value expression the decoder does not evaluate, like
+// LOAD_FILE('...').
+type RawValue string
+
+func (*CreateTable) event() {}
+func (*CreateView) event()  {}
+func (*InsertRows) event()  {}
+func (*Statement) event()   {}
+
+// Decoder reads the statements of a dump as typed events.
+type Decoder struct {
+	scanner *statementScanner
+}
+
+// NewDecoder creates a Decoder reading the dump from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{scanner: newStatementScanner(r)}
+}
+
+var (
+	createTableRe = regexp.MustCompile("(?is)^CREATE\\s+(?:TEMPORARY\\s+)?TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?((?:`(?:[^`]|``)*`|[\\w$]+)(?:\\.(?:`(?:[^`]|``)*`|[\\w$]+))?)")
+	createViewRe  = regexp.MustCompile("(?is)^CREATE\\s+(?:[^(]*?\\s)?VIEW\\s+((?:`(?:[^`]|``)*`|[\\w$]+)(?:\\.(?:`(?:[^`]|``)*`|[\\w$]+))?)")
+	insertRe      = regexp.MustCompile("(?is)^(?:INSERT|REPLACE)\\s+(?:IGNORE\\s+)?INTO\\s+")
+)
+
+// Next returns the next event, or io.EOF at the end of the dump.
+func (d *Decoder) Next() (Event, error) {
+	for {
+		st, err := d.scanner.Next()
+		if err != nil {
+			return nil, err
+		}
+		if st.SQL == "" {
+			continue
+		}
+		if m := insertRe.FindStringIndex(st.SQL); m != nil {
+			rows, err := parseInsert(st.SQL[m[1]:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", st.Line, err)
+			}
+			return rows, nil
+		}
+		if m := createTableRe.FindStringSubmatch(st.SQL); m != nil {
+			return &CreateTable{Name: unquoteName(m[1]), SQL: st.SQL}, nil
+		}
+		if m := createViewRe.FindStringSubmatch(st.SQL); m != nil {
+			return &CreateView{Name: unquoteName(m[1]), SQL: st.SQL}, nil
+		}
+		return &Statement{SQL: st.SQL}, nil
+	}
+}
+
+// unquoteName strips the backticks of a possibly schema qualified name and
+// returns the name of the object without the schema
+func unquoteName(name string) string {
+	p := &valueParser{s: name}
+	ident, _ := p.identifier()
+	for p.consume(".") {
+		ident, _ = p.identifier()
+	}
+	return ident
+}
+
+// parseInsert parses an INSERT statement following the INTO keyword
+func parseInsert(s string) (*InsertRows, error) {
+	p := &valueParser{s: s}
+	rows := &InsertRows{}
+
+	table, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume(".") {
+		if table, err = p.identifier(); err != nil {
+			return nil, err
+		}
+	}
+	rows.Table = table
+
+	if p.consume("(") {
+		for {
+			col, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			rows.Columns = append(rows.Columns, col)
+			if p.consume(")") {
+				break
+			}
+			if !p.consume(",") {
+				return nil, p.errorf("expected , or ) in column list")
+			}
+		}
+	}
+
+	if !p.keyword("VALUES") && !p.keyword("VALUE") {
+		return nil, p.errorf("expected VALUES")
+	}
+
+	for {
+		if !p.consume("(") {
+			return nil, p.errorf("expected (")
+		}
+		var row []interface{}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, v)
+			if p.consume(")") {
+				break
+			}
+			if !p.consume(",") {
+				return nil, p.errorf("expected , or ) in values")
+			}
+		}
+		rows.Rows = append(rows.Rows, row)
+		if !p.consume(",") {
+			break
+		}
+	}
+
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q after values", p.s[p.pos:])
+	}
+	return rows, nil
+}
+
+// valueParser is a minimal tokenizer for the parts of INSERT statements
+type valueParser struct {
+	s   string
+	pos int
+}
+
+func (p *valueParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: "+format, append([]interface{}{p.pos}, args...)...)
+}
+
+// skipSpace skips white space and /* */ comments
+func (p *valueParser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch {
+		case p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r':
+			p.pos++
+		case strings.HasPrefix(p.s[p.pos:], "/*"):
+			end := strings.Index(p.s[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.s)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *valueParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// keyword consumes a case insensitive keyword that is not followed by more
+// identifier characters
+func (p *valueParser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.s) || !strings.EqualFold(p.s[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.s) && isIdentChar(p.s[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch >= 0x80
+}
+
+func (p *valueParser) identifier() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return "", p.errorf("expected identifier")
+	}
+	if p.s[p.pos] == '`' {
+		var b strings.Builder
+		for i := p.pos + 1; i < len(p.s); i++ {
+			if p.s[i] == '`' {
+				if i+1 < len(p.s) && p.s[i+1] == '`' {
+					b.WriteByte('`')
+					i++
+					continue
+				}
+				p.pos = i + 1
+				return b.String(), nil
+			}
+			b.WriteByte(p.s[i])
+		}
+		return "", p.errorf("unterminated identifier")
+	}
+	start := p.pos
+	for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected identifier")
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *valueParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("expected value")
+	}
+	switch {
+	case p.keyword("NULL"):
+		return nil, nil
+	case p.keyword("TRUE"):
+		return true, nil
+	case p.keyword("FALSE"):
+		return false, nil
+	case p.keyword("_binary"):
+		p.skipSpace()
+		s, err := p.quoted()
+		return []byte(s), err
+	case p.s[p.pos] == '\'' || p.s[p.pos] == '"':
+		return p.quoted()
+	case strings.HasPrefix(p.s[p.pos:], "0x") || strings.HasPrefix(p.s[p.pos:], "0X"):
+		start := p.pos + 2
+		p.pos = start
+		for p.pos < len(p.s) && isHexDigit(p.s[p.pos]) {
+			p.pos++
+		}
+		return hex.DecodeString(p.s[start:p.pos])
+	case (p.s[p.pos] == 'x' || p.s[p.pos] == 'X') && p.pos+1 < len(p.s) && p.s[p.pos+1] == '\'':
+		p.pos++
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(s)
+	case p.s[p.pos] == '-' || p.s[p.pos] == '+' || p.s[p.pos] == '.' || (p.s[p.pos] >= '0' && p.s[p.pos] <= '9'):
+		return p.number()
+	}
+	return p.raw()
+}
+
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func (p *valueParser) number() (interface{}, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.s) && strings.IndexByte("0123456789.eE+-", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	text := p.s[start:p.pos]
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return nil, p.errorf("invalid number %q", text)
+}
+
+// raw takes an expression up to the next , or ) outside of parentheses and
+// quotes
+func (p *valueParser) raw() (interface{}, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		switch {
+		case ch == '\'' || ch == '"':
+			if _, err := p.quoted(); err != nil {
+				return nil, err
+			}
+			continue
+		case ch == '(':
+			depth++
+		case ch == ')' && depth == 0, ch == ',' && depth == 0:
+			return RawValue(strings.TrimSpace(p.s[start:p.pos])), nil
+		case ch == ')':
+			depth--
+		}
+		p.pos++
+	}
+	return nil, errors.New("unterminated expression")
+}
+
+// quoted reads a quoted string literal and resolves its escape sequences
+func (p *valueParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	var b strings.Builder
+	for i := p.pos + 1; i < len(p.s); i++ {
+		ch := p.s[i]
+		switch {
+		case ch == '\\' && i+1 < len(p.s):
+			i++
+			b.WriteString(unescape(p.s[i]))
+		case ch == quote && i+1 < len(p.s) && p.s[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case ch == quote:
+			p.pos = i + 1
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// unescape resolves the character after a backslash, the reverse of sanitize
+func unescape(ch byte) string {
+	switch ch {
+	case '0':
+		return "\x00"
+	case 'b':
+		return "\b"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'Z':
+		return "\x1A"
+	case '%', '_':
+		// MySQL keeps the backslash of these, they are LIKE escapes
+		return "\\" + string(ch)
+	}
+	return string(ch)
+}
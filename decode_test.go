@@ -0,0 +1,62 @@
+package mysqldump
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAll(t *testing.T, input string) []Event {
+	var events []Event
+	d := NewDecoder(strings.NewReader(input))
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			return events
+		}
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+}
+
+func TestDecoderEvents(t *testing.T) {
+	events := decodeAll(t, "/*!40101 SET NAMES utf8mb4 */;\n"+
+		"DROP TABLE IF EXISTS `t`;\n"+
+		"CREATE TABLE `t` (\n  `id` int NOT NULL,\n  `s` text\n) ENGINE=InnoDB;\n"+
+		"INSERT INTO `t` (`id`, `s`, `b`, `f`, `h`) VALUES (1,'it\\'s\\n',_binary 'a\\0b',1.5,0x0102),(-2,NULL,NULL /* blob:t/2.b.bin */,2e3,LOAD_FILE('/tmp/x, y'));\n"+
+		"CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY DEFINER VIEW `v` AS select 1 AS `1`;\n")
+
+	assert.Equal(t, []Event{
+		&Statement{SQL: "/*!40101 SET NAMES utf8mb4 */"},
+		&Statement{SQL: "DROP TABLE IF EXISTS `t`"},
+		&CreateTable{Name: "t", SQL: "CREATE TABLE `t` (\n  `id` int NOT NULL,\n  `s` text\n) ENGINE=InnoDB"},
+		&InsertRows{
+			Table:   "t",
+			Columns: []string{"id", "s", "b", "f", "h"},
+			Rows: [][]interface{}{
+				{int64(1), "it's\n", []byte("a\x00b"), 1.5, []byte{1, 2}},
+				{int64(-2), nil, nil, 2000.0, RawValue("LOAD_FILE('/tmp/x, y')")},
+			},
+		},
+		&CreateView{Name: "v", SQL: "CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY DEFINER VIEW `v` AS select 1 AS `1`"},
+	}, events)
+}
+
+func TestDecoderSanitizeRoundTrip(t *testing.T) {
+	input := "a'b\"c\\d\x00e\x1Af\ng\rh\bi\tj%_"
+	events := decodeAll(t, "INSERT INTO `db`.`t` VALUES ('"+sanitize(input)+"');")
+
+	assert.Equal(t, []Event{&InsertRows{Table: "t", Rows: [][]interface{}{{input}}}}, events)
+}
+
+func TestDecoderMalformedInsert(t *testing.T) {
+	_, err := NewDecoder(strings.NewReader("INSERT INTO `t` VALUES (1,'a';\n")).Next()
+	assert.Error(t, err)
+}
+
+func TestDecoderBoolLiterals(t *testing.T) {
+	events := decodeAll(t, "INSERT INTO `t` VALUES (TRUE,false,'TRUE');")
+	assert.Equal(t, []Event{&InsertRows{Table: "t", Rows: [][]interface{}{{true, false, "TRUE"}}}}, events)
+}
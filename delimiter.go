@@ -0,0 +1,64 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultCompoundDelimiter wraps statements containing semicolons when
+// Delimiter is not set
+const defaultCompoundDelimiter = "$$"
+
+// ErrInvalidDelimiter is returned when Delimiter can't be used in a DELIMITER
+// command.
+var ErrInvalidDelimiter = errors.New("delimiter must not contain ; or white space")
+
+// templateFuncs are the functions available to all templates
+func (data *Data) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"terminate": data.terminate,
+	}
+}
+
+// compoundDelimiter returns the delimiter statements with semicolons in their
+// body are wrapped in
+func (data *Data) compoundDelimiter() string {
+	if data.Delimiter == "" {
+		return defaultCompoundDelimiter
+	}
+	return data.Delimiter
+}
+
+// checkDelimiter validates the Delimiter option
+func (data *Data) checkDelimiter() error {
+	if strings.ContainsAny(data.Delimiter, "; \t\r\n") {
+		return ErrInvalidDelimiter
+	}
+	return nil
+}
+
+// terminate ends a statement with the delimiter. Statements that contain a
+// semicolon outside of quotes and comments, like the bodies of routines and
+// triggers, are wrapped in DELIMITER commands so the mysql client and the
+// Restorer read them as one statement.
+func (data *Data) terminate(statement string) (string, error) {
+	if !containsDelimiter(statement, defaultDelimiter) {
+		return statement + defaultDelimiter, nil
+	}
+	delimiter := data.compoundDelimiter()
+	if containsDelimiter(statement, delimiter) {
+		return "", fmt.Errorf("statement contains the delimiter %s: %.60q", delimiter, statement)
+	}
+	return "DELIMITER " + delimiter + "\n" + statement + delimiter + "\nDELIMITER " + defaultDelimiter, nil
+}
+
+// containsDelimiter reports whether delimiter ends statement somewhere before
+// its end, the way the Restorer and the mysql client split statements
+func containsDelimiter(statement, delimiter string) bool {
+	s := newStatementScanner(strings.NewReader(statement))
+	s.delimiter = delimiter
+	st, err := s.Next()
+	return err == nil && !st.Unterminated
+}
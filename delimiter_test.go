@@ -0,0 +1,62 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const createProcedure = "CREATE PROCEDURE `p`()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND"
+
+func TestTerminate(t *testing.T) {
+	data := &Data{}
+
+	for in, out := range map[string]string{
+		"CREATE TABLE `t` (`id` int)":                  "CREATE TABLE `t` (`id` int);",
+		"CREATE TABLE `t` (`s` text COMMENT 'a;b')":    "CREATE TABLE `t` (`s` text COMMENT 'a;b');",
+		"CREATE VIEW `v` AS select 1 /* ; */ AS `a;b`": "CREATE VIEW `v` AS select 1 /* ; */ AS `a;b`;",
+		createProcedure: "DELIMITER $$\n" + createProcedure + "$$\nDELIMITER ;",
+	} {
+		got, err := data.terminate(in)
+		assert.NoError(t, err)
+		assert.Equal(t, out, got)
+	}
+}
+
+func TestTerminateCustomDelimiter(t *testing.T) {
+	data := &Data{Delimiter: ";;"}
+	assert.Equal(t, ErrInvalidDelimiter, data.checkDelimiter())
+
+	data.Delimiter = "//"
+	assert.NoError(t, data.checkDelimiter())
+	got, err := data.terminate(createProcedure)
+	assert.NoError(t, err)
+	assert.Equal(t, "DELIMITER //\n"+createProcedure+"//\nDELIMITER ;", got)
+
+	_, err = data.terminate("CREATE PROCEDURE `p`()\nBEGIN\n  SELECT 1;\n  SELECT 2 // 1;\nEND")
+	assert.Error(t, err)
+}
+
+func TestTerminateRoundTrip(t *testing.T) {
+	got, err := (&Data{}).terminate(createProcedure)
+	assert.NoError(t, err)
+
+	s := newStatementScanner(strings.NewReader(got + "\nSELECT 3;\n"))
+	st, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, createProcedure, st.SQL)
+	st, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 3", st.SQL)
+}
+
+func TestDumpInvalidDelimiter(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &Data{Connection: db, Delimiter: "$ $"}
+	assert.Equal(t, ErrInvalidDelimiter, data.Dump())
+}
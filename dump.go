@@ -4,54 +4,281 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
 /*
 Data struct to configure dump behavior
 
-	Out:              Stream to write to
-	Connection:       Database connection to dump
-	IgnoreTables:     Mark sensitive tables to ignore
-	MaxAllowedPacket: Sets the largest packet size to use in backups
-	LockTables:       Lock all tables for the duration of the dump
+	Out:                  Stream to write to
+	Connection:           Database connection to dump
+	Context:              Stops the dump once done, the transaction and its queries are bound to it (context.Background() if nil)
+	IgnoreTables:         Mark sensitive tables to ignore
+	IncludeTables:        Only dump these tables, all of them if empty
+	IncludePatterns:      Only dump the tables matching one of these patterns or listed in IncludeTables, globs like tmp_* or regular expressions between slashes like /^log_\d+$/
+	ExcludePatterns:      Leave out the tables matching one of these patterns, like IgnoreTables
+	SkipToolArtifacts:    Leave out, with a warning, the leftover tables of pt-online-schema-change, gh-ost and ALTER TABLE, unless listed in IncludeTables
+	MaxAllowedPacket:     Sets the largest packet size to use in backups
+	LockTables:           Lock all tables for the duration of the dump
+	MergeTables:          What to do about the underlying tables of MERGE tables, whose own rows are never dumped: nothing (default), or follow to add them to the dump when IncludeTables leaves them out
+	NonTransactional:     What to do about tables of engines without transactions like MyISAM, which the snapshot does not cover: nothing (default), warn, or lock just them for the duration of the dump
+	BlobThreshold:        Write binary values larger than this many bytes to separate files (0 disables)
+	BlobDir:              Directory the externalized blobs and their manifest are written to
+	BlobMode:             How externalized blobs are referenced from the dump
+	Files:                Write schema, per-table data, manifest and checksums as separate files instead of to Out
+	FileLayout:           How the tables of Files are spread over files, one schema and one data file per table with FileLayoutPerTable, for myloader with FileLayoutMydumper
+	DataFormat:           Format of the data files of Files, INSERT statements, CSV, TSV with the LOAD DATA statements restoring it, or JSON Lines
+	CSVDelimiter:         Delimiter of the fields of DataFormatCSV (, if 0)
+	CSVQuote:             Quote of the fields of DataFormatCSV (" if 0), doubled within them
+	CSVNull:              Field written for the NULL values of DataFormatCSV, empty if empty; the values equal to it are quoted, like empty strings by default
+	CSVHeader:            Write the names of the columns as the first record of every CSV file
+	Codec:                Encodes every file of a multi-file dump but the manifest, the codec name is appended to the file names; with Compress it encodes Out
+	Compress:             Write Out encoded with Codec, or as a gzip stream without one, flushed and closed when the dump returns whether it succeeds or not
+	CompressLevel:        Level of the gzip stream of Compress (gzip.DefaultCompression if 0)
+	Checksums:            Record the CHECKSUM TABLE result of every table in the manifest
+	DeferIndexes:         Add secondary indexes and foreign keys with ALTER TABLE after all data is loaded
+	CharsetConvert:       Rewrite the utf8 and utf8mb3 character sets and collations in the DDL to utf8mb4
+	TargetVersion:        Version of the server the dump is restored on, like 5.7; the MySQL 8.0 collations are replaced for older servers and MariaDB
+	TableOptions:         Rewrites the table options of CREATE TABLE by name, like ENCRYPTION with DropTableOption for a target without a keyring; the others are written as SHOW CREATE TABLE returns them
+	CheckConstraints:     Keep the CHECK constraints, write them NOT ENFORCED or leave them out, with a warning naming the tables changed, for restoring rows they reject
+	CreateDatabase:       Include CREATE DATABASE with the default charset and collation and USE the database
+	AddDropDatabase:      Drop the database before creating it, implies CreateDatabase
+	StrictViews:          Fail on invalid views instead of commenting them out with a warning
+	Delimiter:            Delimiter of the DELIMITER blocks around statements containing semicolons ($$ if empty)
+	HexBlob:              Write binary values and strings that are not valid UTF-8 as hex literals, keeping raw bytes out of the dump
+	ReportWriter:         Receives the rows, bytes and time spent on every table once the dump is done
+	ReportFormat:         Encoding of the report, JSON or CSV
+	HeartbeatInterval:    Check the server responds and report progress this often (0 disables)
+	OnHeartbeat:          Called with the progress on every heartbeat
+	Metrics:              Collector adding up the tables, rows and bytes dumped, the durations and the failures of the dumps
+	HeartbeatComments:    Write the progress of every heartbeat as a comment between the INSERT statements
+	FetchSize:            Read tables with a primary key in pages of this many rows using a prepared statement (0 reads each table with one query)
+	BoolLiterals:         Write 0 and 1 of TINYINT(1) and BOOLEAN columns as FALSE and TRUE
+	SnapshotInfo:         Record the start time, connection id, isolation level and server variables of the transaction in the header and the manifest
+	Grants:               Skip statements that need more than SELECT, like LOCK TABLES, with a warning always (minimal) or when denied (auto)
+	Masks:                Replace the values of columns, keyed by table.column or *.column for any table
+	Transforms:           Replace the values of columns with the one returned by a function seeing their type and NULL, keyed by table then column with * for any table, applied before Masks
+	SkipColumns:          Leave these columns out of the rows of the tables, keyed by table or * for any table, like large or sensitive columns restored with their defaults
+	SchemaDocWriter:      Receives a document of the tables, columns, comments and foreign keys once the dump is done
+	SchemaDocFormat:      Format of the schema document, Markdown, HTML, or a Graphviz or Mermaid diagram
+	BinlogCoordinates:    Record the binary log file, position and GTID set matching the snapshot, taken under FLUSH TABLES WITH READ LOCK
+	BackupLock:           Take LOCK INSTANCE FOR BACKUP on MySQL 8 or BACKUP STAGE BLOCK_COMMIT on MariaDB 10.4 instead of FLUSH TABLES WITH READ LOCK
+	Proxy:                How to deal with a proxy like ProxySQL in front of the servers, ProxyDefault assumes there is none
+	ProxyHint:            Comment prefixed to every statement to pin the backend, like a ProxySQL hostgroup annotation
+	SessionCollation:     Collation of utf8mb4 the connections of the dump are set to with SET NAMES, instead of the one they were opened with
+	DumpID:               Identifies the sessions of the dump as @go_mysqldump_id in performance_schema
+	Routines:             Dump the stored procedures and functions of the database
+	Triggers:             Dump the triggers of the dumped tables
+	Events:               Dump the scheduled events of the database
+	SectionOrder:         Order of the schema, data, routines, triggers and events, DefaultSectionOrder if empty
+	MaterializeViews:     Dump these views as tables holding their rows, read from a temporary table filled once with the result of the view in the snapshot, for views too expensive to evaluate more than once
+	ViewsAsTables:        Dump these views as tables holding their rows, created from the column types of the view, for consumers of denormalized snapshots rather than definitions
+	IncludeGrants:        Dump the accounts of the server with CREATE USER IF NOT EXISTS and their GRANT statements after the other sections, to grants.sql with Files, for migrating a whole server
+	MaxMemory:            Bytes of rows buffered for INSERT statements by all the runs of this Data at once, a dump waits for the others to write theirs beyond it (0 disables)
+	TimeFormat:           Layout of the completion time in the footer and the summary, in UTC (time.RFC3339 if empty)
+	RowComments:          Write a comment with the numbers of the rows in front of every INSERT statement, like -- rows 10001..20000 of `table`, to find ranges of rows with grep
+	LineEnding:           What ends the lines of the SQL files, \n (default) or \r\n, also within statements like the bodies of routines; a dump never starts with a byte order mark
+	OutputStyle:          Layout of the rows of the INSERT statements: compact (default) on one line, pretty with a line per row, or aligned with the values lined up in columns
+	SampleFraction:       Fraction of the rows of every table to dump, picked by a hash of their primary key so the same rows are picked on every run (0 dumps all rows)
+	SampleFractions:      Fraction of the rows to dump by table, overriding SampleFraction, 0 dumps none of the rows of the table
+	SampleSeed:           Seed of the hash picking the sampled rows, another seed picks another sample
+	QualifyNames:         Prefix the names of the tables, views and stored programs in the statements with the database, so the dump restores without a USE, like when concatenated with the dumps of other databases
+	Barrier:              Called with the binlog coordinates, if recorded, once the snapshot is taken and before any row is read, like to record a checkpoint of the application; a failure fails the dump
+	BarrierTimeout:       Time the Barrier is given to return, its context is canceled beyond it and the dump fails (30s if 0)
+	MetadataTimeout:      Time a query reading the structure of the database is given, the dump fails with ErrQueryTimeout beyond it (no limit if 0)
+	QueryTimeout:         Time the server is given to answer the SELECT of the rows of a table or one of its pages, the rows are then read in the time they take (no limit if 0)
+	ValidateOutput:       Split the output into statements and check their syntax while it is written, failing the dump on the first malformed one
+	UseInformationSchema: Read the tables with their engine and row estimate and the columns of all tables from information_schema in one query each, instead of SHOW FULL TABLES and SHOW COLUMNS for every table
+	Concurrency:          Tables whose rows are read at the same time, each on a connection of its own, in the order of the tables (one at a time if 0)
+	MergeBuffer:          Bytes buffered for every table of Concurrency or database of DumpDatabases waiting for the ones ahead of it to be written (16 MiB if 0)
+	RecheckTables:        List the tables again before the footer and warn about the ones created or dropped while the dump ran
 */
 type Data struct {
-	Out              io.Writer
-	Connection       *sql.DB
-	IgnoreTables     []string
-	MaxAllowedPacket int
-	LockTables       bool
-
-	tx         *sql.Tx
-	headerTmpl *template.Template
-	viewTmpl   *template.Template
-	tableTmpl  *template.Template
-	footerTmpl *template.Template
-	err        error
+	Out                  io.Writer
+	Connection           *sql.DB
+	Context              context.Context
+	IgnoreTables         []string
+	IncludeTables        []string
+	IncludePatterns      []string
+	ExcludePatterns      []string
+	SkipToolArtifacts    bool
+	MaxAllowedPacket     int
+	LockTables           bool
+	MergeTables          MergePolicy
+	NonTransactional     NonTransactionalPolicy
+	BlobThreshold        int
+	BlobDir              string
+	BlobMode             BlobMode
+	Files                WriterFactory
+	FileLayout           FileLayout
+	DataFormat           DataFormat
+	CSVDelimiter         rune
+	CSVQuote             rune
+	CSVNull              string
+	CSVHeader            bool
+	Codec                Codec
+	Compress             bool
+	CompressLevel        int
+	Checksums            bool
+	DeferIndexes         bool
+	CharsetConvert       bool
+	TargetVersion        string
+	TableOptions         map[string]TableOption
+	CheckConstraints     CheckConstraints
+	CreateDatabase       bool
+	AddDropDatabase      bool
+	StrictViews          bool
+	Delimiter            string
+	HexBlob              bool
+	ReportWriter         io.Writer
+	ReportFormat         ReportFormat
+	HeartbeatInterval    time.Duration
+	OnHeartbeat          func(Heartbeat)
+	Metrics              *Collector
+	HeartbeatComments    bool
+	FetchSize            int
+	UseInformationSchema bool
+	BoolLiterals         bool
+	SnapshotInfo         bool
+	Grants               GrantMode
+	Masks                map[string]Masker
+	Transforms           map[string]map[string]Transform
+	SkipColumns          map[string][]string
+	SchemaDocWriter      io.Writer
+	SchemaDocFormat      SchemaDocFormat
+	BinlogCoordinates    bool
+	BackupLock           bool
+	Proxy                ProxyMode
+	ProxyHint            string
+	SessionCollation     string
+	DumpID               string
+	Routines             bool
+	Triggers             bool
+	Events               bool
+	SectionOrder         []Section
+	MaterializeViews     []string
+	ViewsAsTables        []string
+	IncludeGrants        bool
+	MaxMemory            int64
+	TimeFormat           string
+	ValidateOutput       bool
+	RowComments          bool
+	OutputStyle          OutputStyle
+	LineEnding           LineEnding
+	SampleFraction       float64
+	SampleFractions      map[string]float64
+	SampleSeed           int64
+	QualifyNames         bool
+	Barrier              func(context.Context, *BinlogCoordinates) error
+	BarrierTimeout       time.Duration
+	MetadataTimeout      time.Duration
+	QueryTimeout         time.Duration
+	Concurrency          int
+	MergeBuffer          int64
+	RecheckTables        bool
+
+	queryTables          []queryTable
+	tx                   transaction
+	workers              []transaction
+	headerTmpl           *template.Template
+	viewTmpl             *template.Template
+	invalidViewTmpl      *template.Template
+	tableTmpl            *template.Template
+	tableSchemaTmpl      *template.Template
+	tableDataTmpl        *template.Template
+	tableIndexesTmpl     *template.Template
+	tableConstraintsTmpl *template.Template
+	databaseTmpl         *template.Template
+	footerTmpl           *template.Template
+	objectsTmpl          *template.Template
+	includePatterns      []*regexp.Regexp
+	materialized         []string
+	listed               []string
+	streamed             *summaryWriter
+	excludePatterns      []*regexp.Regexp
+	usersTmpl            *template.Template
+	manifest             *Manifest
+	warnings             []string
+	report               []TableStats
+	heartbeat            *heartbeat
+	snapshot             *Snapshot
+	schema               *Schema
+	sink                 *sink
+	binlog               *BinlogCoordinates
+	gtidQuery            string
+	qualifier            string
+	budget               *memory
+	atHead               func() bool
+	parent               *Data
+	shared               *state
+	err                  error
 }
 
 type table struct {
-	Name   string
-	Err    error
-	isView bool
-
-	cols   []string
-	data   *Data
-	rows   *sql.Rows
-	values []interface{}
+	Name    string
+	Err     error
+	Invalid error
+	isView  bool
+
+	query         string
+	source        string
+	asTable       bool
+	engine        string
+	rowEstimate   int64
+	columnsLoaded bool
+	cols          []string
+	colTypes      []string
+	createSQL     string
+	pk            []int
+	mergeSkipped  bool
+	keySkipped    bool
+	row           int
+	start         time.Time
+	valueStarts   []int
+	dataStart     *TablePosition
+	dataEnd       *TablePosition
+	sections      []ByteRange
+	bytes         int64
+	buffered      int64 // accessed atomically
+	atHead        func() bool
+	skip          int32 // accessed atomically
+	indexes       []string
+	constraints   []string
+	data          *Data
+	worker        transaction
+	rows          *queryRows
+	pager         *pager
+	stream        *stream
+	values        []interface{}
+	dest          []interface{}
+	types         []string
+	masks         []Masker
+	transforms    []Transform
+	schema        *SchemaTable
 }
 
 type metaData struct {
 	DumpVersion   string
 	ServerVersion string
+	TargetVersion string
 	CompleteTime  string
+	Snapshot      *Snapshot
+	Binlog        *BinlogCoordinates
+
+	database *database
+	// layout is the database the files of FileLayoutPerTable are named after
+	layout  *database
+	started time.Time
 }
 
 const (
@@ -66,6 +293,26 @@ const headerTmpl = `-- Go SQL Dump {{ .DumpVersion }}
 --
 -- ------------------------------------------------------
 -- Server version	{{ .ServerVersion }}
+{{- if .TargetVersion }}
+-- Target version	{{ .TargetVersion }}
+{{- end }}
+{{- with .Snapshot }}
+-- Snapshot time	{{ .Time.Format "2006-01-02 15:04:05.000000" }} UTC
+-- Connection id	{{ .ConnectionID }}
+-- Isolation level	{{ .IsolationLevel }}
+{{- range $name := .VariableNames }}
+-- Variable {{ $name }}	{{ index $.Snapshot.Variables $name }}
+{{- end }}
+{{- end }}
+{{- with .Binlog }}
+-- Binlog file	{{ .File }}
+-- Binlog position	{{ .Position }}
+{{- with .GTIDSet }}
+-- GTID set	{{ . }}
+{{- end }}
+--
+-- CHANGE MASTER TO MASTER_LOG_FILE='{{ .File }}', MASTER_LOG_POS={{ .Position }};
+{{- end }}
 
 /*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
 /*!40101 SET @OLD_CHARACTER_SET_RESULTS=@@CHARACTER_SET_RESULTS */;
@@ -94,57 +341,186 @@ const footerTmpl = `/*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
 `
 
 // Takes a *table
-const tableTmpl = `
+const tableSchemaTmpl = `
 --
 -- Table structure for table {{ .NameEsc }}
 --
 
-DROP TABLE IF EXISTS {{ .NameEsc }};
+DROP TABLE IF EXISTS {{ .QualifiedName }};
 /*!40101 SET @saved_cs_client     = @@character_set_client */;
  SET character_set_client = utf8mb4 ;
-{{ .CreateSQL }};
+{{ terminate .CreateSQL }}
 /*!40101 SET character_set_client = @saved_cs_client */;
+`
 
+// Takes a *table
+const tableDataTmpl = `
 --
 -- Dumping data for table {{ .NameEsc }}
 --
 
-LOCK TABLES {{ .NameEsc }} WRITE;
-/*!40000 ALTER TABLE {{ .NameEsc }} DISABLE KEYS */;
+LOCK TABLES {{ .QualifiedName }} WRITE;
+/*!40000 ALTER TABLE {{ .QualifiedName }} DISABLE KEYS */;
 {{ range $value := .Stream }}
 {{- $value }}
 {{ end -}}
-/*!40000 ALTER TABLE {{ .NameEsc }} ENABLE KEYS */;
+/*!40000 ALTER TABLE {{ .QualifiedName }} ENABLE KEYS */;
 UNLOCK TABLES;
 `
+
+// Takes a *table
+const tableTmpl = tableSchemaTmpl + tableDataTmpl
+
 const viewTmpl = `
 --
 -- View structure for view {{ .NameEsc }}
 --
 
-DROP VIEW IF EXISTS {{ .NameEsc }};
+DROP VIEW IF EXISTS {{ .QualifiedName }};
 /*!40101 SET @saved_cs_client     = @@character_set_client */;
  SET character_set_client = utf8mb4 ;
-{{ .CreateSQL }};
+{{ terminate .CreateSQL }}
 /*!40101 SET character_set_client = @saved_cs_client */;
 `
 
 const nullType = "NULL"
 
-// Dump data using struct
+// DumpDatabase dumps the given database using struct
 func (data *Data) DumpDatabase(database string) error {
+	return data.run(database)
+}
+
+// Dump data using struct
+func (data *Data) Dump() error {
+	return data.run("")
+}
+
+// dump writes the dump of the current database, switching to database first
+// when it is not empty
+func (data *Data) dump(database string) (err error) {
 	meta := metaData{
-		DumpVersion: Version,
+		DumpVersion:   Version,
+		TargetVersion: data.TargetVersion,
+		started:       time.Now(),
 	}
+	defer func() {
+		data.Metrics.observe(data, meta.started, err)
+	}()
 
 	if data.MaxAllowedPacket == 0 {
 		data.MaxAllowedPacket = defaultMaxAllowedPacket
 	}
 
+	if data.BlobThreshold > 0 && data.BlobDir == "" {
+		return errors.New("BlobDir is required when BlobThreshold is set")
+	}
+
+	if err := data.checkDelimiter(); err != nil {
+		return err
+	}
+
+	if _, err := data.sectionOrder(); err != nil {
+		return err
+	}
+
+	if err := data.checkGrants(); err != nil {
+		return err
+	}
+
+	if err := data.checkProxyMode(); err != nil {
+		return err
+	}
+
+	if err := data.checkNonTransactionalPolicy(); err != nil {
+		return err
+	}
+
+	if err := data.checkOutputStyle(); err != nil {
+		return err
+	}
+
+	if err := data.checkLineEnding(); err != nil {
+		return err
+	}
+
+	if err := data.checkPatterns(); err != nil {
+		return err
+	}
+
+	if err := data.checkMergePolicy(); err != nil {
+		return err
+	}
+	if err := data.checkSample(); err != nil {
+		return err
+	}
+
+	if err := data.checkReportFormat(); err != nil {
+		return err
+	}
+
+	if err := data.checkSchemaDocFormat(); err != nil {
+		return err
+	}
+
+	if err := data.checkFileLayout(); err != nil {
+		return err
+	}
+	if err := data.checkDataFormat(); err != nil {
+		return err
+	}
+	if err := data.checkTableOptions(); err != nil {
+		return err
+	}
+	if err := data.checkCheckConstraints(); err != nil {
+		return err
+	}
+	if err := data.checkSessionCollation(); err != nil {
+		return err
+	}
+	if err := data.checkCompress(); err != nil {
+		return err
+	}
+
+	data.budget = data.memory()
+	defer data.Metrics.track(data.budget)()
+	data.warnings = nil
+	data.report = nil
+	data.listed = nil
+	data.streamed = nil
+	data.snapshot = nil
+	data.schema = nil
+	data.binlog = nil
+	data.gtidQuery = ""
+	data.qualifier = ""
+	if data.SchemaDocWriter != nil {
+		data.schema = &Schema{Tables: []SchemaTable{}}
+	}
+
 	if err := data.getTemplates(); err != nil {
 		return err
 	}
 
+	// From here on failures are DumpErrors telling their cause and whether
+	// output was written
+	out := &sink{}
+	data.sink = out
+	data.Out = out.writer(data.Out, true)
+	data.Files = out.factory(data.Files)
+	data.ReportWriter = out.writer(data.ReportWriter, false)
+	data.SchemaDocWriter = out.writer(data.SchemaDocWriter, false)
+	defer func() {
+		err = data.failed(err, out)
+	}()
+	closeOut, err := data.compressOut()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := closeOut(); err == nil {
+			err = cerr
+		}
+	}()
+
 	// Start the read only transaction and defer the rollback until the end
 	// This way the database will have the exact state it did at the beginning of
 	// the backup and nothing can be accidentally committed
@@ -152,133 +528,318 @@ func (data *Data) DumpDatabase(database string) error {
 		return err
 	}
 	defer data.rollback()
-
-	if err := data.useDatabase(database); err != nil {
+	meta.Binlog = data.binlog
+	if err := data.setUpSessions(); err != nil {
 		return err
 	}
 
-	if err := meta.updateServerVersion(data); err != nil {
+	// Behind a proxy only the pinned transaction is sure to reach the server
+	// the snapshot is taken on, the tables are listed from information_schema
+	// as proxies tend to answer or route SHOW statements on their own
+	proxied, err := data.detectProxy()
+	if err != nil {
 		return err
 	}
+	var backend string
+	if proxied {
+		data.UseInformationSchema = true
+		if backend, err = data.backend(); err != nil {
+			return err
+		}
+		// The connections of the workers may reach other servers
+		if len(data.workers) > 0 {
+			data.warn("tables read one at a time behind a proxy")
+			if err := data.stopWorkers(); err != nil {
+				return err
+			}
+		}
+	}
 
-	if err := data.headerTmpl.Execute(data.Out, meta); err != nil {
-		return err
+	if data.SnapshotInfo {
+		var err error
+		if data.snapshot, err = data.readSnapshot(); err != nil {
+			return err
+		}
+		meta.Snapshot = data.snapshot
 	}
 
-	tables, err := data.getTables()
-	if err != nil {
-		return err
+	if data.HeartbeatInterval > 0 {
+		data.heartbeat = data.startHeartbeat()
+		defer func() {
+			data.heartbeat.stop()
+			data.heartbeat = nil
+		}()
 	}
 
-	// Lock all tables before dumping if present
-	if data.LockTables && len(tables) > 0 {
-		var b bytes.Buffer
-		b.WriteString("LOCK TABLES ")
-		for index, table := range tables {
-			if index != 0 {
-				b.WriteString(",")
-			}
-			b.WriteString("`" + table.Name + "` READ /*!32311 LOCAL */")
+	if database != "" {
+		if err := data.useDatabase(database); err != nil {
+			return err
 		}
+	}
 
-		if _, err := data.Connection.Exec(b.String()); err != nil {
+	if data.QualifyNames {
+		if err := data.qualifyNames(database); err != nil {
 			return err
 		}
+	}
+
+	if err := meta.updateServerVersion(data); err != nil {
+		return err
+	}
 
-		defer data.Connection.Exec("UNLOCK TABLES")
+	data.manifest = &Manifest{
+		DumpVersion:   meta.DumpVersion,
+		ServerVersion: meta.ServerVersion,
+		Snapshot:      data.snapshot,
+		Binlog:        data.binlog,
 	}
 
-	for _, table := range tables {
-		if err := data.dumpTable(table); err != nil {
+	if data.CreateDatabase || data.AddDropDatabase {
+		var err error
+		if meta.database, err = data.getDatabase(database); err != nil {
 			return err
 		}
 	}
 
-	if data.err != nil {
-		return data.err
+	if data.perTable() {
+		meta.layout = meta.database
+		if meta.layout == nil {
+			var err error
+			if meta.layout, err = data.getDatabase(database); err != nil {
+				return err
+			}
+		}
+		data.manifest.Database = meta.layout.Name
 	}
 
-	meta.CompleteTime = time.Now().String()
-	return data.footerTmpl.Execute(data.Out, meta)
-}
+	if data.schema != nil {
+		data.schema.Database = database
+		if meta.database != nil {
+			data.schema.Database = meta.database.Name
+		}
+	}
 
-// Dump data using struct
-func (data *Data) Dump() error {
-	meta := metaData{
-		DumpVersion: Version,
+	tables, err := data.getTables()
+	if err != nil {
+		return err
 	}
+	data.listed = listedNames(tables)
 
-	if data.MaxAllowedPacket == 0 {
-		data.MaxAllowedPacket = defaultMaxAllowedPacket
+	if tables, err = data.followMerges(tables); err != nil {
+		return err
 	}
+	data.heartbeat.estimate(tables)
 
-	if err := data.getTemplates(); err != nil {
+	defer data.dropMaterialized()
+	if err := data.materializeViews(tables); err != nil {
 		return err
 	}
+	data.viewsAsTables(tables)
 
-	// Start the read only transaction and defer the rollback until the end
-	// This way the database will have the exact state it did at the beginning of
-	// the backup and nothing can be accidentally committed
-	if err := data.begin(); err != nil {
+	if data.UseInformationSchema {
+		if err := data.loadColumns(tables); err != nil {
+			return err
+		}
+		if err := data.loadKeys(tables); err != nil {
+			return err
+		}
+	}
+
+	// Lock all tables before dumping if present
+	if data.LockTables && len(tables) > 0 && proxied {
+		data.warn("LOCK TABLES skipped behind a proxy, it could reach another server than the transaction")
+	} else if data.LockTables && len(tables) > 0 {
+		var locks *tableLocks
+		if err := data.privileged("LOCK TABLES", func() error {
+			var err error
+			locks, err = data.lockTables(tables)
+			return err
+		}); err != nil {
+			return err
+		}
+		defer data.unlock(locks)
+	}
+
+	locks, err := data.protectNonTransactional(tables, proxied)
+	if err != nil {
 		return err
 	}
-	defer data.rollback()
+	defer data.unlock(locks)
 
-	if err := meta.updateServerVersion(data); err != nil {
+	if tables, err = data.addQueryTables(tables); err != nil {
 		return err
 	}
 
-	if err := data.headerTmpl.Execute(data.Out, meta); err != nil {
+	if err := data.runBarrier(); err != nil {
 		return err
 	}
 
-	tables, err := data.getTables()
+	if data.Files != nil {
+		err = data.writeFiles(&meta, tables)
+	} else {
+		err = data.writeStream(&meta, tables)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Lock all tables before dumping if present
-	if data.LockTables && len(tables) > 0 {
-		var b bytes.Buffer
-		b.WriteString("LOCK TABLES ")
-		for index, table := range tables {
-			if index != 0 {
-				b.WriteString(",")
-			}
-			b.WriteString("`" + table.Name + "` READ /*!32311 LOCAL */")
+	if proxied {
+		after, err := data.backend()
+		if err != nil {
+			return err
+		}
+		if after != backend {
+			return fmt.Errorf("%w: %s, then %s", ErrBackendSwitched, backend, after)
 		}
+	}
 
-		if _, err := data.Connection.Exec(b.String()); err != nil {
+	if data.BlobThreshold > 0 {
+		if err := data.manifest.writeFile(data.BlobDir); err != nil {
 			return err
 		}
+	}
+	if err := data.writeReport(); err != nil {
+		return err
+	}
+	return data.writeSchemaDoc()
+}
+
+// writeStream writes the whole dump to Out. With the schema right in front of
+// the data, the structure of every table is followed by its rows.
+func (data *Data) writeStream(meta *metaData, tables []*table) (err error) {
+	out := data.Out
+	if data.ValidateOutput {
+		check := data.newValidator("")
+		out = io.MultiWriter(out, check)
+		defer func() {
+			if cerr := check.Close(); err == nil {
+				err = cerr
+			}
+		}()
+	}
 
-		defer data.Connection.Exec("UNLOCK TABLES")
+	// The summary ending the dump covers everything written to Out
+	s := data.newSummaryWriter(out)
+	data.Out = s
+	data.streamed = s
+	if err := data.headerTmpl.Execute(data.Out, meta); err != nil {
+		return err
+	}
+	if err := data.writeDatabase(data.Out, meta); err != nil {
+		return err
 	}
 
-	for _, table := range tables {
-		if err := data.dumpTable(table); err != nil {
-			return err
+	order, err := data.sectionOrder()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(order); i++ {
+		switch order[i] {
+		case SectionSchema:
+			together := i+1 < len(order) && order[i+1] == SectionData
+			if together {
+				if err := data.readTables(data.Out, tables, data.writeTableTo); err != nil {
+					return err
+				}
+				i++
+				if err := data.writePostData(tables); err != nil {
+					return err
+				}
+			} else {
+				for _, table := range tables {
+					if err := data.streamTableSchema(table); err != nil {
+						return err
+					}
+				}
+			}
+		case SectionData:
+			var rows []*table
+			for _, table := range tables {
+				if !table.isView {
+					rows = append(rows, table)
+				}
+			}
+			if err := data.readTables(data.Out, rows, data.writeTableData); err != nil {
+				return err
+			}
+			if err := data.writePostData(tables); err != nil {
+				return err
+			}
+		default:
+			if err := data.writeObjects(data.Out, order[i], tables); err != nil {
+				return err
+			}
 		}
 	}
+	if err := data.writeUsers(data.Out, meta); err != nil {
+		return err
+	}
+	if err := data.recheckTables(); err != nil {
+		return err
+	}
+
+	return data.writeFooter(s, meta, data.report)
+}
+
+// streamTableSchema writes the structure of a table without its rows. Tables
+// are recorded along with their rows.
+func (data *Data) streamTableSchema(table *table) error {
+	if table.isView {
+		return data.dumpTableWith(table, data.writeTableSchema)
+	}
+	start := data.streamed.n
+	if err := data.writeTableSchema(data.Out, table); err != nil {
+		return err
+	}
+	table.addSection(start, data.streamed.n)
+	return nil
+}
 
+// writePostData writes the deferred indexes and foreign keys following the
+// rows of all tables
+func (data *Data) writePostData(tables []*table) error {
 	if data.err != nil {
 		return data.err
 	}
-
-	meta.CompleteTime = time.Now().String()
-	return data.footerTmpl.Execute(data.Out, meta)
+	if err := data.writeIndexes(data.Out, tables); err != nil {
+		return err
+	}
+	return data.writeConstraints(data.Out, tables)
 }
 
 // MARK: - Private methods
 
 // begin starts a read only transaction that will be whatever the database was
 // when it was called
-func (data *Data) begin() (err error) {
-	data.tx, err = data.Connection.BeginTx(context.Background(), &sql.TxOptions{
-		Isolation: sql.LevelRepeatableRead,
-		ReadOnly:  true,
-	})
-	return
+func (data *Data) begin() error {
+	var err error
+	switch {
+	case data.BinlogCoordinates || data.Concurrency > 1:
+		err = data.beginWithCoordinates()
+	case data.ProxyHint != "" || data.Barrier != nil:
+		// The Barrier runs before any row is read, the snapshot has to be
+		// taken when the transaction starts
+		err = data.beginPinned()
+	default:
+		var tx *sql.Tx
+		tx, err = data.Connection.BeginTx(data.context(), &sql.TxOptions{
+			Isolation: sql.LevelRepeatableRead,
+			ReadOnly:  true,
+		})
+		if err == nil {
+			data.tx = tx
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if data.ProxyHint != "" {
+		data.tx = &hintTx{transaction: data.tx, hint: data.ProxyHint}
+		for i, worker := range data.workers {
+			data.workers[i] = &hintTx{transaction: worker, hint: data.ProxyHint}
+		}
+	}
+	return nil
 }
 
 // Choose a database to dump
@@ -289,56 +850,185 @@ func (data *Data) useDatabase(database string) error {
 	if _, err := data.tx.Exec("USE " + database); err != nil {
 		return err
 	}
+	for _, worker := range data.workers {
+		if _, err := worker.Exec("USE " + database); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// rollback cancels the transaction
+// rollback cancels the transactions
 func (data *Data) rollback() error {
-	return data.tx.Rollback()
+	err := data.stopWorkers()
+	if rerr := data.tx.Rollback(); rerr != nil {
+		err = rerr
+	}
+	return err
 }
 
 // MARK: writer methods
 
 func (data *Data) dumpTable(table *table) error {
-	if data.err != nil {
-		return data.err
+	return data.dumpTableWith(table, data.writeTableTo)
+}
+
+// dumpTableWith writes table to Out with write and records it
+func (data *Data) dumpTableWith(t *table, write func(io.Writer, *table) error) error {
+	return data.readTables(data.Out, []*table{t}, write)
+}
+
+// recordTable adds a dumped table to the manifest and the report
+func (data *Data) recordTable(table *table) error {
+	checksum, err := data.tableChecksum(table)
+	if err != nil {
+		return err
 	}
-	return data.writeTable(table)
+	data.addTable(table, checksum)
+	return nil
+}
+
+// tableChecksum returns the CHECKSUM TABLE of a dumped table when Checksums
+// is set and the rows dumped are the ones of the table
+func (data *Data) tableChecksum(table *table) (string, error) {
+	if data.Checksums && !table.isView && table.query == "" && table.source == "" && !table.asTable && table.sampleFraction() == 1 {
+		return table.checksum()
+	}
+	return "", nil
+}
+
+// addTable adds a dumped table to the manifest, the report and the schema
+// document
+func (data *Data) addTable(table *table, checksum string) {
+	data.manifest.addTable(table, checksum)
+	data.addStats(table)
+	data.addSchema(table)
 }
 
 func (data *Data) writeTable(table *table) error {
+	return data.writeTableTo(data.Out, table)
+}
+
+// writeTableTo writes the structure and rows of the table or the definition
+// of the view to w
+func (data *Data) writeTableTo(w io.Writer, table *table) error {
 	if table.isView {
-		if err := data.viewTmpl.Execute(data.Out, table); err != nil {
-			return err
-		}
-	} else {
-		if err := data.tableTmpl.Execute(data.Out, table); err != nil {
+		return data.writeView(w, table)
+	}
+	if err := table.checkMerge(); err != nil {
+		return err
+	}
+	defer table.releaseAll()
+	return table.readData(func() error {
+		err := data.tableTmpl.Execute(table.releasing(w), table)
+		table.stopStream()
+		if err != nil {
 			return err
 		}
+		return table.Err
+	})
+}
+
+// writeTableSchema writes the structure of the table or view to w
+func (data *Data) writeTableSchema(w io.Writer, table *table) error {
+	if table.isView {
+		return data.writeView(w, table)
+	}
+	if err := data.tableSchemaTmpl.Execute(w, table); err != nil {
+		return err
 	}
 	return table.Err
 }
 
+// writeView writes the definition of a view, invalid views are commented out
+// and do not fail the dump
+func (data *Data) writeView(w io.Writer, table *table) error {
+	tmpl, err := data.viewTemplate(table)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, table); err != nil {
+		return err
+	}
+	return table.Err
+}
+
+// writeTableData writes the rows of the table to w
+func (data *Data) writeTableData(w io.Writer, table *table) error {
+	if err := table.checkMerge(); err != nil {
+		return err
+	}
+	defer table.releaseAll()
+	return table.readData(func() error {
+		err := data.tableDataTmpl.Execute(table.releasing(w), table)
+		table.stopStream()
+		if err != nil {
+			return err
+		}
+		return table.Err
+	})
+}
+
 // MARK: get methods
 
 // getTemplates initializes the templates on data from the constants in this file
 func (data *Data) getTemplates() (err error) {
-	data.headerTmpl, err = template.New("mysqldumpHeader").Parse(headerTmpl)
+	data.headerTmpl, err = template.New("mysqldumpHeader").Funcs(data.templateFuncs()).Parse(headerTmpl)
+	if err != nil {
+		return
+	}
+
+	data.tableTmpl, err = template.New("mysqldumpTable").Funcs(data.templateFuncs()).Parse(tableTmpl)
+	if err != nil {
+		return
+	}
+
+	data.tableSchemaTmpl, err = template.New("mysqldumpTableSchema").Funcs(data.templateFuncs()).Parse(tableSchemaTmpl)
+	if err != nil {
+		return
+	}
+
+	data.tableDataTmpl, err = template.New("mysqldumpTableData").Funcs(data.templateFuncs()).Parse(tableDataTmpl)
+	if err != nil {
+		return
+	}
+
+	data.tableIndexesTmpl, err = template.New("mysqldumpTableIndexes").Funcs(data.templateFuncs()).Parse(tableIndexesTmpl)
 	if err != nil {
 		return
 	}
 
-	data.tableTmpl, err = template.New("mysqldumpTable").Parse(tableTmpl)
+	data.tableConstraintsTmpl, err = template.New("mysqldumpTableConstraints").Funcs(data.templateFuncs()).Parse(tableConstraintsTmpl)
 	if err != nil {
 		return
 	}
 
-	data.viewTmpl, err = template.New("mysqldumpView").Parse(viewTmpl)
+	data.databaseTmpl, err = template.New("mysqldumpDatabase").Funcs(data.templateFuncs()).Parse(databaseTmpl)
 	if err != nil {
 		return
 	}
 
-	data.footerTmpl, err = template.New("mysqldumpTable").Parse(footerTmpl)
+	data.viewTmpl, err = template.New("mysqldumpView").Funcs(data.templateFuncs()).Parse(viewTmpl)
+	if err != nil {
+		return
+	}
+
+	data.invalidViewTmpl, err = template.New("mysqldumpInvalidView").Funcs(data.templateFuncs()).Parse(invalidViewTmpl)
+	if err != nil {
+		return
+	}
+
+	data.footerTmpl, err = template.New("mysqldumpTable").Funcs(data.templateFuncs()).Parse(footerTmpl)
+	if err != nil {
+		return
+	}
+
+	data.objectsTmpl, err = template.New("mysqldumpObjects").Funcs(data.templateFuncs()).Parse(objectsTmpl)
+	if err != nil {
+		return
+	}
+
+	data.usersTmpl, err = template.New("mysqldumpUsers").Funcs(data.templateFuncs()).Parse(usersTmpl)
 	if err != nil {
 		return
 	}
@@ -346,9 +1036,13 @@ func (data *Data) getTemplates() (err error) {
 }
 
 func (data *Data) getTables() ([]*table, error) {
+	if data.UseInformationSchema {
+		return data.getSchemaTables()
+	}
+
 	tables := make([]*table, 0)
 
-	rows, err := data.tx.Query("SHOW FULL TABLES")
+	rows, err := data.metadataQuery("SHOW FULL TABLES")
 	if err != nil {
 		return tables, err
 	}
@@ -368,13 +1062,68 @@ func (data *Data) getTables() ([]*table, error) {
 	return tables, rows.Err()
 }
 
+// getSchemaTables lists the tables and views of the current database along
+// with their engine and estimated row count in a single query
+func (data *Data) getSchemaTables() ([]*table, error) {
+	tables := make([]*table, 0)
+
+	rows, err := data.metadataQuery("SELECT TABLE_NAME, TABLE_TYPE, ENGINE, TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME")
+	if err != nil {
+		return tables, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName, tableType, engine sql.NullString
+		var rowEstimate sql.NullInt64
+		if err := rows.Scan(&tableName, &tableType, &engine, &rowEstimate); err != nil {
+			return tables, err
+		}
+		if tableName.Valid && !data.isIgnoredTable(tableName.String) {
+			table := data.createTable(tableName.String, tableType.String == "VIEW")
+			table.engine = engine.String
+			table.rowEstimate = rowEstimate.Int64
+			tables = append(tables, table)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// isIgnoredTable reports whether the table is left out of the dump. Excluded
+// names win over included ones, and the tables of online schema changes are
+// only dumped when listed by name in IncludeTables, not for matching one of
+// the IncludePatterns.
 func (data *Data) isIgnoredTable(name string) bool {
-	for _, item := range data.IgnoreTables {
-		if item == name {
-			return true
+	if data.isListed(data.IgnoreTables, name) || matchesAny(data.excludePatterns, name) {
+		return true
+	}
+	if len(data.IncludeTables) == 0 && len(data.includePatterns) == 0 {
+		return data.isSkippedArtifact(name)
+	}
+	if data.isListed(data.IncludeTables, name) {
+		return false
+	}
+	if matchesAny(data.includePatterns, name) {
+		return data.isSkippedArtifact(name)
+	}
+	return true
+}
+
+// AllowedTables returns the tables among names that data dumps, the ones not
+// ignored, excluded or skipped as artifacts and, with IncludeTables or
+// IncludePatterns, included by them. Setting the result as IncludeTables
+// narrows the dump to tables asked for by a client without widening it.
+func (data *Data) AllowedTables(names []string) ([]string, error) {
+	if err := data.checkPatterns(); err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, name := range names {
+		if !data.isIgnoredTable(name) {
+			tables = append(tables, name)
 		}
 	}
-	return false
+	return tables, nil
 }
 
 func (meta *metaData) updateServerVersion(data *Data) (err error) {
@@ -399,7 +1148,35 @@ func (table *table) NameEsc() string {
 }
 
 func (table *table) CreateSQL() (string, error) {
-	rows, err := table.data.tx.Query("SHOW CREATE TABLE " + table.NameEsc())
+	if table.createSQL != "" {
+		return table.createSQL, nil
+	}
+	if table.query != "" {
+		if err := table.describeQuery(); err != nil {
+			return "", err
+		}
+		if table.data.schema != nil {
+			schema := parseSchemaTable(table.Name, table.createSQL, false)
+			table.schema = &schema
+		}
+		table.createSQL = table.data.qualifyDDL(table.createSQL, "TABLE", table.NameEsc())
+		return table.createSQL, nil
+	}
+
+	if table.asTable {
+		create, err := table.viewTableSQL()
+		if err != nil {
+			return "", err
+		}
+		if table.data.schema != nil {
+			schema := parseSchemaTable(table.Name, create, false)
+			table.schema = &schema
+		}
+		table.createSQL = table.data.qualifyDDL(create, "TABLE", table.NameEsc())
+		return table.createSQL, nil
+	}
+
+	rows, err := table.metadataQuery("SHOW CREATE TABLE `" + table.sourceName() + "`")
 	if err != nil {
 		return "", err
 	}
@@ -428,17 +1205,52 @@ func (table *table) CreateSQL() (string, error) {
 		return "", errors.New("database column information is malformed")
 	}
 
-	if info[0].String != table.Name {
+	if info[0].String != table.sourceName() {
 		return "", errors.New("returned table is not the same as requested table")
 	}
 
 	table.isView = strings.Contains(info[1].String, "VIEW")
 
-	return info[1].String, nil
+	create := table.data.rewriteDDL(table.materializedDDL(info[1].String))
+	if !table.isView {
+		create = table.rewriteChecks(table.rewriteTableOptions(create))
+	}
+	if table.data.schema != nil {
+		schema := parseSchemaTable(table.Name, create, table.isView)
+		table.schema = &schema
+	}
+	if table.data.DeferIndexes && !table.isView {
+		create, table.indexes, table.constraints = splitCreateSQL(create)
+	}
+	if table.isView {
+		if create, err = table.qualifyView(create); err != nil {
+			return "", err
+		}
+	} else {
+		create = table.data.qualifyDDL(create, "TABLE", table.NameEsc())
+	}
+	table.createSQL = create
+	return create, nil
+}
+
+// checksum returns the result of CHECKSUM TABLE
+func (table *table) checksum() (string, error) {
+	var name, checksum sql.NullString
+	if err := table.txn().QueryRow("CHECKSUM TABLE "+table.NameEsc()).Scan(&name, &checksum); err != nil {
+		return "", err
+	}
+	return checksum.String, nil
 }
 
 func (table *table) initColumnData() error {
-	colInfo, err := table.data.tx.Query("SHOW COLUMNS FROM " + table.NameEsc())
+	if table.query != "" {
+		return table.describeQuery()
+	}
+	if table.columnsLoaded {
+		return nil
+	}
+
+	colInfo, err := table.metadataQuery("SHOW COLUMNS FROM " + table.NameEsc())
 	if err != nil {
 		return err
 	}
@@ -449,16 +1261,17 @@ func (table *table) initColumnData() error {
 		return err
 	}
 
-	fieldIndex, extraIndex := -1, -1
+	fieldIndex, extraIndex, keyIndex, typeIndex := -1, -1, -1, -1
 	for i, col := range cols {
 		switch col {
 		case "Field", "field":
 			fieldIndex = i
 		case "Extra", "extra":
 			extraIndex = i
-		}
-		if fieldIndex >= 0 && extraIndex >= 0 {
-			break
+		case "Key", "key":
+			keyIndex = i
+		case "Type", "type":
+			typeIndex = i
 		}
 	}
 	if fieldIndex < 0 || extraIndex < 0 {
@@ -471,31 +1284,70 @@ func (table *table) initColumnData() error {
 		scans[i] = &info[i]
 	}
 
-	var result []string
+	table.cols = []string{}
+	table.colTypes = []string{}
+	table.pk = nil
+	table.keySkipped = false
 	for colInfo.Next() {
 		// Read into the pointers to the info marker
 		if err := colInfo.Scan(scans...); err != nil {
 			return err
 		}
 
-		// Ignore the virtual columns
-		if !info[extraIndex].Valid || !strings.Contains(info[extraIndex].String, "VIRTUAL") {
-			result = append(result, info[fieldIndex].String)
+		// Ignore the generated columns, the server computes them on restore.
+		// Invisible columns are listed here but left out of SELECT *, naming
+		// every column keeps them in the dump.
+		if !isGeneratedColumn(info[extraIndex].String) {
+			var columnType string
+			if typeIndex >= 0 {
+				columnType = info[typeIndex].String
+			}
+			table.addColumn(info[fieldIndex].String, columnType, keyIndex >= 0 && info[keyIndex].String == "PRI")
 		}
 	}
-	table.cols = result
+	if err := colInfo.Err(); err != nil {
+		return err
+	}
+	// SHOW KEYS runs on the connection the columns were read from
+	colInfo.Close()
+	if err := table.loadKey(); err != nil {
+		return err
+	}
+	table.columnsLoaded = true
 	return nil
 }
 
+// isGeneratedColumn reports whether the Extra of SHOW COLUMNS describes a
+// generated column. Columns with an expression default are DEFAULT_GENERATED
+// and hold data of their own.
+func isGeneratedColumn(extra string) bool {
+	for _, word := range strings.Fields(extra) {
+		switch strings.ToUpper(word) {
+		case "VIRTUAL", "STORED", "PERSISTENT":
+			return true
+		}
+	}
+	return false
+}
+
 func (table *table) columnsList() string {
 	return "`" + strings.Join(table.cols, "`, `") + "`"
 }
 
+// selectSQL selects all dumped columns of the table
+func (table *table) selectSQL() string {
+	return "SELECT " + table.columnsList() + " FROM " + table.fromSQL()
+}
+
 func (table *table) Init() error {
 	if len(table.values) != 0 {
 		return errors.New("can't init twice")
 	}
 
+	if table.mergeSkipped {
+		return nil
+	}
+
 	if err := table.initColumnData(); err != nil {
 		return err
 	}
@@ -506,7 +1358,11 @@ func (table *table) Init() error {
 	}
 
 	var err error
-	table.rows, err = table.data.tx.Query("SELECT " + table.columnsList() + " FROM " + table.NameEsc())
+	if table.data.FetchSize > 0 && len(table.pk) > 0 {
+		table.rows, err = table.firstPage()
+	} else {
+		table.rows, err = table.selectRows(table.selectSQL())
+	}
 	if err != nil {
 		return err
 	}
@@ -516,14 +1372,52 @@ func (table *table) Init() error {
 		return err
 	}
 
+	table.initMasks()
+	table.initTransforms()
 	table.values = make([]interface{}, len(tt))
+	table.types = make([]string, len(tt))
 	for i, tp := range tt {
 		table.values[i] = reflect.New(reflectColumnType(tp)).Interface()
+		table.types[i] = tp.DatabaseTypeName()
 	}
+	table.dest = scanDestinations(table.values)
 	return nil
 }
 
+var boolLiterals = []string{"FALSE", "TRUE"}
+
+// isBoolColumn reports whether the column is a BOOLEAN, which MySQL stores as
+// TINYINT(1)
+func (table *table) isBoolColumn(column int) bool {
+	return column < len(table.colTypes) && strings.HasPrefix(strings.ToLower(table.colTypes[column]), "tinyint(1)")
+}
+
+// floatBits returns the precision of the floating point column
+func (table *table) floatBits(column int) int {
+	if column < len(table.types) && table.types[column] == "FLOAT" {
+		return 32
+	}
+	return 64
+}
+
+// formatFloat writes the shortest representation that reads back as the same
+// value of the given precision. Unlike %f it neither pads nor rounds to six
+// decimals and does not depend on the locale.
+func formatFloat(f float64, bits int) string {
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
 func reflectColumnType(tp *sql.ColumnType) reflect.Type {
+	// YEAR is a number, whatever the driver scans it into
+	if tp.DatabaseTypeName() == "YEAR" {
+		return reflect.TypeOf(sql.NullInt64{})
+	}
+	// Binary strings keep their bytes, even for drivers scanning them as
+	// strings
+	if isBinaryType(tp.DatabaseTypeName()) {
+		return reflect.TypeOf(sql.RawBytes{})
+	}
+
 	// reflect for ScanType
 	switch tp.ScanType().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -550,26 +1444,60 @@ func reflectColumnType(tp *sql.ColumnType) reflect.Type {
 	return tp.ScanType()
 }
 
+// Next moves to the next row of the table, skipping the ones left out of the
+// sample
 func (table *table) Next() bool {
+	for table.fetch() {
+		if table.sampled() {
+			table.row++
+			return true
+		}
+	}
+	return false
+}
+
+// fetch scans the next row of the result
+func (table *table) fetch() bool {
+	if table.skipped() {
+		table.stopReading()
+		return false
+	}
 	if table.rows == nil {
 		if err := table.Init(); err != nil {
 			table.Err = err
 			return false
 		}
+		if table.rows == nil {
+			return false
+		}
 	}
 	// Fallthrough
-	if table.rows.Next() {
-		if err := table.rows.Scan(table.values...); err != nil {
+	for !table.rows.Next() {
+		err := table.rows.Err()
+		table.rows.Close()
+		table.rows = nil
+		if err == nil {
+			table.rows, err = table.nextPage()
+		}
+		if err != nil {
+			table.closePager()
 			table.Err = err
 			return false
-		} else if err := table.rows.Err(); err != nil {
-			table.Err = err
+		}
+		if table.rows == nil {
 			return false
 		}
-	} else {
-		table.rows.Close()
-		table.rows = nil
+	}
+	table.data.heartbeat.row()
+	if err := table.rows.Scan(table.dest...); err != nil {
+		table.Err = err
 		return false
+	} else if err := table.rows.Err(); err != nil {
+		table.Err = err
+		return false
+	}
+	if table.pager != nil {
+		table.pager.scanned(table)
 	}
 	return true
 }
@@ -582,34 +1510,58 @@ func (table *table) RowBuffer() *bytes.Buffer {
 	var b bytes.Buffer
 	b.WriteString("(")
 
+	aligned := table.data.OutputStyle == StyleAligned
+	table.valueStarts = table.valueStarts[:0]
 	for key, value := range table.values {
 		if key != 0 {
 			b.WriteString(",")
 		}
+		if aligned {
+			table.valueStarts = append(table.valueStarts, b.Len())
+		}
+		value = table.transformed(key, value)
+		if m := table.masker(key); m != nil {
+			if s, ok := textValue(value); ok {
+				fmt.Fprintf(&b, "'%s'", sanitize(m.Mask(s)))
+				continue
+			}
+		}
 		switch s := value.(type) {
 		case nil:
 			b.WriteString(nullType)
 		case *sql.NullString:
-			if s.Valid {
+			if s.Valid && table.data.HexBlob && !utf8.ValidString(s.String) {
+				writeHex(&b, []byte(s.String))
+			} else if s.Valid {
 				fmt.Fprintf(&b, "'%s'", sanitize(s.String))
 			} else {
 				b.WriteString(nullType)
 			}
 		case *sql.NullInt64:
-			if s.Valid {
+			if s.Valid && table.data.BoolLiterals && table.isBoolColumn(key) && (s.Int64 == 0 || s.Int64 == 1) {
+				b.WriteString(boolLiterals[s.Int64])
+			} else if s.Valid {
 				fmt.Fprintf(&b, "%d", s.Int64)
 			} else {
 				b.WriteString(nullType)
 			}
 		case *sql.NullFloat64:
 			if s.Valid {
-				fmt.Fprintf(&b, "%f", s.Float64)
+				b.WriteString(formatFloat(s.Float64, table.floatBits(key)))
 			} else {
 				b.WriteString(nullType)
 			}
 		case *sql.RawBytes:
-			if len(*s) == 0 {
+			if *s == nil {
 				b.WriteString(nullType)
+			} else if table.data.BlobThreshold > 0 && len(*s) > table.data.BlobThreshold {
+				ref, err := table.externalizeBlob(key, *s)
+				if err != nil {
+					table.Err = err
+				}
+				b.WriteString(ref)
+			} else if table.data.HexBlob {
+				writeHex(&b, *s)
 			} else {
 				fmt.Fprintf(&b, "_binary '%s'", sanitize(string(*s)))
 			}
@@ -622,32 +1574,27 @@ func (table *table) RowBuffer() *bytes.Buffer {
 	return &b
 }
 
-func (table *table) Stream() <-chan string {
-	valueOut := make(chan string, 1)
-	go func() {
-		defer close(valueOut)
-		var insert bytes.Buffer
-
-		for table.Next() {
-			b := table.RowBuffer()
-			// Truncate our insert if it won't fit
-			if insert.Len() != 0 && insert.Len()+b.Len() > table.data.MaxAllowedPacket-1 {
-				insert.WriteString(";")
-				valueOut <- insert.String()
-				insert.Reset()
-			}
+// writeHex writes value as a hex literal, an empty X literal if it is empty
+// as 0x needs at least one digit
+func writeHex(b *bytes.Buffer, value []byte) {
+	if len(value) == 0 {
+		b.WriteString("X''")
+		return
+	}
+	b.WriteString("0x")
+	enc := make([]byte, hex.EncodedLen(len(value)))
+	hex.Encode(enc, value)
+	b.Write(enc)
+}
 
-			if insert.Len() == 0 {
-				fmt.Fprint(&insert, "INSERT INTO ", table.NameEsc(), " (", table.columnsList(), ") VALUES ")
-			} else {
-				insert.WriteString(",")
-			}
-			b.WriteTo(&insert)
-		}
-		if insert.Len() != 0 {
-			insert.WriteString(";")
-			valueOut <- insert.String()
-		}
-	}()
-	return valueOut
+// Stream fetches the rows of the table and serializes them into the INSERT
+// statements received by the template, until the rows run out or the template
+// gives up and stopStream is called.
+func (table *table) Stream() <-chan string {
+	s := newStream()
+	table.stream = s
+	rows := make(chan fetchedRow, rowQueue)
+	s.group.Go(func() error { return table.fetchRows(s.ctx, rows) })
+	s.group.Go(func() error { return table.serializeRows(s, rows) })
+	return s.out
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"database/sql"
 	"errors"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -40,16 +42,24 @@ func c(name string, v interface{}) *sqlmock.Column {
 	return sqlmock.NewColumn(name).OfType(t, v).Nullable(true)
 }
 
+func tableNames(tables []*table) []string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
 func TestGetTablesOk(t *testing.T) {
 	data, mock, err := getMockData()
 	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
 	defer data.Close()
 
-	rows := sqlmock.NewRows([]string{"Tables_in_Testdb"}).
-		AddRow("Test_Table_1").
-		AddRow("Test_Table_2")
+	rows := sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table_1", "BASE TABLE").
+		AddRow("Test_Table_2", "BASE TABLE")
 
-	mock.ExpectQuery("^SHOW TABLES$").WillReturnRows(rows)
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(rows)
 
 	result, err := data.getTables()
 	assert.NoError(t, err)
@@ -57,7 +67,7 @@ func TestGetTablesOk(t *testing.T) {
 	// we make sure that all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
 
-	assert.EqualValues(t, []string{"Test_Table_1", "Test_Table_2"}, result)
+	assert.EqualValues(t, []string{"Test_Table_1", "Test_Table_2"}, tableNames(result))
 }
 
 func TestIgnoreTablesOk(t *testing.T) {
@@ -65,11 +75,11 @@ func TestIgnoreTablesOk(t *testing.T) {
 	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
 	defer data.Close()
 
-	rows := sqlmock.NewRows([]string{"Tables_in_Testdb"}).
-		AddRow("Test_Table_1").
-		AddRow("Test_Table_2")
+	rows := sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table_1", "BASE TABLE").
+		AddRow("Test_Table_2", "BASE TABLE")
 
-	mock.ExpectQuery("^SHOW TABLES$").WillReturnRows(rows)
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(rows)
 
 	data.IgnoreTables = []string{"Test_Table_1"}
 
@@ -79,7 +89,7 @@ func TestIgnoreTablesOk(t *testing.T) {
 	// we make sure that all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
 
-	assert.EqualValues(t, []string{"Test_Table_2"}, result)
+	assert.EqualValues(t, []string{"Test_Table_2"}, tableNames(result))
 }
 
 func TestGetTablesNil(t *testing.T) {
@@ -87,12 +97,12 @@ func TestGetTablesNil(t *testing.T) {
 	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
 	defer data.Close()
 
-	rows := sqlmock.NewRows([]string{"Tables_in_Testdb"}).
-		AddRow("Test_Table_1").
-		AddRow(nil).
-		AddRow("Test_Table_3")
+	rows := sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table_1", "BASE TABLE").
+		AddRow(nil, "BASE TABLE").
+		AddRow("Test_Table_3", "BASE TABLE")
 
-	mock.ExpectQuery("^SHOW TABLES$").WillReturnRows(rows)
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(rows)
 
 	result, err := data.getTables()
 	assert.NoError(t, err)
@@ -100,7 +110,7 @@ func TestGetTablesNil(t *testing.T) {
 	// we make sure that all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
 
-	assert.EqualValues(t, []string{"Test_Table_1", "Test_Table_3"}, result)
+	assert.EqualValues(t, []string{"Test_Table_1", "Test_Table_3"}, tableNames(result))
 }
 
 func TestGetServerVersionOk(t *testing.T) {
@@ -296,6 +306,50 @@ func TestCreateTableValuesSteamSmallPackets(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
 }
 
+func TestCreateTableValuesSteamRowComments(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+
+	data.MaxAllowedPacket = 64
+	data.RowComments = true
+
+	table := data.createTable("test", false)
+
+	s := table.Stream()
+	assert.EqualValues(t, "-- rows 1..1 of `test`", <-s)
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES (1,'test@test.de','Test Name 1');", <-s)
+	assert.EqualValues(t, "-- rows 2..2 of `test`", <-s)
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES (2,'test2@test.de','Test Name 2');", <-s)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestCreateTableValuesSteamRowCommentsOneBatch(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+
+	data.MaxAllowedPacket = 4096
+	data.RowComments = true
+
+	table := data.createTable("test", false)
+
+	s := table.Stream()
+	assert.EqualValues(t, "-- rows 1..2 of `test`", <-s)
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES (1,'test@test.de','Test Name 1'),(2,'test2@test.de','Test Name 2');", <-s)
+	_, more := <-s
+	assert.False(t, more)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
 func TestCreateTableAllValuesWithNil(t *testing.T) {
 	data, mock, err := getMockData()
 	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
@@ -449,3 +503,178 @@ UNLOCK TABLES;
 	result := strings.Replace(buf.String(), "`", "~", -1)
 	assert.Equal(t, expectedResult, result)
 }
+
+func TestIncludeTablesOk(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	rows := sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table_1", "BASE TABLE").
+		AddRow("Test_Table_2", "BASE TABLE").
+		AddRow("Test_Table_3", "BASE TABLE")
+
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(rows)
+
+	data.IncludeTables = []string{"Test_Table_1", "Test_Table_3"}
+	data.IgnoreTables = []string{"Test_Table_3"}
+
+	result, err := data.getTables()
+	assert.NoError(t, err)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.EqualValues(t, []string{"Test_Table_1"}, tableNames(result))
+}
+
+func TestInitColumnDataMySQL8(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	cols := sqlmock.NewRows([]string{"Field", "Key", "Extra"}).
+		AddRow("my_row_id", "PRI", "auto_increment INVISIBLE").
+		AddRow("hidden", "", "INVISIBLE").
+		AddRow("uuid", "", "DEFAULT_GENERATED").
+		AddRow("lower", "", "VIRTUAL GENERATED").
+		AddRow("upper", "", "STORED GENERATED INVISIBLE").
+		AddRow("legacy", "", "PERSISTENT").
+		AddRow("created", "", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP")
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(cols)
+
+	table := data.createTable("Test_Table", false)
+	assert.NoError(t, table.initColumnData())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{"my_row_id", "hidden", "uuid", "created"}, table.cols)
+	assert.Equal(t, []int{0}, table.pk)
+	assert.Equal(t, "`my_row_id`, `hidden`, `uuid`, `created`", table.columnsList())
+}
+
+func TestRowBufferHexBlob(t *testing.T) {
+	raw := sql.RawBytes{0x00, 0x01, 0xff}
+	table := (&Data{HexBlob: true}).createTable("test", false)
+	table.values = []interface{}{
+		&raw,
+		&sql.NullString{String: "caf\xe9\x00", Valid: true},
+		&sql.NullString{String: "café\n", Valid: true},
+		&sql.NullString{},
+	}
+
+	assert.Equal(t, "(0x0001ff,0x636166e900,'café\\n',NULL)", table.RowValues())
+
+	table.data.HexBlob = false
+	assert.Equal(t, "(_binary '\\0\x01\xff','caf\xe9\\0','café\\n',NULL)", table.RowValues())
+}
+
+func TestGetTablesInformationSchema(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	rows := sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE", "ENGINE", "TABLE_ROWS"}).
+		AddRow("Test_Table_1", "BASE TABLE", "InnoDB", 1200).
+		AddRow("Test_Table_2", "BASE TABLE", "MyISAM", 3).
+		AddRow("Test_View", "VIEW", nil, nil)
+
+	mock.ExpectQuery(`^SELECT TABLE_NAME, TABLE_TYPE, ENGINE, TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE\(\) ORDER BY TABLE_NAME$`).WillReturnRows(rows)
+
+	data.UseInformationSchema = true
+	data.IgnoreTables = []string{"Test_Table_2"}
+	result, err := data.getTables()
+	assert.NoError(t, err)
+
+	// we make sure that all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.EqualValues(t, []string{"Test_Table_1", "Test_View"}, tableNames(result))
+	assert.Equal(t, "InnoDB", result[0].engine)
+	assert.Equal(t, int64(1200), result[0].rowEstimate)
+	assert.False(t, result[0].isView)
+	assert.True(t, result[1].isView)
+}
+
+func TestFormatFloatRoundTrip(t *testing.T) {
+	values := []float64{0, 1, -1.5, 0.1, 1.0 / 3, 123456789.125, 1e21, 5e-324, math.MaxFloat64, -math.SmallestNonzeroFloat64}
+	for _, f := range values {
+		s := formatFloat(f, 64)
+		assert.NotContains(t, s, ",")
+		got, err := strconv.ParseFloat(s, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, f, got, s)
+	}
+
+	for _, f := range []float32{0.1, 1.1, 3.4028235e38, 1e-45} {
+		s := formatFloat(float64(f), 32)
+		got, err := strconv.ParseFloat(s, 32)
+		assert.NoError(t, err)
+		assert.Equal(t, f, float32(got), s)
+	}
+	assert.Equal(t, "1.1", formatFloat(float64(float32(1.1)), 32))
+	assert.Equal(t, "0.1", formatFloat(0.1, 64))
+}
+
+func TestRowBufferFloatDecodes(t *testing.T) {
+	table := (&Data{}).createTable("test", false)
+	table.values = []interface{}{
+		&sql.NullFloat64{Float64: 0.1, Valid: true},
+		&sql.NullFloat64{Float64: float64(float32(2.2)), Valid: true},
+		&sql.NullFloat64{Float64: 1e100, Valid: true},
+	}
+	table.types = []string{"DOUBLE", "FLOAT", "DOUBLE"}
+
+	row := table.RowValues()
+	assert.Equal(t, "(0.1,2.2,1e+100)", row)
+
+	events := decodeAll(t, "INSERT INTO `test` VALUES "+row+";")
+	assert.Equal(t, []Event{&InsertRows{Table: "test", Rows: [][]interface{}{{0.1, 2.2, 1e100}}}}, events)
+}
+
+func TestCreateTableYearAndBoolean(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	cols := sqlmock.NewRows([]string{"Field", "Type", "Extra"}).
+		AddRow("id", "int", "").
+		AddRow("born", "year", "").
+		AddRow("active", "tinyint(1)", "").
+		AddRow("level", "tinyint", "")
+
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		c("id", 0),
+		sqlmock.NewColumn("born").OfType("YEAR", uint16(0)).Nullable(true),
+		c("active", 0),
+		c("level", 0),
+	).
+		AddRow(1, uint16(1999), 1, 1).
+		AddRow(2, nil, 0, 0).
+		AddRow(3, uint16(0), 2, nil)
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(cols)
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(rows)
+
+	data.BoolLiterals = true
+	table := data.createTable("test", false)
+
+	var results []string
+	for table.Next() {
+		results = append(results, table.RowValues())
+	}
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{"(1,1999,TRUE,1)", "(2,NULL,FALSE,0)", "(3,0,2,NULL)"}, results)
+
+	table.data.BoolLiterals = false
+	assert.Equal(t, "(3,0,2,NULL)", table.RowValues())
+}
+
+// mockCreateTable expects the definition of an InnoDB table, read before its
+// rows to tell whether it is a MERGE table
+func mockCreateTable(mock sqlmock.Sqlmock, name string) {
+	mock.ExpectQuery("^SHOW CREATE TABLE `" + name + "`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).
+			AddRow(name, "CREATE TABLE `"+name+"` (`id` int(11) NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+}
@@ -0,0 +1,45 @@
+package mysqldump
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// LimitError is the error of DumpToBytes once the dump grows past its limit.
+type LimitError struct {
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("dump exceeds the limit of %d bytes", e.Limit)
+}
+
+// DumpToBytes dumps the current database like Dump and returns the dump instead
+// of writing it to Out, for small databases like test seeds. The dump fails
+// with a LimitError as soon as it would grow past limit bytes, with Compress
+// the compressed ones, unless limit is 0. Files is ignored.
+func (data *Data) DumpToBytes(limit int) ([]byte, error) {
+	out := &limitBuffer{limit: limit}
+	run := data.newRun()
+	run.Out = out
+	run.Files = nil
+	err := run.dump("")
+	data.finish(run)
+	if err != nil {
+		return nil, err
+	}
+	return out.buf.Bytes(), nil
+}
+
+// limitBuffer is a buffer refusing the writes that would grow it past limit
+type limitBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.buf.Len()+len(p) > b.limit {
+		return 0, &LimitError{Limit: b.limit}
+	}
+	return b.buf.Write(p)
+}
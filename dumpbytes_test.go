@@ -0,0 +1,42 @@
+package mysqldump_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpToBytes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+
+	data := &mysqldump.Data{Connection: db}
+	b, err := data.DumpToBytes(1 << 20)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Nil(t, data.Out)
+
+	result := strings.Replace(strings.Split(string(b), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
+
+func TestDumpToBytesLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+
+	b, err := (&mysqldump.Data{Connection: db}).DumpToBytes(len(expected) / 2)
+	assert.Nil(t, b)
+	var limitErr *mysqldump.LimitError
+	if assert.True(t, errors.As(err, &limitErr), "%v", err) {
+		assert.Equal(t, len(expected)/2, limitErr.Limit)
+	}
+	assert.Equal(t, mysqldump.CauseSink, mysqldump.CauseOf(err))
+}
@@ -0,0 +1,124 @@
+package mysqldump
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+/*
+FromEnv sets the options of data from the MYSQLDUMP_* environment variables
+that are set, for containers where flags and configuration files are
+inconvenient. Lists are comma separated and booleans parsed by
+strconv.ParseBool. The preset is applied first, overwriting the options it
+covers, and the other variables on top of it. The variables shared with
+RunnerConfig mean the same there, where the destination of the dump is
+configured with MYSQLDUMP_OUTPUT_DIR and MYSQLDUMP_FORMAT instead of
+MYSQLDUMP_FILES_DIR.
+
+	MYSQLDUMP_PRESET:             Preset applied before the other options
+	MYSQLDUMP_FILES_DIR:          Files, a DirWriter writing the files of the dump under this directory
+	MYSQLDUMP_BLOB_DIR:           BlobDir
+	MYSQLDUMP_INCLUDE_TABLES:     IncludeTables
+	MYSQLDUMP_IGNORE_TABLES:      IgnoreTables
+	MYSQLDUMP_INCLUDE_PATTERNS:   IncludePatterns
+	MYSQLDUMP_EXCLUDE_PATTERNS:   ExcludePatterns
+	MYSQLDUMP_SKIP_ARTIFACTS:     SkipToolArtifacts
+	MYSQLDUMP_CODEC:              Codec, by its registered name like gz
+	MYSQLDUMP_COMPRESS:           Compress
+	MYSQLDUMP_COMPRESS_LEVEL:     CompressLevel
+	MYSQLDUMP_LINE_ENDING:        LineEnding, crlf for \r\n
+	MYSQLDUMP_LOCK_TABLES:        LockTables
+	MYSQLDUMP_MAX_ALLOWED_PACKET: MaxAllowedPacket
+	MYSQLDUMP_MAX_MEMORY:         MaxMemory
+	MYSQLDUMP_CONCURRENCY:        Concurrency
+	MYSQLDUMP_FETCH_SIZE:         FetchSize
+	MYSQLDUMP_CHECKSUMS:          Checksums
+	MYSQLDUMP_ROUTINES:           Routines
+	MYSQLDUMP_TRIGGERS:           Triggers
+	MYSQLDUMP_EVENTS:             Events
+	MYSQLDUMP_CREATE_DATABASE:    CreateDatabase
+*/
+func (data *Data) FromEnv() error {
+	if v, ok := os.LookupEnv("MYSQLDUMP_PRESET"); ok {
+		if err := data.ApplyPreset(Preset(v)); err != nil {
+			return errors.New("MYSQLDUMP_PRESET: " + err.Error())
+		}
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_FILES_DIR"); ok {
+		data.Files = DirWriter(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_BLOB_DIR"); ok {
+		data.BlobDir = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_INCLUDE_TABLES"); ok {
+		data.IncludeTables = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_IGNORE_TABLES"); ok {
+		data.IgnoreTables = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_INCLUDE_PATTERNS"); ok {
+		data.IncludePatterns = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_EXCLUDE_PATTERNS"); ok {
+		data.ExcludePatterns = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_LINE_ENDING"); ok {
+		data.LineEnding = LineEnding(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_CODEC"); ok {
+		codec, err := LookupCodec(v)
+		if err != nil {
+			return errors.New("MYSQLDUMP_CODEC: " + err.Error())
+		}
+		data.Codec = codec
+	}
+
+	for _, env := range []struct {
+		name   string
+		option *bool
+	}{
+		{"MYSQLDUMP_SKIP_ARTIFACTS", &data.SkipToolArtifacts},
+		{"MYSQLDUMP_COMPRESS", &data.Compress},
+		{"MYSQLDUMP_LOCK_TABLES", &data.LockTables},
+		{"MYSQLDUMP_CHECKSUMS", &data.Checksums},
+		{"MYSQLDUMP_ROUTINES", &data.Routines},
+		{"MYSQLDUMP_TRIGGERS", &data.Triggers},
+		{"MYSQLDUMP_EVENTS", &data.Events},
+		{"MYSQLDUMP_CREATE_DATABASE", &data.CreateDatabase},
+	} {
+		if v, ok := os.LookupEnv(env.name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.New(env.name + ": " + err.Error())
+			}
+			*env.option = b
+		}
+	}
+
+	for _, env := range []struct {
+		name   string
+		option *int
+	}{
+		{"MYSQLDUMP_COMPRESS_LEVEL", &data.CompressLevel},
+		{"MYSQLDUMP_MAX_ALLOWED_PACKET", &data.MaxAllowedPacket},
+		{"MYSQLDUMP_CONCURRENCY", &data.Concurrency},
+		{"MYSQLDUMP_FETCH_SIZE", &data.FetchSize},
+	} {
+		if v, ok := os.LookupEnv(env.name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New(env.name + ": " + err.Error())
+			}
+			*env.option = n
+		}
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_MAX_MEMORY"); ok {
+		max, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.New("MYSQLDUMP_MAX_MEMORY: " + err.Error())
+		}
+		data.MaxMemory = max
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package mysqldump_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func setEnv(t *testing.T, env map[string]string) func() {
+	for k, v := range env {
+		assert.NoError(t, os.Setenv(k, v))
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		"MYSQLDUMP_PRESET":           string(mysqldump.PresetMysqldumpCompatible),
+		"MYSQLDUMP_INCLUDE_TABLES":   "users, orders",
+		"MYSQLDUMP_EXCLUDE_PATTERNS": "tmp_*",
+		"MYSQLDUMP_CODEC":            "gz",
+		"MYSQLDUMP_LOCK_TABLES":      "false",
+		"MYSQLDUMP_CONCURRENCY":      "4",
+		"MYSQLDUMP_MAX_MEMORY":       "1048576",
+		"MYSQLDUMP_TRIGGERS":         "1",
+		"MYSQLDUMP_FILES_DIR":        "/backups/today",
+	})()
+
+	data := &mysqldump.Data{IgnoreTables: []string{"kept"}}
+	assert.NoError(t, data.FromEnv())
+	assert.Equal(t, []string{"users", "orders"}, data.IncludeTables)
+	assert.Equal(t, []string{"kept"}, data.IgnoreTables)
+	assert.Equal(t, []string{"tmp_*"}, data.ExcludePatterns)
+	if assert.NotNil(t, data.Codec) {
+		assert.Equal(t, "gz", data.Codec.Name())
+	}
+	// The preset locks the tables, the variable applied after it does not
+	assert.False(t, data.LockTables)
+	assert.Equal(t, 4, data.Concurrency)
+	assert.Equal(t, int64(1048576), data.MaxMemory)
+	assert.True(t, data.Triggers)
+	assert.Equal(t, mysqldump.DirWriter("/backups/today"), data.Files)
+}
+
+func TestDirWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := mysqldump.DirWriter(dir).Create("data/users.sql")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("INSERT INTO users VALUES (1);"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "data", "users.sql"))
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users VALUES (1);", string(content))
+}
+
+func TestFromEnvInvalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"MYSQLDUMP_PRESET":     "fastest",
+		"MYSQLDUMP_CODEC":      "rar",
+		"MYSQLDUMP_CHECKSUMS":  "maybe",
+		"MYSQLDUMP_FETCH_SIZE": "many",
+		"MYSQLDUMP_MAX_MEMORY": "1G",
+	} {
+		unset := setEnv(t, map[string]string{name: value})
+		err := (&mysqldump.Data{}).FromEnv()
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), name)
+		}
+		unset()
+	}
+}
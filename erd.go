@@ -0,0 +1,134 @@
+package mysqldump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteDOT writes the tables and their foreign keys as a Graphviz graph, with
+// an edge from every table to the tables it references.
+func (s *Schema) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph schema {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, "  node [shape=record];")
+	for _, t := range s.Tables {
+		var fields []string
+		for _, col := range t.Columns {
+			field := col.Name + " : " + col.Type
+			if col.PrimaryKey {
+				field += " (PK)"
+			}
+			fields = append(fields, dotRecordEscape(field)+`\l`)
+		}
+		label := dotRecordEscape(t.Name)
+		if t.View {
+			label += ` (view)`
+		}
+		if len(fields) > 0 {
+			label = "{" + label + "|" + strings.Join(fields, "") + "}"
+		}
+		fmt.Fprintf(b, "  %s [label=\"%s\"];\n", dotQuote(t.Name), strings.Replace(label, `"`, `\"`, -1))
+	}
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(b, "  %s -> %s [label=%s];\n", dotQuote(t.Name), dotQuote(fk.RefTable), dotQuote(fk.Name))
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteMermaid writes the tables and their foreign keys as a Mermaid entity
+// relationship diagram.
+func (s *Schema) WriteMermaid(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "erDiagram")
+	for _, t := range s.Tables {
+		fmt.Fprintf(b, "  %s {\n", mermaidName(t.Name))
+		for _, col := range t.Columns {
+			line := "    " + mermaidType(col.Type) + " " + mermaidName(col.Name)
+			if col.PrimaryKey {
+				line += " PK"
+			} else if t.isForeignKey(col.Name) {
+				line += " FK"
+			}
+			fmt.Fprintln(b, line)
+		}
+		fmt.Fprintln(b, "  }")
+	}
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			// Nullable keys may reference nothing
+			cardinality := "}o--||"
+			for _, name := range fk.Columns {
+				if col := t.column(name); col != nil && col.Nullable {
+					cardinality = "}o--o|"
+				}
+			}
+			fmt.Fprintf(b, "  %s %s %s : %q\n", mermaidName(t.Name), cardinality, mermaidName(fk.RefTable), fk.Name)
+		}
+	}
+	return b.Flush()
+}
+
+func (t *SchemaTable) column(name string) *SchemaColumn {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *SchemaTable) isForeignKey(column string) bool {
+	for _, fk := range t.ForeignKeys {
+		for _, name := range fk.Columns {
+			if name == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// dotRecordEscape escapes the characters that structure record labels
+func dotRecordEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace(s)
+}
+
+var (
+	mermaidNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	mermaidTypeRe = regexp.MustCompile(`^(\w+)(\(\d+(?:,\d+)?\))?`)
+)
+
+// mermaidName quotes names Mermaid would not read as a single word
+func mermaidName(name string) string {
+	if mermaidNameRe.MatchString(name) {
+		return name
+	}
+	return `"` + strings.Replace(name, `"`, "'", -1) + `"`
+}
+
+// mermaidType shortens column types to what Mermaid accepts as attribute type,
+// like varchar(255) or enum for enum('a','b')
+func mermaidType(t string) string {
+	m := mermaidTypeRe.FindStringSubmatch(t)
+	if m == nil {
+		return "unknown"
+	}
+	s := m[1] + strings.Replace(m[2], ",", "-", -1)
+	for _, attr := range []string{"unsigned", "zerofill"} {
+		if strings.Contains(t, " "+attr) {
+			s += "_" + attr
+		}
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func erdSchema() *Schema {
+	return &Schema{Tables: []SchemaTable{
+		parseSchemaTable("orders", ordersCreateSQL, false),
+		{Name: "users", Columns: []SchemaColumn{{Name: "id", Type: "int(11)", PrimaryKey: true}}},
+		{Name: "order totals", View: true},
+	}}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, erdSchema().WriteDOT(&buf))
+	assert.Equal(t, `digraph schema {
+  rankdir=LR;
+  node [shape=record];
+  "orders" [label="{orders|id : int(10) unsigned (PK)\luser_id : int(11)\lstatus : enum('new','paid')\lnote : varchar(255)\lcreated : datetime(6)\l}"];
+  "users" [label="{users|id : int(11) (PK)\l}"];
+  "order totals" [label="order totals (view)"];
+  "orders" -> "users" [label="orders_user"];
+}
+`, buf.String())
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, erdSchema().WriteMermaid(&buf))
+	assert.Equal(t, `erDiagram
+  orders {
+    int(10)_unsigned id PK
+    int(11) user_id FK
+    enum status
+    varchar(255) note
+    datetime(6) created
+  }
+  users {
+    int(11) id PK
+  }
+  "order totals" {
+  }
+  orders }o--|| users : "orders_user"
+`, buf.String())
+}
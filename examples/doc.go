@@ -0,0 +1,14 @@
+/*
+Package examples holds runnable recipes combining the features of
+go-mysqldump, each a program of its own covered by tests against stub
+databases:
+
+	dump-to-s3-with-masking:   Masks columns and uploads the compressed dump through a presigned S3 URL
+	parallel-dump-and-restore: Copies several databases at once, every dump streamed straight into its restore
+	incremental-with-binlog:   Takes full dumps recording their binlog coordinates and prints the mysqlbinlog command replaying the changes since the previous one
+
+Run them with go run, e.g.
+
+	go run ./dump-to-s3-with-masking -dsn 'user:password@tcp(db:3306)/app' -url "$PRESIGNED_URL" -mask users.email,users.phone
+*/
+package examples
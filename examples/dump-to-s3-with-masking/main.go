@@ -0,0 +1,144 @@
+/*
+Command dump-to-s3-with-masking dumps a database with some of its columns
+masked and uploads the gzipped dump to S3 through a presigned PUT URL, so the
+machine running it needs no AWS credentials.
+
+	dump-to-s3-with-masking -dsn DSN -url URL [-mask table.column,...]
+
+The masked values are hashes kept consistent by a MaskMapping, the same email
+gets the same mask in every table so joins on it still work.
+*/
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jamf/go-mysqldump"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("MYSQLDUMP_DSN"), "data source name of the database to dump")
+	url := flag.String("url", "", "presigned S3 PUT URL the dump is uploaded to")
+	mask := flag.String("mask", "", "comma separated table.column list of the columns to mask, *.column for any table")
+	flag.Parse()
+	if *dsn == "" || *url == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	var columns []string
+	if *mask != "" {
+		columns = strings.Split(*mask, ",")
+	}
+	uploader := &presignedUploader{URL: *url}
+	if err := dumpToS3(context.Background(), db, uploader, columns); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// hashMasker replaces values by the start of their SHA-256
+var hashMasker = mysqldump.MaskFunc(func(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+})
+
+// dumpToS3 streams the gzipped dump of db with columns masked to uploader
+func dumpToS3(ctx context.Context, db *sql.DB, uploader mysqldump.Uploader, columns []string) error {
+	codec, err := mysqldump.LookupCodec("gz")
+	if err != nil {
+		return err
+	}
+	// One mapping for all columns, the same value is masked the same way
+	// wherever it appears
+	mapping := mysqldump.NewMaskMapping(hashMasker)
+	data := &mysqldump.Data{
+		Connection: db,
+		Context:    ctx,
+		Masks:      map[string]mysqldump.Masker{},
+	}
+	for _, column := range columns {
+		data.Masks[column] = mapping
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := codec.Wrap(pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		data.Out = w
+		if err := data.Dump(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(w.Close())
+	}()
+	err = uploader.Upload(ctx, "dump.sql.gz", pr)
+	pr.CloseWithError(errors.New("upload ended"))
+	return err
+}
+
+// presignedUploader PUTs the dump to a presigned S3 URL. S3 wants the length
+// of the object up front, the dump is spooled to a temporary file first.
+type presignedUploader struct {
+	URL    string
+	Client *http.Client
+}
+
+func (u *presignedUploader) Upload(ctx context.Context, name string, r io.Reader) error {
+	f, err := ioutil.TempFile("", name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.URL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload of %s failed: %s %s", name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func userRows() *sqlmock.Rows {
+	return sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", 0),
+		sqlmock.NewColumn("email").OfType("VARCHAR", "").Nullable(true))
+}
+
+func mockUsersDump(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRows([]string{"Tables_in_app", "Table_type"}).AddRow("users", "BASE TABLE").AddRow("orders", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `users`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("users", "CREATE TABLE `users` (`id` int NOT NULL, `email` varchar(255), PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `users`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `users`$").WillReturnRows(
+		userRows().AddRow(1, "ada@example.com").AddRow(2, "grace@example.com"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `orders`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("orders", "CREATE TABLE `orders` (`id` int NOT NULL, `email` varchar(255), PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `orders`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `orders`$").WillReturnRows(
+		userRows().AddRow(10, "ada@example.com"))
+	mock.ExpectRollback()
+}
+
+func TestDumpToS3(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockUsersDump(mock)
+
+	var uploaded string
+	var length int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		length = r.ContentLength
+		gz, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, err := ioutil.ReadAll(gz)
+		assert.NoError(t, err)
+		uploaded = string(b)
+	}))
+	defer server.Close()
+
+	uploader := &presignedUploader{URL: server.URL + "/bucket/dump.sql.gz?X-Amz-Signature=abc", Client: server.Client()}
+	assert.NoError(t, dumpToS3(context.Background(), db, uploader, []string{"*.email"}))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.True(t, length > 0)
+	masked := hashMasker.Mask("ada@example.com")
+	assert.NotContains(t, uploaded, "@example.com")
+	assert.Contains(t, uploaded, "INSERT INTO `users` (`id`, `email`) VALUES (1,'"+masked+"'),(2,'"+hashMasker.Mask("grace@example.com")+"');")
+	// The mapping masks the same email the same way in every table
+	assert.Contains(t, uploaded, "INSERT INTO `orders` (`id`, `email`) VALUES (10,'"+masked+"');")
+}
+
+func TestPresignedUploaderFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockUsersDump(mock)
+
+	err = dumpToS3(context.Background(), db, &presignedUploader{URL: server.URL, Client: server.Client()}, nil)
+	assert.EqualError(t, err, "upload of dump.sql.gz failed: 403 Forbidden <Error><Code>AccessDenied</Code></Error>")
+}
@@ -0,0 +1,181 @@
+/*
+Command incremental-with-binlog takes a full dump recording the binary log
+coordinates its snapshot matches, and prints the mysqlbinlog command replaying
+the changes made between the previous dump and this one. Restoring an older
+dump followed by the replays brings a database up to any later dump without
+keeping every dump.
+
+	incremental-with-binlog -dsn DSN -dir DIR
+
+The dumps are written to DIR, the coordinates of the last one to
+DIR/binlog.json. The server needs a binary log and the user the RELOAD and
+REPLICATION CLIENT privileges.
+*/
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jamf/go-mysqldump"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("MYSQLDUMP_DSN"), "data source name of the database to dump")
+	dir := flag.String("dir", ".", "directory of the dumps and their binlog coordinates")
+	flag.Parse()
+	if *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	replay, err := dumpIncremental(context.Background(), db, *dir, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if replay != "" {
+		fmt.Println(replay)
+	}
+}
+
+// checkpoint is the content of binlog.json
+type checkpoint struct {
+	Dump   string                       `json:"dump"`
+	Binlog *mysqldump.BinlogCoordinates `json:"binlog"`
+}
+
+const checkpointFile = "binlog.json"
+
+// dumpIncremental writes a full dump of db to dir and returns the command
+// replaying the binlog from the previous dump to this one, empty for the
+// first dump
+func dumpIncremental(ctx context.Context, db *sql.DB, dir string, now time.Time) (string, error) {
+	previous, err := readCheckpoint(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		return "", err
+	}
+
+	name := "full-" + now.UTC().Format("20060102T150405") + ".sql"
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data := &mysqldump.Data{
+		Connection:        db,
+		Context:           ctx,
+		Out:               f,
+		BinlogCoordinates: true,
+	}
+	if err := data.Dump(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// The checkpoint only moves once the dump is complete
+	current := &checkpoint{Dump: name, Binlog: data.Binlog()}
+	b, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, checkpointFile), b, 0644); err != nil {
+		return "", err
+	}
+	if previous == nil {
+		return "", nil
+	}
+
+	files, err := binlogFiles(ctx, db, previous.Binlog.File, current.Binlog.File)
+	if err != nil {
+		return "", err
+	}
+	return replayCommand(previous, current, files), nil
+}
+
+func readCheckpoint(path string) (*checkpoint, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var c checkpoint
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Binlog == nil {
+		return nil, fmt.Errorf("%s: no binlog coordinates", path)
+	}
+	return &c, nil
+}
+
+// binlogFiles lists the binary logs of the server from first to last, both
+// included
+func binlogFiles(ctx context.Context, db *sql.DB, first, last string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for rows.Next() {
+		// Log_name, File_size and, on newer servers, Encrypted
+		values := make([]sql.RawBytes, len(cols))
+		scans := make([]interface{}, len(cols))
+		for i := range values {
+			scans[i] = &values[i]
+		}
+		if err := rows.Scan(scans...); err != nil {
+			return nil, err
+		}
+		name := string(values[0])
+		if name >= first && name <= last {
+			files = append(files, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 || files[0] != first {
+		return nil, fmt.Errorf("binary log %s of the previous dump is purged, the changes since it can't be replayed", first)
+	}
+	return files, nil
+}
+
+// replayCommand is the mysqlbinlog command applying the changes between the
+// coordinates of the two dumps
+func replayCommand(previous, current *checkpoint, files []string) string {
+	args := []string{
+		"mysqlbinlog",
+		"--start-position=" + strconv.FormatInt(previous.Binlog.Position, 10),
+		"--stop-position=" + strconv.FormatInt(current.Binlog.Position, 10),
+	}
+	args = append(args, files...)
+	return fmt.Sprintf("# replays the changes from %s to %s\n%s | mysql", previous.Dump, current.Dump, strings.Join(args, " "))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDump expects a dump of an empty database whose snapshot matches the
+// position in file
+func mockDump(mock sqlmock.Sqlmock, file string, position int) {
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34-log"))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SHOW MASTER STATUS$`).WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+			AddRow(file, position, "", "", ""))
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34-log"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_app", "Table_type"}))
+	mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func mockBinaryLogs(mock sqlmock.Sqlmock, files ...string) {
+	rows := sqlmock.NewRows([]string{"Log_name", "File_size", "Encrypted"})
+	for _, file := range files {
+		rows.AddRow(file, 1024, "No")
+	}
+	mock.ExpectQuery(`^SHOW BINARY LOGS$`).WillReturnRows(rows)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "incremental")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestDumpIncremental(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	dir := tempDir(t)
+
+	// The first dump only records its coordinates
+	mockDump(mock, "mysql-bin.000003", 157)
+	first := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	replay, err := dumpIncremental(context.Background(), db, dir, first)
+	assert.NoError(t, err)
+	assert.Empty(t, replay)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, checkpointFile))
+	assert.NoError(t, err)
+	var c checkpoint
+	assert.NoError(t, json.Unmarshal(b, &c))
+	assert.Equal(t, checkpoint{Dump: "full-20240301T020000.sql", Binlog: &mysqldump.BinlogCoordinates{File: "mysql-bin.000003", Position: 157}}, c)
+	content, err := ioutil.ReadFile(filepath.Join(dir, c.Dump))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "-- CHANGE MASTER TO MASTER_LOG_FILE='mysql-bin.000003', MASTER_LOG_POS=157;")
+
+	// The next one replays the binlogs in between
+	mockDump(mock, "mysql-bin.000005", 900)
+	mockBinaryLogs(mock, "mysql-bin.000002", "mysql-bin.000003", "mysql-bin.000004", "mysql-bin.000005", "mysql-bin.000006")
+	replay, err = dumpIncremental(context.Background(), db, dir, first.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "# replays the changes from full-20240301T020000.sql to full-20240302T020000.sql\n"+
+		"mysqlbinlog --start-position=157 --stop-position=900 mysql-bin.000003 mysql-bin.000004 mysql-bin.000005 | mysql", replay)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpIncrementalPurged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	dir := tempDir(t)
+
+	b, _ := json.Marshal(checkpoint{Dump: "full-20240301T020000.sql", Binlog: &mysqldump.BinlogCoordinates{File: "mysql-bin.000003", Position: 157}})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, checkpointFile), b, 0644))
+
+	mockDump(mock, "mysql-bin.000009", 4)
+	mockBinaryLogs(mock, "mysql-bin.000008", "mysql-bin.000009")
+	_, err = dumpIncremental(context.Background(), db, dir, time.Now())
+	assert.EqualError(t, err, "binary log mysql-bin.000003 of the previous dump is purged, the changes since it can't be replayed")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
@@ -0,0 +1,110 @@
+/*
+Command parallel-dump-and-restore copies databases from one server to another,
+several at once. Every dump is streamed straight into its restore without
+touching the disk, and all of them share one MaxMemory budget.
+
+	parallel-dump-and-restore -source DSN -target DSN -databases a,b,c [-parallel 2]
+
+The target databases are created if missing, a restore refuses databases that
+already contain tables.
+*/
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jamf/go-mysqldump"
+)
+
+func main() {
+	source := flag.String("source", "", "data source name of the server to copy from")
+	target := flag.String("target", "", "data source name of the server to copy to")
+	databases := flag.String("databases", "", "comma separated list of the databases to copy")
+	parallel := flag.Int("parallel", 2, "databases copied at once")
+	flag.Parse()
+	if *source == "" || *target == "" || *databases == "" || *parallel < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := sql.Open("mysql", *source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer src.Close()
+	dst, err := sql.Open("mysql", *target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer dst.Close()
+
+	if err := copyDatabases(context.Background(), src, dst, strings.Split(*databases, ","), *parallel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// copyDatabases dumps every database of source into target, parallel of them
+// at once, and returns the first error
+func copyDatabases(ctx context.Context, source, target *sql.DB, databases []string, parallel int) error {
+	// The runs started from one Data share its MaxMemory budget, each works
+	// on a copy of the options taken by Start
+	data := &mysqldump.Data{
+		Connection: source,
+		Context:    ctx,
+		MaxMemory:  64 << 20,
+	}
+
+	slots := make(chan struct{}, parallel)
+	errs := make([]error, len(databases))
+	var wg sync.WaitGroup
+	for i, name := range databases {
+		slots <- struct{}{}
+		if _, err := target.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS `"+name+"`"); err != nil {
+			<-slots
+			errs[i] = err
+			break
+		}
+
+		pr, pw := io.Pipe()
+		data.Out = pw
+		run := data.Start(name)
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			go func() {
+				pw.CloseWithError(run.Wait())
+			}()
+
+			err := (&mysqldump.Restorer{Connection: target, Database: name}).Restore(pr)
+			// A failed restore stops reading, the dump fails writing then
+			pr.CloseWithError(errors.New("restore ended"))
+			if dumpErr := run.Wait(); dumpErr != nil && err == nil {
+				err = dumpErr
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("copying %s: %w", name, err)
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSource expects the dump of a database holding a table t with two rows
+func mockSource(mock sqlmock.Sqlmock, database string) {
+	mock.ExpectBegin()
+	mock.ExpectExec("^USE " + database + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRows([]string{"Tables_in_" + database, "Table_type"}).AddRow("t", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `t`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("t", "CREATE TABLE `t` (`id` int NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `t`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `t`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("id").OfType("INT", 0)).AddRow(1).AddRow(2))
+	mock.ExpectRollback()
+}
+
+// sessionStatements is the number of the statements of the dump of t that
+// set up or restore the session, or drop and lock t
+const sessionStatements = 26
+
+// mockTarget expects the restore of the dump of mockSource into database
+func mockTarget(mock sqlmock.Sqlmock, database string) {
+	mock.ExpectExec("^CREATE DATABASE IF NOT EXISTS `" + database + "`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^USE `" + database + "`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.35"))
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec("^CREATE TABLE `t`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO `t` \\(`id`\\) VALUES \\(1\\),\\(2\\)$").WillReturnResult(sqlmock.NewResult(0, 2))
+	for i := 0; i < sessionStatements; i++ {
+		mock.ExpectExec(`^(/\*!|SET|DROP|LOCK|UNLOCK)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func TestCopyDatabases(t *testing.T) {
+	source, sourceMock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer source.Close()
+	target, targetMock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer target.Close()
+
+	// Both copies run at once, their statements interleave
+	sourceMock.MatchExpectationsInOrder(false)
+	targetMock.MatchExpectationsInOrder(false)
+	for _, database := range []string{"a", "b"} {
+		mockSource(sourceMock, database)
+		mockTarget(targetMock, database)
+	}
+
+	assert.NoError(t, copyDatabases(context.Background(), source, target, []string{"a", "b"}, 2))
+	assert.NoError(t, sourceMock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.NoError(t, targetMock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestCopyDatabasesRestoreFails(t *testing.T) {
+	source, sourceMock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer source.Close()
+	target, targetMock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer target.Close()
+
+	mockSource(sourceMock, "a")
+	targetMock.ExpectExec("^CREATE DATABASE IF NOT EXISTS `a`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	targetMock.ExpectExec("^USE `a`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	targetMock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.35"))
+	targetMock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+
+	err = copyDatabases(context.Background(), source, target, []string{"a"}, 2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "copying a: target schema is not empty")
+}
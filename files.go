@@ -0,0 +1,326 @@
+package mysqldump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriterFactory creates the files of a multi-file dump. Names are slash
+// separated paths relative to the root of the dump.
+type WriterFactory interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirWriter is a WriterFactory that writes the files of the dump under the
+// directory it names, creating the directories in their names as needed.
+type DirWriter string
+
+// Create creates the file name under the directory, replacing any file there
+func (dir DirWriter) Create(name string) (io.WriteCloser, error) {
+	p := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+const (
+	schemaFileName    = "schema.sql"
+	checksumsFileName = "SHA256SUMS"
+	grantsFileName    = "grants.sql"
+)
+
+func dataFileName(table string) string {
+	return "data/" + safeFileName(table) + ".sql"
+}
+
+// writeFiles writes the structure of every table and view to schema.sql, or
+// files of their own with FileLayoutPerTable, the rows of every table to its
+// own data file, the deferred indexes and foreign
+// keys to indexes.sql and constraints.sql, the stored programs to
+// routines.sql, triggers.sql and events.sql in SectionOrder, the accounts to
+// grants.sql, and closes with the manifest and the checksums of all of them
+func (data *Data) writeFiles(meta *metaData, tables []*table) error {
+	order, err := data.sectionOrder()
+	if err != nil {
+		return err
+	}
+	for _, section := range order {
+		switch section {
+		case SectionSchema:
+			if meta.layout != nil {
+				err = data.writeTableSchemaFiles(meta, tables)
+			} else {
+				err = data.writeSchemaFile(meta, tables)
+			}
+		case SectionData:
+			err = data.writeDataFiles(meta, tables)
+		default:
+			if data.dumpsSection(section) {
+				err = data.writePostDataFile(meta, sectionFileName(section), tables, func(w io.Writer, tables []*table) error {
+					return data.writeObjects(w, section, tables)
+				})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if data.IncludeGrants {
+		if err := data.writePostDataFile(meta, grantsFileName, nil, func(w io.Writer, _ []*table) error {
+			return data.writeUsers(w, meta)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := data.recheckTables(); err != nil {
+		return err
+	}
+	if data.FileLayout == FileLayoutMydumper {
+		if err := data.writeMydumperMetadata(meta); err != nil {
+			return err
+		}
+	}
+	if err := data.writeManifestFile(); err != nil {
+		return err
+	}
+	return data.writeChecksums()
+}
+
+// writeDataFiles writes the rows of every table to its own file, followed by
+// load.sql with DataFormatTSV and the files of the deferred indexes and
+// foreign keys
+func (data *Data) writeDataFiles(meta *metaData, tables []*table) error {
+	if err := data.readTables(nil, tables, func(_ io.Writer, table *table) error {
+		if table.isView {
+			return nil
+		}
+		switch data.DataFormat {
+		case DataFormatCSV:
+			return data.writeCSVFile(meta, table)
+		case DataFormatTSV:
+			return data.writeTSVFile(meta, table)
+		case DataFormatJSON:
+			return data.writeJSONFile(meta, table)
+		}
+		return data.writeDataFile(meta, table)
+	}); err != nil {
+		return err
+	}
+	if data.DataFormat == DataFormatTSV {
+		if err := data.writePostDataFile(meta, loadFileName, tables, func(w io.Writer, tables []*table) error {
+			return data.writeLoadStatements(w, meta, tables)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if data.DeferIndexes {
+		if err := data.writePostDataFile(meta, indexesFileName, tables, data.writeIndexes); err != nil {
+			return err
+		}
+		if err := data.writePostDataFile(meta, constraintsFileName, tables, data.writeConstraints); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSchemaFile writes the structure of all tables and views to one file
+func (data *Data) writeSchemaFile(meta *metaData, tables []*table) error {
+	f, err := data.createFile(schemaFileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := data.newSummaryWriter(f)
+	if err := data.headerTmpl.Execute(s, meta); err != nil {
+		return err
+	}
+	if err := data.writeDatabase(s, meta); err != nil {
+		return err
+	}
+	stats := make([]TableStats, len(tables))
+	for i, table := range tables {
+		if err := data.writeTableSchema(s, table); err != nil {
+			return err
+		}
+		stats[i].View = table.isView
+	}
+	if err := data.writeFooter(s, meta, stats); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeDataFile writes the rows of table to its own file, wrapped in the
+// header and footer so it can be restored on its own
+func (data *Data) writeDataFile(meta *metaData, table *table) error {
+	f, err := data.createFile(data.dataFileName(meta, table))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := data.newSummaryWriter(f)
+	if err := data.headerTmpl.Execute(s, meta); err != nil {
+		return err
+	}
+	if err := data.writeTableData(s, table); err != nil {
+		return err
+	}
+	if err := data.writeFooter(s, meta, []TableStats{{Rows: int64(table.row)}}); err != nil {
+		return err
+	}
+	table.bytes = f.written
+	return f.Close()
+}
+
+func (data *Data) writeManifestFile() error {
+	f, err := data.createFile(manifestFileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := data.manifest.encode(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeChecksums lists every file of the dump in the format of sha256sum
+func (data *Data) writeChecksums() error {
+	w, err := data.Files.Create(checksumsFileName)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for _, file := range data.manifest.Files {
+		if _, err := fmt.Fprintf(w, "%s  %s\n", file.SHA256, file.Name); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// createFile creates the named file of the dump, keeping track of its size and
+// checksum for the manifest. Every file but the manifest and the metadata of
+// mydumper is encoded with the Codec, if there is one.
+func (data *Data) createFile(name string) (*dumpFile, error) {
+	sqlFile := strings.HasSuffix(name, ".sql")
+	codec := data.Codec
+	if name == manifestFileName || name == mydumperMetadataFileName {
+		codec = nil
+	}
+	if codec != nil {
+		name += "." + codec.Name()
+	}
+	w, err := data.Files.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	f := &dumpFile{
+		name:     name,
+		w:        w,
+		hash:     sha256.New(),
+		manifest: data.manifest,
+	}
+	f.out = fileWriter{f}
+	if codec != nil {
+		f.codec = codec.Name()
+		if f.out, err = codec.Wrap(f.out); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	if data.ValidateOutput && sqlFile {
+		f.check = data.newValidator(name)
+	}
+	return f, nil
+}
+
+type dumpFile struct {
+	name     string
+	codec    string
+	w        io.WriteCloser
+	out      io.Writer
+	hash     hash.Hash
+	size     int64
+	written  int64
+	manifest *Manifest
+	check    *validator
+	closed   bool
+}
+
+func (f *dumpFile) Write(p []byte) (int, error) {
+	n, err := f.out.Write(p)
+	f.written += int64(n)
+	if err == nil && f.check != nil {
+		_, err = f.check.Write(p)
+	}
+	return n, err
+}
+
+// fileWriter writes the encoded bytes of a file, on which the size and the
+// checksum are computed
+type fileWriter struct {
+	f *dumpFile
+}
+
+func (w fileWriter) Write(p []byte) (int, error) {
+	n, err := w.f.w.Write(p)
+	w.f.hash.Write(p[:n])
+	w.f.size += int64(n)
+	return n, err
+}
+
+// Close closes the underlying writer and records the file in the manifest. It
+// is safe to call more than once.
+func (f *dumpFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.check != nil {
+		if err := f.check.Close(); err != nil {
+			f.w.Close()
+			return err
+		}
+	}
+	if c, ok := f.out.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			f.w.Close()
+			return err
+		}
+	}
+	if err := f.w.Close(); err != nil {
+		return err
+	}
+	f.manifest.addFile(ManifestFile{
+		Name:   f.name,
+		Codec:  f.codec,
+		Size:   f.size,
+		SHA256: hex.EncodeToString(f.hash.Sum(nil)),
+	})
+	return nil
+}
+
+// encode writes the manifest as indented JSON
+func (m *Manifest) encode(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
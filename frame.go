@@ -0,0 +1,212 @@
+package mysqldump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+/*
+The framed format wraps a dump so damage in storage or transit is detected on
+restore rather than silently replayed. It is made of
+
+	header:  8 bytes magic "GOSQLFR1"
+	blocks:  4 bytes length, the payload, 4 bytes CRC-32C of the payload
+	trailer: 4 zero bytes, 8 bytes total payload length, 4 bytes CRC-32C of the
+	         whole payload, 8 bytes magic "GOSQLEND"
+
+All numbers are big endian.
+*/
+const (
+	frameMagic   = "GOSQLFR1"
+	frameEnd     = "GOSQLEND"
+	frameSize    = 64 * 1024
+	maxFrameSize = 16 * 1024 * 1024
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// Errors of FrameReader
+var (
+	ErrFrameMagic     = errors.New("not a framed dump")
+	ErrFrameChecksum  = errors.New("frame checksum mismatch")
+	ErrFrameTruncated = errors.New("framed dump is truncated")
+	ErrFrameCorrupt   = errors.New("frame is corrupt")
+)
+
+// FrameWriter writes the framed format to an underlying writer. Close must be
+// called to write the trailer, it does not close the underlying writer.
+type FrameWriter struct {
+	w      io.Writer
+	buf    []byte
+	total  uint64
+	crc    uint32
+	header bool
+	closed bool
+}
+
+// NewFrameWriter returns a FrameWriter writing to w.
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: w, buf: make([]byte, 0, frameSize)}
+}
+
+func (f *FrameWriter) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n := 0
+	for len(p) > 0 {
+		c := copy(f.buf[len(f.buf):cap(f.buf)], p)
+		f.buf = f.buf[:len(f.buf)+c]
+		p = p[c:]
+		n += c
+		if len(f.buf) == cap(f.buf) {
+			if err := f.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush writes the buffered data as a block.
+func (f *FrameWriter) Flush() error {
+	if err := f.writeHeader(); err != nil {
+		return err
+	}
+	if len(f.buf) == 0 {
+		return nil
+	}
+	var head [4]byte
+	binary.BigEndian.PutUint32(head[:], uint32(len(f.buf)))
+	var tail [4]byte
+	binary.BigEndian.PutUint32(tail[:], crc32.Checksum(f.buf, crc32c))
+	for _, b := range [][]byte{head[:], f.buf, tail[:]} {
+		if _, err := f.w.Write(b); err != nil {
+			return err
+		}
+	}
+	f.total += uint64(len(f.buf))
+	f.crc = crc32.Update(f.crc, crc32c, f.buf)
+	f.buf = f.buf[:0]
+	return nil
+}
+
+// Close flushes the buffered data and writes the trailer.
+func (f *FrameWriter) Close() error {
+	if f.closed {
+		return nil
+	}
+	if err := f.Flush(); err != nil {
+		return err
+	}
+	f.closed = true
+	trailer := make([]byte, 4+8+4, 4+8+4+len(frameEnd))
+	binary.BigEndian.PutUint64(trailer[4:], f.total)
+	binary.BigEndian.PutUint32(trailer[12:], f.crc)
+	_, err := f.w.Write(append(trailer, frameEnd...))
+	return err
+}
+
+func (f *FrameWriter) writeHeader() error {
+	if f.header {
+		return nil
+	}
+	f.header = true
+	_, err := io.WriteString(f.w, frameMagic)
+	return err
+}
+
+// FrameReader reads the payload of the framed format, verifying every block
+// and the trailer. A dump that ends before its trailer fails with
+// ErrFrameTruncated.
+type FrameReader struct {
+	r       *bufio.Reader
+	started bool
+	block   []byte
+	total   uint64
+	crc     uint32
+	err     error
+}
+
+// NewFrameReader returns a FrameReader unwrapping r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: bufio.NewReader(r)}
+}
+
+func (f *FrameReader) Read(p []byte) (int, error) {
+	for len(f.block) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		f.err = f.next()
+	}
+	n := copy(p, f.block)
+	f.block = f.block[n:]
+	return n, nil
+}
+
+// next reads the header on the first call and then the next block, or the
+// trailer which ends the payload with io.EOF
+func (f *FrameReader) next() error {
+	if !f.started {
+		f.started = true
+		magic := make([]byte, len(frameMagic))
+		if _, err := io.ReadFull(f.r, magic); err != nil || string(magic) != frameMagic {
+			return ErrFrameMagic
+		}
+	}
+
+	var head [4]byte
+	if err := f.readFull(head[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(head[:])
+	if size == 0 {
+		return f.trailer()
+	}
+	if size > maxFrameSize {
+		return ErrFrameCorrupt
+	}
+	block := make([]byte, size+4)
+	if err := f.readFull(block); err != nil {
+		return err
+	}
+	payload := block[:size]
+	if crc32.Checksum(payload, crc32c) != binary.BigEndian.Uint32(block[size:]) {
+		return ErrFrameChecksum
+	}
+	f.total += uint64(size)
+	f.crc = crc32.Update(f.crc, crc32c, payload)
+	f.block = payload
+	return nil
+}
+
+func (f *FrameReader) trailer() error {
+	trailer := make([]byte, 8+4+len(frameEnd))
+	if err := f.readFull(trailer); err != nil {
+		return err
+	}
+	if string(trailer[12:]) != frameEnd {
+		return ErrFrameCorrupt
+	}
+	if binary.BigEndian.Uint64(trailer) != f.total {
+		return ErrFrameTruncated
+	}
+	if binary.BigEndian.Uint32(trailer[8:]) != f.crc {
+		return ErrFrameChecksum
+	}
+	return io.EOF
+}
+
+func (f *FrameReader) readFull(p []byte) error {
+	if _, err := io.ReadFull(f.r, p); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrFrameTruncated
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func frame(t *testing.T, payload []byte) []byte {
+	var buf bytes.Buffer
+	w := mysqldump.NewFrameWriter(&buf)
+	_, err := w.Write(payload)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("INSERT INTO `t` VALUES (1);\n"), 10000)
+	framed := frame(t, payload)
+	assert.Equal(t, "GOSQLFR1", string(framed[:8]))
+	assert.Equal(t, "GOSQLEND", string(framed[len(framed)-8:]))
+
+	unwrapped, err := ioutil.ReadAll(mysqldump.NewFrameReader(bytes.NewReader(framed)))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, unwrapped)
+
+	unwrapped, err = ioutil.ReadAll(mysqldump.NewFrameReader(bytes.NewReader(frame(t, nil))))
+	assert.NoError(t, err)
+	assert.Empty(t, unwrapped)
+}
+
+func TestFrameDamage(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 100000)
+	framed := frame(t, payload)
+
+	flipped := append([]byte(nil), framed...)
+	flipped[70000] ^= 1
+	_, err := ioutil.ReadAll(mysqldump.NewFrameReader(bytes.NewReader(flipped)))
+	assert.Equal(t, mysqldump.ErrFrameChecksum, err)
+
+	_, err = ioutil.ReadAll(mysqldump.NewFrameReader(bytes.NewReader(framed[:len(framed)-10])))
+	assert.Equal(t, mysqldump.ErrFrameTruncated, err)
+
+	// A dump cut right after a block looks complete without the trailer
+	_, err = ioutil.ReadAll(mysqldump.NewFrameReader(bytes.NewReader(framed[:8+4+64*1024+4])))
+	assert.Equal(t, mysqldump.ErrFrameTruncated, err)
+
+	_, err = ioutil.ReadAll(mysqldump.NewFrameReader(strings.NewReader("-- Go SQL Dump")))
+	assert.Equal(t, mysqldump.ErrFrameMagic, err)
+}
+
+func TestHandlerFramed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?format=sql.frame", nil))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+
+	content, err := ioutil.ReadAll(mysqldump.NewFrameReader(rec.Body))
+	assert.NoError(t, err)
+	result := strings.Replace(strings.Split(string(content), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
@@ -3,6 +3,7 @@ module github.com/jamf/go-mysqldump
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/sync v0.1.0
 )
 
 go 1.13
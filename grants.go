@@ -0,0 +1,65 @@
+package mysqldump
+
+import "errors"
+
+// GrantMode tells how a dump deals with statements that need more than the
+// SELECT privilege.
+type GrantMode string
+
+const (
+	// GrantsDefault runs every statement the options ask for.
+	GrantsDefault GrantMode = ""
+	// GrantsMinimal skips the statements that need more than SELECT, like
+	// LOCK TABLES, and records a warning for each of them.
+	GrantsMinimal GrantMode = "minimal"
+	// GrantsAuto runs those statements but skips them with a warning when the
+	// server denies them.
+	GrantsAuto GrantMode = "auto"
+)
+
+// ErrUnknownGrantMode is returned by dumps with a GrantMode it does not know.
+var ErrUnknownGrantMode = errors.New("unknown grant mode")
+
+// accessDeniedErrors are the server errors of missing privileges: access
+// denied to the database, command denied for a table, missing global
+// privilege and command denied for a routine
+var accessDeniedErrors = map[string]bool{
+	"1044": true,
+	"1142": true,
+	"1227": true,
+	"1370": true,
+}
+
+func isAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	m := mysqlErrorNumber.FindStringSubmatch(err.Error())
+	return m != nil && accessDeniedErrors[m[1]]
+}
+
+func (data *Data) checkGrants() error {
+	switch data.Grants {
+	case GrantsDefault, GrantsMinimal, GrantsAuto:
+		return nil
+	}
+	return ErrUnknownGrantMode
+}
+
+// privileged runs a statement that needs more than the SELECT privilege,
+// described by what, unless the grant mode skips it
+func (data *Data) privileged(what string, run func() error) error {
+	switch data.Grants {
+	case GrantsMinimal:
+		data.warn(what + " skipped to work with SELECT privileges only")
+		return nil
+	case GrantsAuto:
+		err := run()
+		if isAccessDenied(err) {
+			data.warn(what + " skipped: " + err.Error())
+			return nil
+		}
+		return err
+	}
+	return run()
+}
@@ -0,0 +1,76 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantsMinimal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &buf,
+		LockTables: true,
+		Grants:     mysqldump.GrantsMinimal,
+	}
+
+	mockDump(mock)
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	result := strings.Replace(strings.Split(buf.String(), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+	assert.Equal(t, []string{"LOCK TABLES skipped to work with SELECT privileges only"}, data.Warnings())
+}
+
+func TestGrantsAuto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &bytes.Buffer{},
+		LockTables: true,
+		Grants:     mysqldump.GrantsAuto,
+	}
+
+	denied := errors.New("Error 1227 (42000): Access denied; you need (at least one of) the LOCK TABLES privilege(s) for this operation")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectExec("^LOCK TABLES `Test_Table` READ").WillReturnError(denied)
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int)"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(mockColumnRows())
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []string{"LOCK TABLES skipped: " + denied.Error()}, data.Warnings())
+
+	// Other errors still fail the dump
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectExec("^LOCK TABLES `Test_Table` READ").WillReturnError(errors.New("Error 1205 (HY000): Lock wait timeout exceeded"))
+	mock.ExpectRollback()
+
+	assert.EqualError(t, data.Dump(), "Error 1205 (HY000): Lock wait timeout exceeded")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestGrantsUnknown(t *testing.T) {
+	data := &mysqldump.Data{Grants: "none"}
+	assert.Equal(t, mysqldump.ErrUnknownGrantMode, data.Dump())
+}
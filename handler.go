@@ -0,0 +1,170 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+Handler serves a dump of DB on GET requests.
+
+	DB:        Database that will be dumped
+	Authorize: Called before dumping, a non nil error rejects the request with 403 Forbidden
+	NewData:   Returns the options for a request, Connection, Context and the output are set by the handler
+
+The query parameters select what is dumped and how:
+
+	tables: Comma separated list of the only tables to dump, among the ones NewData dumps
+	ignore: Comma separated list of tables to leave out
+	format: sql (default), tar or zip, sql and tar optionally followed by the name of a codec like sql.gz or tar.gz
+*/
+type Handler struct {
+	DB        *sql.DB
+	Authorize func(r *http.Request) error
+	NewData   func(r *http.Request) *Data
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Authorize != nil {
+		if err := h.Authorize(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "sql"
+	}
+	base, codec, err := parseFormat(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	contentType := "application/sql"
+	switch {
+	case codec != nil && codec.Name() == "gz":
+		contentType = "application/gzip"
+	case codec != nil:
+		contentType = "application/octet-stream"
+	case base == "tar":
+		contentType = "application/x-tar"
+	case base == "zip":
+		contentType = "application/zip"
+	}
+
+	data := &Data{}
+	if h.NewData != nil {
+		data = h.NewData(r)
+	}
+	data.Connection = h.DB
+	data.Context = r.Context()
+	if tables := splitList(r.URL.Query().Get("tables")); len(tables) > 0 {
+		allowed, err := data.AllowedTables(tables)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(allowed) == 0 {
+			http.Error(w, "none of the tables may be dumped", http.StatusForbidden)
+			return
+		}
+		data.IncludeTables = allowed
+	}
+	if ignore := splitList(r.URL.Query().Get("ignore")); len(ignore) > 0 {
+		data.IgnoreTables = append(data.IgnoreTables, ignore...)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="dump-`+time.Now().UTC().Format("20060102T150405")+"."+format+`"`)
+
+	out := &sentWriter{w: w}
+	if err := dumpFormat(data, out, format); err != nil {
+		if !out.sent {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// The status has been sent with the first byte of the dump, aborting
+		// the response is the only way left to tell the client it is broken
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// sentWriter records whether anything was written to w
+type sentWriter struct {
+	w    io.Writer
+	sent bool
+}
+
+func (s *sentWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		s.sent = true
+	}
+	return s.w.Write(p)
+}
+
+// dumpFormat writes the dump of data to w in the given format
+func dumpFormat(data *Data, w io.Writer, format string) error {
+	return dumpDatabaseFormat(data, w, format, "")
+}
+
+// dumpDatabaseFormat writes the dump of database to w in the given format,
+// the current database is dumped if it is empty
+func dumpDatabaseFormat(data *Data, w io.Writer, format, database string) error {
+	base, codec, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+	if base == "tar" {
+		archive, err := NewTarWriterCodec(w, codec)
+		if err != nil {
+			return err
+		}
+		data.Files = archive
+		if err := data.DumpDatabase(database); err != nil {
+			return err
+		}
+		return archive.Close()
+	}
+	if base == "zip" {
+		archive := NewZipWriter(w)
+		data.Files = archive
+		if err := data.DumpDatabase(database); err != nil {
+			return err
+		}
+		return archive.Close()
+	}
+	if codec == nil {
+		data.Out = w
+		return data.DumpDatabase(database)
+	}
+	cw, err := codec.Wrap(w)
+	if err != nil {
+		return err
+	}
+	data.Out = cw
+	if err := data.DumpDatabase(database); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,160 @@
+package mysqldump_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump", nil))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/sql", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), ".sql\"")
+
+	result := strings.Replace(strings.Split(rec.Body.String(), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
+
+func TestHandlerFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+		AddRow("Test_Table", "BASE TABLE").
+		AddRow("Other_Table", "BASE TABLE")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(showTablesRows)
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?tables=Test_Table&ignore=Test_Table", nil))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "Table structure")
+}
+
+func TestHandlerRejects(t *testing.T) {
+	h := &mysqldump.Handler{Authorize: func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			return errors.New("invalid token")
+		}
+		return nil
+	}}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dump", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/dump?format=rar", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandlerFailsBeforeWriting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin().WillReturnError(errors.New("too many connections"))
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	assert.NotPanics(t, func() {
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump", nil))
+	})
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "too many connections")
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+}
+
+func TestHandlerRestrictsTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	h := &mysqldump.Handler{DB: db, NewData: func(r *http.Request) *mysqldump.Data {
+		return &mysqldump.Data{IncludeTables: []string{"Test_Table"}}
+	}}
+
+	// The client can't widen the tables the server allows
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?tables=Other_Table", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+		AddRow("Test_Table", "BASE TABLE").
+		AddRow("Other_Table", "BASE TABLE")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(showTablesRows)
+	mock.ExpectRollback()
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?tables=Other_Table,Test_Table&ignore=Test_Table", nil))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "Other_Table")
+}
+
+func TestHandlerRestrictsTablesToPatterns(t *testing.T) {
+	h := &mysqldump.Handler{NewData: func(r *http.Request) *mysqldump.Data {
+		return &mysqldump.Data{IncludePatterns: []string{"public_*"}, ExcludePatterns: []string{"public_keys"}}
+	}}
+
+	for _, tables := range []string{"secret", "public_keys", "secret,public_keys"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?tables="+tables, nil))
+		assert.Equal(t, http.StatusForbidden, rec.Code, tables)
+	}
+
+	h.NewData = func(r *http.Request) *mysqldump.Data {
+		return &mysqldump.Data{IncludePatterns: []string{"/[/"}}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?tables=secret", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestHandlerRequestContext(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	(&mysqldump.Handler{DB: db}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "context canceled")
+}
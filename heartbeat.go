@@ -0,0 +1,180 @@
+package mysqldump
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat reports the progress of a running dump.
+type Heartbeat struct {
+	// Time the heartbeat was taken
+	Time time.Time
+	// Elapsed time since the dump started
+	Elapsed time.Duration
+	// Table that is being dumped, empty before the first one
+	Table string
+	// Rows of Table dumped so far
+	Rows int64
+	// TotalRows is the rows of all tables dumped so far
+	TotalRows int64
+	// EstimatedRows is the estimate of the rows of all tables from
+	// information_schema, only known with UseInformationSchema
+	EstimatedRows int64
+	// Bytes of the dump written so far
+	Bytes int64
+	// Memory is the bytes of rows buffered by the runs sharing MaxMemory
+	Memory int64
+	// Err is the result of the query checking the server still responds
+	Err error
+}
+
+// ETA estimates the time left from the rate of the rows dumped so far, 0 when
+// EstimatedRows is unknown or already reached.
+func (h Heartbeat) ETA() time.Duration {
+	remaining := h.EstimatedRows - h.TotalRows
+	if h.EstimatedRows <= 0 || h.TotalRows <= 0 || remaining <= 0 {
+		return 0
+	}
+	return time.Duration(float64(h.Elapsed) * float64(remaining) / float64(h.TotalRows))
+}
+
+func (h Heartbeat) String() string {
+	s := fmt.Sprintf("Heartbeat %s: %d rows of `%s` after %s", h.Time.UTC().Format(time.RFC3339), h.Rows, h.Table, h.Elapsed.Round(time.Second))
+	if h.Memory > 0 {
+		s += fmt.Sprintf(", %d bytes buffered", h.Memory)
+	}
+	if h.Err != nil {
+		s += ", ping failed: " + h.Err.Error()
+	}
+	return s
+}
+
+// heartbeat runs the periodic check of a dump
+type heartbeat struct {
+	rows      int64 // accessed atomically
+	total     int64 // accessed atomically
+	estimated int64 // accessed atomically
+	start     time.Time
+	mu        sync.Mutex
+	table     string
+	comment   string
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// startHeartbeat checks the server and reports progress every
+// HeartbeatInterval until stop is called
+func (data *Data) startHeartbeat() *heartbeat {
+	ctx, cancel := context.WithCancel(data.context())
+	beat := &heartbeat{
+		start:  time.Now(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(beat.done)
+		ticker := time.NewTicker(data.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				data.beat(ctx, beat)
+			}
+		}
+	}()
+	return beat
+}
+
+// beat takes a single heartbeat
+func (data *Data) beat(ctx context.Context, beat *heartbeat) {
+	var one int
+	err := data.Connection.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	if ctx.Err() != nil {
+		return
+	}
+
+	h := beat.current()
+	h.Memory = data.MemoryInUse()
+	h.Bytes = data.sink.bytes()
+	h.Err = err
+	if data.OnHeartbeat != nil {
+		data.OnHeartbeat(h)
+	}
+	if data.HeartbeatComments {
+		beat.mu.Lock()
+		beat.comment = "-- " + h.String()
+		beat.mu.Unlock()
+	}
+}
+
+func (beat *heartbeat) stop() {
+	beat.cancel()
+	<-beat.done
+}
+
+// current returns the progress without the result of the check
+func (beat *heartbeat) current() Heartbeat {
+	now := time.Now()
+	beat.mu.Lock()
+	defer beat.mu.Unlock()
+	return Heartbeat{
+		Time:          now,
+		Elapsed:       now.Sub(beat.start),
+		Table:         beat.table,
+		Rows:          atomic.LoadInt64(&beat.rows),
+		TotalRows:     atomic.LoadInt64(&beat.total),
+		EstimatedRows: atomic.LoadInt64(&beat.estimated),
+	}
+}
+
+// enter is called when the dump of table starts
+func (beat *heartbeat) enter(table string) {
+	if beat == nil {
+		return
+	}
+	beat.mu.Lock()
+	defer beat.mu.Unlock()
+	beat.table = table
+	atomic.StoreInt64(&beat.rows, 0)
+}
+
+// row is called for every row read
+func (beat *heartbeat) row() {
+	if beat != nil {
+		atomic.AddInt64(&beat.rows, 1)
+		atomic.AddInt64(&beat.total, 1)
+	}
+}
+
+// estimate records the estimated rows of the tables of the dump, known when
+// they are read from information_schema
+func (beat *heartbeat) estimate(tables []*table) {
+	if beat == nil {
+		return
+	}
+	var rows int64
+	for _, table := range tables {
+		if !table.isView {
+			rows += table.rowEstimate
+		}
+	}
+	atomic.StoreInt64(&beat.estimated, rows)
+}
+
+// takeComment returns the progress comment to write to the dump, if there is
+// a new one
+func (beat *heartbeat) takeComment() string {
+	if beat == nil {
+		return ""
+	}
+	beat.mu.Lock()
+	defer beat.mu.Unlock()
+	comment := beat.comment
+	beat.comment = ""
+	return comment
+}
@@ -0,0 +1,131 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeat(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT 1$`).WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	beats := make(chan Heartbeat, 1)
+	data.HeartbeatInterval = 10 * time.Millisecond
+	data.OnHeartbeat = func(h Heartbeat) {
+		select {
+		case beats <- h:
+		default:
+		}
+	}
+	data.HeartbeatComments = true
+
+	beat := data.startHeartbeat()
+	beat.enter("test")
+	beat.row()
+	beat.row()
+
+	h := <-beats
+	beat.stop()
+
+	assert.Equal(t, "test", h.Table)
+	assert.Equal(t, int64(2), h.Rows)
+	assert.NoError(t, h.Err)
+	assert.True(t, h.Elapsed > 0)
+	assert.True(t, strings.HasPrefix(beat.takeComment(), "-- Heartbeat "))
+	assert.Equal(t, "", beat.takeComment())
+}
+
+func TestHeartbeatPingFailed(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT 1$`).WillReturnError(errors.New("gone away"))
+
+	var got Heartbeat
+	data.OnHeartbeat = func(h Heartbeat) { got = h }
+	beat := &heartbeat{start: time.Now()}
+	beat.enter("test")
+	data.beat(context.Background(), beat)
+
+	assert.EqualError(t, got.Err, "gone away")
+	assert.Contains(t, got.String(), "0 rows of `test` after 0s, ping failed: gone away")
+	assert.Equal(t, "", beat.takeComment())
+}
+
+func TestStreamHeartbeatComment(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+
+	data.MaxAllowedPacket = 4096
+	data.heartbeat = &heartbeat{comment: "-- Heartbeat"}
+
+	var values []string
+	for value := range data.createTable("test", false).Stream() {
+		values = append(values, value)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{
+		"-- Heartbeat",
+		"INSERT INTO `test` (`id`, `email`, `name`) VALUES (1,'test@test.de','Test Name 1'),(2,'test2@test.de','Test Name 2');",
+	}, values)
+}
+
+func TestHeartbeatTotals(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT 1$`).WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	out := &sink{}
+	data.sink = out
+	out.writer(ioutil.Discard, true).Write([]byte("0123456789"))
+
+	var got Heartbeat
+	data.OnHeartbeat = func(h Heartbeat) { got = h }
+	beat := &heartbeat{start: time.Now()}
+	first, second := data.createTable("a", false), data.createTable("b", false)
+	first.rowEstimate, second.rowEstimate = 3, 5
+	view := data.createTable("v", true)
+	view.rowEstimate = 100
+	beat.estimate([]*table{first, second, view})
+	beat.enter("a")
+	beat.row()
+	beat.row()
+	beat.enter("b")
+	beat.row()
+	data.beat(context.Background(), beat)
+
+	assert.Equal(t, "b", got.Table)
+	assert.Equal(t, int64(1), got.Rows)
+	assert.Equal(t, int64(3), got.TotalRows)
+	assert.Equal(t, int64(8), got.EstimatedRows)
+	assert.Equal(t, int64(10), got.Bytes)
+}
+
+func TestHeartbeatETA(t *testing.T) {
+	h := Heartbeat{Elapsed: 10 * time.Second, TotalRows: 100, EstimatedRows: 400}
+	assert.Equal(t, 30*time.Second, h.ETA())
+
+	h.EstimatedRows = 0
+	assert.Zero(t, h.ETA())
+	h.EstimatedRows, h.TotalRows = 50, 100
+	assert.Zero(t, h.ETA())
+	h.TotalRows = 0
+	assert.Zero(t, h.ETA())
+}
@@ -0,0 +1,174 @@
+package mysqldump
+
+import (
+	"io"
+	"strings"
+)
+
+const (
+	indexesFileName     = "indexes.sql"
+	constraintsFileName = "constraints.sql"
+)
+
+// Takes a *table
+const tableIndexesTmpl = `
+--
+-- Indexes for table {{ .NameEsc }}
+--
+
+{{ .IndexesSQL }};
+`
+
+// Takes a *table
+const tableConstraintsTmpl = `
+--
+-- Constraints for table {{ .NameEsc }}
+--
+
+{{ .ConstraintsSQL }};
+`
+
+// secondaryIndexPrefixes start the index definitions of SHOW CREATE TABLE that
+// DeferIndexes moves behind the data
+var secondaryIndexPrefixes = []string{"KEY ", "UNIQUE KEY ", "FULLTEXT KEY ", "SPATIAL KEY "}
+
+// ftsDocIDIndex is the unique index InnoDB requires on a user defined
+// FTS_DOC_ID column before a FULLTEXT index can be added
+const ftsDocIDIndex = "FTS_DOC_ID_INDEX"
+
+// splitCreateSQL takes the secondary indexes and foreign keys out of the
+// output of SHOW CREATE TABLE. Indexes on the AUTO_INCREMENT column and the
+// FTS_DOC_ID_INDEX stay as the table can't be created or indexed without them.
+// Deferring FULLTEXT and SPATIAL indexes matters most, maintaining them row by
+// row is far slower than building them once.
+func splitCreateSQL(create string) (string, []string, []string) {
+	lines := strings.Split(create, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], ")") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return create, nil, nil
+	}
+
+	var autoIncrement string
+	for _, line := range lines[1:end] {
+		def := strings.TrimSpace(line)
+		if strings.HasPrefix(def, "`") && strings.Contains(def, " AUTO_INCREMENT") {
+			autoIncrement = def[:strings.Index(def[1:], "`")+2]
+		}
+	}
+
+	var defs, indexes, constraints []string
+	for _, line := range lines[1:end] {
+		def := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		switch {
+		case isSecondaryIndex(def) && !indexesColumn(def, autoIncrement) && !strings.Contains(def, " `"+ftsDocIDIndex+"` "):
+			indexes = append(indexes, def)
+		case strings.HasPrefix(def, "CONSTRAINT ") && strings.Contains(def, " FOREIGN KEY "):
+			constraints = append(constraints, def)
+		default:
+			defs = append(defs, def)
+		}
+	}
+	if len(indexes) == 0 && len(constraints) == 0 {
+		return create, nil, nil
+	}
+	return lines[0] + "\n  " + strings.Join(defs, ",\n  ") + "\n" + strings.Join(lines[end:], "\n"), indexes, constraints
+}
+
+func isSecondaryIndex(def string) bool {
+	for _, prefix := range secondaryIndexPrefixes {
+		if strings.HasPrefix(def, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexesColumn reports whether the index definition starts with column
+func indexesColumn(def, column string) bool {
+	if column == "" {
+		return false
+	}
+	i := strings.Index(def, "(")
+	return i >= 0 && strings.HasPrefix(def[i+1:], column)
+}
+
+// alterSQL adds the definitions to the table in a single ALTER TABLE
+func (table *table) alterSQL(defs []string) string {
+	return "ALTER TABLE " + table.QualifiedName() + "\n  ADD " + strings.Join(defs, ",\n  ADD ")
+}
+
+// IndexesSQL adds the deferred indexes. InnoDB builds one FULLTEXT index per
+// statement, so they each get their own ALTER TABLE after the other indexes.
+func (table *table) IndexesSQL() string {
+	var defs, statements []string
+	for _, def := range table.indexes {
+		if !strings.HasPrefix(def, "FULLTEXT ") {
+			defs = append(defs, def)
+		}
+	}
+	if len(defs) != 0 {
+		statements = append(statements, table.alterSQL(defs))
+	}
+	for _, def := range table.indexes {
+		if strings.HasPrefix(def, "FULLTEXT ") {
+			statements = append(statements, table.alterSQL([]string{def}))
+		}
+	}
+	return strings.Join(statements, defaultDelimiter+"\n")
+}
+
+func (table *table) ConstraintsSQL() string {
+	return table.alterSQL(table.constraints)
+}
+
+// writeIndexes writes the deferred indexes of all tables to w
+func (data *Data) writeIndexes(w io.Writer, tables []*table) error {
+	for _, table := range tables {
+		if len(table.indexes) != 0 {
+			if err := data.tableIndexesTmpl.Execute(w, table); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeConstraints writes the deferred foreign keys of all tables to w
+func (data *Data) writeConstraints(w io.Writer, tables []*table) error {
+	for _, table := range tables {
+		if len(table.constraints) != 0 {
+			if err := data.tableConstraintsTmpl.Execute(w, table); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writePostDataFile writes one of the sections following the data to its own
+// file, wrapped in the header and footer
+func (data *Data) writePostDataFile(meta *metaData, name string, tables []*table, write func(io.Writer, []*table) error) error {
+	f, err := data.createFile(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := data.newSummaryWriter(f)
+	if err := data.headerTmpl.Execute(s, meta); err != nil {
+		return err
+	}
+	if err := write(s, tables); err != nil {
+		return err
+	}
+	if err := data.writeFooter(s, meta, nil); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,151 @@
+package mysqldump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const createWithIndexes = "CREATE TABLE `child` (\n" +
+	"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+	"  `parent_id` int NOT NULL,\n" +
+	"  `body` text,\n" +
+	"  PRIMARY KEY (`parent_id`,`id`),\n" +
+	"  KEY `id` (`id`),\n" +
+	"  UNIQUE KEY `parent` (`parent_id`,`body`(10)),\n" +
+	"  FULLTEXT KEY `ft` (`body`),\n" +
+	"  CONSTRAINT `chk` CHECK ((`id` > 0)),\n" +
+	"  CONSTRAINT `fk` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+func TestSplitCreateSQL(t *testing.T) {
+	create, indexes, constraints := splitCreateSQL(createWithIndexes)
+
+	assert.Equal(t, "CREATE TABLE `child` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT,\n"+
+		"  `parent_id` int NOT NULL,\n"+
+		"  `body` text,\n"+
+		"  PRIMARY KEY (`parent_id`,`id`),\n"+
+		"  KEY `id` (`id`),\n"+
+		"  CONSTRAINT `chk` CHECK ((`id` > 0))\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", create)
+	assert.Equal(t, []string{"UNIQUE KEY `parent` (`parent_id`,`body`(10))", "FULLTEXT KEY `ft` (`body`)"}, indexes)
+	assert.Equal(t, []string{"CONSTRAINT `fk` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`)"}, constraints)
+}
+
+func TestSplitCreateSQLWithoutIndexes(t *testing.T) {
+	create := "CREATE TABLE `t` (\n  `id` int NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+	got, indexes, constraints := splitCreateSQL(create)
+	assert.Equal(t, create, got)
+	assert.Nil(t, indexes)
+	assert.Nil(t, constraints)
+}
+
+func TestDeferIndexesStream(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `child`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("child", createWithIndexes))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `child`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `child`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0)).AddRow(1))
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	data.MaxAllowedPacket = 4096
+	data.DeferIndexes = true
+	data.manifest = &Manifest{}
+	assert.NoError(t, data.getTemplates())
+
+	meta := &metaData{DumpVersion: Version}
+	assert.NoError(t, data.writeStream(meta, []*table{data.createTable("child", false)}))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	result := buf.String()
+	assert.NotContains(t, strings.Split(result, "-- Dumping data")[0], "FULLTEXT KEY")
+	assert.Contains(t, result, "\n--\n-- Indexes for table `child`\n--\n\n"+
+		"ALTER TABLE `child`\n  ADD UNIQUE KEY `parent` (`parent_id`,`body`(10));\n"+
+		"ALTER TABLE `child`\n  ADD FULLTEXT KEY `ft` (`body`);\n")
+	assert.Contains(t, result, "\n--\n-- Constraints for table `child`\n--\n\n"+
+		"ALTER TABLE `child`\n  ADD CONSTRAINT `fk` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`);\n")
+	assert.True(t, strings.Index(result, "INSERT INTO") < strings.Index(result, "-- Indexes for table"))
+	assert.True(t, strings.Index(result, "-- Indexes for table") < strings.Index(result, "-- Constraints for table"))
+}
+
+func TestSectionTableDeferred(t *testing.T) {
+	assert.Equal(t, "child/indexes", sectionTable([]string{"Indexes for table `child`"}))
+	assert.Equal(t, "child/constraints", sectionTable([]string{"Constraints for table `child`"}))
+}
+
+func TestSplitCreateSQLMySQL8(t *testing.T) {
+	create := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `s` varchar(10) DEFAULT (uuid()),\n" +
+		"  `h` int DEFAULT NULL /*!80023 INVISIBLE */,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `f` ((lower(`s`))),\n" +
+		"  KEY `d` (`id` DESC,`s`),\n" +
+		"  CONSTRAINT `c` CHECK ((`h` > 0))\n" +
+		") ENGINE=InnoDB"
+	got, indexes, constraints := splitCreateSQL(create)
+
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `s` varchar(10) DEFAULT (uuid()),\n"+
+		"  `h` int DEFAULT NULL /*!80023 INVISIBLE */,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  CONSTRAINT `c` CHECK ((`h` > 0))\n"+
+		") ENGINE=InnoDB", got)
+	assert.Equal(t, []string{"KEY `f` ((lower(`s`)))", "KEY `d` (`id` DESC,`s`)"}, indexes)
+	assert.Nil(t, constraints)
+}
+
+func TestIndexesSQLFulltext(t *testing.T) {
+	create := "CREATE TABLE `doc` (\n" +
+		"  `FTS_DOC_ID` bigint unsigned NOT NULL,\n" +
+		"  `title` varchar(200) DEFAULT NULL,\n" +
+		"  `body` text,\n" +
+		"  `pos` point NOT NULL,\n" +
+		"  UNIQUE KEY `FTS_DOC_ID_INDEX` (`FTS_DOC_ID`),\n" +
+		"  FULLTEXT KEY `title` (`title`),\n" +
+		"  FULLTEXT KEY `title_body` (`title`,`body`),\n" +
+		"  SPATIAL KEY `pos` (`pos`),\n" +
+		"  KEY `t` (`title`)\n" +
+		") ENGINE=InnoDB"
+
+	table := (&Data{}).createTable("doc", false)
+	var got string
+	got, table.indexes, table.constraints = splitCreateSQL(create)
+	assert.Contains(t, got, "UNIQUE KEY `FTS_DOC_ID_INDEX` (`FTS_DOC_ID`)\n)")
+
+	assert.Equal(t, "ALTER TABLE `doc`\n  ADD SPATIAL KEY `pos` (`pos`),\n  ADD KEY `t` (`title`);\n"+
+		"ALTER TABLE `doc`\n  ADD FULLTEXT KEY `title` (`title`);\n"+
+		"ALTER TABLE `doc`\n  ADD FULLTEXT KEY `title_body` (`title`,`body`)", table.IndexesSQL())
+
+	table.indexes = table.indexes[:1]
+	assert.Equal(t, "ALTER TABLE `doc`\n  ADD FULLTEXT KEY `title` (`title`)", table.IndexesSQL())
+}
+
+func TestSplitCreateSQLInvisibleIndexes(t *testing.T) {
+	create := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `a` int DEFAULT NULL,\n" +
+		"  `h` int DEFAULT NULL /*!80023 INVISIBLE */,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `a` (`a`) /*!80000 INVISIBLE */,\n" +
+		"  UNIQUE KEY `h` (`h`) COMMENT 'hidden' /*!80000 INVISIBLE */\n" +
+		") ENGINE=InnoDB"
+	got, indexes, _ := splitCreateSQL(create)
+	assert.NotContains(t, got, "KEY `a`")
+	assert.Equal(t, []string{"KEY `a` (`a`) /*!80000 INVISIBLE */", "UNIQUE KEY `h` (`h`) COMMENT 'hidden' /*!80000 INVISIBLE */"}, indexes)
+
+	table := (&Data{}).createTable("t", false)
+	table.indexes = indexes
+	assert.Equal(t, "ALTER TABLE `t`\n  ADD KEY `a` (`a`) /*!80000 INVISIBLE */,\n  ADD UNIQUE KEY `h` (`h`) COMMENT 'hidden' /*!80000 INVISIBLE */", table.IndexesSQL())
+}
@@ -0,0 +1,132 @@
+package mysqldump
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// jsonWriter writes the rows of a table as JSON objects, one per line
+type jsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+	eol  string
+}
+
+func (data *Data) newJSONWriter(w io.Writer, cols []string) (*jsonWriter, error) {
+	j := &jsonWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(cols)), eol: "\n"}
+	for i, col := range cols {
+		key, err := json.Marshal(col)
+		if err != nil {
+			return nil, err
+		}
+		j.keys[i] = key
+	}
+	if data.LineEnding == LineEndingCRLF {
+		j.eol = "\r\n"
+	}
+	return j, nil
+}
+
+// write writes the object of a row from the JSON encoded values of its
+// columns
+func (j *jsonWriter) write(values [][]byte) error {
+	j.w.WriteString("{")
+	for i, value := range values {
+		if i > 0 {
+			j.w.WriteString(",")
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteString(":")
+		j.w.Write(value)
+	}
+	j.w.WriteString("}")
+	_, err := j.w.WriteString(j.eol)
+	return err
+}
+
+// jsonValue returns a value of the current row JSON encoded. Numbers are
+// numbers, binary strings are base64 encoded like []byte and the values of
+// JSON columns are embedded as they are. Transforms and Masks apply like to
+// the INSERT statements, masked values being strings.
+func (table *table) jsonValue(column int, value interface{}) ([]byte, error) {
+	value = table.transformed(column, value)
+	var columnType string
+	if column < len(table.types) {
+		columnType = table.types[column]
+	}
+	if m := table.masker(column); m != nil {
+		if s, ok := textValue(value); ok {
+			return json.Marshal(m.Mask(s))
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case *sql.NullInt64:
+		if !v.Valid {
+			return []byte("null"), nil
+		}
+		return []byte(strconv.FormatInt(v.Int64, 10)), nil
+	case *sql.NullFloat64:
+		if !v.Valid {
+			return []byte("null"), nil
+		}
+		return []byte(formatFloat(v.Float64, table.floatBits(column))), nil
+	case *sql.RawBytes:
+		if *v == nil {
+			return []byte("null"), nil
+		}
+		if isBinaryType(columnType) {
+			return json.Marshal(base64.StdEncoding.EncodeToString(*v))
+		}
+	}
+	s, ok := textValue(value)
+	if !ok {
+		return []byte("null"), nil
+	}
+	if columnType == "JSON" && json.Valid([]byte(s)) {
+		return []byte(s), nil
+	}
+	return json.Marshal(s)
+}
+
+// writeTableJSON writes the rows of the table to w as JSON Lines
+func (data *Data) writeTableJSON(w io.Writer, table *table) error {
+	var j *jsonWriter
+	var values [][]byte
+	return table.writeRows(func() (err error) {
+		j, err = data.newJSONWriter(w, table.cols)
+		values = make([][]byte, len(table.values))
+		return err
+	}, func() error {
+		for i, value := range table.values {
+			b, err := table.jsonValue(i, value)
+			if err != nil {
+				return err
+			}
+			values[i] = b
+		}
+		return j.write(values)
+	}, func() error {
+		return j.w.Flush()
+	})
+}
+
+// writeJSONFile writes the rows of table to its own JSON Lines file
+func (data *Data) writeJSONFile(meta *metaData, table *table) error {
+	f, err := data.createFile(textFileName(data.dataFileName(meta, table), ".jsonl"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := data.writeTableJSON(f, table); err != nil {
+		return err
+	}
+	table.bytes = f.written
+	return f.Close()
+}
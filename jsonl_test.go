@@ -0,0 +1,68 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpJSON(t *testing.T) {
+	files := dumpCSV(t, &mysqldump.Data{DataFormat: mysqldump.DataFormatJSON})
+	assert.NotContains(t, files, "data/Test_Table.csv")
+	assert.Equal(t, `{"id":1,"email":null,"name":"plain"}`+"\n"+
+		`{"id":2,"email":"","name":"with, comma"}`+"\n"+
+		`{"id":3,"email":"say \"hi\"","name":"two\nlines"}`+"\n", files["data/Test_Table.jsonl"])
+}
+
+func TestDumpJSONTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int(11) NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).
+		AddRow("id", "int(11)", "NO", "PRI", nil, "").
+		AddRow("doc", "json", "YES", "", nil, "").
+		AddRow("bin", "blob", "YES", "", nil, "").
+		AddRow("secret", "varchar(255)", "YES", "", nil, ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", 0),
+		sqlmock.NewColumn("doc").OfType("JSON", "").Nullable(true),
+		sqlmock.NewColumn("bin").OfType("BLOB", []byte{}).Nullable(true),
+		sqlmock.NewColumn("secret").OfType("VARCHAR", "").Nullable(true)).
+		AddRow(1, `{"a": [1, 2]}`, []byte{0, 0xff}, "hunter2").
+		AddRow(2, nil, nil, nil))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	archive := mysqldump.NewZipWriter(&buf)
+	data := &mysqldump.Data{
+		Connection: db,
+		Files:      archive,
+		DataFormat: mysqldump.DataFormatJSON,
+		Masks:      map[string]mysqldump.Masker{"Test_Table.secret": mysqldump.MaskFunc(func(string) string { return "****" })},
+	}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, archive.Close())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	_, files := readZip(t, buf.Bytes())
+	lines := strings.Split(strings.TrimSuffix(files["data/Test_Table.jsonl"], "\n"), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, `{"id":1,"doc":{"a": [1, 2]},"bin":"AP8=","secret":"****"}`, lines[0])
+		assert.Equal(t, `{"id":2,"doc":null,"bin":null,"secret":null}`, lines[1])
+		for _, line := range lines {
+			assert.True(t, json.Valid([]byte(line)), line)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FileLayout is how the tables of a multi-file dump are spread over files.
+type FileLayout string
+
+const (
+	// FileLayoutSections writes the structure of every table and view to
+	// schema.sql and the rows of every table to data/<table>.sql.
+	FileLayoutSections FileLayout = ""
+	// FileLayoutPerTable writes the structure and the rows of every table to
+	// files of their own named after the database, <db>.<table>-schema.sql
+	// and <db>.<table>.sql, and the database to <db>-schema-create.sql, the
+	// way mydumper does. Any table can be restored on its own, or all of them
+	// in parallel once their schema files are.
+	FileLayoutPerTable FileLayout = "per-table"
+	// FileLayoutMydumper writes the files of a mydumper backup for myloader
+	// to restore: the structure of views to <db>.<view>-schema-view.sql, the
+	// rows of every table to <db>.<table>.00000.sql and the start, end and
+	// binary log coordinates of the dump to metadata. The database file
+	// creates it without selecting it, the other ones are restored in the
+	// database myloader selects.
+	FileLayoutMydumper FileLayout = "mydumper"
+)
+
+// ErrUnknownFileLayout is returned for file layouts that don't exist.
+var ErrUnknownFileLayout = errors.New("unknown file layout")
+
+const mydumperMetadataFileName = "metadata"
+
+// mydumperTimeFormat is the format of the times of the metadata of mydumper
+const mydumperTimeFormat = "2006-01-02 15:04:05"
+
+func (data *Data) checkFileLayout() error {
+	switch data.FileLayout {
+	case FileLayoutSections, FileLayoutPerTable, FileLayoutMydumper:
+		return nil
+	}
+	return ErrUnknownFileLayout
+}
+
+// perTable reports whether the dump writes the files of every table apart,
+// with FileLayoutPerTable or FileLayoutMydumper
+func (data *Data) perTable() bool {
+	return data.Files != nil && (data.FileLayout == FileLayoutPerTable || data.FileLayout == FileLayoutMydumper)
+}
+
+func (meta *metaData) schemaCreateFileName() string {
+	return safeFileName(meta.layout.Name) + "-schema-create.sql"
+}
+
+func (data *Data) tableSchemaFileName(meta *metaData, table *table) string {
+	suffix := "-schema.sql"
+	if table.isView && data.FileLayout == FileLayoutMydumper {
+		suffix = "-schema-view.sql"
+	}
+	return safeFileName(meta.layout.Name+"."+table.Name) + suffix
+}
+
+// dataFileName returns the name of the file the rows of table are written to
+func (data *Data) dataFileName(meta *metaData, table *table) string {
+	if meta.layout == nil {
+		return dataFileName(table.Name)
+	}
+	name := safeFileName(meta.layout.Name + "." + table.Name)
+	if data.FileLayout == FileLayoutMydumper {
+		// The first and only chunk of the table
+		name += ".00000"
+	}
+	return name + ".sql"
+}
+
+// writeTableSchemaFiles writes the database and the structure of every table
+// and view to files of their own, each with the header and footer so it can
+// be restored on its own
+func (data *Data) writeTableSchemaFiles(meta *metaData, tables []*table) error {
+	if err := data.writeSchemaPart(meta, meta.schemaCreateFileName(), nil, func(f *summaryWriter) error {
+		if data.FileLayout == FileLayoutMydumper {
+			create, err := data.terminate(meta.layout.CreateSQL)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(f, "\n"+create+"\n")
+			return err
+		}
+		return data.databaseTmpl.Execute(f, meta.layout)
+	}); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		t := t
+		if err := data.writeSchemaPart(meta, data.tableSchemaFileName(meta, t), []TableStats{{View: t.isView}}, func(f *summaryWriter) error {
+			return data.writeTableSchema(f, t)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSchemaPart writes one file of the schema with write
+func (data *Data) writeSchemaPart(meta *metaData, name string, stats []TableStats, write func(*summaryWriter) error) error {
+	f, err := data.createFile(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := data.newSummaryWriter(f)
+	if err := data.headerTmpl.Execute(s, meta); err != nil {
+		return err
+	}
+	if err := write(s); err != nil {
+		return err
+	}
+	if err := data.writeFooter(s, meta, stats); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeMydumperMetadata writes the metadata file of FileLayoutMydumper, in the
+// format of mydumper before 0.13 that myloader still reads
+func (data *Data) writeMydumperMetadata(meta *metaData) error {
+	f, err := data.createFile(mydumperMetadataFileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Started dump at: %s\n", meta.started.Format(mydumperTimeFormat))
+	if data.binlog != nil {
+		fmt.Fprintf(&b, "SHOW MASTER STATUS:\n\tLog: %s\n\tPos: %d\n\tGTID:%s\n\n", data.binlog.File, data.binlog.Position, data.binlog.GTIDSet)
+	}
+	fmt.Fprintf(&b, "Finished dump at: %s\n", time.Now().Format(mydumperTimeFormat))
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,80 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// dumpLayout dumps the mocked Testdb database to a zip with the layout
+func dumpLayout(t *testing.T, layout mysqldump.FileLayout) (names []string, files map[string]string) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SELECT DATABASE\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("Testdb"))
+	mock.ExpectQuery("^SHOW CREATE DATABASE `Testdb`$").WillReturnRows(sqlmock.NewRows([]string{"Database", "Create Database"}).
+		AddRow("Testdb", "CREATE DATABASE `Testdb` /*!40100 DEFAULT CHARACTER SET utf8mb4 */"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int(11) NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(mockColumnRows())
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).
+		AddRow(1, "test@test.de", "Test Name 1"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	archive := mysqldump.NewZipWriter(&buf)
+	data := &mysqldump.Data{Connection: db, Files: archive, FileLayout: layout, MaxAllowedPacket: 4194304}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, archive.Close())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	entries, files := readZip(t, buf.Bytes())
+	for _, f := range entries {
+		names = append(names, f.Name)
+	}
+	return names, files
+}
+
+func TestDumpFileLayoutPerTable(t *testing.T) {
+	names, files := dumpLayout(t, mysqldump.FileLayoutPerTable)
+	assert.Equal(t, []string{"Testdb-schema-create.sql", "Testdb.Test_Table-schema.sql", "Testdb.Test_Table.sql", "manifest.json", "SHA256SUMS"}, names)
+	assert.Contains(t, files["Testdb-schema-create.sql"], "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `Testdb`")
+	assert.Contains(t, files["Testdb.Test_Table-schema.sql"], "CREATE TABLE `Test_Table`")
+	assert.NotContains(t, files["Testdb.Test_Table-schema.sql"], "USE `Testdb`")
+	assert.NotContains(t, files["Testdb.Test_Table-schema.sql"], "INSERT INTO")
+	assert.Contains(t, files["Testdb.Test_Table.sql"], "INSERT INTO `Test_Table` (`id`, `email`, `name`) VALUES (1,'test@test.de','Test Name 1');")
+	assert.NotContains(t, files["Testdb.Test_Table.sql"], "CREATE TABLE")
+
+	manifest, err := mysqldump.ReadManifest(strings.NewReader(files["manifest.json"]))
+	assert.NoError(t, err)
+	assert.Equal(t, "Testdb", manifest.Database)
+	if assert.Len(t, manifest.Tables, 1) {
+		assert.Equal(t, int64(1), manifest.Tables[0].Rows)
+	}
+}
+
+func TestDumpFileLayoutMydumper(t *testing.T) {
+	names, files := dumpLayout(t, mysqldump.FileLayoutMydumper)
+	assert.Equal(t, []string{"Testdb-schema-create.sql", "Testdb.Test_Table-schema.sql", "Testdb.Test_Table.00000.sql", "metadata", "manifest.json", "SHA256SUMS"}, names)
+
+	// myloader restores into the database of its choice
+	assert.Contains(t, files["Testdb-schema-create.sql"], "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `Testdb` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;")
+	for name, content := range files {
+		assert.NotContains(t, content, "USE `Testdb`", name)
+	}
+	assert.Contains(t, files["Testdb.Test_Table.00000.sql"], "INSERT INTO `Test_Table`")
+	assert.Regexp(t, `^Started dump at: \d{4}-\d\d-\d\d \d\d:\d\d:\d\d\nFinished dump at: \d{4}-\d\d-\d\d \d\d:\d\d:\d\d\n$`, files["metadata"])
+}
+
+func TestDumpUnknownFileLayout(t *testing.T) {
+	assert.Equal(t, mysqldump.ErrUnknownFileLayout, (&mysqldump.Data{FileLayout: "per-database"}).Dump())
+}
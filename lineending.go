@@ -0,0 +1,54 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// LineEnding is what ends the lines of the SQL files of a dump. The files
+// never start with a byte order mark, whatever the line ending.
+type LineEnding string
+
+const (
+	// LineEndingLF ends the lines with \n.
+	LineEndingLF LineEnding = ""
+	// LineEndingCRLF ends the lines with \r\n, for Windows tools that
+	// mangle files mixing both.
+	LineEndingCRLF LineEnding = "crlf"
+)
+
+// ErrUnknownLineEnding is returned for line endings that don't exist.
+var ErrUnknownLineEnding = errors.New("unknown line ending")
+
+func (data *Data) checkLineEnding() error {
+	switch data.LineEnding {
+	case LineEndingLF, LineEndingCRLF:
+		return nil
+	}
+	return ErrUnknownLineEnding
+}
+
+// newSummaryWriter returns the summary writer of a SQL file written to w with
+// the LineEnding
+func (data *Data) newSummaryWriter(w io.Writer) *summaryWriter {
+	s := newSummaryWriter(w)
+	s.crlf = data.LineEnding == LineEndingCRLF
+	return s
+}
+
+// toCRLF returns p with a \r in front of every \n that lacks one, cr telling
+// whether the previous write ended with \r
+func toCRLF(p []byte, cr bool) []byte {
+	if bytes.IndexByte(p, '\n') < 0 {
+		return p
+	}
+	b := make([]byte, 0, len(p)+bytes.Count(p, []byte{'\n'}))
+	for i, c := range p {
+		if c == '\n' && !(i == 0 && cr || i > 0 && p[i-1] == '\r') {
+			b = append(b, '\r')
+		}
+		b = append(b, c)
+	}
+	return b
+}
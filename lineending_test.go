@@ -0,0 +1,67 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// dumpLineEnding dumps the mocked database with the line ending
+func dumpLineEnding(t *testing.T, ending mysqldump.LineEnding) []byte {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Out: &buf, Connection: db, LineEnding: ending, ValidateOutput: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	return buf.Bytes()
+}
+
+func TestDumpLineEndingCRLF(t *testing.T) {
+	b := dumpLineEnding(t, mysqldump.LineEndingCRLF)
+	assert.False(t, bytes.HasPrefix(b, []byte("\xef\xbb\xbf")), "the dump starts with a byte order mark")
+	assert.Equal(t, bytes.Count(b, []byte("\n")), bytes.Count(b, []byte("\r\n")), "not every line ends with CRLF")
+	assert.True(t, bytes.HasSuffix(b, []byte("}\r\n")))
+
+	// Apart from the line endings it is the LF dump
+	lf := strings.Split(string(dumpLineEnding(t, mysqldump.LineEndingLF)), "-- Dump completed")[0]
+	assert.Equal(t, lf, strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "-- Dump completed")[0])
+
+	summary, err := mysqldump.ReadSummary(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), summary.Rows)
+
+	d := mysqldump.NewDecoder(bytes.NewReader(b))
+	var rows *mysqldump.InsertRows
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if r, ok := event.(*mysqldump.InsertRows); ok {
+			rows = r
+		}
+	}
+	if assert.NotNil(t, rows) {
+		assert.Len(t, rows.Rows, 2)
+	}
+}
+
+func TestDumpLineEndingLF(t *testing.T) {
+	b := dumpLineEnding(t, mysqldump.LineEndingLF)
+	assert.False(t, bytes.HasPrefix(b, []byte("\xef\xbb\xbf")), "the dump starts with a byte order mark")
+	assert.NotContains(t, string(b), "\r")
+}
+
+func TestDumpUnknownLineEnding(t *testing.T) {
+	assert.Equal(t, mysqldump.ErrUnknownLineEnding, (&mysqldump.Data{LineEnding: "cr"}).Dump())
+}
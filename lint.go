@@ -0,0 +1,173 @@
+package mysqldump
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// LintSeverity tells whether a lint issue blocks a restore.
+type LintSeverity int
+
+const (
+	// LintWarning is an issue that may need attention, like DEFINER clauses
+	// that need privileges on the target.
+	LintWarning LintSeverity = iota
+	// LintError is an issue that makes the restore fail.
+	LintError
+)
+
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// The checks Lint runs
+const (
+	LintCheckPacket     = "max_allowed_packet"
+	LintCheckTerminator = "terminator"
+	LintCheckIdentifier = "identifier"
+	LintCheckCharset    = "charset"
+	LintCheckDefiner    = "definer"
+)
+
+// LintIssue is a problem found in a dump.
+type LintIssue struct {
+	Line     int          `json:"line"`
+	Severity LintSeverity `json:"severity"`
+	Check    string       `json:"check"`
+	Message  string       `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("line %d: %s: %s: %s", i.Line, i.Severity, i.Check, i.Message)
+}
+
+// LintReport lists the issues found in a dump.
+type LintReport struct {
+	Statements int         `json:"statements"`
+	Issues     []LintIssue `json:"issues"`
+}
+
+// Passed reports whether the dump has no issues that block a restore.
+func (r *LintReport) Passed() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == LintError {
+			return false
+		}
+	}
+	return true
+}
+
+// Linter checks dumps for common restore blockers.
+type Linter struct {
+	// MaxAllowedPacket of the target, 4MB if 0
+	MaxAllowedPacket int
+	// TargetVersion flags collations the target does not know, like the
+	// utf8mb4_0900 collations on servers older than MySQL 8.0
+	TargetVersion string
+}
+
+// Lint checks the dump read from r with the default settings of Linter.
+func Lint(r io.Reader) (*LintReport, error) {
+	return (&Linter{}).Lint(r)
+}
+
+var (
+	setNamesRe     = regexp.MustCompile(`(?i)^\s*(?:/\*!\d+\s*)?SET\s+NAMES\s+'?(\w+)`)
+	tableCharsetRe = regexp.MustCompile(`(?i)\bDEFAULT\s+(?:CHARSET|CHARACTER SET)\s*=?\s*(\w+)`)
+	definerRe      = regexp.MustCompile("(?i)\\bDEFINER\\s*=\\s*(`[^`]*`@`[^`]*`|'[^']*'@'[^']*'|\\S+)")
+)
+
+// Lint checks the dump read from r.
+func (l *Linter) Lint(r io.Reader) (*LintReport, error) {
+	maxAllowedPacket := l.MaxAllowedPacket
+	if maxAllowedPacket == 0 {
+		maxAllowedPacket = defaultMaxAllowedPacket
+	}
+	var target *serverVersion
+	if l.TargetVersion != "" {
+		v := parseServerVersion(l.TargetVersion)
+		target = &v
+	}
+
+	report := &LintReport{Issues: []LintIssue{}}
+	add := func(st *statement, severity LintSeverity, check, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, LintIssue{
+			Line:     st.Line,
+			Severity: severity,
+			Check:    check,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	scanner := newStatementScanner(r)
+	section, names := "", ""
+	for {
+		st, err := scanner.Next()
+		if err == io.EOF {
+			return report, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if table := sectionTable(st.Comments); table != "" {
+			section = table
+		}
+		if st.SQL == "" {
+			continue
+		}
+		report.Statements++
+
+		if st.Unterminated {
+			add(st, LintError, LintCheckTerminator, "statement is not terminated by %s", scanner.delimiter)
+		}
+		if len(st.SQL)+1 > maxAllowedPacket {
+			add(st, LintError, LintCheckPacket, "statement of %d bytes exceeds %d", len(st.SQL)+1, maxAllowedPacket)
+		}
+
+		if m := setNamesRe.FindStringSubmatch(st.SQL); m != nil {
+			names = strings.ToLower(m[1])
+		}
+		owner := strings.TrimSuffix(section, "/data")
+		if name := statementTable(st.SQL); name != "" && owner != "" && !strings.Contains(owner, "/") && name != owner {
+			add(st, LintError, LintCheckIdentifier, "statement for `%s` in the section of `%s`", name, owner)
+		}
+		if m := tableCharsetRe.FindStringSubmatch(st.SQL); m != nil && names != "" && names != "utf8mb4" && !strings.EqualFold(m[1], names) {
+			add(st, LintWarning, LintCheckCharset, "table charset %s is read with SET NAMES %s", m[1], names)
+		}
+		if target != nil && (target.MariaDB || !target.atLeast(8, 0)) {
+			if m := uca900CollationRe.FindString(st.SQL); m != "" {
+				add(st, LintError, LintCheckCharset, "collation %s is unknown to %s", m, l.TargetVersion)
+			}
+		}
+		if m := definerRe.FindStringSubmatch(st.SQL); m != nil {
+			add(st, LintWarning, LintCheckDefiner, "DEFINER=%s needs the account and SET_USER_ID or SUPER on the target", m[1])
+		}
+	}
+}
+
+// statementTable returns the table created or filled by a CREATE TABLE,
+// CREATE VIEW or INSERT statement
+func statementTable(sql string) string {
+	if m := insertRe.FindStringIndex(sql); m != nil {
+		p := &valueParser{s: sql[m[1]:]}
+		name, err := p.identifier()
+		for err == nil && p.consume(".") {
+			name, err = p.identifier()
+		}
+		if err != nil {
+			return ""
+		}
+		return name
+	}
+	if m := createTableRe.FindStringSubmatch(sql); m != nil {
+		return unquoteName(m[1])
+	}
+	if m := createViewRe.FindStringSubmatch(sql); m != nil {
+		return unquoteName(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lintDump = `-- Server version	8.0.34
+
+/*!40101 SET NAMES latin1 */;
+
+--
+-- Table structure for table ` + "`a`" + `
+--
+
+CREATE TABLE ` + "`a`" + ` (id int) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+--
+-- Dumping data for table ` + "`a`" + `
+--
+
+INSERT INTO ` + "`b`" + ` VALUES (1);
+
+--
+-- View structure for view ` + "`v`" + `
+--
+
+CREATE ALGORITHM=UNDEFINED DEFINER=` + "`root`@`%`" + ` SQL SECURITY DEFINER VIEW ` + "`v`" + ` AS select 1 AS ` + "`1`" + `;
+INSERT INTO ` + "`v`" + ` VALUES ('` + "0123456789" + `')
+`
+
+func TestLint(t *testing.T) {
+	report, err := (&Linter{MaxAllowedPacket: 94, TargetVersion: "5.7"}).Lint(strings.NewReader(lintDump))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, report.Statements)
+	assert.False(t, report.Passed())
+
+	var issues []string
+	for _, issue := range report.Issues {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"line 9: warning: charset: table charset utf8mb4 is read with SET NAMES latin1",
+		"line 9: error: charset: collation utf8mb4_0900_ai_ci is unknown to 5.7",
+		"line 15: error: identifier: statement for `b` in the section of `a`",
+		"line 21: error: max_allowed_packet: statement of 95 bytes exceeds 94",
+		"line 21: warning: definer: DEFINER=`root`@`%` needs the account and SET_USER_ID or SUPER on the target",
+		"line 22: error: terminator: statement is not terminated by ;",
+	}, issues)
+}
+
+func TestLintClean(t *testing.T) {
+	report, err := Lint(strings.NewReader(resumeDump))
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+	assert.Equal(t, []LintIssue{}, report.Issues)
+	assert.Equal(t, 5, report.Statements)
+}
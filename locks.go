@@ -0,0 +1,101 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+)
+
+// tableLocks are the LOCK TABLES of a dump. They are held on a connection of
+// their own, UNLOCK TABLES only releases the locks of the session that took
+// them and a connection of the pool could be any other.
+type tableLocks struct {
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// lockTables takes LOCK TABLES ... READ on the tables until unlock is called
+func (data *Data) lockTables(tables []*table) (*tableLocks, error) {
+	ctx := data.context()
+	conn, err := data.Connection.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, lockTablesSQL(tables)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	locks := &tableLocks{conn: conn}
+
+	s := data.root().state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks == nil {
+		s.locks = make(map[*tableLocks]bool)
+	}
+	s.locks[locks] = true
+	return locks, nil
+}
+
+// unlock releases locks, deferred by dump so it runs on errors and panics too
+func (data *Data) unlock(locks *tableLocks) error {
+	if locks == nil {
+		return nil
+	}
+	s := data.root().state()
+	s.mu.Lock()
+	delete(s.locks, locks)
+	s.mu.Unlock()
+	return locks.release()
+}
+
+// release unlocks the tables once, whatever the state of the context of the
+// dump. A connection UNLOCK TABLES failed on is closed instead of going back
+// to the pool, the server releases the locks with the session.
+func (l *tableLocks) release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+
+	_, err := conn.ExecContext(context.Background(), "UNLOCK TABLES")
+	if err != nil {
+		conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+	}
+	conn.Close()
+	return err
+}
+
+// ForceUnlock releases the LOCK TABLES held by the running dumps of data right
+// away, like from a signal handler when a dump hangs. The dumps go on without
+// the locks. It returns the first error of UNLOCK TABLES, the connections are
+// closed in that case, which releases their locks as well.
+func (data *Data) ForceUnlock() error {
+	s := data.root().state()
+	s.mu.Lock()
+	locks := s.locks
+	s.locks = nil
+	s.mu.Unlock()
+
+	var first error
+	for l := range locks {
+		if err := l.release(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// root is the Data the run was started from
+func (data *Data) root() *Data {
+	for data.parent != nil {
+		data = data.parent
+	}
+	return data
+}
@@ -0,0 +1,105 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockLockedDump expects a LockTables dump of the test table up to its rows
+func mockLockedDump(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("test", "BASE TABLE"))
+	mock.ExpectExec("^LOCK TABLES `test` READ /\\*!32311 LOCAL \\*/$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("^SHOW CREATE TABLE `test`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("test", "CREATE TABLE `test` (`id` int)"))
+}
+
+func TestDumpUnlocksTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockLockedDump(mock)
+	mockTableSelect(mock, "test")
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	data := &Data{Connection: db, Out: &bytes.Buffer{}, LockTables: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpUnlocksTablesOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockLockedDump(mock)
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnError(errors.New("Error 2013: Lost connection to MySQL server during query"))
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	data := &Data{Connection: db, Out: &bytes.Buffer{}, LockTables: true}
+	assert.Error(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpUnlocksTablesOnPanic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockLockedDump(mock)
+	mockTableSelect(mock, "test")
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	data := &Data{
+		Connection: db,
+		Out:        &bytes.Buffer{},
+		LockTables: true,
+		Masks: map[string]Masker{"test.email": MaskFunc(func(string) string {
+			panic("masker bug")
+		})},
+	}
+	err = data.Dump()
+	assert.EqualError(t, err, "reading the rows of `test`: masker bug")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestForceUnlock(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	run := data.newRun()
+	mock.ExpectExec("^LOCK TABLES `test` READ /\\*!32311 LOCAL \\*/$").WillReturnResult(sqlmock.NewResult(0, 0))
+	locks, err := run.lockTables([]*table{run.createTable("test", false)})
+	assert.NoError(t, err)
+
+	// The locks are released once, by whichever comes first
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NoError(t, data.ForceUnlock())
+	assert.NoError(t, run.unlock(locks))
+	assert.NoError(t, data.ForceUnlock())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestUnlockFailure(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectExec("^LOCK TABLES `test` READ /\\*!32311 LOCAL \\*/$").WillReturnResult(sqlmock.NewResult(0, 0))
+	locks, err := data.lockTables([]*table{data.createTable("test", false)})
+	assert.NoError(t, err)
+
+	failure := errors.New("Error 2013: Lost connection to MySQL server during query")
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnError(failure)
+	assert.Equal(t, failure, data.unlock(locks))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
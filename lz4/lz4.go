@@ -0,0 +1,63 @@
+// Package lz4 registers the lz4 codec, compressing dumps with the LZ4 frame
+// format. It compresses less than gzip or zstd but at a speed that keeps up
+// with the fastest disks and networks. Importing the package registers the
+// codec at the fast level, for the formats of the Handler like sql.lz4 and
+// tar.lz4:
+//
+//	import _ "github.com/jamf/go-mysqldump/lz4"
+//
+// Set it as the Codec of Compress or Files to compress a dump with it, at
+// another level if need be:
+//
+//	data.Codec = lz4.NewCodec(9)
+//	data.Compress = true
+//
+// It lives in a module of its own so that the dependency on
+// github.com/pierrec/lz4 is only taken by the programs using it.
+package lz4
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/pierrec/lz4/v4"
+)
+
+func init() {
+	mysqldump.RegisterCodec(NewCodec(0))
+}
+
+// NewCodec returns the lz4 codec compressing at a level of the lz4 command,
+// from 1 to 9, or its fast default if 0.
+func NewCodec(level int) mysqldump.Codec {
+	if level < 0 {
+		level = 0
+	}
+	if level > 9 {
+		level = 9
+	}
+	c := codec{level: lz4.Fast}
+	if level > 0 {
+		c.level = lz4.CompressionLevel(1 << uint(8+level))
+	}
+	return c
+}
+
+type codec struct {
+	level lz4.CompressionLevel
+}
+
+func (codec) Name() string { return "lz4" }
+
+func (c codec) Wrap(w io.Writer) (io.WriteCloser, error) {
+	zw := lz4.NewWriter(w)
+	if err := zw.Apply(lz4.CompressionLevelOption(c.level)); err != nil {
+		return nil, err
+	}
+	return zw, nil
+}
+
+func (codec) Unwrap(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(lz4.NewReader(r)), nil
+}
@@ -0,0 +1,134 @@
+package mysqldump
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Manifest describes the artifacts written next to a dump.
+type Manifest struct {
+	DumpVersion   string             `json:"dumpVersion"`
+	ServerVersion string             `json:"serverVersion"`
+	Database      string             `json:"database,omitempty"`
+	Snapshot      *Snapshot          `json:"snapshot,omitempty"`
+	Binlog        *BinlogCoordinates `json:"binlog,omitempty"`
+	Tables        []ManifestTable    `json:"tables,omitempty"`
+	Files         []ManifestFile     `json:"files,omitempty"`
+	Blobs         []ManifestBlob     `json:"blobs,omitempty"`
+
+	mu sync.Mutex
+}
+
+// ManifestTable records a dumped table or view.
+type ManifestTable struct {
+	Name      string         `json:"name"`
+	View      bool           `json:"view,omitempty"`
+	Rows      int64          `json:"rows"`
+	Checksum  string         `json:"checksum,omitempty"`
+	DataStart *TablePosition `json:"dataStart,omitempty"`
+	DataEnd   *TablePosition `json:"dataEnd,omitempty"`
+	Sections  []ByteRange    `json:"sections,omitempty"`
+}
+
+// ByteRange is where a section of a table lies in a dump written to Out,
+// counted from the start of the dump before Compress. A table has one
+// section with its structure and rows, or one for each when the schema is
+// written apart from the data.
+type ByteRange struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// TablePosition is where a dump stood when it started or finished reading the
+// rows of a table: the time and, for dumps recording a GTID set, the GTID set
+// executed by the server at that time. The rows themselves are the ones of the
+// snapshot, the positions tell which transactions committed around the reads.
+type TablePosition struct {
+	Time    time.Time `json:"time"`
+	GTIDSet string    `json:"gtidSet,omitempty"`
+}
+
+// ManifestFile records a file of a multi-file dump.
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Codec  string `json:"codec,omitempty"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestBlob records a binary value that was written to its own file
+// instead of being inlined in the dump.
+type ManifestBlob struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Key    string `json:"key"`
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+const manifestFileName = "manifest.json"
+
+// ReadManifest decodes a manifest written along with a dump.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Manifest returns the manifest of the last dump, or nil before the first one.
+func (data *Data) Manifest() *Manifest {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.manifest
+}
+
+func (m *Manifest) addTable(table *table, checksum string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Tables = append(m.Tables, ManifestTable{
+		Name:      table.Name,
+		View:      table.isView,
+		Rows:      int64(table.row),
+		Checksum:  checksum,
+		DataStart: table.dataStart,
+		DataEnd:   table.dataEnd,
+		Sections:  table.sections,
+	})
+}
+
+func (m *Manifest) addFile(file ManifestFile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files = append(m.Files, file)
+}
+
+func (m *Manifest) addBlob(blob ManifestBlob) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Blobs = append(m.Blobs, blob)
+}
+
+// writeFile stores the manifest as manifest.json inside dir
+func (m *Manifest) writeFile(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.encode(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
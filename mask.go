@@ -0,0 +1,122 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Masker replaces the value of a column before it is written to the dump.
+// NULL values are not masked. The masked value is written as a string, which
+// MySQL converts for numeric columns on restore.
+type Masker interface {
+	Mask(value string) string
+}
+
+// MaskFunc adapts a function to a Masker.
+type MaskFunc func(value string) string
+
+// Mask calls f(value).
+func (f MaskFunc) Mask(value string) string {
+	return f(value)
+}
+
+/*
+MaskMapping keeps the masking of key-like values consistent: equal values get
+equal masked values in every column and table it masks, and different values
+never share a masked value, so joins on masked keys and unique indexes survive.
+Use the same MaskMapping for all columns holding the same keys, like users.email
+and orders.user_email. It remembers every value it masked during its lifetime.
+
+	Masker: Produces the masked values, collisions are masked again with a counter appended to the value
+	Fold:   Treat values differing only in case as equal, like the _ci collations do
+*/
+type MaskMapping struct {
+	Masker Masker
+	Fold   bool
+
+	mu     sync.Mutex
+	masked map[string]string
+	used   map[string]bool
+}
+
+// NewMaskMapping returns a MaskMapping over m.
+func NewMaskMapping(m Masker) *MaskMapping {
+	return &MaskMapping{Masker: m}
+}
+
+// Mask returns the masked value of value, the same one on every call.
+func (m *MaskMapping) Mask(value string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.masked == nil {
+		m.masked = map[string]string{}
+		m.used = map[string]bool{}
+	}
+
+	key := m.fold(value)
+	if masked, ok := m.masked[key]; ok {
+		return masked
+	}
+	masked := m.Masker.Mask(value)
+	for n := 1; m.used[m.fold(masked)]; n++ {
+		if n <= 16 {
+			masked = m.Masker.Mask(value + "#" + strconv.Itoa(n))
+		} else {
+			masked = m.Masker.Mask(value) + "-" + strconv.Itoa(n)
+		}
+	}
+	m.masked[key] = masked
+	m.used[m.fold(masked)] = true
+	return masked
+}
+
+func (m *MaskMapping) fold(value string) string {
+	if m.Fold {
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+// initMasks looks up the masker of every column, by table.column first and
+// *.column for the column in any table
+func (table *table) initMasks() {
+	if len(table.data.Masks) == 0 {
+		return
+	}
+	table.masks = make([]Masker, len(table.cols))
+	for i, col := range table.cols {
+		if m, ok := table.data.Masks[table.Name+"."+col]; ok {
+			table.masks[i] = m
+		} else if m, ok := table.data.Masks["*."+col]; ok {
+			table.masks[i] = m
+		}
+	}
+}
+
+func (table *table) masker(column int) Masker {
+	if column < len(table.masks) {
+		return table.masks[column]
+	}
+	return nil
+}
+
+// textValue returns a scanned value as the text a Masker receives, false for
+// NULL
+func textValue(value interface{}) (string, bool) {
+	switch s := value.(type) {
+	case *sql.NullString:
+		return s.String, s.Valid
+	case *sql.NullInt64:
+		return strconv.FormatInt(s.Int64, 10), s.Valid
+	case *sql.NullFloat64:
+		return strconv.FormatFloat(s.Float64, 'g', -1, 64), s.Valid
+	case *sql.RawBytes:
+		return string(*s), *s != nil
+	case nil:
+		return "", false
+	}
+	return fmt.Sprintf("%s", value), true
+}
@@ -0,0 +1,61 @@
+package mysqldump
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowBufferMasks(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+
+	data.Masks = map[string]Masker{
+		"test.name": MaskFunc(strings.ToUpper),
+		"*.id":      MaskFunc(func(v string) string { return v + "0" }),
+		"other.email": MaskFunc(func(string) string {
+			t.Fatal("expected masks of other tables to be ignored")
+			return ""
+		}),
+	}
+
+	table := data.createTable("test", false)
+	var rows []string
+	for table.Next() {
+		rows = append(rows, table.RowValues())
+	}
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{
+		"('10','test@test.de','TEST NAME 1')",
+		"('20','test2@test.de','TEST NAME 2')",
+	}, rows)
+}
+
+func TestMaskMapping(t *testing.T) {
+	// A masker with few outputs to force collisions
+	calls := 0
+	m := NewMaskMapping(MaskFunc(func(v string) string {
+		calls++
+		return "user" + strconv.Itoa(len(v)%2) + "@example.com"
+	}))
+	m.Fold = true
+
+	a := m.Mask("jane@example.com")
+	assert.Equal(t, a, m.Mask("jane@example.com"))
+	assert.Equal(t, a, m.Mask("Jane@Example.com"))
+	assert.Equal(t, 1, calls)
+
+	b := m.Mask("john.doe@example.com")
+	c := m.Mask("joan.doe@example.com")
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, b, c)
+	assert.NotEqual(t, a, c)
+	assert.Equal(t, c, m.Mask("JOAN.DOE@example.com"))
+}
@@ -0,0 +1,152 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// materializeViews fills a temporary table with the result of every view of
+// MaterializeViews, in the snapshot of the dump, and turns the view into a
+// table whose rows are read from it. The result of an expensive view is this
+// way computed once, however many queries read it. Views the Grants mode
+// skips for the CREATE TEMPORARY TABLES privilege are dumped as views.
+func (data *Data) materializeViews(tables []*table) error {
+	if len(data.MaterializeViews) == 0 {
+		return nil
+	}
+	found := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		found[table.Name] = true
+		if !table.isView || !data.isListed(data.MaterializeViews, table.Name) {
+			continue
+		}
+		source := "_mysqldump_materialized_" + strconv.Itoa(len(data.materialized))
+		created := false
+		if err := data.privileged("materializing view "+table.NameEsc(), func() error {
+			if _, err := data.tx.Exec("CREATE TEMPORARY TABLE `" + source + "` AS SELECT * FROM " + table.NameEsc()); err != nil {
+				return err
+			}
+			created = true
+			return nil
+		}); err != nil {
+			return err
+		}
+		if created {
+			data.materialized = append(data.materialized, source)
+			table.isView = false
+			table.source = source
+		}
+	}
+	for _, name := range data.MaterializeViews {
+		if !found[name] {
+			data.warn("view `" + name + "` of MaterializeViews not dumped, it is not part of the dump")
+		}
+	}
+	return nil
+}
+
+// viewsAsTables turns the views of ViewsAsTables into tables of their rows,
+// created from the types of the columns of the view. Unlike MaterializeViews
+// the view is evaluated by every query reading it, without the need of a
+// temporary table.
+func (data *Data) viewsAsTables(tables []*table) {
+	if len(data.ViewsAsTables) == 0 {
+		return
+	}
+	found := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		found[table.Name] = true
+		if table.isView && data.isListed(data.ViewsAsTables, table.Name) {
+			table.isView = false
+			table.asTable = true
+		}
+	}
+	for _, name := range data.ViewsAsTables {
+		if !found[name] {
+			data.warn("view `" + name + "` of ViewsAsTables not dumped, it is not part of the dump")
+		}
+	}
+}
+
+// viewTableSQL is the CREATE TABLE of a view dumped as a table, with a column
+// of the type and nullability of every dumped column of the view. Keys,
+// defaults and collations of the underlying tables are not part of it.
+func (table *table) viewTableSQL() (string, error) {
+	rows, err := table.metadataQuery("SHOW COLUMNS FROM " + table.NameEsc())
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	fieldIndex, typeIndex, nullIndex := -1, -1, -1
+	for i, col := range cols {
+		switch strings.ToLower(col) {
+		case "field":
+			fieldIndex = i
+		case "type":
+			typeIndex = i
+		case "null":
+			nullIndex = i
+		}
+	}
+	if fieldIndex < 0 || typeIndex < 0 {
+		return "", errors.New("database column information is malformed")
+	}
+
+	info := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range info {
+		scans[i] = &info[i]
+	}
+	var defs []string
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return "", err
+		}
+		if table.isSkippedColumn(info[fieldIndex].String) {
+			continue
+		}
+		def := "  `" + strings.Replace(info[fieldIndex].String, "`", "``", -1) + "` " + info[typeIndex].String
+		if nullIndex >= 0 && info[nullIndex].String == "NO" {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "CREATE TABLE " + table.NameEsc() + " (\n" + strings.Join(defs, ",\n") + "\n)", nil
+}
+
+// dropMaterialized drops the temporary tables of the materialized views,
+// which would otherwise outlive the dump on the connection given back to the
+// pool
+func (data *Data) dropMaterialized() {
+	for _, source := range data.materialized {
+		data.tx.Exec("DROP TEMPORARY TABLE IF EXISTS `" + source + "`")
+	}
+	data.materialized = nil
+}
+
+// sourceName is the table the structure and rows of the table are read from
+func (table *table) sourceName() string {
+	if table.source != "" {
+		return table.source
+	}
+	return table.Name
+}
+
+// materializedDDL turns the CREATE TEMPORARY TABLE of the source of a
+// materialized view into the CREATE TABLE of the view
+func (table *table) materializedDDL(create string) string {
+	if table.source == "" {
+		return create
+	}
+	return strings.Replace(create, "CREATE TEMPORARY TABLE `"+table.source+"`", "CREATE TABLE "+table.NameEsc(), 1)
+}
@@ -0,0 +1,101 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpMaterializeViews(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("totals", "VIEW").AddRow("other", "VIEW"))
+	mock.ExpectExec("^CREATE TEMPORARY TABLE `_mysqldump_materialized_0` AS SELECT \\* FROM `totals`$").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("^SHOW CREATE TABLE `_mysqldump_materialized_0`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+		AddRow("_mysqldump_materialized_0", "CREATE TEMPORARY TABLE `_mysqldump_materialized_0` (\n  `day` date DEFAULT NULL,\n  `total` decimal(32,2) DEFAULT NULL\n) ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `totals`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("day", "").AddRow("total", ""))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `day`, `total` FROM `_mysqldump_materialized_0` AS `totals`")).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("day").OfType("DATE", "").Nullable(true),
+			sqlmock.NewColumn("total").OfType("DECIMAL", "").Nullable(true),
+		).AddRow("2024-01-01", "10.50").AddRow("2024-01-02", "3.00"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `other`$").WillReturnRows(sqlmock.NewRows([]string{"View", "Create View", "character_set_client", "collation_connection"}).
+		AddRow("other", "CREATE VIEW `other` AS select 1 AS `1`", "utf8mb4", "utf8mb4_0900_ai_ci"))
+	mock.ExpectExec("^DROP TEMPORARY TABLE IF EXISTS `_mysqldump_materialized_0`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, MaterializeViews: []string{"totals", "missing"}}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := strings.Replace(buf.String(), "`", "~", -1)
+	assert.Contains(t, out, "CREATE TABLE ~totals~ (\n  ~day~ date DEFAULT NULL,\n  ~total~ decimal(32,2) DEFAULT NULL\n) ENGINE=InnoDB;")
+	assert.Contains(t, out, "INSERT INTO ~totals~ (~day~, ~total~) VALUES ('2024-01-01','10.50'),('2024-01-02','3.00');")
+	assert.NotContains(t, out, "_mysqldump_materialized_")
+	assert.Contains(t, out, "CREATE VIEW ~other~")
+	assert.Equal(t, []string{"view `missing` of MaterializeViews not dumped, it is not part of the dump"}, data.Warnings())
+}
+
+func TestDumpMaterializeViewsMinimalGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("totals", "VIEW"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `totals`$").WillReturnRows(sqlmock.NewRows([]string{"View", "Create View", "character_set_client", "collation_connection"}).
+		AddRow("totals", "CREATE VIEW `totals` AS select 1 AS `1`", "utf8mb4", "utf8mb4_0900_ai_ci"))
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}, MaterializeViews: []string{"totals"}, Grants: mysqldump.GrantsMinimal}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []string{"materializing view `totals` skipped to work with SELECT privileges only"}, data.Warnings())
+}
+
+func TestDumpViewsAsTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	columns := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).
+			AddRow("id", "int", "NO", "", "0", "").
+			AddRow("customer", "varchar(64)", "YES", "", nil, "")
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("order_view", "VIEW"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `order_view`$").WillReturnRows(columns())
+	mock.ExpectQuery("^SHOW COLUMNS FROM `order_view`$").WillReturnRows(columns())
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `customer` FROM `order_view`")).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT", 0),
+			sqlmock.NewColumn("customer").OfType("VARCHAR", "").Nullable(true),
+		).AddRow(1, "ACME").AddRow(2, nil))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, ViewsAsTables: []string{"order_view"}, Checksums: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := strings.Replace(buf.String(), "`", "~", -1)
+	assert.Contains(t, out, "-- Table structure for table ~order_view~")
+	assert.Contains(t, out, "CREATE TABLE ~order_view~ (\n  ~id~ int NOT NULL,\n  ~customer~ varchar(64)\n);")
+	assert.Contains(t, out, "INSERT INTO ~order_view~ (~id~, ~customer~) VALUES (1,'ACME'),(2,NULL);")
+	assert.NotContains(t, out, "CREATE VIEW")
+	assert.Empty(t, data.Warnings())
+}
@@ -0,0 +1,168 @@
+package mysqldump
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// memory is the budget of MaxMemory, shared by the runs started from the same
+// Data. Bytes are taken by the rows added to an INSERT statement and given
+// back as the statement is written.
+type memory struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	max  int64
+	used int64
+}
+
+// memory returns the budget of the runs of data, nil without MaxMemory
+func (data *Data) memory() *memory {
+	if data.MaxMemory <= 0 {
+		return nil
+	}
+	s := data.root().state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memory == nil {
+		s.memory = &memory{}
+		s.memory.cond = sync.NewCond(&s.memory.mu)
+	}
+	s.memory.max = data.MaxMemory
+	return s.memory
+}
+
+// MemoryInUse returns the bytes of rows the running dumps of data have
+// buffered and not written yet. It is only tracked with MaxMemory.
+func (data *Data) MemoryInUse() int64 {
+	m := data.memory()
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.used
+}
+
+// tryAcquire takes n bytes if they fit in the budget
+func (m *memory) tryAcquire(n int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used > 0 && m.used+n > m.max {
+		return false
+	}
+	m.used += n
+	return true
+}
+
+// acquire takes n bytes, waiting for them to fit in the budget. A row larger
+// than the budget is let through once nothing else is buffered, and so is a
+// row while ahead reports that the others holding the budget wait for it.
+func (m *memory) acquire(n int64, ahead func() bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.used > 0 && m.used+n > m.max && !ahead() {
+		m.cond.Wait()
+	}
+	m.used += n
+}
+
+func (m *memory) release(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.used -= n
+	m.cond.Broadcast()
+}
+
+// wake has the waiting rows check again whether they are ahead, nothing on a
+// nil budget
+func (m *memory) wake() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cond.Broadcast()
+}
+
+// tryBuffer takes the memory of a row added to the INSERT statement of table
+// if it fits in the budget
+func (table *table) tryBuffer(n int) bool {
+	m := table.data.budget
+	if m == nil {
+		return true
+	}
+	if !m.tryAcquire(int64(n)) {
+		return false
+	}
+	atomic.AddInt64(&table.buffered, int64(n))
+	return true
+}
+
+// buffer takes the memory of a row starting an INSERT statement, waiting for
+// the written statements of this and the other dumps to give it back
+func (table *table) buffer(n int) {
+	m := table.data.budget
+	if m == nil {
+		return
+	}
+	m.acquire(int64(n), table.ahead)
+	atomic.AddInt64(&table.buffered, int64(n))
+}
+
+// ahead reports whether the output of table is at the head of the merged
+// output of Concurrency and DumpDatabases. The parts behind it wait for it to
+// be written while they hold memory, so it takes memory past MaxMemory rather
+// than wait for them.
+func (table *table) ahead() bool {
+	merged := false
+	for _, head := range []func() bool{table.atHead, table.data.atHead} {
+		if head != nil {
+			if !head() {
+				return false
+			}
+			merged = true
+		}
+	}
+	return merged
+}
+
+// releaseAll gives back the memory table still holds
+func (table *table) releaseAll() {
+	if n := atomic.SwapInt64(&table.buffered, 0); n > 0 {
+		table.data.budget.release(n)
+	}
+}
+
+// releasing wraps w to give back the memory of the rows of table as they are
+// written
+func (table *table) releasing(w io.Writer) io.Writer {
+	if table.data.budget == nil {
+		return w
+	}
+	return &releaseWriter{w: w, table: table}
+}
+
+type releaseWriter struct {
+	w     io.Writer
+	table *table
+}
+
+func (w *releaseWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	for {
+		held := atomic.LoadInt64(&w.table.buffered)
+		release := int64(n)
+		if release > held {
+			release = held
+		}
+		if release == 0 {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&w.table.buffered, held, held-release) {
+			w.table.data.budget.release(release)
+			break
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,129 @@
+package mysqldump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	data := &Data{MaxMemory: 10}
+	m := data.memory()
+	assert.Same(t, m, (&Data{MaxMemory: 10, parent: data}).memory())
+
+	behind := func() bool { return false }
+	m.acquire(6, behind)
+	assert.False(t, m.tryAcquire(6))
+
+	acquired := make(chan struct{})
+	go func() {
+		m.acquire(6, behind)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond MaxMemory")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.release(6)
+	<-acquired
+	assert.Equal(t, int64(6), data.MemoryInUse())
+
+	// A row larger than the budget goes through on its own
+	m.release(6)
+	assert.True(t, m.tryAcquire(100))
+	m.release(100)
+	assert.Zero(t, data.MemoryInUse())
+}
+
+func TestMemoryDisabled(t *testing.T) {
+	data := &Data{}
+	assert.Nil(t, data.memory())
+	assert.Zero(t, data.MemoryInUse())
+}
+
+func TestWriteTableDataMaxMemory(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.MaxAllowedPacket = defaultMaxAllowedPacket
+	data.MaxMemory = 10
+	data.budget = data.memory()
+	assert.NoError(t, data.getTemplates())
+	mockCreateTable(mock, "test")
+	mockTableSelect(mock, "test")
+
+	// Every row is larger than MaxMemory, each one waits for the statement
+	// before it to be written
+	var buf bytes.Buffer
+	assert.NoError(t, data.writeTableData(&buf, data.createTable("test", false)))
+	assert.Equal(t, 2, strings.Count(buf.String(), "INSERT INTO"))
+	assert.Contains(t, buf.String(), "INSERT INTO `test` (`id`, `email`, `name`) VALUES (2,'test2@test.de','Test Name 2');")
+	assert.Zero(t, data.MemoryInUse())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestHeartbeatMemory(t *testing.T) {
+	h := Heartbeat{Time: time.Unix(0, 0), Table: "test", Rows: 2, Memory: 512}
+	assert.Equal(t, "Heartbeat 1970-01-01T00:00:00Z: 2 rows of `test` after 0s, 512 bytes buffered", h.String())
+}
+
+func TestDumpConcurrencyMaxMemory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectExec(`^FLUSH TABLES WITH READ LOCK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec(`^SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+			AddRow("a", "BASE TABLE").AddRow("b", "BASE TABLE"))
+	for _, name := range []string{"a", "b"} {
+		mockCreateTable(mock, name)
+		mock.ExpectQuery("^SHOW COLUMNS FROM `" + name + "`$").WillReturnRows(
+			sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int(11)", "NO", "PRI", nil, ""))
+		rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0))
+		for id := 1; id <= 200; id++ {
+			rows.AddRow(id)
+		}
+		// b fills its merge buffer holding memory while a is still read
+		if name == "a" {
+			mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`").WillDelayFor(20 * time.Millisecond).WillReturnRows(rows)
+		} else {
+			mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`").WillReturnRows(rows)
+		}
+	}
+
+	var buf bytes.Buffer
+	data := &Data{Connection: db, Out: &buf, Concurrency: 2, MaxAllowedPacket: 4096, MaxMemory: 100, MergeBuffer: 500}
+	done := make(chan error, 1)
+	go func() {
+		done <- data.Dump()
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dump deadlocked on MaxMemory")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	a, b := strings.Index(out, "INSERT INTO `a`"), strings.Index(out, "INSERT INTO `b`")
+	assert.True(t, a >= 0 && b > a, out)
+	assert.Equal(t, 400, strings.Count(out, "),(")+strings.Count(out, "INSERT INTO"))
+	assert.Zero(t, data.MemoryInUse())
+}
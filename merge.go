@@ -0,0 +1,141 @@
+package mysqldump
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// MergePolicy tells what a dump does about the underlying tables of MERGE
+// tables. The rows of a MERGE table are never dumped, they are the rows of
+// its underlying tables and would be restored twice, or fail to restore with
+// INSERT_METHOD=NO.
+type MergePolicy string
+
+const (
+	// MergeSkip dumps the definition of MERGE tables only, their rows are
+	// dumped with the underlying tables if those are part of the dump.
+	MergeSkip MergePolicy = ""
+	// MergeFollow adds the underlying tables of MERGE tables to the dump
+	// when IncludeTables leaves them out, so the MERGE tables restore with
+	// their rows. Tables in IgnoreTables stay out of the dump.
+	MergeFollow MergePolicy = "follow"
+)
+
+// ErrUnknownMergePolicy is returned for policies that don't exist.
+var ErrUnknownMergePolicy = errors.New("unknown merge policy")
+
+func (data *Data) checkMergePolicy() error {
+	switch data.MergeTables {
+	case MergeSkip, MergeFollow:
+		return nil
+	}
+	return ErrUnknownMergePolicy
+}
+
+var (
+	mergeEngineRe = regexp.MustCompile("(?i)\\bENGINE=(MRG_MyISAM|MERGE)\\b")
+	mergeUnionRe  = regexp.MustCompile("(?i)\\bUNION=\\(([^)]*)\\)")
+)
+
+// isMerge reports whether the table is a MERGE table, from its engine when
+// read from information_schema or else from its definition
+func (table *table) isMerge() (bool, error) {
+	if table.isView || table.query != "" {
+		return false, nil
+	}
+	if table.engine != "" {
+		return strings.EqualFold(table.engine, "MRG_MyISAM") || strings.EqualFold(table.engine, "MERGE"), nil
+	}
+	create, err := table.CreateSQL()
+	if err != nil {
+		return false, err
+	}
+	return mergeEngineRe.MatchString(create), nil
+}
+
+// mergeUnion returns the underlying tables of a MERGE table, the ones of
+// other databases, like `db`.`t`, separately
+func (table *table) mergeUnion() (names []string, others []string, err error) {
+	create, err := table.CreateSQL()
+	if err != nil {
+		return nil, nil, err
+	}
+	m := mergeUnionRe.FindStringSubmatch(create)
+	if m == nil {
+		return nil, nil, nil
+	}
+	for _, item := range strings.Split(m[1], ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case strings.Contains(item, "`.`"):
+			others = append(others, item)
+		default:
+			names = append(names, strings.Trim(item, "`"))
+		}
+	}
+	return names, others, nil
+}
+
+// checkMerge leaves out the rows of MERGE tables with a warning
+func (table *table) checkMerge() error {
+	merge, err := table.isMerge()
+	if err != nil || !merge {
+		return err
+	}
+	table.mergeSkipped = true
+	table.data.warn("rows of MERGE table " + table.NameEsc() + " not dumped, they are the rows of its underlying tables")
+	return nil
+}
+
+// followMerges adds the underlying tables of the MERGE tables that are
+// missing from the dump with MergeFollow
+func (data *Data) followMerges(tables []*table) ([]*table, error) {
+	if data.MergeTables != MergeFollow {
+		return tables, nil
+	}
+	dumped := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		dumped[table.Name] = true
+	}
+	for _, table := range tables {
+		merge, err := table.isMerge()
+		if err != nil {
+			return nil, err
+		}
+		if !merge {
+			continue
+		}
+		names, others, err := table.mergeUnion()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			switch {
+			case dumped[name]:
+			case data.isListed(data.IgnoreTables, name):
+				data.warn("underlying table `" + name + "` of MERGE table " + table.NameEsc() + " not dumped, it is in IgnoreTables")
+			case matchesAny(data.excludePatterns, name):
+				data.warn("underlying table `" + name + "` of MERGE table " + table.NameEsc() + " not dumped, it matches ExcludePatterns")
+			default:
+				dumped[name] = true
+				tables = append(tables, data.createTable(name, false))
+				data.warn("underlying table `" + name + "` of MERGE table " + table.NameEsc() + " added to the dump")
+			}
+		}
+		for _, other := range others {
+			data.warn("underlying table " + other + " of MERGE table " + table.NameEsc() + " not dumped, it is in another database")
+		}
+	}
+	return tables, nil
+}
+
+func (data *Data) isListed(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
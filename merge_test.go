@@ -0,0 +1,97 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const mergeCreateSQL = "CREATE TABLE `logs` (`id` int(11) NOT NULL) ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 INSERT_METHOD=LAST UNION=(`logs_2023`,`logs_2024`,`archive`.`logs_2022`)"
+
+func mockMergeCreate(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("^SHOW CREATE TABLE `logs`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("logs", mergeCreateSQL))
+}
+
+func TestWriteMergeTable(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.MaxAllowedPacket = defaultMaxAllowedPacket
+	assert.NoError(t, data.getTemplates())
+	mockMergeCreate(mock)
+
+	// Neither the columns nor the rows are read
+	var buf bytes.Buffer
+	table := data.createTable("logs", false)
+	assert.NoError(t, data.writeTableTo(&buf, table))
+	assert.Contains(t, buf.String(), mergeCreateSQL)
+	assert.NotContains(t, buf.String(), "INSERT INTO")
+	assert.Zero(t, table.row)
+	assert.Equal(t, []string{"rows of MERGE table `logs` not dumped, they are the rows of its underlying tables"}, data.warnings)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestIsMergeEngine(t *testing.T) {
+	data := &Data{}
+	table := data.createTable("logs", false)
+	table.engine = "MRG_MYISAM"
+	merge, err := table.isMerge()
+	assert.NoError(t, err)
+	assert.True(t, merge)
+
+	table.engine = "MyISAM"
+	merge, err = table.isMerge()
+	assert.NoError(t, err)
+	assert.False(t, merge)
+}
+
+func TestMergeUnion(t *testing.T) {
+	data := &Data{}
+	table := data.createTable("logs", false)
+	table.createSQL = mergeCreateSQL
+	names, others, err := table.mergeUnion()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"logs_2023", "logs_2024"}, names)
+	assert.Equal(t, []string{"`archive`.`logs_2022`"}, others)
+}
+
+func TestFollowMerges(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.MergeTables = MergeFollow
+	data.IncludeTables = []string{"logs"}
+	data.IgnoreTables = []string{"logs_2024"}
+	mockMergeCreate(mock)
+
+	tables, err := data.followMerges([]*table{data.createTable("logs", false)})
+	assert.NoError(t, err)
+	names := []string{}
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"logs", "logs_2023"}, names)
+	assert.Equal(t, []string{
+		"underlying table `logs_2023` of MERGE table `logs` added to the dump",
+		"underlying table `logs_2024` of MERGE table `logs` not dumped, it is in IgnoreTables",
+		"underlying table `archive`.`logs_2022` of MERGE table `logs` not dumped, it is in another database",
+	}, data.warnings)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestFollowMergesSkip(t *testing.T) {
+	data := &Data{}
+	tables := []*table{data.createTable("logs", false)}
+	followed, err := data.followMerges(tables)
+	assert.NoError(t, err)
+	assert.Equal(t, tables, followed)
+}
+
+func TestDumpUnknownMergePolicy(t *testing.T) {
+	assert.Equal(t, ErrUnknownMergePolicy, (&Data{MergeTables: "union"}).Dump())
+}
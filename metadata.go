@@ -0,0 +1,83 @@
+package mysqldump
+
+import "database/sql"
+
+// loadColumns reads the columns of all tables in a single query instead of a
+// SHOW COLUMNS per table. Every later phase uses the result kept on the
+// tables. The CREATE statements are not part of information_schema, they are
+// still read with a SHOW CREATE TABLE per table, once, and kept on the table by
+// CreateSQL like the columns.
+func (data *Data) loadColumns(tables []*table) error {
+	byName := make(map[string]*table, len(tables))
+	for _, table := range tables {
+		if !table.isView {
+			byName[table.Name] = table
+			table.cols = []string{}
+			table.colTypes = []string{}
+			table.pk = nil
+			table.keySkipped = false
+			table.columnsLoaded = true
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+
+	rows, err := data.metadataQuery("SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName, column, columnType, key, extra sql.NullString
+		if err := rows.Scan(&tableName, &column, &columnType, &key, &extra); err != nil {
+			return err
+		}
+		table, ok := byName[tableName.String]
+		if !ok || isGeneratedColumn(extra.String) {
+			continue
+		}
+		table.addColumn(column.String, columnType.String, key.String == "PRI")
+	}
+	return rows.Err()
+}
+
+// loadKeys reads the order of the columns of the primary keys of several
+// columns, in a single query of STATISTICS by SEQ_IN_INDEX, for the tables
+// loadColumns read
+func (data *Data) loadKeys(tables []*table) error {
+	byName := make(map[string]*table, len(tables))
+	for _, table := range tables {
+		if !table.isView && len(table.pk) > 1 {
+			byName[table.Name] = table
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+
+	rows, err := data.metadataQuery("SELECT TABLE_NAME, COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND INDEX_NAME = 'PRIMARY' ORDER BY TABLE_NAME, SEQ_IN_INDEX")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := make(map[string][]string, len(byName))
+	for rows.Next() {
+		var tableName, column sql.NullString
+		if err := rows.Scan(&tableName, &column); err != nil {
+			return err
+		}
+		if _, ok := byName[tableName.String]; ok {
+			keys[tableName.String] = append(keys[tableName.String], column.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for name, columns := range keys {
+		byName[name].orderKey(columns)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpInformationSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	tablesRows := sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE", "ENGINE", "TABLE_ROWS"}).
+		AddRow("Test_Table", "BASE TABLE", "InnoDB", 2)
+
+	columnsRows := sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_KEY", "EXTRA"}).
+		AddRow("Other_Table", "x", "int", "", "").
+		AddRow("Test_Table", "id", "int(11)", "PRI", "auto_increment").
+		AddRow("Test_Table", "email", "char(60)", "", "").
+		AddRow("Test_Table", "name", "char(60)", "", "").
+		AddRow("Test_Table", "hash", "varchar(255)", "", "VIRTUAL GENERATED")
+
+	createTableRows := sqlmock.NewRowsWithColumnDefinition(c("Table", ""), c("Create Table", "")).
+		AddRow("Test_Table", "CREATE TABLE 'Test_Table' (`id` int(11) NOT NULL AUTO_INCREMENT,`email` char(60) DEFAULT NULL, `name` char(60), PRIMARY KEY (`id`))ENGINE=InnoDB DEFAULT CHARSET=latin1")
+
+	createTableValueRows := sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).
+		AddRow(1, nil, "Test Name 1").
+		AddRow(2, "test2@test.de", "Test Name 2")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("Version()", "")).AddRow("test_version"))
+	mock.ExpectQuery(`^SELECT TABLE_NAME, TABLE_TYPE, ENGINE, TABLE_ROWS FROM information_schema.TABLES`).WillReturnRows(tablesRows)
+	mock.ExpectQuery(`^SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS`).WillReturnRows(columnsRows)
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(createTableRows)
+	mock.ExpectQuery("^SELECT `id`, `email`, `name` FROM `Test_Table`$").WillReturnRows(createTableValueRows)
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection:           db,
+		Out:                  &buf,
+		UseInformationSchema: true,
+	}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	result := strings.Replace(strings.Split(buf.String(), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
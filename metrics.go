@@ -0,0 +1,195 @@
+package mysqldump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+Collector accumulates the metrics of the dumps of the Data it is the Metrics
+of, for scheduled backups to be monitored and alerted on. It serves them in the
+text format of Prometheus to be scraped as they are, and lists them for
+registries to export, like the prometheus module does for client_golang.
+
+	Namespace: Prefix of the metric names, mysqldump if empty
+
+The metrics are:
+
+	<namespace>_dumps_total                        counter of the dumps run
+	<namespace>_errors_total{cause}                counter of the dumps failed, by Cause
+	<namespace>_tables_total                       counter of the tables and views dumped
+	<namespace>_rows_total                         counter of the rows dumped
+	<namespace>_bytes_total                        counter of the bytes written to Out and Files
+	<namespace>_duration_seconds                   gauge of the duration of the last dump
+	<namespace>_last_success_timestamp_seconds     gauge of the time the last successful dump finished
+	<namespace>_memory_bytes                       gauge of the bytes of rows the running dumps buffered and did not write yet, with MaxMemory
+*/
+type Collector struct {
+	Namespace string
+
+	mu          sync.Mutex
+	dumps       int64
+	errors      map[Cause]int64
+	tables      int64
+	rows        int64
+	bytes       int64
+	duration    time.Duration
+	lastSuccess time.Time
+	// budgets are the memory budgets of the running dumps, by the number of
+	// dumps running with each
+	budgets map[*memory]int
+}
+
+// Metric is a sample of a Collector.
+type Metric struct {
+	Name   string
+	Help   string
+	Type   string // counter or gauge
+	Labels map[string]string
+	Value  float64
+}
+
+// NewCollector returns a Collector of metrics named after namespace.
+func NewCollector(namespace string) *Collector {
+	return &Collector{Namespace: namespace}
+}
+
+// observe records a dump of data that started at start and failed with err,
+// nothing without a Collector
+func (c *Collector) observe(data *Data, start time.Time, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dumps++
+	c.duration = time.Since(start)
+	// The results are the ones of an earlier run until the options are checked
+	if data.sink != nil {
+		c.tables += int64(len(data.report))
+		for _, stats := range data.report {
+			c.rows += stats.Rows
+		}
+		c.bytes += data.sink.bytes()
+	}
+	if err == nil {
+		c.lastSuccess = time.Now()
+		return
+	}
+	if c.errors == nil {
+		c.errors = map[Cause]int64{}
+	}
+	c.errors[CauseOf(err)]++
+}
+
+// track adds the memory budget of a running dump to the memory in use until
+// the function returned is called, nothing without a Collector or a budget
+func (c *Collector) track(m *memory) func() {
+	if c == nil || m == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.budgets == nil {
+		c.budgets = map[*memory]int{}
+	}
+	c.budgets[m]++
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.budgets[m]--; c.budgets[m] == 0 {
+			delete(c.budgets, m)
+		}
+	}
+}
+
+// Metrics returns the current samples of the metrics, in the order of the
+// documentation. Dumps failed without a Cause, like for invalid options, are
+// counted with the cause other.
+func (c *Collector) Metrics() []Metric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := func(s string) string {
+		if c.Namespace == "" {
+			return "mysqldump_" + s
+		}
+		return c.Namespace + "_" + s
+	}
+
+	metrics := []Metric{{Name: name("dumps_total"), Help: "Dumps run.", Type: "counter", Value: float64(c.dumps)}}
+	causes := make([]string, 0, len(c.errors))
+	for cause := range c.errors {
+		causes = append(causes, string(cause))
+	}
+	sort.Strings(causes)
+	for _, cause := range causes {
+		label := cause
+		if label == "" {
+			label = "other"
+		}
+		metrics = append(metrics, Metric{Name: name("errors_total"), Help: "Dumps failed, by cause.", Type: "counter",
+			Labels: map[string]string{"cause": label}, Value: float64(c.errors[Cause(cause)])})
+	}
+	metrics = append(metrics,
+		Metric{Name: name("tables_total"), Help: "Tables and views dumped.", Type: "counter", Value: float64(c.tables)},
+		Metric{Name: name("rows_total"), Help: "Rows dumped.", Type: "counter", Value: float64(c.rows)},
+		Metric{Name: name("bytes_total"), Help: "Bytes written by the dumps.", Type: "counter", Value: float64(c.bytes)},
+		Metric{Name: name("duration_seconds"), Help: "Duration of the last dump.", Type: "gauge", Value: c.duration.Seconds()},
+	)
+	var lastSuccess float64
+	if !c.lastSuccess.IsZero() {
+		lastSuccess = float64(c.lastSuccess.UnixNano()) / 1e9
+	}
+	var memory int64
+	for m := range c.budgets {
+		m.mu.Lock()
+		memory += m.used
+		m.mu.Unlock()
+	}
+	return append(metrics,
+		Metric{Name: name("last_success_timestamp_seconds"), Help: "Time the last successful dump finished.", Type: "gauge", Value: lastSuccess},
+		Metric{Name: name("memory_bytes"), Help: "Bytes of rows buffered by the running dumps and not written yet.", Type: "gauge", Value: float64(memory)},
+	)
+}
+
+// WriteTo writes the metrics to w in the text format of Prometheus.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	previous := ""
+	for _, m := range c.Metrics() {
+		if m.Name != previous {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Type)
+			previous = m.Name
+		}
+		b.WriteString(m.Name)
+		if len(m.Labels) > 0 {
+			names := make([]string, 0, len(m.Labels))
+			for name := range m.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			b.WriteString("{")
+			for i, name := range names {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString(name + "=" + strconv.Quote(m.Labels[name]))
+			}
+			b.WriteString("}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(m.Value, 'g', -1, 64) + "\n")
+	}
+	return b.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics to be scraped by Prometheus.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
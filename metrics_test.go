@@ -0,0 +1,104 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	c := mysqldump.NewCollector("backup")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+	var buf bytes.Buffer
+	assert.NoError(t, (&mysqldump.Data{Out: &buf, Connection: db, Metrics: c}).Dump())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnError(errors.New("gone away"))
+	mock.ExpectRollback()
+	assert.Error(t, (&mysqldump.Data{Out: &bytes.Buffer{}, Connection: db, Metrics: c}).Dump())
+	assert.Error(t, (&mysqldump.Data{Metrics: c, LineEnding: "cr"}).Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	metrics := map[string]float64{}
+	for _, m := range c.Metrics() {
+		name := m.Name
+		if cause, ok := m.Labels["cause"]; ok {
+			name += "/" + cause
+		}
+		metrics[name] = m.Value
+	}
+	assert.Equal(t, float64(3), metrics["backup_dumps_total"])
+	assert.Equal(t, float64(1), metrics["backup_errors_total/server"])
+	assert.Equal(t, float64(1), metrics["backup_errors_total/other"])
+	assert.Equal(t, float64(1), metrics["backup_tables_total"])
+	assert.Equal(t, float64(2), metrics["backup_rows_total"])
+	assert.Equal(t, float64(buf.Len()), metrics["backup_bytes_total"])
+	assert.True(t, metrics["backup_last_success_timestamp_seconds"] > 0)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	body := rec.Body.String()
+	assert.Contains(t, body, "# HELP backup_dumps_total Dumps run.\n# TYPE backup_dumps_total counter\nbackup_dumps_total 3\n")
+	assert.Contains(t, body, "# TYPE backup_errors_total counter\nbackup_errors_total{cause=\"other\"} 1\nbackup_errors_total{cause=\"server\"} 1\n")
+	assert.Equal(t, 1, strings.Count(body, "# TYPE backup_errors_total"))
+}
+
+func TestCollectorNamespace(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := (&mysqldump.Collector{}).WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "\nmysqldump_rows_total 0\n")
+	assert.NotContains(t, buf.String(), "errors_total")
+}
+
+// gaugeWriter records the largest value of a gauge of c seen while the dump
+// is written
+type gaugeWriter struct {
+	bytes.Buffer
+	c    *mysqldump.Collector
+	name string
+	max  float64
+}
+
+func (w *gaugeWriter) Write(p []byte) (int, error) {
+	for _, m := range w.c.Metrics() {
+		if m.Name == w.name && m.Value > w.max {
+			w.max = m.Value
+		}
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestCollectorMemory(t *testing.T) {
+	c := mysqldump.NewCollector("backup")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockDump(mock)
+	out := &gaugeWriter{c: c, name: "backup_memory_bytes"}
+	assert.NoError(t, (&mysqldump.Data{Out: out, Connection: db, Metrics: c, MaxMemory: 1 << 20}).Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	// The rows were held while their INSERT statement was written, and given
+	// back once the dump returned
+	assert.True(t, out.max > 0)
+	for _, m := range c.Metrics() {
+		if m.Name == "backup_memory_bytes" {
+			assert.Equal(t, "gauge", m.Type)
+			assert.Equal(t, float64(0), m.Value)
+		}
+	}
+}
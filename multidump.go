@@ -0,0 +1,251 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrMergeFiles is returned by DumpDatabases for a dump written to Files,
+// which has no single stream to merge the databases into.
+var ErrMergeFiles = errors.New("DumpDatabases writes to Out, not Files")
+
+const defaultMergeBuffer = 16 << 20
+
+// DumpDatabases dumps every database to Out, running up to parallel of them at
+// the same time. The output is the one of dumping them one after the other in
+// the given order, each with CreateDatabase: the database at the head of the
+// order writes straight to Out while the following ones are buffered up to
+// MergeBuffer bytes and wait once it is full. A database holds its place among
+// the parallel runs until its output is written, so no more than parallel
+// buffers are kept at a time.
+//
+// The warnings and the report of data cover every database, the table names of
+// the report being qualified with the database. The other results are the
+// ones of the last database.
+func (data *Data) DumpDatabases(databases []string, parallel int) error {
+	if data.Files != nil {
+		return ErrMergeFiles
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	limit := data.MergeBuffer
+	if limit <= 0 {
+		limit = defaultMergeBuffer
+	}
+
+	m := newOrderedMerger(data.Out, len(databases), limit)
+	m.budget = data.memory()
+	runs := make([]*Data, 0, len(databases))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, database := range databases {
+		slots <- struct{}{}
+		if m.failed() {
+			break
+		}
+		run := data.newRun()
+		run.Out = m.part(i)
+		run.CreateDatabase = true
+		head := i
+		run.atHead = func() bool { return m.atHead(head) }
+		runs = append(runs, run)
+
+		wg.Add(1)
+		go func(i int, database string) {
+			defer wg.Done()
+			m.close(i, run.dump(database))
+			m.wait(i)
+			<-slots
+		}(i, database)
+	}
+	wg.Wait()
+
+	data.finishAll(databases, runs)
+	return m.err
+}
+
+// finishAll keeps the results of the runs of DumpDatabases
+func (data *Data) finishAll(databases []string, runs []*Data) {
+	if len(runs) == 0 {
+		return
+	}
+	data.finish(runs[len(runs)-1])
+
+	var warnings []string
+	var report []TableStats
+	for i, run := range runs {
+		for _, warning := range run.warnings {
+			warnings = append(warnings, "database `"+databases[i]+"`: "+warning)
+		}
+		for _, stats := range run.report {
+			stats.Name = databases[i] + "." + stats.Name
+			report = append(report, stats)
+		}
+	}
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data.warnings = warnings
+	data.report = report
+}
+
+// orderedMerger writes the output of parts written at the same time to out in
+// the order of the parts. The part at the head writes through, the others are
+// buffered until it is closed.
+type orderedMerger struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	out     io.Writer
+	limit   int64
+	head    int
+	parts   []*mergePart
+	err     error
+	counted *summaryWriter
+	budget  *memory
+}
+
+type mergePart struct {
+	m      *orderedMerger
+	index  int
+	buf    bytes.Buffer
+	closed bool
+	start  int64
+	end    int64
+}
+
+func newOrderedMerger(out io.Writer, n int, limit int64) *orderedMerger {
+	m := &orderedMerger{out: out, limit: limit, parts: make([]*mergePart, n)}
+	m.cond = sync.NewCond(&m.mu)
+	for i := range m.parts {
+		m.parts[i] = &mergePart{m: m, index: i}
+	}
+	return m
+}
+
+// part returns the writer of the part i
+func (m *orderedMerger) part(i int) io.Writer {
+	return m.parts[i]
+}
+
+func (p *mergePart) Write(b []byte) (int, error) {
+	m := p.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		switch {
+		case m.err != nil:
+			return 0, m.err
+		case m.head == p.index:
+			return m.write(b)
+		case int64(p.buf.Len()+len(b)) <= m.limit || p.buf.Len() == 0:
+			// A write larger than the whole buffer is still taken alone, so
+			// every part moves on
+			return p.buf.Write(b)
+		}
+		m.cond.Wait()
+	}
+}
+
+// write writes to out, failing the merger when out fails. m.mu is held.
+func (m *orderedMerger) write(b []byte) (int, error) {
+	n, err := m.out.Write(b)
+	if err != nil {
+		m.fail(err)
+	}
+	return n, err
+}
+
+// fail stops every part with err, the first error is kept. m.mu is held.
+func (m *orderedMerger) fail(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+	m.cond.Broadcast()
+}
+
+// close ends the part i with the error of its run and writes out the parts
+// that follow it if it is at the head
+func (m *orderedMerger) close(i int, err error) {
+	// The rows waiting for memory held by the parts behind the new head take
+	// it past MaxMemory
+	defer m.budget.wake()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.fail(err)
+	}
+	m.parts[i].closed = true
+	for m.err == nil && m.head < len(m.parts) && m.parts[m.head].closed {
+		if m.counted != nil {
+			m.parts[m.head].end = m.counted.n
+		}
+		m.head++
+		if m.head < len(m.parts) {
+			if m.counted != nil {
+				m.parts[m.head].start = m.counted.n
+			}
+			m.flush(m.parts[m.head])
+		}
+	}
+	m.cond.Broadcast()
+}
+
+// track records where the output of every part lies in out, counted by s
+// which out writes to
+func (m *orderedMerger) track(s *summaryWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counted = s
+	if len(m.parts) > 0 {
+		m.parts[0].start = s.n
+	}
+}
+
+// section returns where the output of the part i, written out, lies when the
+// merger is tracked
+func (m *orderedMerger) section(i int) (start, end int64, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counted == nil || m.err != nil || m.head <= i {
+		return 0, 0, false
+	}
+	return m.parts[i].start, m.parts[i].end, true
+}
+
+// flush writes the buffer of the part p that just became the head. m.mu is
+// held.
+func (m *orderedMerger) flush(p *mergePart) {
+	if p.buf.Len() == 0 {
+		return
+	}
+	if _, err := m.write(p.buf.Bytes()); err != nil {
+		return
+	}
+	p.buf = bytes.Buffer{}
+}
+
+// wait blocks until the output of the part i is written, or the merger failed
+func (m *orderedMerger) wait(i int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.err == nil && m.head <= i {
+		m.cond.Wait()
+	}
+}
+
+// atHead reports whether the part i is written through to out
+func (m *orderedMerger) atHead(i int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.head == i
+}
+
+// failed reports whether a part or out failed
+func (m *orderedMerger) failed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err != nil
+}
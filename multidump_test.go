@@ -0,0 +1,115 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingWriter records the largest amount buffered by the parts of m at the
+// time of a write to out
+type countingWriter struct {
+	bytes.Buffer
+	m       *orderedMerger
+	maxSeen int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	buffered := 0
+	for _, part := range w.m.parts {
+		buffered += part.buf.Len()
+	}
+	if buffered > w.maxSeen {
+		w.maxSeen = buffered
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestOrderedMerger(t *testing.T) {
+	out := &countingWriter{}
+	m := newOrderedMerger(out, 4, 16)
+	out.m = m
+
+	var wg sync.WaitGroup
+	for i := 3; i >= 0; i-- {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := m.part(i)
+			for j := 0; j < 10; j++ {
+				_, err := w.Write([]byte{byte('a' + i), byte('0' + j)})
+				assert.NoError(t, err)
+			}
+			m.close(i, nil)
+			m.wait(i)
+		}(i)
+	}
+	wg.Wait()
+
+	var expected strings.Builder
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 10; j++ {
+			expected.WriteByte(byte('a' + i))
+			expected.WriteByte(byte('0' + j))
+		}
+	}
+	assert.NoError(t, m.err)
+	assert.Equal(t, expected.String(), out.String())
+	assert.True(t, out.maxSeen <= 3*16, "buffered %d bytes", out.maxSeen)
+}
+
+func TestOrderedMergerError(t *testing.T) {
+	var out bytes.Buffer
+	m := newOrderedMerger(&out, 2, 4)
+	failure := errors.New("failed")
+
+	_, err := m.part(1).Write([]byte("abcd"))
+	assert.NoError(t, err)
+	m.close(0, failure)
+	m.wait(1)
+
+	_, err = m.part(1).Write([]byte("e"))
+	assert.Equal(t, failure, err)
+	assert.Equal(t, failure, m.err)
+	assert.Empty(t, out.String())
+}
+
+func TestDumpDatabases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	for _, name := range []string{"first", "second"} {
+		mock.ExpectBegin()
+		mock.ExpectExec("^USE " + name + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Version()", "")).AddRow("8.0.30"))
+		mock.ExpectQuery("^SHOW CREATE DATABASE `" + name + "`$").WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Database", ""), c("Create Database", "")).
+				AddRow(name, "CREATE DATABASE `"+name+"`"))
+		mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Tables_in_"+name, ""), c("Table_type", "")))
+		mock.ExpectRollback()
+	}
+
+	var buf bytes.Buffer
+	data := &Data{Out: &buf, Connection: db}
+	assert.NoError(t, data.DumpDatabases([]string{"first", "second"}, 1))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	first := strings.Index(out, "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `first`")
+	second := strings.Index(out, "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `second`")
+	assert.True(t, first >= 0 && second > first, out)
+	assert.False(t, data.CreateDatabase)
+}
+
+func TestDumpDatabasesFiles(t *testing.T) {
+	data := &Data{Files: NewZipWriter(&bytes.Buffer{})}
+	assert.Equal(t, ErrMergeFiles, data.DumpDatabases([]string{"test"}, 2))
+}
@@ -58,6 +58,19 @@ func DumpDatabase(db *sql.DB, out io.Writer, database string) error {
 	}).DumpDatabase(database)
 }
 
+// DumpTar dumps the current database as a tar archive holding the schema, one
+// data file per table, the manifest and the checksums of all files.
+func DumpTar(db *sql.DB, out io.Writer, compress bool) error {
+	archive := NewTarWriter(out, compress)
+	if err := (&Data{
+		Connection: db,
+		Files:      archive,
+	}).Dump(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
 // Close the dumper.
 // Will also close the database the dumper is connected to as well as the out stream if it has a Close method.
 //
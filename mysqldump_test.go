@@ -93,8 +93,8 @@ func RunDump(t testing.TB, data *mysqldump.Data) {
 	defer db.Close()
 
 	data.Connection = db
-	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", "")).
-		AddRow("Test_Table")
+	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+		AddRow("Test_Table", "BASE TABLE")
 
 	showColumnsRows := mockColumnRows()
 
@@ -110,7 +110,7 @@ func RunDump(t testing.TB, data *mysqldump.Data) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(serverVersionRows)
-	mock.ExpectQuery(`^SHOW TABLES$`).WillReturnRows(showTablesRows)
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(showTablesRows)
 	mock.ExpectExec("^LOCK TABLES `Test_Table` READ /\\*!32311 LOCAL \\*/$").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(createTableRows)
 	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(showColumnsRows)
@@ -133,21 +133,10 @@ func TestDumpOk(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
-func TestNoLockOk(t *testing.T) {
-	var buf bytes.Buffer
-
-	data := &mysqldump.Data{
-		Out:        &buf,
-		LockTables: false,
-	}
-
-	db, mock, err := sqlmock.New()
-	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
-	defer db.Close()
-
-	data.Connection = db
-	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", "")).
-		AddRow("Test_Table")
+// mockDump expects the queries of a dump of Test_Table without locking
+func mockDump(mock sqlmock.Sqlmock) {
+	showTablesRows := sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+		AddRow("Test_Table", "BASE TABLE")
 
 	showColumnsRows := mockColumnRows()
 
@@ -163,11 +152,27 @@ func TestNoLockOk(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(serverVersionRows)
-	mock.ExpectQuery(`^SHOW TABLES$`).WillReturnRows(showTablesRows)
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(showTablesRows)
 	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(createTableRows)
 	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(showColumnsRows)
 	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(createTableValueRows)
 	mock.ExpectRollback()
+}
+
+func TestNoLockOk(t *testing.T) {
+	var buf bytes.Buffer
+
+	data := &mysqldump.Data{
+		Out:        &buf,
+		LockTables: false,
+	}
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data.Connection = db
+	mockDump(mock)
 
 	assert.NoError(t, data.Dump(), "an error was not expected when dumping a stub database connection")
 
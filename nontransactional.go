@@ -0,0 +1,112 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// NonTransactionalPolicy tells what a dump does about tables of engines
+// without transactions, like MyISAM, whose rows the snapshot does not cover.
+type NonTransactionalPolicy string
+
+const (
+	// NonTransactionalIgnore dumps the tables without looking at their
+	// engines.
+	NonTransactionalIgnore NonTransactionalPolicy = ""
+	// NonTransactionalWarn dumps the rows of the tables as they are when they
+	// are read, with a warning naming them. Nothing waits on the dump.
+	NonTransactionalWarn NonTransactionalPolicy = "warn"
+	// NonTransactionalLock takes LOCK TABLES ... READ on those tables only
+	// for the duration of the dump, so they don't change while it runs.
+	// Writes to them wait for the dump.
+	NonTransactionalLock NonTransactionalPolicy = "lock"
+)
+
+// ErrUnknownNonTransactionalPolicy is returned for policies that don't exist.
+var ErrUnknownNonTransactionalPolicy = errors.New("unknown non-transactional policy")
+
+func (data *Data) checkNonTransactionalPolicy() error {
+	switch data.NonTransactional {
+	case NonTransactionalIgnore, NonTransactionalWarn, NonTransactionalLock:
+		return nil
+	}
+	return ErrUnknownNonTransactionalPolicy
+}
+
+// nonTransactionalTables returns the tables whose engine has no transactions
+func (data *Data) nonTransactionalTables(tables []*table) ([]*table, error) {
+	rows, err := data.metadataQuery("SELECT t.TABLE_NAME FROM information_schema.TABLES t JOIN information_schema.ENGINES e ON e.ENGINE = t.ENGINE WHERE t.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE' AND e.TRANSACTIONS <> 'YES'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var found []*table
+	for _, table := range tables {
+		if names[table.Name] {
+			found = append(found, table)
+		}
+	}
+	return found, nil
+}
+
+// lockTablesSQL is the LOCK TABLES statement for reading the tables
+func lockTablesSQL(tables []*table) string {
+	var b bytes.Buffer
+	b.WriteString("LOCK TABLES ")
+	for index, table := range tables {
+		if index != 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("`" + table.Name + "` READ /*!32311 LOCAL */")
+	}
+	return b.String()
+}
+
+// protectNonTransactional applies the NonTransactional policy to the tables
+// unless LockTables already locks them all. It returns the locks it took, to
+// be released at the end of the dump.
+func (data *Data) protectNonTransactional(tables []*table, proxied bool) (*tableLocks, error) {
+	if data.NonTransactional == NonTransactionalIgnore || data.LockTables && !proxied {
+		return nil, nil
+	}
+	found, err := data.nonTransactionalTables(tables)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	names := make([]string, len(found))
+	for i, table := range found {
+		names[i] = table.NameEsc()
+	}
+	list := strings.Join(names, ", ")
+
+	switch {
+	case data.NonTransactional == NonTransactionalWarn:
+		data.warn("tables without transactions are read as they are, not as of the snapshot: " + list)
+		return nil, nil
+	case proxied:
+		data.warn("LOCK TABLES on " + list + " skipped behind a proxy, they are read as they are, not as of the snapshot")
+		return nil, nil
+	}
+
+	var locks *tableLocks
+	err = data.privileged("LOCK TABLES on "+list, func() error {
+		var err error
+		locks, err = data.lockTables(found)
+		return err
+	})
+	return locks, err
+}
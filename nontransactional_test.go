@@ -0,0 +1,101 @@
+package mysqldump
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const nonTransactionalQuery = `^SELECT t.TABLE_NAME FROM information_schema.TABLES t JOIN information_schema.ENGINES e`
+
+func TestProtectNonTransactionalLock(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.NonTransactional = NonTransactionalLock
+
+	mock.ExpectQuery(nonTransactionalQuery).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("logs").AddRow("ignored"))
+	mock.ExpectExec("^LOCK TABLES `logs` READ /\\*!32311 LOCAL \\*/$").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tables := []*table{data.createTable("orders", false), data.createTable("logs", false)}
+	locks, err := data.protectNonTransactional(tables, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, locks)
+	assert.Empty(t, data.warnings)
+
+	mock.ExpectExec(`^UNLOCK TABLES$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NoError(t, data.unlock(locks))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestProtectNonTransactionalWarn(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.NonTransactional = NonTransactionalWarn
+
+	mock.ExpectQuery(nonTransactionalQuery).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("logs").AddRow("cache"))
+
+	tables := []*table{data.createTable("cache", false), data.createTable("orders", false), data.createTable("logs", false)}
+	locks, err := data.protectNonTransactional(tables, false)
+	assert.NoError(t, err)
+	assert.Nil(t, locks)
+	assert.Equal(t, []string{"tables without transactions are read as they are, not as of the snapshot: `cache`, `logs`"}, data.warnings)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestProtectNonTransactionalTimeout(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.NonTransactional = NonTransactionalWarn
+	data.MetadataTimeout = 10 * time.Millisecond
+
+	mock.ExpectQuery(nonTransactionalQuery).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}))
+
+	_, err = data.protectNonTransactional([]*table{data.createTable("logs", false)}, false)
+	assert.True(t, errors.Is(err, ErrQueryTimeout), "%v", err)
+}
+
+func TestProtectNonTransactionalProxied(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.NonTransactional = NonTransactionalLock
+	data.LockTables = true
+
+	mock.ExpectQuery(nonTransactionalQuery).WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("logs"))
+
+	locks, err := data.protectNonTransactional([]*table{data.createTable("logs", false)}, true)
+	assert.NoError(t, err)
+	assert.Nil(t, locks)
+	assert.Len(t, data.warnings, 1)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestProtectNonTransactionalSkipped(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	tables := []*table{data.createTable("logs", false)}
+
+	// Nothing is asked without a policy, or with every table locked already
+	locks, err := data.protectNonTransactional(tables, false)
+	assert.NoError(t, err)
+	assert.Nil(t, locks)
+
+	data.NonTransactional = NonTransactionalLock
+	data.LockTables = true
+	locks, err = data.protectNonTransactional(tables, false)
+	assert.NoError(t, err)
+	assert.Nil(t, locks)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpUnknownNonTransactionalPolicy(t *testing.T) {
+	data := &Data{NonTransactional: "sometimes"}
+	assert.Equal(t, ErrUnknownNonTransactionalPolicy, data.Dump())
+}
@@ -0,0 +1,87 @@
+package mysqldump
+
+import (
+	"io"
+	"sort"
+)
+
+// offset returns where the next write to out lands in the dump written to
+// Out, -1 when out is not the counted stream
+func (data *Data) offset(out io.Writer) int64 {
+	if data.streamed == nil || out == nil || out != io.Writer(data.streamed) {
+		return -1
+	}
+	return data.streamed.n
+}
+
+// addSection records that a section of the table lies between start and end
+// in Out
+func (table *table) addSection(start, end int64) {
+	table.sections = append(table.sections, ByteRange{Offset: start, Length: end - start})
+}
+
+// skippedSections returns the sections of the manifest of the tables left out
+// by Tables, in the order of the dump
+func (r *Restorer) skippedSections() []ByteRange {
+	var skipped []ByteRange
+	for _, t := range r.Manifest.Tables {
+		if r.skipped("", t.Name) {
+			skipped = append(skipped, t.Sections...)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Offset < skipped[j].Offset })
+	return skipped
+}
+
+// seekingReader reads a dump, seeking over the sections it skips instead of
+// reading them
+type seekingReader struct {
+	r       io.ReadSeeker
+	base    int64
+	pos     int64
+	skipped []ByteRange
+}
+
+// newSeekingReader returns a reader of the dump starting at the current
+// position of r, without the skipped sections
+func newSeekingReader(r io.ReadSeeker, skipped []ByteRange) (*seekingReader, error) {
+	base, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return &seekingReader{r: r, base: base, skipped: skipped}, nil
+}
+
+func (s *seekingReader) Read(p []byte) (int, error) {
+	for len(s.skipped) > 0 && s.skipped[0].Offset <= s.pos {
+		next := s.skipped[0]
+		s.skipped = s.skipped[1:]
+		if end := next.Offset + next.Length; end > s.pos {
+			if _, err := s.r.Seek(s.base+end, io.SeekStart); err != nil {
+				return 0, err
+			}
+			s.pos = end
+		}
+	}
+	if len(s.skipped) > 0 && int64(len(p)) > s.skipped[0].Offset-s.pos {
+		p = p[:s.skipped[0].Offset-s.pos]
+	}
+	n, err := s.r.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// restoredManifest returns the manifest of the tables restored, the ones of
+// Tables if set
+func (r *Restorer) restoredManifest() *Manifest {
+	if len(r.Tables) == 0 {
+		return r.Manifest
+	}
+	m := &Manifest{}
+	for _, t := range r.Manifest.Tables {
+		if !r.skipped("", t.Name) {
+			m.Tables = append(m.Tables, t)
+		}
+	}
+	return m
+}
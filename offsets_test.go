@@ -0,0 +1,117 @@
+package mysqldump
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// section returns the section of the dump s
+func section(s string, r ByteRange) string {
+	return s[r.Offset : r.Offset+r.Length]
+}
+
+func TestDumpSections(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).
+			AddRow("a", "BASE TABLE").AddRow("b", "BASE TABLE"))
+	for _, name := range []string{"a", "b"} {
+		mockCreateTable(mock, name)
+	}
+	for _, name := range []string{"a", "b"} {
+		mock.ExpectQuery("^SHOW COLUMNS FROM `" + name + "`$").WillReturnRows(
+			sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int(11)", "NO", "PRI", nil, ""))
+		mock.ExpectQuery("^SELECT (.+) FROM `" + name + "`").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0)).AddRow(1))
+	}
+	mock.ExpectRollback()
+
+	// The schema apart from the data, every table has two sections
+	var buf bytes.Buffer
+	data := &Data{Connection: db, Out: &buf, LineEnding: LineEndingCRLF, SectionOrder: []Section{SectionSchema, SectionRoutines, SectionData, SectionTriggers, SectionEvents}}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	tables := data.Manifest().Tables
+	if assert.Len(t, tables, 2) {
+		for _, table := range tables {
+			if assert.Len(t, table.Sections, 2, table.Name) {
+				schema, rows := section(out, table.Sections[0]), section(out, table.Sections[1])
+				assert.True(t, strings.HasPrefix(schema, "\r\n--\r\n-- Table structure for table `"+table.Name+"`"), schema)
+				assert.True(t, strings.HasSuffix(schema, "SET character_set_client = @saved_cs_client */;\r\n"), schema)
+				assert.True(t, strings.HasPrefix(rows, "\r\n--\r\n-- Dumping data for table `"+table.Name+"`"), rows)
+				assert.True(t, strings.HasSuffix(rows, "UNLOCK TABLES;\r\n"), rows)
+			}
+		}
+	}
+}
+
+func TestDumpSectionsConcurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	mockConcurrentDump(mock)
+
+	var buf bytes.Buffer
+	data := &Data{Connection: db, Out: &buf, Concurrency: 2, MaxAllowedPacket: 4096}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	// The tables written by the workers follow each other
+	out := buf.String()
+	tables := data.Manifest().Tables
+	if assert.Len(t, tables, 3) {
+		for i, table := range tables {
+			if !assert.Len(t, table.Sections, 1, table.Name) {
+				continue
+			}
+			s := section(out, table.Sections[0])
+			assert.True(t, strings.HasPrefix(s, "\n--\n-- Table structure for table `"+table.Name+"`"), s)
+			assert.Contains(t, s, "INSERT INTO `"+table.Name+"` (`id`) VALUES (1),(2),(3);")
+			assert.True(t, strings.HasSuffix(s, "UNLOCK TABLES;\n"), s)
+			if i > 0 {
+				previous := tables[i-1].Sections[0]
+				assert.Equal(t, previous.Offset+previous.Length, table.Sections[0].Offset)
+			}
+		}
+	}
+}
+
+func TestSeekingReader(t *testing.T) {
+	dump := "header;aaa;bbb;ccc;footer;"
+	r := &Restorer{
+		Tables: []string{"b"},
+		Manifest: &Manifest{Tables: []ManifestTable{
+			{Name: "c", Sections: []ByteRange{{Offset: 15, Length: 4}}},
+			{Name: "a", Sections: []ByteRange{{Offset: 7, Length: 4}}},
+			{Name: "b", Sections: []ByteRange{{Offset: 11, Length: 4}}},
+		}},
+	}
+	assert.Equal(t, []ByteRange{{Offset: 7, Length: 4}, {Offset: 15, Length: 4}}, r.skippedSections())
+
+	// The dump starts where the reader stands
+	in := strings.NewReader("junk" + dump)
+	in.Seek(4, 0)
+	s, err := newSeekingReader(in, r.skippedSections())
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "header;bbb;footer;", string(b))
+
+	// Only the restored tables are verified
+	m := r.restoredManifest()
+	if assert.Len(t, m.Tables, 1) {
+		assert.Equal(t, "b", m.Tables[0].Name)
+	}
+}
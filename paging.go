@@ -0,0 +1,106 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// pager reads a table in pages of FetchSize rows ordered by the primary key.
+// Every page after the first continues after the key of the last row with the
+// same prepared statement, so the server never materializes the whole table
+// for a single result.
+type pager struct {
+	stmt  *sql.Stmt
+	query string
+	size  int
+	count int
+	last  []interface{}
+}
+
+// orderBy returns the primary key columns for ORDER BY
+func (table *table) orderBy() string {
+	cols := make([]string, len(table.pk))
+	for i, index := range table.pk {
+		cols[i] = "`" + table.cols[index] + "`"
+	}
+	return strings.Join(cols, ", ")
+}
+
+// firstPage starts paging through the table
+func (table *table) firstPage() (*queryRows, error) {
+	table.pager = &pager{size: table.data.FetchSize}
+	return table.selectRows(table.selectSQL() + " ORDER BY " + table.orderBy() + " LIMIT " + strconv.Itoa(table.pager.size))
+}
+
+// nextPage queries the rows following the last page. It returns nil once the
+// previous page was the last one.
+func (table *table) nextPage() (*queryRows, error) {
+	p := table.pager
+	if p == nil || p.count < p.size {
+		return nil, table.closePager()
+	}
+	table.data.waitWhilePaused()
+	if p.stmt == nil {
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(table.pk)), ", ")
+		p.query = table.selectSQL() + " WHERE (" + table.orderBy() + ") > (" + marks + ") ORDER BY " + table.orderBy() + " LIMIT " + strconv.Itoa(p.size)
+		var err error
+		if p.stmt, err = table.txn().Prepare(p.query); err != nil {
+			return nil, err
+		}
+	}
+	p.count = 0
+	return table.data.timedQuery(table.data.QueryTimeout, p.query, func(ctx context.Context) (*sql.Rows, error) {
+		return p.stmt.QueryContext(ctx, p.last...)
+	})
+}
+
+// closePager releases the prepared statement of the pager
+func (table *table) closePager() error {
+	p := table.pager
+	table.pager = nil
+	if p == nil || p.stmt == nil {
+		return nil
+	}
+	return p.stmt.Close()
+}
+
+// scanned is called for every row read while paging. The key of the last row
+// of a full page is kept to continue from, copied as sql.RawBytes is only
+// valid until the next row is read.
+func (p *pager) scanned(table *table) {
+	p.count++
+	if p.count != p.size {
+		return
+	}
+	p.last = make([]interface{}, len(table.pk))
+	for i, index := range table.pk {
+		p.last[i] = argValue(table.values[index])
+	}
+}
+
+// argValue converts a scanned value to a query argument
+func argValue(value interface{}) interface{} {
+	switch s := value.(type) {
+	case *sql.NullString:
+		if s.Valid {
+			return s.String
+		}
+	case *sql.NullInt64:
+		if s.Valid {
+			return s.Int64
+		}
+	case *sql.NullFloat64:
+		if s.Valid {
+			return s.Float64
+		}
+	case *sql.RawBytes:
+		if *s != nil {
+			return append([]byte{}, *s...)
+		}
+	default:
+		return value
+	}
+	return nil
+}
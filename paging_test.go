@@ -0,0 +1,109 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchSizePages(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	cols := sqlmock.NewRows([]string{"Field", "Key", "Extra"}).
+		AddRow("id", "PRI", "").
+		AddRow("name", "", "")
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(cols)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `name` FROM `test` ORDER BY `id` LIMIT 2")).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("name", "")).AddRow(1, "a").AddRow(2, "b"))
+	next := mock.ExpectPrepare(regexp.QuoteMeta("SELECT `id`, `name` FROM `test` WHERE (`id`) > (?) ORDER BY `id` LIMIT 2"))
+	next.ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("name", "")).AddRow(3, "c").AddRow(4, "d"))
+	next.ExpectQuery().WithArgs(4).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("name", "")).AddRow(5, "e"))
+	next.WillBeClosed()
+
+	data.FetchSize = 2
+	table := data.createTable("test", false)
+
+	var results []string
+	for table.Next() {
+		results = append(results, table.RowValues())
+	}
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{"(1,'a')", "(2,'b')", "(3,'c')", "(4,'d')", "(5,'e')"}, results)
+	assert.Nil(t, table.pager)
+}
+
+func TestFetchSizeCompositeKeyOrder(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	// PRIMARY KEY (`order_id`, `line`) with the columns in the other order
+	cols := sqlmock.NewRows([]string{"Field", "Key", "Extra"}).
+		AddRow("line", "PRI", "").
+		AddRow("order_id", "PRI", "").
+		AddRow("sku", "", "")
+	mock.ExpectQuery("^SHOW COLUMNS FROM `lines`$").WillReturnRows(cols)
+	mock.ExpectQuery(regexp.QuoteMeta("SHOW KEYS FROM `lines` WHERE Key_name = 'PRIMARY'")).WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name"}).
+			AddRow("lines", 0, "PRIMARY", 1, "order_id").
+			AddRow("lines", 0, "PRIMARY", 2, "line"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `line`, `order_id`, `sku` FROM `lines` ORDER BY `order_id`, `line` LIMIT 2")).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("line", 0), c("order_id", 0), c("sku", "")).AddRow(1, 7, "a").AddRow(2, 7, "b"))
+	next := mock.ExpectPrepare(regexp.QuoteMeta("SELECT `line`, `order_id`, `sku` FROM `lines` WHERE (`order_id`, `line`) > (?, ?) ORDER BY `order_id`, `line` LIMIT 2"))
+	next.ExpectQuery().WithArgs(7, 2).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("line", 0), c("order_id", 0), c("sku", "")).AddRow(1, 8, "c"))
+	next.WillBeClosed()
+
+	data.FetchSize = 2
+	table := data.createTable("lines", false)
+
+	var results []string
+	for table.Next() {
+		results = append(results, table.RowValues())
+	}
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, []string{"(1,7,'a')", "(2,7,'b')", "(1,8,'c')"}, results)
+}
+
+func TestFetchSizeWithoutPrimaryKey(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+
+	data.FetchSize = 1
+	table := data.createTable("test", false)
+
+	rows := 0
+	for table.Next() {
+		rows++
+	}
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, 2, rows)
+}
+
+func TestPagerCopiesRawBytes(t *testing.T) {
+	raw := sql.RawBytes("key")
+	table := &table{pk: []int{0}, values: []interface{}{&raw}}
+	p := &pager{size: 1}
+
+	p.scanned(table)
+	raw[0] = 'X'
+
+	assert.Equal(t, []interface{}{[]byte("key")}, p.last)
+	assert.Equal(t, int64(1), argValue(&sql.NullInt64{Int64: 1, Valid: true}))
+	assert.Nil(t, argValue(&sql.NullString{}))
+}
@@ -0,0 +1,71 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPattern is returned for IncludePatterns and ExcludePatterns that
+// are not valid regular expressions.
+var ErrInvalidPattern = errors.New("invalid table pattern")
+
+// compilePattern compiles a table pattern. A pattern between slashes like
+// /^log_\d+$/ is a regular expression matching anywhere in the name, any
+// other one a glob matching the whole name, where * stands for any run of
+// characters and ? for one.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", ErrInvalidPattern, pattern, err)
+		}
+		return re, nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), nil
+}
+
+// checkPatterns compiles IncludePatterns and ExcludePatterns
+func (data *Data) checkPatterns() error {
+	var err error
+	if data.includePatterns, err = compilePatterns(data.IncludePatterns); err != nil {
+		return err
+	}
+	data.excludePatterns, err = compilePatterns(data.ExcludePatterns)
+	return err
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package mysqldump
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePattern(t *testing.T) {
+	for pattern, cases := range map[string]map[string]bool{
+		"tmp_*":        {"tmp_": true, "tmp_orders": true, "orders_tmp_1": false, "tmpx": false},
+		"log_201?":     {"log_2019": true, "log_201": false, "log_20199": false},
+		"a.b":          {"a.b": true, "axb": false},
+		`/^log_\d+$/`:  {"log_1": true, "log_x": false},
+		"/_(old|new)/": {"_orders_old": true, "orders": false},
+	} {
+		re, err := compilePattern(pattern)
+		assert.NoError(t, err)
+		for name, match := range cases {
+			assert.Equal(t, match, re.MatchString(name), "%s ~ %s", name, pattern)
+		}
+	}
+
+	_, err := compilePattern("/log_(/")
+	assert.True(t, errors.Is(err, ErrInvalidPattern), "%v", err)
+}
+
+func TestIsIgnoredTablePatterns(t *testing.T) {
+	data := &Data{
+		IncludeTables:     []string{"_orders_new"},
+		IncludePatterns:   []string{"orders*", "_orders_*", "/^log_\\d+$/"},
+		ExcludePatterns:   []string{"*_archive"},
+		IgnoreTables:      []string{"orders_secret"},
+		SkipToolArtifacts: true,
+	}
+	assert.NoError(t, data.checkPatterns())
+	for name, ignored := range map[string]bool{
+		"orders":         false,
+		"orders_2024":    false,
+		"log_7":          false,
+		"users":          true,
+		"orders_archive": true,
+		"orders_secret":  true,
+		// Only listing it by name dumps a table of an online schema change
+		"_orders_new": false,
+		"_orders_old": true,
+	} {
+		assert.Equal(t, ignored, data.isIgnoredTable(name), name)
+	}
+
+	data = &Data{ExcludePatterns: []string{"tmp_*"}}
+	assert.NoError(t, data.checkPatterns())
+	assert.True(t, data.isIgnoredTable("tmp_import"))
+	assert.False(t, data.isIgnoredTable("users"))
+}
+
+func TestDumpInvalidPattern(t *testing.T) {
+	err := (&Data{ExcludePatterns: []string{"/[/"}}).Dump()
+	assert.True(t, errors.Is(err, ErrInvalidPattern), "%v", err)
+}
+
+func TestAllowedTables(t *testing.T) {
+	names := []string{"public_a", "public_keys", "secret", "_public_b_new"}
+
+	allowed, err := (&Data{}).AllowedTables(names)
+	assert.NoError(t, err)
+	assert.Equal(t, names, allowed)
+
+	allowed, err = (&Data{IncludeTables: []string{"secret"}}).AllowedTables(names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"secret"}, allowed)
+
+	data := &Data{IncludePatterns: []string{"public_*", "_public_*"}, ExcludePatterns: []string{"public_keys"}, SkipToolArtifacts: true}
+	allowed, err = data.AllowedTables(names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"public_a"}, allowed)
+
+	_, err = (&Data{ExcludePatterns: []string{"/[/"}}).AllowedTables(names)
+	assert.Error(t, err)
+}
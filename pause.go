@@ -0,0 +1,52 @@
+package mysqldump
+
+// Pause holds the running dumps of data before their next table, or before
+// the next page when reading with FetchSize. The transaction stays open, so a
+// dump still reads the same snapshot once it is resumed. Heartbeats keep
+// running while paused.
+func (data *Data) Pause() {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resume == nil {
+		s.resume = make(chan struct{})
+	}
+}
+
+// Resume continues the paused dumps.
+func (data *Data) Resume() {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+	}
+}
+
+// Paused reports whether Pause was called without Resume.
+func (data *Data) Paused() bool {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resume != nil
+}
+
+// waitWhilePaused blocks until the run and the Data it was started from are
+// both resumed, or the Context is done
+func (data *Data) waitWhilePaused() {
+	done := data.context().Done()
+	for d := data; d != nil; d = d.parent {
+		s := d.state()
+		s.mu.Lock()
+		resume := s.resume
+		s.mu.Unlock()
+		if resume != nil {
+			select {
+			case <-resume:
+			case <-done:
+				return
+			}
+		}
+	}
+}
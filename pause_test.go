@@ -0,0 +1,75 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPause(t *testing.T) {
+	data := &Data{}
+	assert.False(t, data.Paused())
+	data.waitWhilePaused()
+
+	data.Pause()
+	data.Pause()
+	assert.True(t, data.Paused())
+
+	done := make(chan struct{})
+	go func() {
+		data.waitWhilePaused()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected the dump to wait while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	data.Resume()
+	data.Resume()
+	assert.False(t, data.Paused())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the dump to continue once resumed")
+	}
+}
+
+func TestPauseBetweenTables(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	data.MaxAllowedPacket = 4096
+	data.manifest = &Manifest{}
+	assert.NoError(t, data.getTemplates())
+
+	data.Pause()
+	done := make(chan error)
+	go func() {
+		done <- data.dumpTable(data.createTable("test", false))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected the table to wait while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Zero(t, buf.Len())
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("test", "CREATE TABLE `test` (`id` int)"))
+	mockTableSelect(mock, "test")
+	data.Resume()
+
+	assert.NoError(t, <-done)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Contains(t, buf.String(), "INSERT INTO `test`")
+}
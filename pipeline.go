@@ -0,0 +1,252 @@
+package mysqldump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// A dump runs as a pipeline of stages, each joined to the next by a bounded
+// channel so that a slow stage holds up the ones before it rather than the
+// memory growing:
+//
+//	enumerate: lists the tables in the order of the dump, within the snapshot
+//	fetch:     scans the rows of a table and renders their values
+//	serialize: groups the rows into INSERT statements within MaxAllowedPacket
+//	           and MaxMemory, ranged over by the template writing them to Out
+//	compress:  encodes Out with Compress and writes it to the sink
+//	sink:      the Out, Files or Uploader the dump was given
+//
+// Enumerate runs once before the others: the tables are listed, and locked if
+// need be, on the transaction the rows are then read from. Fetch and serialize
+// run for every table on the goroutines of an errgroup, compress for the whole
+// dump on one of its own. The first stage to fail stops the others, and every
+// goroutine has returned by the time the table or the dump it started for is
+// done, whether it succeeded or not.
+
+const (
+	// rowQueue is the number of rendered rows fetch runs ahead of serialize
+	rowQueue = 64
+	// chunkQueue is the number of writes to Out compress runs behind
+	chunkQueue = 16
+)
+
+// stream runs the fetch and serialize stages of a table for the template
+// ranging over out
+type stream struct {
+	out    chan string
+	ctx    context.Context
+	cancel context.CancelFunc
+	group  *errgroup.Group
+}
+
+func newStream() *stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	group, ctx := errgroup.WithContext(ctx)
+	return &stream{
+		out:    make(chan string, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		group:  group,
+	}
+}
+
+// send hands a statement to the template, false when it stopped reading
+func (s *stream) send(statement string) bool {
+	select {
+	case s.out <- statement:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// fetchedRow is a row rendered by fetch for serialize
+type fetchedRow struct {
+	n      int
+	values *bytes.Buffer
+	// aligned are the values of the row with StyleAligned
+	aligned []string
+}
+
+// fetchRows is the fetch stage: it scans the rows of table and renders their
+// values until the rows run out, they fail or the stream stops
+func (table *table) fetchRows(ctx context.Context, rows chan<- fetchedRow) (err error) {
+	defer close(rows)
+	// A panic of a Masker fails the dump instead of the process, which would
+	// leave the tables locked until the server drops the session
+	defer func() {
+		if r := recover(); r != nil {
+			table.Err = fmt.Errorf("reading the rows of %s: %v", table.NameEsc(), r)
+			err = table.Err
+		}
+	}()
+	aligned := table.data.OutputStyle == StyleAligned
+	for table.Next() {
+		row := fetchedRow{n: table.row, values: table.RowBuffer()}
+		if table.Err != nil {
+			return table.Err
+		}
+		if aligned {
+			row.aligned = table.rowValues(row.values)
+		}
+		select {
+		case rows <- row:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return table.Err
+}
+
+// serializeRows is the serialize stage: it groups the rows fetched into INSERT
+// statements and sends them to the template, along with the comments of
+// RowComments and HeartbeatComments
+func (table *table) serializeRows(s *stream, rows <-chan fetchedRow) error {
+	defer close(s.out)
+	var insert bytes.Buffer
+	var aligned *alignedBatch
+	if table.data.OutputStyle == StyleAligned {
+		aligned = &alignedBatch{}
+	}
+	separator := table.data.rowSeparator()
+	first, last := 0, 0
+	flush := func() bool {
+		if aligned != nil {
+			aligned.writeTo(&insert)
+		}
+		insert.WriteString(defaultDelimiter)
+		if table.data.RowComments && !s.send(fmt.Sprintf("-- rows %d..%d of %s", first, last, table.NameEsc())) {
+			return false
+		}
+		ok := s.send(insert.String())
+		insert.Reset()
+		return ok
+	}
+
+	for row := range rows {
+		b := row.values
+		if comment := table.data.heartbeat.takeComment(); comment != "" {
+			if insert.Len() != 0 && !flush() {
+				return nil
+			}
+			if !s.send(comment) {
+				return nil
+			}
+		}
+		// Truncate our insert if it won't fit, in the packet or in what
+		// MaxMemory leaves, until it is written to give the memory back
+		buffered := false
+		if insert.Len() != 0 {
+			size := insert.Len() + len(separator) - 1 + b.Len()
+			if aligned != nil {
+				size = insert.Len() + aligned.size(row.aligned)
+			}
+			if size <= table.data.MaxAllowedPacket-1 {
+				buffered = table.tryBuffer(b.Len())
+			}
+			if !buffered && !flush() {
+				return nil
+			}
+		}
+		if !buffered {
+			table.buffer(b.Len())
+		}
+
+		if insert.Len() == 0 {
+			first = row.n
+			fmt.Fprint(&insert, "INSERT INTO ", table.QualifiedName(), " (", table.columnsList(), ")", table.data.valuesSQL())
+		} else if aligned == nil {
+			insert.WriteString(separator)
+		}
+		if aligned != nil {
+			aligned.add(row.aligned)
+		} else {
+			b.WriteTo(&insert)
+		}
+		last = row.n
+	}
+	if insert.Len() != 0 && s.ctx.Err() == nil {
+		flush()
+	}
+	return nil
+}
+
+// stopStream is called once the template executed, whether it ranged over
+// every statement or gave up on a write error. It stops the stages of the
+// stream and waits for them to return, which they would otherwise never do
+// blocked on a statement nobody reads, then closes the rows they left open.
+func (table *table) stopStream() {
+	s := table.stream
+	if s == nil {
+		return
+	}
+	table.stream = nil
+	s.cancel()
+	if err := s.group.Wait(); err != nil && table.Err == nil {
+		table.Err = err
+	}
+	table.stopReading()
+}
+
+// compressStage is the compress stage, encoding what is written to it on a
+// goroutine of its own
+type compressStage struct {
+	chunks chan []byte
+	ctx    context.Context
+	group  *errgroup.Group
+	closed bool
+}
+
+// newCompressStage starts encoding the writes to the stage to w
+func newCompressStage(w io.Writer) *compressStage {
+	group, ctx := errgroup.WithContext(context.Background())
+	c := &compressStage{chunks: make(chan []byte, chunkQueue), ctx: ctx, group: group}
+	group.Go(func() error {
+		for chunk := range c.chunks {
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return c
+}
+
+// Write queues a copy of p, it returns the error of the encoder or the sink
+// once they failed
+func (c *compressStage) Write(p []byte) (int, error) {
+	chunk := append([]byte(nil), p...)
+	select {
+	case <-c.ctx.Done():
+		return 0, c.stopped()
+	default:
+	}
+	select {
+	case c.chunks <- chunk:
+		return len(p), nil
+	case <-c.ctx.Done():
+		return 0, c.stopped()
+	}
+}
+
+// stopped returns the error of a stage that stopped encoding, io.ErrClosedPipe
+// if it was closed without one
+func (c *compressStage) stopped() error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	return io.ErrClosedPipe
+}
+
+// Close waits for the queued writes and returns the first error of the stage
+func (c *compressStage) Close() error {
+	if !c.closed {
+		c.closed = true
+		close(c.chunks)
+	}
+	return c.group.Wait()
+}
@@ -0,0 +1,108 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// insertFailingWriter fails the writes of INSERT statements
+type insertFailingWriter struct{}
+
+func (insertFailingWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "INSERT INTO") {
+		return 0, errors.New("no space left on device")
+	}
+	return len(p), nil
+}
+
+func TestWriteTableDataStopsStream(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	// Every row is a statement of its own, the goroutine has more to send
+	// than the channel holds when the first write fails
+	data.MaxAllowedPacket = 32
+	assert.NoError(t, data.getTemplates())
+	rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""))
+	for i := 0; i < 10; i++ {
+		rows.AddRow(i, "test@test.de")
+	}
+	mockCreateTable(mock, "test")
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(rows).RowsWillBeClosed()
+
+	before := runtime.NumGoroutine()
+	table := data.createTable("test", false)
+	assert.EqualError(t, data.writeTableData(insertFailingWriter{}, table), "no space left on device")
+	assert.Nil(t, table.rows)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "the Stream goroutine leaked")
+}
+
+func TestWriteTableDataFetchError(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	assert.NoError(t, data.getTemplates())
+	rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", "")).
+		AddRow(1, "test@test.de").
+		AddRow(2, "test@test.de").
+		RowError(1, errors.New("Error 2013: Lost connection to MySQL server during query"))
+	mockCreateTable(mock, "test")
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(rows).RowsWillBeClosed()
+
+	var buf bytes.Buffer
+	table := data.createTable("test", false)
+	assert.EqualError(t, data.writeTableData(&buf, table), "Error 2013: Lost connection to MySQL server during query")
+	assert.Nil(t, table.rows)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestCompressStage(t *testing.T) {
+	var buf bytes.Buffer
+	stage := newCompressStage(&buf)
+	for i := 0; i < 100; i++ {
+		_, err := stage.Write([]byte("INSERT INTO `test` VALUES (1);\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, stage.Close())
+	assert.Equal(t, strings.Repeat("INSERT INTO `test` VALUES (1);\n", 100), buf.String())
+
+	_, err := stage.Write([]byte("more"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+func TestCompressStageError(t *testing.T) {
+	stage := newCompressStage(failingWriter{})
+	var err error
+	for i := 0; i < 2*chunkQueue+2 && err == nil; i++ {
+		_, err = stage.Write([]byte("INSERT INTO `test` VALUES (1);\n"))
+	}
+	assert.EqualError(t, err, "no space left on device")
+	assert.EqualError(t, stage.Close(), "no space left on device")
+}
@@ -0,0 +1,120 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// DumpPlan is what a dump with the options of a Data would do, as reported by
+// Plan: the tables and views it would dump with the estimates of the server,
+// the ones it would leave out and the locks it would take.
+type DumpPlan struct {
+	ServerVersion  string
+	Database       string
+	Tables         []PlanTable
+	Ignored        []string
+	Locking        []string
+	EstimatedRows  int64
+	EstimatedBytes int64
+	Warnings       []string
+}
+
+// PlanTable is a table or view of a DumpPlan. The rows and bytes are the
+// estimates of information_schema, which can be far off for InnoDB. Query is
+// the query of the tables added with AddQueryTable, which have no estimates.
+type PlanTable struct {
+	Name          string
+	View          bool
+	Engine        string
+	Transactional bool
+	EstimatedRows int64
+	DataLength    int64
+	Query         string
+}
+
+// Plan looks up what a dump of the current database with the options of data
+// would include without starting one or writing anything.
+func (data *Data) Plan() (*DumpPlan, error) {
+	run := data.newRun()
+	if err := run.checkProxyMode(); err != nil {
+		return nil, err
+	}
+	if err := run.checkPatterns(); err != nil {
+		return nil, err
+	}
+	run.warnings = nil
+
+	plan := &DumpPlan{}
+	var version, database sql.NullString
+	if err := run.Connection.QueryRow("SELECT version(), DATABASE()").Scan(&version, &database); err != nil {
+		return nil, err
+	}
+	plan.ServerVersion, plan.Database = version.String, database.String
+
+	rows, err := run.Connection.Query("SELECT t.TABLE_NAME, t.TABLE_TYPE, t.ENGINE, t.TABLE_ROWS, t.DATA_LENGTH, e.TRANSACTIONS FROM information_schema.TABLES t " +
+		"LEFT JOIN information_schema.ENGINES e ON e.ENGINE = t.ENGINE WHERE t.TABLE_SCHEMA = DATABASE() ORDER BY t.TABLE_NAME")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var nonTransactional []string
+	for rows.Next() {
+		var name, tableType, engine, transactions sql.NullString
+		var estimate, length sql.NullInt64
+		if err := rows.Scan(&name, &tableType, &engine, &estimate, &length, &transactions); err != nil {
+			return nil, err
+		}
+		if run.isIgnoredTable(name.String) {
+			plan.Ignored = append(plan.Ignored, name.String)
+			continue
+		}
+		t := PlanTable{
+			Name:          name.String,
+			View:          tableType.String == "VIEW",
+			Engine:        engine.String,
+			Transactional: transactions.String == "YES",
+			EstimatedRows: estimate.Int64,
+			DataLength:    length.Int64,
+		}
+		if !t.View {
+			plan.EstimatedRows += t.EstimatedRows
+			plan.EstimatedBytes += t.DataLength
+			if !t.Transactional {
+				nonTransactional = append(nonTransactional, "`"+t.Name+"`")
+			}
+		}
+		plan.Tables = append(plan.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, q := range run.queryTables {
+		plan.Tables = append(plan.Tables, PlanTable{Name: q.name, Transactional: true, Query: q.query})
+	}
+
+	plan.Locking = run.plannedLocks(parseServerVersion(version.String), len(plan.Tables) > 0, nonTransactional)
+	plan.Warnings = run.warnings
+	return plan, nil
+}
+
+// plannedLocks lists the locks a dump takes and the snapshot it reads, in the
+// order it takes them
+func (data *Data) plannedLocks(version serverVersion, tables bool, nonTransactional []string) []string {
+	var locks []string
+	if data.BinlogCoordinates || data.Concurrency > 1 {
+		locks = append(locks, data.backupLock(version).name+" while the snapshot is taken")
+	}
+	if data.Concurrency > 1 {
+		locks = append(locks, "START TRANSACTION WITH CONSISTENT SNAPSHOT on the connection of each of the "+strconv.Itoa(data.Concurrency)+" workers")
+	} else {
+		locks = append(locks, "START TRANSACTION WITH CONSISTENT SNAPSHOT")
+	}
+	switch {
+	case data.LockTables && tables:
+		locks = append(locks, "LOCK TABLES READ on every table")
+	case data.NonTransactional == NonTransactionalLock && len(nonTransactional) > 0:
+		locks = append(locks, "LOCK TABLES READ on "+strings.Join(nonTransactional, ", "))
+	}
+	return locks
+}
@@ -0,0 +1,67 @@
+package mysqldump_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockPlan(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`^SELECT version\(\), DATABASE\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()", "DATABASE()"}).AddRow("8.0.36", "shop"))
+	mock.ExpectQuery(`^SELECT t.TABLE_NAME, t.TABLE_TYPE, t.ENGINE, t.TABLE_ROWS, t.DATA_LENGTH, e.TRANSACTIONS FROM information_schema.TABLES t`).
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE", "ENGINE", "TABLE_ROWS", "DATA_LENGTH", "TRANSACTIONS"}).
+			AddRow("counters", "BASE TABLE", "MyISAM", 10, 2048, "NO").
+			AddRow("orders", "BASE TABLE", "InnoDB", 1000, 65536, "YES").
+			AddRow("orders_by_day", "VIEW", nil, nil, nil, nil).
+			AddRow("tmp_import", "BASE TABLE", "InnoDB", 50, 16384, "YES"))
+}
+
+func TestPlan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockPlan(mock)
+
+	data := &mysqldump.Data{Connection: db, ExcludePatterns: []string{"tmp_*"}, NonTransactional: mysqldump.NonTransactionalLock}
+	assert.NoError(t, data.AddQueryTable("totals", "SELECT COUNT(*) FROM orders"))
+	plan, err := data.Plan()
+	assert.NoError(t, err)
+	// Nothing but the discovery queries, no transaction
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, "8.0.36", plan.ServerVersion)
+	assert.Equal(t, "shop", plan.Database)
+	assert.Equal(t, []mysqldump.PlanTable{
+		{Name: "counters", Engine: "MyISAM", EstimatedRows: 10, DataLength: 2048},
+		{Name: "orders", Engine: "InnoDB", Transactional: true, EstimatedRows: 1000, DataLength: 65536},
+		{Name: "orders_by_day", View: true},
+		{Name: "totals", Transactional: true, Query: "SELECT COUNT(*) FROM orders"},
+	}, plan.Tables)
+	assert.Equal(t, []string{"tmp_import"}, plan.Ignored)
+	assert.Equal(t, int64(1010), plan.EstimatedRows)
+	assert.Equal(t, int64(67584), plan.EstimatedBytes)
+	assert.Equal(t, []string{"START TRANSACTION WITH CONSISTENT SNAPSHOT", "LOCK TABLES READ on `counters`"}, plan.Locking)
+}
+
+func TestPlanLocking(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mockPlan(mock)
+
+	plan, err := (&mysqldump.Data{Connection: db, Concurrency: 4, LockTables: true}).Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"FLUSH TABLES WITH READ LOCK while the snapshot is taken",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT on the connection of each of the 4 workers",
+		"LOCK TABLES READ on every table",
+	}, plan.Locking)
+	assert.Empty(t, plan.Ignored)
+}
+
+func TestPlanInvalidPattern(t *testing.T) {
+	_, err := (&mysqldump.Data{ExcludePatterns: []string{"/(/"}}).Plan()
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Preset names a consistent combination of options for a common use case.
+type Preset string
+
+const (
+	// PresetMysqldumpCompatible mirrors the defaults of the mysqldump CLI.
+	PresetMysqldumpCompatible Preset = "mysqldump-compatible"
+	// PresetFastRestore favours large extended inserts that replay quickly and
+	// builds secondary indexes after the data is loaded.
+	PresetFastRestore Preset = "fast-restore"
+	// PresetPortable keeps statements small and self-contained so the dump
+	// restores on servers with conservative settings, binary values written
+	// as hex literals so no raw bytes depend on the charset of the client.
+	PresetPortable Preset = "portable"
+	// PresetAnonymizedDev produces small, self-contained dumps meant for
+	// development databases like PresetPortable, the email, phone, first_name,
+	// last_name, address and password columns of every table masked with
+	// numbered fake values.
+	PresetAnonymizedDev Preset = "anonymized-dev"
+)
+
+// ErrUnknownPreset is returned by ApplyPreset for names it does not know.
+var ErrUnknownPreset = errors.New("unknown preset")
+
+// ApplyPreset sets every option the preset is responsible for, overwriting
+// previous values: MaxAllowedPacket, LockTables, BlobThreshold, DeferIndexes
+// and HexBlob, and with PresetAnonymizedDev the Masks of its columns, which
+// are added to the other Masks. Options the preset does not cover are left
+// untouched, like CharsetConvert, SessionCollation and TargetVersion that
+// depend on the server the dump is restored on, so it should be applied
+// before customizing individual options.
+func (data *Data) ApplyPreset(preset Preset) error {
+	switch preset {
+	case PresetMysqldumpCompatible:
+		data.MaxAllowedPacket = defaultMaxAllowedPacket
+		data.LockTables = true
+		data.BlobThreshold = 0
+		data.DeferIndexes = false
+		data.HexBlob = false
+	case PresetFastRestore:
+		data.MaxAllowedPacket = 16 * 1024 * 1024
+		data.LockTables = false
+		data.BlobThreshold = 0
+		data.DeferIndexes = true
+		data.HexBlob = false
+	case PresetPortable:
+		data.MaxAllowedPacket = 1024 * 1024
+		data.LockTables = false
+		data.BlobThreshold = 0
+		data.DeferIndexes = false
+		data.HexBlob = true
+	case PresetAnonymizedDev:
+		data.MaxAllowedPacket = 1024 * 1024
+		data.LockTables = false
+		data.BlobThreshold = 0
+		data.DeferIndexes = false
+		data.HexBlob = true
+		if data.Masks == nil {
+			data.Masks = map[string]Masker{}
+		}
+		for column, masker := range devMasks() {
+			data.Masks[column] = masker
+		}
+	default:
+		return ErrUnknownPreset
+	}
+	return nil
+}
+
+// devMasks returns the Masks of PresetAnonymizedDev. Each column gets the next
+// number of its format the first time a value shows up in it and the same
+// fake value afterwards, so joins on the masked columns survive and nothing of
+// the original values is left to reverse.
+func devMasks() map[string]Masker {
+	email := &MaskMapping{Masker: &sequenceMasker{format: "user%d@example.com"}, Fold: true}
+	return map[string]Masker{
+		"*.email":      email,
+		"*.phone":      NewMaskMapping(&sequenceMasker{format: "+1 555 %07d"}),
+		"*.first_name": NewMaskMapping(&sequenceMasker{format: "First%d"}),
+		"*.last_name":  NewMaskMapping(&sequenceMasker{format: "Last%d"}),
+		"*.address":    NewMaskMapping(&sequenceMasker{format: "%d Example Street"}),
+		"*.password":   MaskFunc(func(string) string { return "" }),
+	}
+}
+
+// sequenceMasker masks every value with the next number of format, it relies
+// on the lock of the MaskMapping around it
+type sequenceMasker struct {
+	format string
+	n      int
+}
+
+func (m *sequenceMasker) Mask(string) string {
+	m.n++
+	return fmt.Sprintf(m.format, m.n)
+}
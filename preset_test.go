@@ -0,0 +1,57 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPreset(t *testing.T) {
+	data := &Data{BlobThreshold: 1024, IgnoreTables: []string{"secret"}}
+
+	assert.NoError(t, data.ApplyPreset(PresetMysqldumpCompatible))
+	assert.True(t, data.LockTables)
+	assert.Equal(t, defaultMaxAllowedPacket, data.MaxAllowedPacket)
+	assert.Equal(t, 0, data.BlobThreshold)
+	assert.Equal(t, []string{"secret"}, data.IgnoreTables)
+
+	assert.NoError(t, data.ApplyPreset(PresetFastRestore))
+	assert.False(t, data.LockTables)
+	assert.Equal(t, 16*1024*1024, data.MaxAllowedPacket)
+	assert.True(t, data.DeferIndexes)
+	assert.False(t, data.HexBlob)
+
+	data.CharsetConvert = true
+	assert.NoError(t, data.ApplyPreset(PresetPortable))
+	assert.True(t, data.HexBlob)
+	assert.True(t, data.CharsetConvert)
+}
+
+func TestApplyPresetAnonymizedDev(t *testing.T) {
+	data := &Data{Masks: map[string]Masker{"users.ssn": MaskFunc(func(string) string { return "" })}}
+
+	assert.NoError(t, data.ApplyPreset(PresetAnonymizedDev))
+	assert.Equal(t, 1024*1024, data.MaxAllowedPacket)
+	assert.True(t, data.HexBlob)
+	// The Masks of the caller are kept next to the ones of the preset
+	assert.Equal(t, "", data.Masks["users.ssn"].Mask("078-05-1120"))
+
+	email := data.Masks["*.email"]
+	assert.Equal(t, "user1@example.com", email.Mask("jane@corp.com"))
+	assert.Equal(t, "user2@example.com", email.Mask("john@corp.com"))
+	assert.Equal(t, "user1@example.com", email.Mask("Jane@Corp.com"))
+	assert.Equal(t, "+1 555 0000001", data.Masks["*.phone"].Mask("+41 79 123 45 67"))
+	assert.Equal(t, "First1", data.Masks["*.first_name"].Mask("Jane"))
+	assert.Equal(t, "", data.Masks["*.password"].Mask("$2y$10$secret"))
+
+	// Every application masks from scratch
+	assert.NoError(t, data.ApplyPreset(PresetAnonymizedDev))
+	assert.Equal(t, "user1@example.com", data.Masks["*.email"].Mask("john@corp.com"))
+}
+
+func TestApplyPresetUnknown(t *testing.T) {
+	data := &Data{MaxAllowedPacket: 10}
+
+	assert.Equal(t, ErrUnknownPreset, data.ApplyPreset("nope"))
+	assert.Equal(t, 10, data.MaxAllowedPacket)
+}
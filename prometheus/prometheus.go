@@ -0,0 +1,85 @@
+// Package prometheus exports the metrics of a mysqldump.Collector through a
+// Prometheus registry, next to the metrics of the rest of the program:
+//
+//	metrics := mysqldump.NewCollector("backup")
+//	prometheus.MustRegister(mysqldumpprom.NewCollector(metrics))
+//	data.Metrics = metrics
+//
+// It lives in a module of its own so that the dependency on
+// github.com/prometheus/client_golang is only taken by the programs using it.
+// The Collector of mysqldump serves the same metrics without it through
+// ServeHTTP.
+package prometheus
+
+import (
+	"sort"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector of the metrics of a mysqldump.Collector.
+type Collector struct {
+	metrics *mysqldump.Collector
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns the prometheus.Collector of metrics.
+func NewCollector(metrics *mysqldump.Collector) *Collector {
+	return &Collector{metrics: metrics}
+}
+
+// Describe sends the descriptions of the metrics, the errors by cause
+// included before any dump failed.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	described := map[string]bool{}
+	for _, m := range c.metrics.Metrics() {
+		if !described[m.Name] {
+			described[m.Name] = true
+			ch <- desc(m)
+		}
+	}
+	if name := c.name("errors_total"); !described[name] {
+		ch <- prometheus.NewDesc(name, "Dumps failed, by cause.", []string{"cause"}, nil)
+	}
+}
+
+// Collect sends the current samples of the metrics.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.metrics.Metrics() {
+		typ := prometheus.GaugeValue
+		if m.Type == "counter" {
+			typ = prometheus.CounterValue
+		}
+		names := labelNames(m)
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = m.Labels[name]
+		}
+		ch <- prometheus.MustNewConstMetric(desc(m), typ, m.Value, values...)
+	}
+}
+
+// name returns the name of a metric of c, after its namespace
+func (c *Collector) name(s string) string {
+	namespace := c.metrics.Namespace
+	if namespace == "" {
+		namespace = "mysqldump"
+	}
+	return namespace + "_" + s
+}
+
+func desc(m mysqldump.Metric) *prometheus.Desc {
+	return prometheus.NewDesc(m.Name, m.Help, labelNames(m), nil)
+}
+
+// labelNames returns the sorted names of the labels of m
+func labelNames(m mysqldump.Metric) []string {
+	names := make([]string, 0, len(m.Labels))
+	for name := range m.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
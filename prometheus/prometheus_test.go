@@ -0,0 +1,54 @@
+package prometheus_test
+
+import (
+	"testing"
+
+	"github.com/jamf/go-mysqldump"
+	mysqldumpprom "github.com/jamf/go-mysqldump/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	metrics := mysqldump.NewCollector("backup")
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(mysqldumpprom.NewCollector(metrics)))
+
+	assert.Error(t, (&mysqldump.Data{Metrics: metrics, LineEnding: "cr"}).Dump())
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range m.GetLabel() {
+				name += "/" + label.GetValue()
+			}
+			if m.GetCounter() != nil {
+				values[name] = m.GetCounter().GetValue()
+			} else {
+				values[name] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), values["backup_dumps_total"])
+	assert.Equal(t, float64(1), values["backup_errors_total/other"])
+	assert.Equal(t, float64(0), values["backup_rows_total"])
+	assert.Contains(t, values, "backup_duration_seconds")
+	assert.Contains(t, values, "backup_memory_bytes")
+}
+
+func TestCollectorDescribe(t *testing.T) {
+	ch := make(chan *prometheus.Desc, 16)
+	mysqldumpprom.NewCollector(&mysqldump.Collector{}).Describe(ch)
+	close(ch)
+	var descs []string
+	for desc := range ch {
+		descs = append(descs, desc.String())
+	}
+	assert.Len(t, descs, 8)
+	assert.Contains(t, descs[6], `fqName: "mysqldump_memory_bytes"`)
+	assert.Contains(t, descs[7], `fqName: "mysqldump_errors_total"`)
+	assert.Contains(t, descs[7], `variableLabels: {cause}`)
+}
@@ -0,0 +1,114 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// ProxyMode tells how a dump deals with a proxy like ProxySQL between it and
+// the servers.
+type ProxyMode string
+
+const (
+	// ProxyDefault connects as if there was no proxy.
+	ProxyDefault ProxyMode = ""
+	// ProxyAuto asks for @@version_comment, which ProxySQL answers itself,
+	// and handles a proxy if it finds one.
+	ProxyAuto ProxyMode = "auto"
+	// ProxyAlways handles a proxy without asking.
+	ProxyAlways ProxyMode = "always"
+)
+
+var (
+	// ErrUnknownProxyMode is returned for proxy modes that don't exist.
+	ErrUnknownProxyMode = errors.New("unknown proxy mode")
+	// ErrBackendSwitched is returned when the statements of a dump behind a
+	// proxy did not all reach the same server.
+	ErrBackendSwitched = errors.New("the proxy switched backends during the dump")
+)
+
+func (data *Data) checkProxyMode() error {
+	switch data.Proxy {
+	case ProxyDefault, ProxyAuto, ProxyAlways:
+		return nil
+	}
+	return ErrUnknownProxyMode
+}
+
+// hintTx prefixes every statement with a comment the proxy routes by, like
+// the /* hostgroup=1 */ annotation of ProxySQL
+type hintTx struct {
+	transaction
+	hint string
+}
+
+func (tx *hintTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.transaction.Exec(tx.hint+" "+query, args...)
+}
+
+func (tx *hintTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.transaction.Query(tx.hint+" "+query, args...)
+}
+
+func (tx *hintTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.transaction.QueryContext(ctx, tx.hint+" "+query, args...)
+}
+
+func (tx *hintTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.transaction.QueryRow(tx.hint+" "+query, args...)
+}
+
+func (tx *hintTx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.transaction.Prepare(tx.hint + " " + query)
+}
+
+// hinted prefixes query with the ProxyHint
+func (data *Data) hinted(query string) string {
+	if data.ProxyHint == "" {
+		return query
+	}
+	return data.ProxyHint + " " + query
+}
+
+// beginPinned starts the transaction with the ProxyHint on the statement
+// that starts it, which is where proxies pick the backend of a transaction
+func (data *Data) beginPinned() error {
+	ctx := data.context()
+	conn, err := data.Connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	if err := data.startSnapshot(ctx, conn); err != nil {
+		conn.ExecContext(context.Background(), "ROLLBACK")
+		conn.Close()
+		return err
+	}
+	data.tx = &connTx{ctx: ctx, conn: conn}
+	return nil
+}
+
+// detectProxy reports whether the dump runs behind a proxy
+func (data *Data) detectProxy() (bool, error) {
+	switch data.Proxy {
+	case ProxyAlways:
+		return true, nil
+	case ProxyAuto:
+		var comment sql.NullString
+		if err := data.tx.QueryRow("SELECT @@version_comment LIMIT 1").Scan(&comment); err != nil {
+			return false, err
+		}
+		return strings.Contains(strings.ToLower(comment.String), "proxysql"), nil
+	}
+	return false, nil
+}
+
+// backend identifies the server the transaction runs on
+func (data *Data) backend() (string, error) {
+	var id, host sql.NullString
+	if err := data.tx.QueryRow("SELECT @@server_id, @@hostname").Scan(&id, &host); err != nil {
+		return "", err
+	}
+	return id.String + "@" + host.String, nil
+}
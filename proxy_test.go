@@ -0,0 +1,81 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProxiedDump expects a dump of Test_Table through ProxySQL, the server
+// reached last is backend
+func mockProxiedDump(mock sqlmock.Sqlmock, hint, backend string) {
+	if hint != "" {
+		mock.ExpectExec(`^` + hint + ` SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^` + hint + ` START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	} else {
+		mock.ExpectBegin()
+	}
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT @@version_comment LIMIT 1$`).WillReturnRows(sqlmock.NewRows([]string{"@@version_comment"}).AddRow("(ProxySQL)"))
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT @@server_id, @@hostname$`).WillReturnRows(sqlmock.NewRows([]string{"@@server_id", "@@hostname"}).AddRow("1", "db-1"))
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT TABLE_NAME, TABLE_TYPE, ENGINE, TABLE_ROWS FROM information_schema.TABLES`).WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE", "ENGINE", "TABLE_ROWS"}).AddRow("Test_Table", "BASE TABLE", "InnoDB", 1))
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS`).WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_KEY", "EXTRA"}).AddRow("Test_Table", "id", "int", "", ""))
+	mock.ExpectQuery("^(" + hint + " )?SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int)"))
+	mock.ExpectQuery("^(" + hint + " )?SELECT `id` FROM `Test_Table`$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(c("id", 0)).AddRow(1))
+	mock.ExpectQuery(`^(` + hint + ` )?SELECT @@server_id, @@hostname$`).WillReturnRows(sqlmock.NewRows([]string{"@@server_id", "@@hostname"}).AddRow("1", backend))
+	if hint != "" {
+		mock.ExpectExec(`^ROLLBACK$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	} else {
+		mock.ExpectRollback()
+	}
+}
+
+func TestDumpBehindProxy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockProxiedDump(mock, `/\* hostgroup=1 \*/`, "db-2")
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &buf,
+		LockTables: true,
+		Proxy:      mysqldump.ProxyAuto,
+		ProxyHint:  "/* hostgroup=1 */",
+	}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, mysqldump.ErrBackendSwitched), "%v", err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpBehindProxySameBackend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockProxiedDump(mock, "", "db-1")
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, LockTables: true, Proxy: mysqldump.ProxyAuto}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Contains(t, buf.String(), "INSERT INTO `Test_Table` (`id`) VALUES (1);")
+	assert.Equal(t, []string{"LOCK TABLES skipped behind a proxy, it could reach another server than the transaction"}, data.Warnings())
+}
+
+func TestDumpUnknownProxyMode(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}, Proxy: "maxscale"}
+	assert.Equal(t, mysqldump.ErrUnknownProxyMode, data.Dump())
+}
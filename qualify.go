@@ -0,0 +1,85 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// qualifyNames resolves the database QualifyNames prefixes the names with,
+// the current one unless database is given
+func (data *Data) qualifyNames(database string) error {
+	if database == "" {
+		var current sql.NullString
+		if err := data.tx.QueryRow("SELECT DATABASE()").Scan(&current); err != nil {
+			return err
+		}
+		if !current.Valid {
+			return errors.New("no database selected to qualify the names with")
+		}
+		database = current.String
+	}
+	data.qualifier = "`" + strings.Replace(database, "`", "``", -1) + "`."
+	return nil
+}
+
+// QualifiedName is the name of the table in the statements of the dump,
+// prefixed with the database if QualifyNames is set
+func (table *table) QualifiedName() string {
+	return table.data.qualifier + table.NameEsc()
+}
+
+// QualifiedName is the name of the stored program in the statements of the
+// dump, prefixed with the database if QualifyNames is set
+func (o *object) QualifiedName() string {
+	return o.qualifier + o.NameEsc()
+}
+
+// qualifyDDL prefixes the name following keyword in the CREATE statement ddl
+// with the database. The table of a trigger is qualified as well, it is looked
+// up in the default database otherwise.
+func (data *Data) qualifyDDL(ddl, keyword, nameEsc string) string {
+	if data.qualifier == "" {
+		return ddl
+	}
+	i := strings.Index(ddl, keyword+" "+nameEsc)
+	if i < 0 {
+		return ddl
+	}
+	i += len(keyword) + 1
+	ddl = ddl[:i] + data.qualifier + ddl[i:]
+	if keyword == "TRIGGER" {
+		if j := strings.Index(ddl[i:], " ON `"); j >= 0 {
+			j += i + len(" ON ")
+			ddl = ddl[:j] + data.qualifier + ddl[j:]
+		}
+	}
+	return ddl
+}
+
+// qualifyView qualifies the name of a view and replaces its body with the one
+// of information_schema, where the tables it selects from are qualified, to
+// restore it without a default database
+func (table *table) qualifyView(create string) (string, error) {
+	if table.data.qualifier == "" {
+		return create, nil
+	}
+	create = table.data.qualifyDDL(create, "VIEW", table.NameEsc())
+	as := strings.Index(create, table.QualifiedName()+" AS ")
+	if as < 0 {
+		return create, nil
+	}
+	var definition, check sql.NullString
+	err := table.txn().QueryRow("SELECT VIEW_DEFINITION, CHECK_OPTION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table.Name).Scan(&definition, &check)
+	if err != nil {
+		return "", err
+	}
+	if !definition.Valid || definition.String == "" {
+		return create, nil
+	}
+	create = create[:as+len(table.QualifiedName())+len(" AS ")] + definition.String
+	if check.String != "" && check.String != "NONE" {
+		create += " WITH " + check.String + " CHECK OPTION"
+	}
+	return create, nil
+}
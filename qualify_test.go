@@ -0,0 +1,64 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualifyDDL(t *testing.T) {
+	data := &Data{qualifier: "`shop`."}
+
+	assert.Equal(t, "CREATE TABLE `shop`.`orders` (\n  `id` int NOT NULL\n)",
+		data.qualifyDDL("CREATE TABLE `orders` (\n  `id` int NOT NULL\n)", "TABLE", "`orders`"))
+	assert.Equal(t, "CREATE DEFINER=`root`@`%` PROCEDURE `shop`.`refresh`()\nBEGIN\n  SELECT 1;\nEND",
+		data.qualifyDDL("CREATE DEFINER=`root`@`%` PROCEDURE `refresh`()\nBEGIN\n  SELECT 1;\nEND", "PROCEDURE", "`refresh`"))
+	assert.Equal(t, "CREATE DEFINER=`root`@`%` TRIGGER `shop`.`audit` BEFORE INSERT ON `shop`.`orders` FOR EACH ROW SET NEW.id = 1",
+		data.qualifyDDL("CREATE DEFINER=`root`@`%` TRIGGER `audit` BEFORE INSERT ON `orders` FOR EACH ROW SET NEW.id = 1", "TRIGGER", "`audit`"))
+
+	data.qualifier = ""
+	assert.Equal(t, "CREATE TABLE `orders` (`id` int)", data.qualifyDDL("CREATE TABLE `orders` (`id` int)", "TABLE", "`orders`"))
+}
+
+func TestParseDropQualified(t *testing.T) {
+	drop, ok := parseDrop("DROP TABLE IF EXISTS `shop`.`orders`")
+	assert.True(t, ok)
+	assert.Equal(t, RestoreDrop{Kind: "TABLE", Name: "orders"}, drop)
+}
+
+func TestDumpQualifyNames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT DATABASE\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("shop"))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRows([]string{"Tables_in_shop", "Table_type"}).AddRow("orders", "BASE TABLE").AddRow("big_orders", "VIEW"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `orders`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("orders", "CREATE TABLE `orders` (`id` int NOT NULL, PRIMARY KEY (`id`))"))
+	mockTableSelect(mock, "orders")
+	mock.ExpectQuery("^SHOW CREATE TABLE `big_orders`$").WillReturnRows(
+		sqlmock.NewRows([]string{"View", "Create View"}).AddRow("big_orders", "CREATE ALGORITHM=UNDEFINED SQL SECURITY DEFINER VIEW `big_orders` AS select `orders`.`id` AS `id` from `orders` where (`orders`.`id` > 1)"))
+	mock.ExpectQuery(`^SELECT VIEW_DEFINITION, CHECK_OPTION FROM information_schema.VIEWS`).WithArgs("big_orders").WillReturnRows(
+		sqlmock.NewRows([]string{"VIEW_DEFINITION", "CHECK_OPTION"}).AddRow("select `shop`.`orders`.`id` AS `id` from `shop`.`orders` where (`shop`.`orders`.`id` > 1)", "NONE"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &Data{Connection: db, Out: &buf, QualifyNames: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	assert.Contains(t, out, "-- Table structure for table `orders`\n")
+	assert.Contains(t, out, "DROP TABLE IF EXISTS `shop`.`orders`;")
+	assert.Contains(t, out, "CREATE TABLE `shop`.`orders` (`id` int NOT NULL, PRIMARY KEY (`id`));")
+	assert.Contains(t, out, "LOCK TABLES `shop`.`orders` WRITE;")
+	assert.Contains(t, out, "INSERT INTO `shop`.`orders` (`id`, `email`, `name`) VALUES ")
+	assert.Contains(t, out, "DROP VIEW IF EXISTS `shop`.`big_orders`;")
+	assert.Contains(t, out, "CREATE ALGORITHM=UNDEFINED SQL SECURITY DEFINER VIEW `shop`.`big_orders` AS select `shop`.`orders`.`id` AS `id` from `shop`.`orders` where (`shop`.`orders`.`id` > 1);")
+	assert.NotContains(t, out, "USE ")
+}
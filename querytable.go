@@ -0,0 +1,131 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidQueryTable is returned by AddQueryTable for an empty name or
+	// query, or a name with a backtick.
+	ErrInvalidQueryTable = errors.New("query table needs a name without backticks and a query")
+	// ErrDuplicateTable is returned when a query table has the name of a
+	// dumped table or of another query table.
+	ErrDuplicateTable = errors.New("duplicate table name")
+)
+
+// queryTable is a table of the dump made of the result of a query
+type queryTable struct {
+	name  string
+	query string
+}
+
+// AddQueryTable adds the result of the SELECT statement query to the dump as
+// the table name, written as a CREATE TABLE with a column for every column of
+// the result followed by its rows. It is dumped along with the tables of the
+// database, in the same transaction, whatever IncludeTables and IgnoreTables
+// are. The column types are the ones reported by the driver.
+func (data *Data) AddQueryTable(name, query string) error {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	if name == "" || strings.Contains(name, "`") || query == "" {
+		return ErrInvalidQueryTable
+	}
+	for _, q := range data.queryTables {
+		if q.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicateTable, name)
+		}
+	}
+	data.queryTables = append(data.queryTables, queryTable{name: name, query: query})
+	return nil
+}
+
+// addQueryTables appends the query tables to tables
+func (data *Data) addQueryTables(tables []*table) ([]*table, error) {
+	names := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		names[table.Name] = true
+	}
+	for _, q := range data.queryTables {
+		if names[q.name] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTable, q.name)
+		}
+		table := data.createTable(q.name, false)
+		table.query = q.query
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// fromSQL is what the rows of the table are selected from
+func (table *table) fromSQL() string {
+	if table.query != "" {
+		return "(" + table.query + ") AS " + table.NameEsc()
+	}
+	if table.source != "" {
+		return "`" + table.source + "` AS " + table.NameEsc()
+	}
+	return table.NameEsc()
+}
+
+// describeQuery reads the columns of the result of the query of the table
+// without reading any row
+func (table *table) describeQuery() error {
+	if table.columnsLoaded {
+		return nil
+	}
+	rows, err := table.txn().Query("SELECT * FROM " + table.fromSQL() + " LIMIT 0")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	table.cols = make([]string, len(types))
+	table.colTypes = make([]string, len(types))
+	defs := make([]string, len(types))
+	for i, tp := range types {
+		table.cols[i] = tp.Name()
+		table.colTypes[i] = strings.ToLower(columnDDL(tp))
+		defs[i] = "  `" + tp.Name() + "` " + columnDDL(tp)
+		if nullable, ok := tp.Nullable(); ok && !nullable {
+			defs[i] += " NOT NULL"
+		}
+	}
+	table.createSQL = "CREATE TABLE " + table.NameEsc() + " (\n" + strings.Join(defs, ",\n") + "\n)"
+	table.columnsLoaded = true
+	return rows.Err()
+}
+
+// columnDDL returns the type of a column of a query table, falling back to
+// TEXT and BLOB where the driver does not report the size
+func columnDDL(tp *sql.ColumnType) string {
+	name := strings.ToUpper(tp.DatabaseTypeName())
+	if strings.HasPrefix(name, "UNSIGNED ") {
+		return strings.TrimPrefix(name, "UNSIGNED ") + " UNSIGNED"
+	}
+	switch name {
+	case "CHAR", "VARCHAR":
+		if length, ok := tp.Length(); ok && length > 0 && length <= 16383 {
+			return fmt.Sprintf("%s(%d)", name, length)
+		}
+		return "TEXT"
+	case "BINARY", "VARBINARY":
+		if length, ok := tp.Length(); ok && length > 0 && length <= 65535 {
+			return fmt.Sprintf("%s(%d)", name, length)
+		}
+		return "BLOB"
+	case "DECIMAL":
+		if precision, scale, ok := tp.DecimalSize(); ok && precision > 0 {
+			return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+		}
+		return "DECIMAL(65,30)"
+	case "", "ENUM", "SET", "NULL":
+		return "TEXT"
+	}
+	return name
+}
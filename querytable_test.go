@@ -0,0 +1,77 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+const queryTableQuery = "SELECT customer_id, SUM(total) AS revenue, MAX(email) AS email FROM orders GROUP BY customer_id"
+
+func queryTableColumns() []*sqlmock.Column {
+	return []*sqlmock.Column{
+		sqlmock.NewColumn("customer_id").OfType("UNSIGNED BIGINT", int64(0)).Nullable(false),
+		sqlmock.NewColumn("revenue").OfType("DECIMAL", "").WithPrecisionAndScale(32, 2).Nullable(true),
+		sqlmock.NewColumn("email").OfType("VARCHAR", "").WithLength(255).Nullable(true),
+	}
+}
+
+func TestAddQueryTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}))
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT * FROM ("+queryTableQuery+") AS `revenue` LIMIT 0") + "$").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(queryTableColumns()...))
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT `customer_id`, `revenue`, `email` FROM ("+queryTableQuery+") AS `revenue`") + "$").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(queryTableColumns()...).
+			AddRow(int64(1), "10.50", "a@example.com").
+			AddRow(int64(2), "3.00", nil))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf}
+	assert.NoError(t, data.AddQueryTable("revenue", queryTableQuery+";"))
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Contains(t, buf.String(), "DROP TABLE IF EXISTS `revenue`;")
+	assert.Contains(t, buf.String(), "CREATE TABLE `revenue` (\n  `customer_id` BIGINT UNSIGNED NOT NULL,\n  `revenue` DECIMAL(32,2),\n  `email` VARCHAR(255)\n);")
+	assert.Contains(t, buf.String(), "INSERT INTO `revenue` (`customer_id`, `revenue`, `email`) VALUES (1,'10.50','a@example.com'),(2,'3.00',NULL);")
+	assert.Equal(t, "revenue", data.Report()[0].Name)
+}
+
+func TestAddQueryTableInvalid(t *testing.T) {
+	data := &mysqldump.Data{}
+	assert.Equal(t, mysqldump.ErrInvalidQueryTable, data.AddQueryTable("", "SELECT 1"))
+	assert.Equal(t, mysqldump.ErrInvalidQueryTable, data.AddQueryTable("a`b", "SELECT 1"))
+	assert.Equal(t, mysqldump.ErrInvalidQueryTable, data.AddQueryTable("a", " ; "))
+	assert.NoError(t, data.AddQueryTable("a", "SELECT 1"))
+	assert.True(t, errors.Is(data.AddQueryTable("a", "SELECT 2"), mysqldump.ErrDuplicateTable))
+}
+
+func TestAddQueryTableConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("orders", "BASE TABLE"))
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}}
+	assert.NoError(t, data.AddQueryTable("orders", "SELECT 1"))
+	err = data.Dump()
+	assert.True(t, errors.Is(err, mysqldump.ErrDuplicateTable))
+	assert.EqualError(t, err, "duplicate table name: orders")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
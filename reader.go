@@ -0,0 +1,38 @@
+package mysqldump
+
+import "io"
+
+// NewReader returns a reader producing the dump of data lazily as it is read.
+// The dump starts with the first Read and data.Out is replaced by the writing
+// end of the pipe, so it must not be used otherwise until the reader is done.
+//
+// Errors of the dump are returned by Read once the output before them has been
+// consumed. Closing the reader early aborts the dump.
+func NewReader(data *Data) io.ReadCloser {
+	pr, pw := io.Pipe()
+	data.Out = pw
+	return &reader{data: data, pr: pr, pw: pw}
+}
+
+type reader struct {
+	data    *Data
+	pr      *io.PipeReader
+	pw      *io.PipeWriter
+	started bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		go func() {
+			r.pw.CloseWithError(r.data.Dump())
+		}()
+	}
+	return r.pr.Read(p)
+}
+
+// Close stops the dump if it is still running. The pending write of the dump
+// fails, which makes it roll back and return.
+func (r *reader) Close() error {
+	return r.pr.Close()
+}
@@ -0,0 +1,49 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReaderOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	r := mysqldump.NewReader(&mysqldump.Data{Connection: db})
+	defer r.Close()
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, r)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	result := strings.Replace(strings.Split(buf.String(), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+}
+
+func TestNewReaderClose(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	r := mysqldump.NewReader(&mysqldump.Data{Connection: db})
+	_, err = io.CopyN(ioutil.Discard, r, 10)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+
+	_, err = r.Read(make([]byte, 1))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
@@ -0,0 +1,44 @@
+package mysqldump
+
+import "sort"
+
+// listedNames returns the sorted names of the tables
+func listedNames(tables []*table) []string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recheckTables lists the tables again with RecheckTables and warns about the
+// ones created or dropped since the dump listed them. The transaction reads
+// the rows of its snapshot, but not the tables of one: a table created in the
+// meantime is missing from the dump, one dropped after its rows were read is
+// in it although it no longer exists.
+func (data *Data) recheckTables() error {
+	if !data.RecheckTables {
+		return nil
+	}
+	tables, err := data.getTables()
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool, len(data.listed))
+	for _, name := range data.listed {
+		listed[name] = true
+	}
+	for _, name := range listedNames(tables) {
+		if !listed[name] {
+			data.warn("table `" + name + "` created during the dump, it is not in it")
+		}
+		delete(listed, name)
+	}
+	for _, name := range data.listed {
+		if listed[name] {
+			data.warn("table `" + name + "` dropped during the dump")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package mysqldump
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecheckTables(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.RecheckTables = true
+	data.IgnoreTables = []string{"ignored"}
+	data.listed = []string{"dropped", "kept"}
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("kept", "BASE TABLE").
+		AddRow("created", "VIEW").
+		AddRow("ignored", "BASE TABLE"))
+
+	assert.NoError(t, data.recheckTables())
+	assert.Equal(t, []string{
+		"table `created` created during the dump, it is not in it",
+		"table `dropped` dropped during the dump",
+	}, data.Warnings())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRecheckTablesUnchanged(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.RecheckTables = true
+	data.listed = []string{"a", "b"}
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("b", "BASE TABLE").
+		AddRow("a", "BASE TABLE"))
+
+	assert.NoError(t, data.recheckTables())
+	assert.Empty(t, data.Warnings())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	// Without RecheckTables the tables are listed once
+	data.RecheckTables = false
+	assert.NoError(t, data.recheckTables())
+}
+
+func TestDumpRecheckTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	// Both listings are read in the transaction of the dump, the second one
+	// once the rows are
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("test", "BASE TABLE"))
+	mockCreateTable(mock, "test")
+	mockTableSelect(mock, "test")
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).
+		AddRow("test", "BASE TABLE").
+		AddRow("test_new", "BASE TABLE"))
+	mock.ExpectRollback()
+
+	data := &Data{Out: ioutil.Discard, Connection: db, RecheckTables: true, MaxAllowedPacket: defaultMaxAllowedPacket}
+	assert.NoError(t, data.Dump())
+	assert.Equal(t, []string{"table `test_new` created during the dump, it is not in it"}, data.Warnings())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
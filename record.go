@@ -0,0 +1,108 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Status of a recorded dump run
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// BackupRecord describes a single dump run for a backup catalog.
+type BackupRecord struct {
+	Name     string
+	Location string
+	Format   string
+	Database string
+	Bytes    int64
+	Checksum string // sha256 of the written dump
+	Start    time.Time
+	Duration time.Duration
+	Status   string
+	Error    string
+}
+
+// Recorder keeps a catalog of dump runs. Runner calls Record once per run,
+// whether the dump succeeded or not.
+type Recorder interface {
+	Record(ctx context.Context, record BackupRecord) error
+}
+
+// ErrNoRecordTable is returned by SQLRecorder without a table name.
+var ErrNoRecordTable = errors.New("no table to record dumps in")
+
+/*
+SQLRecorder records dump runs as rows of a table, the one created by
+CreateTable, so the catalog can be queried with SQL.
+
+	DB:    Database holding the catalog, usually not the one being dumped
+	Table: Name of the catalog table
+*/
+type SQLRecorder struct {
+	DB    *sql.DB
+	Table string
+}
+
+const recordTableTmpl = `CREATE TABLE IF NOT EXISTS %s (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(255) NOT NULL,
+  location varchar(1024) NOT NULL,
+  format varchar(32) NOT NULL,
+  database_name varchar(64) NOT NULL,
+  bytes bigint NOT NULL,
+  checksum char(64) NOT NULL,
+  started_at datetime(6) NOT NULL,
+  duration_seconds double NOT NULL,
+  status varchar(16) NOT NULL,
+  error text,
+  PRIMARY KEY (id),
+  KEY started_at (started_at)
+)`
+
+// CreateTable creates the catalog table if it does not exist yet.
+func (r *SQLRecorder) CreateTable(ctx context.Context) error {
+	name, err := r.tableName()
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.ExecContext(ctx, strings.Replace(recordTableTmpl, "%s", name, 1))
+	return err
+}
+
+// Record inserts record into the catalog table.
+func (r *SQLRecorder) Record(ctx context.Context, record BackupRecord) error {
+	name, err := r.tableName()
+	if err != nil {
+		return err
+	}
+	var recordErr interface{}
+	if record.Error != "" {
+		recordErr = record.Error
+	}
+	_, err = r.DB.ExecContext(ctx, "INSERT INTO "+name+" (name, location, format, database_name, bytes, checksum, started_at, duration_seconds, status, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		record.Name,
+		record.Location,
+		record.Format,
+		record.Database,
+		record.Bytes,
+		record.Checksum,
+		record.Start.UTC(),
+		record.Duration.Seconds(),
+		record.Status,
+		recordErr,
+	)
+	return err
+}
+
+func (r *SQLRecorder) tableName() (string, error) {
+	if r.Table == "" {
+		return "", ErrNoRecordTable
+	}
+	return "`" + strings.Replace(r.Table, "`", "``", -1) + "`", nil
+}
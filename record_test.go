@@ -0,0 +1,142 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder []mysqldump.BackupRecord
+
+func (r *recorder) Record(ctx context.Context, record mysqldump.BackupRecord) error {
+	*r = append(*r, record)
+	return nil
+}
+
+func TestRunnerRecorder(t *testing.T) {
+	dsn := fmt.Sprintf("runner-record-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var records recorder
+	r := &mysqldump.Runner{
+		Config: mysqldump.RunnerConfig{
+			Driver:     "sqlmock",
+			DSN:        dsn,
+			OutputDir:  dir,
+			FileFormat: "dump",
+		},
+		Recorder: &records,
+		Log:      &bytes.Buffer{},
+	}
+
+	mockDump(mock)
+	assert.Equal(t, mysqldump.ExitOK, r.Run(context.Background()))
+	mock.ExpectBegin().WillReturnError(errors.New("Error 1045 (28000): Access denied for user"))
+	assert.Equal(t, mysqldump.ExitPermanent, r.Run(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "dump.sql"))
+	assert.NoError(t, err)
+	sum := sha256.Sum256(content)
+
+	assert.Len(t, records, 2)
+	assert.Equal(t, "dump.sql", records[0].Name)
+	assert.Equal(t, filepath.Join(dir, "dump.sql"), records[0].Location)
+	assert.Equal(t, "sql", records[0].Format)
+	assert.EqualValues(t, len(content), records[0].Bytes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), records[0].Checksum)
+	assert.Equal(t, mysqldump.StatusSuccess, records[0].Status)
+	assert.Empty(t, records[0].Error)
+	assert.False(t, records[0].Start.IsZero())
+
+	assert.Equal(t, mysqldump.StatusFailed, records[1].Status)
+	assert.Equal(t, "Error 1045 (28000): Access denied for user", records[1].Error)
+	assert.Zero(t, records[1].Bytes)
+}
+
+// liveRecorder records only on a context that is not done, like a database
+type liveRecorder struct {
+	recorder
+}
+
+func (r *liveRecorder) Record(ctx context.Context, record mysqldump.BackupRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.recorder.Record(ctx, record)
+}
+
+func TestRunnerRecorderCanceled(t *testing.T) {
+	dsn := fmt.Sprintf("runner-record-canceled-%d", os.Getpid())
+	db, _, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	records := &liveRecorder{}
+	r := &mysqldump.Runner{
+		Config:   mysqldump.RunnerConfig{Driver: "sqlmock", DSN: dsn, OutputDir: dir, FileFormat: "dump"},
+		Recorder: records,
+		Log:      &bytes.Buffer{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotEqual(t, mysqldump.ExitOK, r.Run(ctx))
+
+	// The failure of the canceled run is still recorded
+	if assert.Len(t, records.recorder, 1) {
+		assert.Equal(t, mysqldump.StatusFailed, records.recorder[0].Status)
+	}
+}
+
+func TestSQLRecorder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	r := &mysqldump.SQLRecorder{DB: db, Table: "backups"}
+	start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	mock.ExpectExec("^CREATE TABLE IF NOT EXISTS `backups` \\(").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO `backups` \\(name, location, format, database_name, bytes, checksum, started_at, duration_seconds, status, error\\) VALUES").
+		WithArgs("dump.sql", "/backups/dump.sql", "sql", "app", 42, "abc", start, 1.5, mysqldump.StatusFailed, "boom").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, r.CreateTable(context.Background()))
+	assert.NoError(t, r.Record(context.Background(), mysqldump.BackupRecord{
+		Name:     "dump.sql",
+		Location: "/backups/dump.sql",
+		Format:   "sql",
+		Database: "app",
+		Bytes:    42,
+		Checksum: "abc",
+		Start:    start,
+		Duration: 1500 * time.Millisecond,
+		Status:   mysqldump.StatusFailed,
+		Error:    "boom",
+	}))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, mysqldump.ErrNoRecordTable, (&mysqldump.SQLRecorder{DB: db}).Record(context.Background(), mysqldump.BackupRecord{}))
+}
@@ -0,0 +1,130 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidRemap is returned by restores with an Engine, RowFormat, Charset
+// or Collation that is not a plain name, or with a Collation but no Charset.
+var ErrInvalidRemap = errors.New("invalid schema remapping")
+
+var (
+	// invisibleIndexRe matches the INVISIBLE attribute SHOW CREATE TABLE
+	// writes after index definitions, columns have theirs in
+	// /*!80023 INVISIBLE */
+	invisibleIndexRe = regexp.MustCompile(`\s*/\*!80000 INVISIBLE \*/`)
+	// tableDDLRe matches the statements defining tables and their indexes
+	tableDDLRe       = regexp.MustCompile(`(?is)^(?:/\*!\d+\s*)?(?:CREATE|ALTER)\s+(?:TEMPORARY\s+)?TABLE\s`)
+	createDatabaseRe = regexp.MustCompile(`(?is)^CREATE\s+(?:DATABASE|SCHEMA)\s`)
+	remapNameRe      = regexp.MustCompile(`^\w+$`)
+	engineOptionRe   = regexp.MustCompile(`(?i)(\bENGINE\s*=\s*)\w+`)
+	rowFormatRe      = regexp.MustCompile(`(?i)(\bROW_FORMAT\s*=\s*)\w+`)
+	charsetRe        = regexp.MustCompile(`(?i)(\b(?:CHARSET|CHARACTER SET)(?:\s*=\s*|\s+))\w+`)
+	collateRe        = regexp.MustCompile(`(?i)(\s*)\bCOLLATE(\s*=\s*|\s+)\w+`)
+)
+
+// checkRemap validates the names the DDL of the dump is remapped to
+func (r *Restorer) checkRemap() error {
+	for option, name := range map[string]string{"engine": r.Engine, "row format": r.RowFormat, "charset": r.Charset, "collation": r.Collation} {
+		if name != "" && !remapNameRe.MatchString(name) {
+			return fmt.Errorf("%w: %s %q", ErrInvalidRemap, option, name)
+		}
+	}
+	if r.Collation != "" && r.Charset == "" {
+		return fmt.Errorf("%w: collation %s without a charset", ErrInvalidRemap, r.Collation)
+	}
+	return nil
+}
+
+// rewrite adjusts a statement of the dump to the options of the restore
+// before it is executed
+func (r *Restorer) rewrite(statement string) string {
+	if r.VisibleIndexes && tableDDLRe.MatchString(statement) {
+		statement = invisibleIndexRe.ReplaceAllString(statement, "")
+	}
+	switch {
+	case createTableRe.MatchString(statement):
+		statement = r.remapTable(statement)
+	case r.Charset != "" && createDatabaseRe.MatchString(statement):
+		statement = rewriteOutsideQuotes(statement, r.remapCharset)
+	}
+	return statement
+}
+
+// remapTable forces the engine, row format and charset of a CREATE TABLE.
+// The engine and row format are set in the table options following the
+// column definitions, and in the partitions for the engine, adding them if
+// the dump leaves them to the defaults of the server.
+func (r *Restorer) remapTable(statement string) string {
+	if r.Charset != "" {
+		statement = rewriteOutsideQuotes(statement, r.remapCharset)
+	}
+	end := columnsEnd(statement)
+	if end < 0 {
+		return statement
+	}
+	options := statement[end+1:]
+	var added []string
+	if r.Engine != "" {
+		found := false
+		options = rewriteOutsideQuotes(options, func(s string) string {
+			found = found || engineOptionRe.MatchString(s)
+			return engineOptionRe.ReplaceAllString(s, "${1}"+r.Engine)
+		})
+		if !found {
+			added = append(added, "ENGINE="+r.Engine)
+		}
+	}
+	if r.RowFormat != "" {
+		found := false
+		options = rewriteOutsideQuotes(options, func(s string) string {
+			found = found || rowFormatRe.MatchString(s)
+			return rowFormatRe.ReplaceAllString(s, "${1}"+r.RowFormat)
+		})
+		if !found {
+			added = append(added, "ROW_FORMAT="+r.RowFormat)
+		}
+	}
+	if len(added) > 0 {
+		options = " " + strings.Join(added, " ") + options
+	}
+	return statement[:end+1] + options
+}
+
+// remapCharset replaces the character sets of s by Charset, and its
+// collations by Collation or drops them for the default one of Charset
+func (r *Restorer) remapCharset(s string) string {
+	s = charsetRe.ReplaceAllString(s, "${1}"+r.Charset)
+	if r.Collation != "" {
+		return collateRe.ReplaceAllString(s, "${1}COLLATE${2}"+r.Collation)
+	}
+	return collateRe.ReplaceAllString(s, "")
+}
+
+// columnsEnd returns the index of the parenthesis closing the column
+// definitions of a CREATE TABLE, -1 for CREATE TABLE ... LIKE or SELECT
+// without them
+func columnsEnd(statement string) int {
+	depth := 0
+	for i := 0; i < len(statement); i++ {
+		switch c := statement[i]; c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'', '"', '`':
+			for i++; i < len(statement) && statement[i] != c; i++ {
+				if statement[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,83 @@
+package mysqldump
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const oldTable = "CREATE TABLE `t` (\n" +
+	"  `id` int(11) NOT NULL,\n" +
+	"  `name` varchar(20) CHARACTER SET latin1 COLLATE latin1_bin DEFAULT 'ENGINE=MyISAM',\n" +
+	"  PRIMARY KEY (`id`)\n" +
+	") ENGINE=MyISAM DEFAULT CHARSET=latin1 ROW_FORMAT=COMPACT COMMENT='CHARSET=latin1'"
+
+func TestRemapEngineRowFormat(t *testing.T) {
+	r := &Restorer{Engine: "InnoDB", RowFormat: "DYNAMIC"}
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int(11) NOT NULL,\n"+
+		"  `name` varchar(20) CHARACTER SET latin1 COLLATE latin1_bin DEFAULT 'ENGINE=MyISAM',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1 ROW_FORMAT=DYNAMIC COMMENT='CHARSET=latin1'", r.rewrite(oldTable))
+
+	// Options left to the server defaults are added
+	assert.Equal(t, "CREATE TABLE `t` (`id` int) ENGINE=InnoDB ROW_FORMAT=DYNAMIC", r.rewrite("CREATE TABLE `t` (`id` int)"))
+
+	// Partitions must all have the engine of the table
+	partitioned := "CREATE TABLE `p` (`id` int) ENGINE=MyISAM\n/*!50100 PARTITION BY RANGE (`id`)\n" +
+		"(PARTITION p0 VALUES LESS THAN (10) ENGINE = MyISAM,\n PARTITION p1 VALUES LESS THAN MAXVALUE ENGINE = MyISAM) */"
+	assert.Equal(t, "CREATE TABLE `p` (`id` int) ROW_FORMAT=DYNAMIC ENGINE=InnoDB\n/*!50100 PARTITION BY RANGE (`id`)\n"+
+		"(PARTITION p0 VALUES LESS THAN (10) ENGINE = InnoDB,\n PARTITION p1 VALUES LESS THAN MAXVALUE ENGINE = InnoDB) */", r.rewrite(partitioned))
+
+	// Other statements are left alone
+	insert := "INSERT INTO `t` VALUES (1,'ENGINE=MyISAM')"
+	assert.Equal(t, insert, r.rewrite(insert))
+	assert.Equal(t, "CREATE TABLE `c` LIKE `t`", r.rewrite("CREATE TABLE `c` LIKE `t`"))
+}
+
+func TestRemapCharset(t *testing.T) {
+	r := &Restorer{Charset: "utf8mb4"}
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int(11) NOT NULL,\n"+
+		"  `name` varchar(20) CHARACTER SET utf8mb4 DEFAULT 'ENGINE=MyISAM',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=MyISAM DEFAULT CHARSET=utf8mb4 ROW_FORMAT=COMPACT COMMENT='CHARSET=latin1'", r.rewrite(oldTable))
+
+	r.Collation = "utf8mb4_unicode_ci"
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int(11) NOT NULL,\n"+
+		"  `name` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT 'ENGINE=MyISAM',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=MyISAM DEFAULT CHARSET=utf8mb4 ROW_FORMAT=COMPACT COMMENT='CHARSET=latin1'", r.rewrite(oldTable))
+	assert.Equal(t, "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `db` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci */",
+		r.rewrite("CREATE DATABASE /*!32312 IF NOT EXISTS*/ `db` /*!40100 DEFAULT CHARACTER SET latin1 COLLATE latin1_swedish_ci */"))
+}
+
+func TestRestoreInvalidRemap(t *testing.T) {
+	for _, r := range []*Restorer{
+		{Engine: "InnoDB; DROP TABLE t"},
+		{RowFormat: "DYNAMIC "},
+		{Charset: "utf8mb4*/"},
+		{Collation: "utf8mb4_bin"},
+	} {
+		err := r.Restore(strings.NewReader("SELECT 1;\n"))
+		assert.True(t, errors.Is(err, ErrInvalidRemap), "%v", err)
+	}
+}
+
+func TestRestoreRemap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec("^CREATE TABLE `t` \\(`id` int\\) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO `t` VALUES \\(1\\)$").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true, Engine: "InnoDB", Charset: "utf8mb4"}
+	assert.NoError(t, r.Restore(strings.NewReader("CREATE TABLE `t` (`id` int) ENGINE=MyISAM DEFAULT CHARSET=latin1;\nINSERT INTO `t` VALUES (1);\n")))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
@@ -0,0 +1,113 @@
+package mysqldump
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ReportFormat selects the encoding of the per-table report.
+type ReportFormat int
+
+const (
+	// ReportJSON writes the report as a JSON array.
+	ReportJSON ReportFormat = iota
+	// ReportCSV writes the report as CSV with a header line.
+	ReportCSV
+)
+
+// ErrUnknownReportFormat is returned for report formats that don't exist.
+var ErrUnknownReportFormat = errors.New("unknown report format")
+
+// TableStats is the report entry of a dumped table or view. Bytes counts the
+// dump output of the table, for multi-file dumps the size of its data file.
+type TableStats struct {
+	Name     string        `json:"name"`
+	View     bool          `json:"view,omitempty"`
+	Rows     int64         `json:"rows"`
+	Bytes    int64         `json:"bytes"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+}
+
+var reportHeader = []string{"name", "view", "rows", "bytes", "start", "seconds"}
+
+// Report returns the statistics of every table of the last dump.
+func (data *Data) Report() []TableStats {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.report
+}
+
+// addStats records the statistics of a dumped table
+func (data *Data) addStats(table *table) {
+	duration := time.Since(table.start)
+	data.report = append(data.report, TableStats{
+		Name:     table.Name,
+		View:     table.isView,
+		Rows:     int64(table.row),
+		Bytes:    table.bytes,
+		Start:    table.start,
+		Duration: duration,
+		Seconds:  duration.Seconds(),
+	})
+}
+
+// checkReportFormat fails on unknown report formats before the dump starts
+func (data *Data) checkReportFormat() error {
+	if data.ReportWriter == nil {
+		return nil
+	}
+	switch data.ReportFormat {
+	case ReportJSON, ReportCSV:
+		return nil
+	}
+	return ErrUnknownReportFormat
+}
+
+// writeReport writes the report to ReportWriter if it is set
+func (data *Data) writeReport() error {
+	if data.ReportWriter == nil {
+		return nil
+	}
+	switch data.ReportFormat {
+	case ReportJSON:
+		report := data.report
+		if report == nil {
+			report = []TableStats{}
+		}
+		enc := json.NewEncoder(data.ReportWriter)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case ReportCSV:
+		return writeCSVReport(data.ReportWriter, data.report)
+	}
+	return ErrUnknownReportFormat
+}
+
+func writeCSVReport(w io.Writer, report []TableStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportHeader); err != nil {
+		return err
+	}
+	for _, stats := range report {
+		record := []string{
+			stats.Name,
+			strconv.FormatBool(stats.View),
+			strconv.FormatInt(stats.Rows, 10),
+			strconv.FormatInt(stats.Bytes, 10),
+			stats.Start.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(stats.Seconds, 'f', 6, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,79 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// tableSize is the length of the output of Test_Table in expected, from its
+// structure to the end of its data
+var tableSize = int64(bytes.Index([]byte(expected), []byte("/*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */")) -
+	bytes.Index([]byte(expected), []byte("\n--\n-- Table structure for table")))
+
+func TestDumpReportJSON(t *testing.T) {
+	var out, report bytes.Buffer
+	data := &mysqldump.Data{Out: &out, LockTables: true, ReportWriter: &report}
+	RunDump(t, data)
+
+	var stats []mysqldump.TableStats
+	assert.NoError(t, json.Unmarshal(report.Bytes(), &stats))
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "Test_Table", stats[0].Name)
+	assert.Equal(t, int64(2), stats[0].Rows)
+	assert.Equal(t, tableSize, stats[0].Bytes)
+	assert.False(t, stats[0].Start.IsZero())
+	assert.Equal(t, data.Report()[0].Seconds, stats[0].Seconds)
+}
+
+func TestDumpReportCSV(t *testing.T) {
+	var out, report bytes.Buffer
+	RunDump(t, &mysqldump.Data{Out: &out, LockTables: true, ReportWriter: &report, ReportFormat: mysqldump.ReportCSV})
+
+	records, err := csv.NewReader(&report).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []string{"name", "view", "rows", "bytes", "start", "seconds"}, records[0])
+	assert.Equal(t, []string{"Test_Table", "false", "2", strconv.FormatInt(tableSize, 10)}, records[1][:4])
+}
+
+func TestDumpReportFiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var out, report bytes.Buffer
+	tw := mysqldump.NewTarWriter(&out, false)
+	data := &mysqldump.Data{Connection: db, Files: tw, ReportWriter: &report}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, tw.Close())
+
+	var size int64
+	for _, file := range data.Manifest().Files {
+		if file.Name == "data/Test_Table.sql" {
+			size = file.Size
+		}
+	}
+	assert.NotZero(t, size)
+	assert.Equal(t, size, data.Report()[0].Bytes)
+}
+
+func TestDumpReportUnknownFormat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var out, report bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &out, ReportWriter: &report, ReportFormat: 7}
+	assert.Equal(t, mysqldump.ErrUnknownReportFormat, data.Dump())
+}
@@ -0,0 +1,438 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+/*
+Restorer replays a dump into a database, statement by statement on a single
+connection.
+
+	Connection:       Database the dump is restored into
+	Force:            Restore into a schema that already contains tables
+	SkipVersionCheck: Restore even if the target server is older than the source or of another flavor
+	Checkpoint:       Records completed sections, like a or a/data for the structure or the rows of table a, so an interrupted restore resumes after the last one
+	FromTable:        Start with this table, skipping the ones in front of it, instead of resuming from Checkpoint
+	Manifest:         Verify the restored tables against the manifest of the dump once done
+	Tables:           Restore only the sections of these tables and views, the statements around them still run; the sections the Manifest locates are seeked over in a dump that is an io.ReadSeeker
+	Database:         Restore into this schema instead, the database statements of the dump are skipped
+	DeferTriggers:    Create the triggers of the dump only once everything else is restored, so loading rows fires none
+	TriggerGuard:     User variable set to 1 for the session, for triggers that do nothing while it is set, e.g. DISABLE_TRIGGERS
+	VisibleIndexes:   Create the INVISIBLE indexes of MySQL 8 as visible ones, like for a MariaDB target that would reject the attribute
+	Engine:           Create the tables with this storage engine instead, like InnoDB for the MyISAM tables of old dumps
+	RowFormat:        Create the tables with this ROW_FORMAT, like DYNAMIC for the COMPACT and REDUNDANT tables of old dumps
+	Charset:          Replace the character sets of the databases, tables and columns by this one
+	Collation:        Replace their collations by this one of Charset, which are otherwise dropped for the default collation of Charset
+	Parallel:         Data files RestoreFiles restores at the same time, each on a connection of its own, the largest first (one at a time if 0)
+*/
+type Restorer struct {
+	Connection       *sql.DB
+	Force            bool
+	SkipVersionCheck bool
+	Checkpoint       Checkpoint
+	FromTable        string
+	Manifest         *Manifest
+	Tables           []string
+	Database         string
+	DeferTriggers    bool
+	TriggerGuard     string
+	VisibleIndexes   bool
+	Engine           string
+	RowFormat        string
+	Charset          string
+	Collation        string
+	Parallel         int
+}
+
+// RestorePlan is what a restore of a dump would do, as reported by Plan.
+type RestorePlan struct {
+	Statements int
+	Tables     []string
+	Drops      []RestoreDrop
+}
+
+// RestoreDrop is a table, view or database a dump drops before creating it.
+type RestoreDrop struct {
+	Kind string
+	Name string
+}
+
+func (d RestoreDrop) String() string {
+	return strings.ToLower(d.Kind) + " `" + d.Name + "`"
+}
+
+var (
+	// ErrSchemaNotEmpty is returned when restoring into a schema with tables
+	// without Force.
+	ErrSchemaNotEmpty = errors.New("target schema is not empty")
+	// ErrIncompatibleVersion is returned when the target server is older than
+	// the server the dump was taken from, or of another flavor.
+	ErrIncompatibleVersion = errors.New("target server version is not compatible with the dump")
+	// ErrStatementTooLarge is returned before sending a statement the target
+	// would reject because of its max_allowed_packet.
+	ErrStatementTooLarge = errors.New("statement exceeds max_allowed_packet of the target")
+	// ErrTableNotFound is returned when the table to start from is not part of
+	// the dump.
+	ErrTableNotFound = errors.New("table not found in dump")
+	// ErrInvalidTriggerGuard is returned when TriggerGuard is not a plain
+	// variable name.
+	ErrInvalidTriggerGuard = errors.New("trigger guard must be a variable name")
+)
+
+const (
+	serverVersionComment = "Server version"
+	targetVersionComment = "Target version"
+)
+
+// Restore executes all statements of the dump read from in. The safety checks
+// run before the first statement is executed, except for the packet size
+// which is checked for each statement before sending it.
+//
+// When resuming, the statements in front of the first table section, like the
+// session settings of the header, are executed again while the sections of
+// the tables already restored are skipped.
+//
+// Deferred triggers are created after the last statement, even those of
+// sections skipped when resuming as their DROP TRIGGER IF EXISTS makes this
+// safe.
+func (r *Restorer) Restore(in io.Reader) error {
+	if !isVariableName(r.TriggerGuard) {
+		return ErrInvalidTriggerGuard
+	}
+	if err := r.checkRemap(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := r.Connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := r.use(ctx, conn); err != nil {
+		return err
+	}
+
+	// The sections of the tables left out are not even read when the
+	// manifest tells where they are
+	if rs, ok := in.(io.ReadSeeker); ok && r.Manifest != nil && len(r.Tables) > 0 {
+		if in, err = newSeekingReader(rs, r.skippedSections()); err != nil {
+			return err
+		}
+	}
+
+	scanner := newStatementScanner(in)
+	st, err := scanner.Next()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !r.SkipVersionCheck {
+		if err := checkVersion(ctx, conn, dumpServerVersion(st.Comments)); err != nil {
+			return err
+		}
+	}
+
+	resume := newResumer(r.FromTable)
+	if r.FromTable == "" && r.Checkpoint != nil {
+		last, err := r.Checkpoint.Load()
+		if err != nil {
+			return err
+		}
+		resume = newResumerAfter(last)
+	}
+
+	// A resumed restore finds the tables it has restored before
+	if !r.Force && !resume.skipping {
+		if err := checkEmpty(ctx, conn); err != nil {
+			return err
+		}
+	}
+
+	var maxAllowedPacket int
+	if err := conn.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&maxAllowedPacket); err != nil {
+		return err
+	}
+	exec := func(st *statement) error {
+		query := r.rewrite(st.SQL)
+		if len(query)+1 > maxAllowedPacket {
+			return fmt.Errorf("line %d: %w (%d > %d bytes)", st.Line, ErrStatementTooLarge, len(query)+1, maxAllowedPacket)
+		}
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("line %d: %w", st.Line, err)
+		}
+		return nil
+	}
+
+	if r.TriggerGuard != "" {
+		if _, err := conn.ExecContext(ctx, "SET @"+r.TriggerGuard+" = 1"); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, "SET @"+r.TriggerGuard+" = NULL")
+	}
+
+	var triggers []*statement
+	current := ""
+	for ; err == nil; st, err = scanner.Next() {
+		if table := sectionTable(st.Comments); table != "" && table != current {
+			if err := r.completed(current, resume); err != nil {
+				return err
+			}
+			current = table
+			resume.enter(table)
+		}
+		if st.SQL == "" || r.skipped(st.SQL, current) {
+			continue
+		}
+		if r.DeferTriggers && (strings.HasSuffix(current, "/triggers") || isCreateTrigger(st.SQL)) {
+			triggers = append(triggers, st)
+			continue
+		}
+		if resume.skipping && current != "" {
+			continue
+		}
+		// Never drop what a previous attempt restored
+		if resume.skipping && isDropDatabase(st.SQL) {
+			continue
+		}
+		if err := exec(st); err != nil {
+			return err
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	if resume.missing() {
+		return fmt.Errorf("%w: %s", ErrTableNotFound, resume.from)
+	}
+	if err := r.completed(current, resume); err != nil {
+		return err
+	}
+	for _, st := range triggers {
+		if err := exec(st); err != nil {
+			return err
+		}
+	}
+	// Done, the next restore starts from scratch
+	if r.Checkpoint != nil {
+		if err := r.Checkpoint.Save(""); err != nil {
+			return err
+		}
+	}
+
+	if r.Manifest != nil {
+		report, err := verify(ctx, conn, r.restoredManifest())
+		if err != nil {
+			return err
+		}
+		if !report.Passed {
+			return &VerifyError{Report: report}
+		}
+	}
+	return nil
+}
+
+// Plan reads the dump from in like Restore without executing anything and
+// reports the statements that would run, the tables restored and the objects
+// dropped on the way.
+func (r *Restorer) Plan(in io.Reader) (*RestorePlan, error) {
+	plan := &RestorePlan{}
+	seen := map[string]bool{}
+	scanner := newStatementScanner(in)
+	current := ""
+	for {
+		st, err := scanner.Next()
+		if err == io.EOF {
+			return plan, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if table := sectionTable(st.Comments); table != "" {
+			current = table
+		}
+		if st.SQL == "" || r.skipped(st.SQL, current) {
+			continue
+		}
+		plan.Statements++
+		if name := sectionBase(current); name != "" && !seen[name] {
+			seen[name] = true
+			plan.Tables = append(plan.Tables, name)
+		}
+		if drop, ok := parseDrop(st.SQL); ok {
+			plan.Drops = append(plan.Drops, drop)
+		}
+	}
+}
+
+// Conflicts returns the objects dropped by plan that exist in the target, the
+// tables and views of the target schema and the databases.
+func (r *Restorer) Conflicts(plan *RestorePlan) ([]RestoreDrop, error) {
+	ctx := context.Background()
+	conn, err := r.Connection.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := r.use(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	var conflicts []RestoreDrop
+	for _, drop := range plan.Drops {
+		found := existing[drop.Name]
+		if drop.Kind == "DATABASE" {
+			var count int
+			if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", drop.Name).Scan(&count); err != nil {
+				return nil, err
+			}
+			found = count > 0
+		}
+		if found {
+			conflicts = append(conflicts, drop)
+		}
+	}
+	return conflicts, nil
+}
+
+// use switches conn to the target schema
+func (r *Restorer) use(ctx context.Context, conn *sql.Conn) error {
+	if r.Database == "" {
+		return nil
+	}
+	_, err := conn.ExecContext(ctx, "USE `"+strings.Replace(r.Database, "`", "``", -1)+"`")
+	return err
+}
+
+// skipped reports whether a statement in the section of table is left out
+// because of Tables or Database
+func (r *Restorer) skipped(statement, table string) bool {
+	if r.Database != "" && isDatabaseStatement(statement) {
+		return true
+	}
+	if len(r.Tables) == 0 || table == "" {
+		return false
+	}
+	name := sectionBase(table)
+	for _, t := range r.Tables {
+		if t == name {
+			return false
+		}
+	}
+	return true
+}
+
+// sectionBase is the table of a section named by sectionTable
+func sectionBase(table string) string {
+	if i := strings.IndexByte(table, '/'); i >= 0 {
+		return table[:i]
+	}
+	return table
+}
+
+var dropRe = regexp.MustCompile("(?is)^(?:/\\*!\\d+\\s*)?DROP\\s+(TABLE|VIEW|DATABASE)\\s+(?:IF\\s+EXISTS\\s+)?(?:`(?:[^`]|``)+`\\.)?`((?:[^`]|``)+)`")
+
+// parseDrop recognizes the DROP statements written by Dump
+func parseDrop(statement string) (RestoreDrop, bool) {
+	m := dropRe.FindStringSubmatch(strings.TrimSpace(statement))
+	if m == nil {
+		return RestoreDrop{}, false
+	}
+	return RestoreDrop{Kind: strings.ToUpper(m[1]), Name: strings.Replace(m[2], "``", "`", -1)}, true
+}
+
+var createTriggerRe = regexp.MustCompile(`(?is)^(?:/\*!\d+\s*)?CREATE(?:\s*\*/)?\s+(?:/\*!\d+\s*)?(?:DEFINER\s*=\s*\S+\s*(?:\*/\s*)?)?(?:/\*!\d+\s*)?TRIGGER\s`)
+
+// isCreateTrigger recognizes CREATE TRIGGER statements, including the ones
+// of mysqldump split into versioned comments
+func isCreateTrigger(statement string) bool {
+	return createTriggerRe.MatchString(strings.TrimSpace(statement))
+}
+
+// isVariableName reports whether name can follow @ without quoting
+func isVariableName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isIdentChar(name[i]) && name[i] != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// completed records in the checkpoint that the section of table was restored
+func (r *Restorer) completed(table string, resume *resumer) error {
+	if table == "" || resume.skipping || r.Checkpoint == nil {
+		return nil
+	}
+	return r.Checkpoint.Save(table)
+}
+
+// dumpServerVersion finds the source server version in the header comments of
+// a dump. Dumps made for an older target record that version instead.
+func dumpServerVersion(comments []string) string {
+	version := ""
+	for _, comment := range comments {
+		if strings.HasPrefix(comment, targetVersionComment) {
+			return strings.TrimSpace(strings.TrimPrefix(comment, targetVersionComment))
+		}
+		if strings.HasPrefix(comment, serverVersionComment) {
+			version = strings.TrimSpace(strings.TrimPrefix(comment, serverVersionComment))
+		}
+	}
+	return version
+}
+
+// checkVersion makes sure the target is of the same flavor and at least as new
+// as the source. Dumps without a recorded version pass.
+func checkVersion(ctx context.Context, conn *sql.Conn, source string) error {
+	if source == "" {
+		return nil
+	}
+	var target string
+	if err := conn.QueryRowContext(ctx, "SELECT version()").Scan(&target); err != nil {
+		return err
+	}
+	s, t := parseServerVersion(source), parseServerVersion(target)
+	if s.MariaDB != t.MariaDB || t.less(s) {
+		return fmt.Errorf("%w: dump from %s, target is %s", ErrIncompatibleVersion, source, target)
+	}
+	return nil
+}
+
+// checkEmpty refuses schemas that already contain tables or views
+func checkEmpty(ctx context.Context, conn *sql.Conn) error {
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d tables found", ErrSchemaNotEmpty, count)
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+package mysqldump
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const restoreDump = `-- Go SQL Dump 0.7.0
+--
+-- ------------------------------------------------------
+-- Server version	8.0.34
+
+/*!40101 SET NAMES utf8mb4 */;
+CREATE TABLE ` + "`t`" + ` (id int);
+INSERT INTO ` + "`t`" + ` VALUES (1),(2);
+`
+
+func TestRestoreOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.35"))
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^/\*!40101 SET NAMES utf8mb4 \*/$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `t` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO `t` VALUES \\(1\\),\\(2\\)$").WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, (&Restorer{Connection: db}).Restore(strings.NewReader(restoreDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreOlderTarget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("5.7.44-log"))
+
+	err = (&Restorer{Connection: db}).Restore(strings.NewReader(restoreDump))
+	assert.True(t, errors.Is(err, ErrIncompatibleVersion))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreNotEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+
+	err = (&Restorer{Connection: db, SkipVersionCheck: true}).Restore(strings.NewReader(restoreDump))
+	assert.True(t, errors.Is(err, ErrSchemaNotEmpty))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreStatementTooLarge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(30))
+	mock.ExpectExec(`^/\*!40101 SET NAMES utf8mb4 \*/$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `t` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = (&Restorer{Connection: db, SkipVersionCheck: true, Force: true}).Restore(strings.NewReader(restoreDump))
+	assert.True(t, errors.Is(err, ErrStatementTooLarge))
+	assert.Contains(t, err.Error(), "line 8:")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestParseServerVersion(t *testing.T) {
+	assert.Equal(t, serverVersion{Major: 8, Minor: 0, Patch: 34}, parseServerVersion("8.0.34-log"))
+	assert.Equal(t, serverVersion{Major: 10, Minor: 6, Patch: 12, MariaDB: true}, parseServerVersion("5.5.5-10.6.12-MariaDB-1:10.6.12+maria~ubu2004"))
+	assert.Equal(t, serverVersion{Major: 5, Minor: 7}, parseServerVersion("5.7"))
+	assert.True(t, parseServerVersion("8.0.11").atLeast(8, 0))
+	assert.False(t, parseServerVersion("5.7.44").atLeast(8, 0))
+}
+
+const resumeDump = `-- Server version	8.0.34
+
+SET NAMES utf8mb4;
+
+--
+-- Table structure for table ` + "`a`" + `
+--
+
+CREATE TABLE a (id int);
+
+--
+-- Dumping data for table ` + "`a`" + `
+--
+
+INSERT INTO a VALUES (1);
+
+--
+-- Table structure for table ` + "`b`" + `
+--
+
+CREATE TABLE b (id int);
+INSERT INTO b VALUES (1);
+`
+
+func TestRestoreResume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	checkpoint := FileCheckpoint(filepath.Join(dir, "checkpoint"))
+	assert.NoError(t, checkpoint.Save("a/data"))
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE b \(id int\)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO b VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, (&Restorer{Connection: db, SkipVersionCheck: true, Checkpoint: checkpoint}).Restore(strings.NewReader(resumeDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	last, err := checkpoint.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "", last)
+}
+
+const schemaFirstDump = `-- Server version	8.0.34
+
+SET NAMES utf8mb4;
+
+--
+-- Table structure for table ` + "`a`" + `
+--
+
+CREATE TABLE a (id int);
+
+--
+-- Table structure for table ` + "`b`" + `
+--
+
+CREATE TABLE b (id int);
+
+--
+-- Dumping data for table ` + "`a`" + `
+--
+
+INSERT INTO a VALUES (1);
+
+--
+-- Dumping data for table ` + "`b`" + `
+--
+
+INSERT INTO b VALUES (1);
+`
+
+func TestRestoreResumeSchemaFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The rows of a went in, so the restore resumes with the rows of b and
+	// neither creates the tables again nor inserts the rows of a twice
+	checkpoint := FileCheckpoint(filepath.Join(dir, "checkpoint"))
+	assert.NoError(t, checkpoint.Save("a/data"))
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO b VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, (&Restorer{Connection: db, SkipVersionCheck: true, Checkpoint: checkpoint}).Restore(strings.NewReader(schemaFirstDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreFromTableSchemaFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	// The restore starts with the first section of b, its structure, and
+	// goes on with every section after it
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE b \(id int\)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO a VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO b VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, FromTable: "b"}
+	assert.NoError(t, r.Restore(strings.NewReader(schemaFirstDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+type memoryCheckpoint struct {
+	saved []string
+}
+
+func (m *memoryCheckpoint) Load() (string, error) {
+	return "", nil
+}
+
+func (m *memoryCheckpoint) Save(table string) error {
+	m.saved = append(m.saved, table)
+	return nil
+}
+
+func TestRestoreCheckpoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE a \(id int\)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO a VALUES \(1\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^CREATE TABLE b \(id int\)$`).WillReturnError(errors.New("interrupted"))
+
+	checkpoint := &memoryCheckpoint{}
+	err = (&Restorer{Connection: db, SkipVersionCheck: true, Force: true, Checkpoint: checkpoint}).Restore(strings.NewReader(resumeDump))
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []string{"a", "a/data"}, checkpoint.saved)
+}
+
+func TestRestoreFromTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET NAMES utf8mb4$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = (&Restorer{Connection: db, SkipVersionCheck: true, FromTable: "c"}).Restore(strings.NewReader(resumeDump))
+	assert.True(t, errors.Is(err, ErrTableNotFound))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+const restoreSectionsDump = `-- Go SQL Dump 0.7.0
+--
+-- ------------------------------------------------------
+-- Server version	8.0.34
+
+/*!40101 SET NAMES utf8mb4 */;
+
+--
+-- Current Database: ` + "`src`" + `
+--
+
+/*!40000 DROP DATABASE IF EXISTS ` + "`src`" + `*/;
+CREATE DATABASE ` + "`src`" + ` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;
+
+USE ` + "`src`" + `;
+
+--
+-- Table structure for table ` + "`a`" + `
+--
+
+DROP TABLE IF EXISTS ` + "`a`" + `;
+CREATE TABLE ` + "`a`" + ` (id int);
+
+--
+-- Table structure for table ` + "`b`" + `
+--
+
+DROP TABLE IF EXISTS ` + "`b`" + `;
+CREATE TABLE ` + "`b`" + ` (id int);
+
+--
+-- View structure for view ` + "`v`" + `
+--
+
+DROP VIEW IF EXISTS ` + "`v`" + `;
+CREATE VIEW ` + "`v`" + ` AS SELECT 1;
+`
+
+func TestRestoreTablesIntoDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectExec("^USE `dst`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^/\*!40101 SET NAMES utf8mb4 \*/$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^DROP TABLE IF EXISTS `b`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `b` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true, Tables: []string{"b"}, Database: "dst"}
+	assert.NoError(t, r.Restore(strings.NewReader(restoreSectionsDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestorePlan(t *testing.T) {
+	plan, err := (&Restorer{}).Plan(strings.NewReader(restoreSectionsDump))
+	assert.NoError(t, err)
+	assert.Equal(t, &RestorePlan{
+		Statements: 10,
+		Tables:     []string{"a", "b", "v"},
+		Drops: []RestoreDrop{
+			{Kind: "DATABASE", Name: "src"},
+			{Kind: "TABLE", Name: "a"},
+			{Kind: "TABLE", Name: "b"},
+			{Kind: "VIEW", Name: "v"},
+		},
+	}, plan)
+	assert.Equal(t, "database `src`", plan.Drops[0].String())
+
+	plan, err = (&Restorer{Tables: []string{"a"}, Database: "dst"}).Plan(strings.NewReader(restoreSectionsDump))
+	assert.NoError(t, err)
+	assert.Equal(t, &RestorePlan{
+		Statements: 3,
+		Tables:     []string{"a"},
+		Drops:      []RestoreDrop{{Kind: "TABLE", Name: "a"}},
+	}, plan)
+}
+
+func TestRestoreConflicts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE\(\)$`).
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("b").AddRow("other"))
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = \?$`).
+		WithArgs("src").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+
+	r := &Restorer{Connection: db}
+	plan, err := r.Plan(strings.NewReader(restoreSectionsDump))
+	assert.NoError(t, err)
+	conflicts, err := r.Conflicts(plan)
+	assert.NoError(t, err)
+	assert.Equal(t, []RestoreDrop{{Kind: "DATABASE", Name: "src"}, {Kind: "TABLE", Name: "b"}}, conflicts)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+const restoreTriggersDump = `--
+-- Table structure for table ` + "`t`" + `
+--
+
+CREATE TABLE ` + "`t`" + ` (id int);
+/*!50003 CREATE*/ /*!50017 DEFINER=` + "`root`@`localhost`" + `*/ /*!50003 TRIGGER ` + "`early`" + ` AFTER INSERT ON ` + "`t`" + ` FOR EACH ROW SET @n = 1 */;
+
+--
+-- Dumping data for table ` + "`t`" + `
+--
+
+INSERT INTO ` + "`t`" + ` VALUES (1);
+
+--
+-- Triggers for table ` + "`t`" + `
+--
+
+/*!50003 DROP TRIGGER IF EXISTS ` + "`audit`" + ` */;
+CREATE TRIGGER ` + "`audit`" + ` AFTER INSERT ON ` + "`t`" + ` FOR EACH ROW INSERT INTO log VALUES (NEW.id);
+`
+
+func TestRestoreDeferTriggers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(`^SET @DISABLE_TRIGGERS = 1$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE `t` \\(id int\\)$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO `t` VALUES \\(1\\)$").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("TRIGGER `early`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^/\\*!50003 DROP TRIGGER IF EXISTS `audit` \\*/$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TRIGGER `audit`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET @DISABLE_TRIGGERS = NULL$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true, DeferTriggers: true, TriggerGuard: "DISABLE_TRIGGERS"}
+	assert.NoError(t, r.Restore(strings.NewReader(restoreTriggersDump)))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreInvalidTriggerGuard(t *testing.T) {
+	err := (&Restorer{TriggerGuard: "x = 0; DROP TABLE t"}).Restore(strings.NewReader(restoreTriggersDump))
+	assert.True(t, errors.Is(err, ErrInvalidTriggerGuard))
+}
+
+func TestIsCreateTrigger(t *testing.T) {
+	assert.True(t, isCreateTrigger("CREATE TRIGGER `a` BEFORE INSERT ON `t` FOR EACH ROW SET @n = 1"))
+	assert.True(t, isCreateTrigger("CREATE DEFINER=`root`@`%` TRIGGER `a` BEFORE INSERT ON `t` FOR EACH ROW SET @n = 1"))
+	assert.True(t, isCreateTrigger("/*!50003 CREATE*/ /*!50017 DEFINER=`root`@`localhost`*/ /*!50003 TRIGGER `a` AFTER INSERT ON `t` FOR EACH ROW SET @n = 1 */"))
+	assert.False(t, isCreateTrigger("CREATE TABLE `TRIGGER` (id int)"))
+	assert.False(t, isCreateTrigger("INSERT INTO `t` VALUES ('CREATE TRIGGER')"))
+}
+
+func TestRestoreVisibleIndexes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dump := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `h` int DEFAULT NULL /*!80023 INVISIBLE */,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `h` (`h`) /*!80000 INVISIBLE */\n" +
+		") ENGINE=InnoDB;\n" +
+		"INSERT INTO `t` VALUES (1,'/*!80000 INVISIBLE */');\n" +
+		"ALTER TABLE `t`\n  ADD KEY `id_h` (`id`,`h`) /*!80000 INVISIBLE */;\n"
+
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	// Invisible columns stay invisible, only the indexes turn visible
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE `t` (\n  `id` int NOT NULL,\n  `h` int DEFAULT NULL /*!80023 INVISIBLE */,\n  PRIMARY KEY (`id`),\n  KEY `h` (`h`)\n) ENGINE=InnoDB")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `t` VALUES (1,'/*!80000 INVISIBLE */')")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `t`\n  ADD KEY `id_h` (`id`,`h`)") + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true, VisibleIndexes: true}
+	assert.NoError(t, r.Restore(strings.NewReader(dump)))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
@@ -0,0 +1,228 @@
+package mysqldump
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReaderFactory opens the files of a multi-file dump, by the names of its
+// manifest.
+type ReaderFactory interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// ErrResumeFiles is returned by RestoreFiles with Checkpoint or FromTable,
+// which only apply to the statements of a single dump.
+var ErrResumeFiles = errors.New("a multi-file dump cannot be resumed")
+
+// RestoreFiles restores the multi-file dump of the manifest. The database and
+// the structure of the tables are restored first, then the data files of
+// every table, Parallel of them at a time with the largest in the manifest
+// starting first, so the total time is not held up by a large table started
+// last. The files of the indexes, constraints, stored programs and grants
+// follow once all rows are in.
+//
+// Every file is restored like a dump of its own with Restore, the target has
+// to be empty for the first one only, and the manifest is verified once all
+// tables are restored when Manifest is set.
+func (r *Restorer) RestoreFiles(files ReaderFactory, manifest *Manifest) error {
+	if r.Checkpoint != nil || r.FromTable != "" {
+		return ErrResumeFiles
+	}
+	schema, data, post := restoreOrder(manifest)
+
+	// The first file runs the checks of an empty target
+	first := true
+	sequential := func(names []ManifestFile) error {
+		for _, file := range names {
+			if err := r.restoreFile(files, file, !first); err != nil {
+				return err
+			}
+			first = false
+		}
+		return nil
+	}
+	if err := sequential(schema); err != nil {
+		return err
+	}
+	if err := r.restoreData(files, data, !first); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		first = false
+	}
+	if err := sequential(post); err != nil {
+		return err
+	}
+
+	if r.Manifest != nil {
+		ctx := context.Background()
+		conn, err := r.Connection.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		report, err := verify(ctx, conn, r.Manifest)
+		if err != nil {
+			return err
+		}
+		if !report.Passed {
+			return &VerifyError{Report: report}
+		}
+	}
+	return nil
+}
+
+// restoreData restores the data files, Parallel of them on their own
+// connections. No file starts once one failed, the first error is returned
+// once the running ones are done.
+func (r *Restorer) restoreData(files ReaderFactory, names []ManifestFile, force bool) error {
+	parallel := r.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	slots := make(chan struct{}, parallel)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	for i, file := range names {
+		slots <- struct{}{}
+		if failed() {
+			break
+		}
+		// The very first file checks that the target is empty before the
+		// other ones start
+		if i == 0 && !force {
+			err := r.restoreFile(files, file, false)
+			<-slots
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(file ManifestFile) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := r.restoreFile(files, file, true); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(file)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// restoreFile restores one file of the dump, decoded with its codec
+func (r *Restorer) restoreFile(files ReaderFactory, file ManifestFile, force bool) error {
+	f, err := files.Open(file.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var in io.Reader = f
+	if file.Codec != "" {
+		codec, err := LookupCodec(file.Codec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		dec, err := codec.Unwrap(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		defer dec.Close()
+		in = dec
+	}
+
+	restorer := *r
+	restorer.Force = r.Force || force
+	restorer.Manifest = nil
+	if err := restorer.Restore(in); err != nil {
+		return fmt.Errorf("%s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// restoreOrder splits the SQL files of the manifest into the ones of the
+// schema, restored first in the order they were written, the data files of
+// the tables, largest first, and the remaining ones restored last
+func restoreOrder(manifest *Manifest) (schema, data, post []ManifestFile) {
+	tables := map[string]bool{}
+	for _, table := range manifest.Tables {
+		if table.View {
+			continue
+		}
+		tables[dataFileName(table.Name)] = true
+		if manifest.Database != "" {
+			name := safeFileName(manifest.Database + "." + table.Name)
+			tables[name+".sql"] = true
+			tables[name+".00000.sql"] = true
+		}
+	}
+	for _, file := range manifest.Files {
+		name := file.Name
+		if file.Codec != "" {
+			name = strings.TrimSuffix(name, "."+file.Codec)
+		}
+		switch {
+		case !strings.HasSuffix(name, ".sql"):
+		case tables[name]:
+			data = append(data, file)
+		case name == schemaFileName || strings.HasSuffix(name, "-schema-create.sql") ||
+			strings.HasSuffix(name, "-schema.sql") || strings.HasSuffix(name, "-schema-view.sql"):
+			schema = append(schema, file)
+		default:
+			post = append(post, file)
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Size > data[j].Size
+	})
+	return schema, data, post
+}
+
+// ZipReader is a ReaderFactory opening the entries of a zip archive, like one
+// written by a ZipWriter.
+type ZipReader struct {
+	files map[string]*zip.File
+}
+
+// NewZipReader reads the central directory of the archive of size bytes.
+func NewZipReader(r io.ReaderAt, size int64) (*ZipReader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	z := &ZipReader{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		z.files[f.Name] = f
+	}
+	return z, nil
+}
+
+// Open opens the named entry of the archive.
+func (z *ZipReader) Open(name string) (io.ReadCloser, error) {
+	f, ok := z.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: file not found in archive", name)
+	}
+	return f.Open()
+}
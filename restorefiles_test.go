@@ -0,0 +1,167 @@
+package mysqldump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapFiles is a ReaderFactory of files in memory
+type mapFiles map[string]string
+
+func (m mapFiles) Open(name string) (io.ReadCloser, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no such file", name)
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+func restoreFile(statement string) string {
+	return "-- Server version\t8.0.34\n\n" + statement + ";\n"
+}
+
+func fileNames(files []ManifestFile) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+	return names
+}
+
+func TestRestoreOrder(t *testing.T) {
+	schema, data, post := restoreOrder(&Manifest{
+		Database: "db",
+		Tables:   []ManifestTable{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "v", View: true}},
+		Files: []ManifestFile{
+			{Name: "db-schema-create.sql"},
+			{Name: "db.a-schema.sql"},
+			{Name: "db.v-schema-view.sql"},
+			{Name: "db.a.sql", Size: 10},
+			{Name: "db.b.sql.gz", Codec: "gz", Size: 300},
+			{Name: "db.c.00000.sql", Size: 20},
+			{Name: "indexes.sql"},
+			{Name: "metadata"},
+			{Name: "manifest.json"},
+		},
+	})
+	assert.Equal(t, []string{"db-schema-create.sql", "db.a-schema.sql", "db.v-schema-view.sql"}, fileNames(schema))
+	assert.Equal(t, []string{"db.b.sql.gz", "db.c.00000.sql", "db.a.sql"}, fileNames(data))
+	assert.Equal(t, []string{"indexes.sql"}, fileNames(post))
+
+	// The files of FileLayoutSections
+	schema, data, post = restoreOrder(&Manifest{
+		Tables: []ManifestTable{{Name: "a"}, {Name: "b"}},
+		Files:  []ManifestFile{{Name: "schema.sql"}, {Name: "data/a.sql", Size: 1}, {Name: "data/b.sql", Size: 2}, {Name: "routines.sql"}},
+	})
+	assert.Equal(t, []string{"schema.sql"}, fileNames(schema))
+	assert.Equal(t, []string{"data/b.sql", "data/a.sql"}, fileNames(data))
+	assert.Equal(t, []string{"routines.sql"}, fileNames(post))
+}
+
+var restoreFilesManifest = &Manifest{
+	Database: "db",
+	Tables:   []ManifestTable{{Name: "small"}, {Name: "large"}},
+	Files: []ManifestFile{
+		{Name: "db.small-schema.sql"},
+		{Name: "db.large-schema.sql"},
+		{Name: "db.small.sql", Size: 10},
+		{Name: "db.large.sql", Size: 1000},
+		{Name: "indexes.sql"},
+	},
+}
+
+var restoreFilesContent = mapFiles{
+	"db.small-schema.sql": restoreFile("CREATE TABLE `small` (id int)"),
+	"db.large-schema.sql": restoreFile("CREATE TABLE `large` (id int)"),
+	"db.small.sql":        restoreFile("INSERT INTO `small` VALUES (1)"),
+	"db.large.sql":        restoreFile("INSERT INTO `large` VALUES (1)"),
+	"indexes.sql":         restoreFile("ALTER TABLE `large` ADD KEY (id)"),
+}
+
+func expectRestoreFile(mock sqlmock.Sqlmock, statement string) {
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec(statement).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestRestoreFilesLargestFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	// Only the first file finds an empty target
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM information_schema.TABLES`).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	expectRestoreFile(mock, "^CREATE TABLE `small`")
+	expectRestoreFile(mock, "^CREATE TABLE `large`")
+	expectRestoreFile(mock, "^INSERT INTO `large`")
+	expectRestoreFile(mock, "^INSERT INTO `small`")
+	expectRestoreFile(mock, "^ALTER TABLE `large`")
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true}
+	assert.NoError(t, r.RestoreFiles(restoreFilesContent, restoreFilesManifest))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreFilesParallel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	for _, statement := range []string{"^CREATE TABLE `small`", "^CREATE TABLE `large`", "^INSERT INTO `large`", "^INSERT INTO `small`", "^ALTER TABLE `large`"} {
+		expectRestoreFile(mock, statement)
+	}
+
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true, Parallel: 2}
+	assert.NoError(t, r.RestoreFiles(restoreFilesContent, restoreFilesManifest))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestRestoreFilesError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	expectRestoreFile(mock, "^CREATE TABLE `small`")
+	expectRestoreFile(mock, "^CREATE TABLE `large`")
+	mock.ExpectQuery(`^SELECT @@max_allowed_packet$`).WillReturnRows(sqlmock.NewRows([]string{"@@max_allowed_packet"}).AddRow(4194304))
+	mock.ExpectExec("^INSERT INTO `large`").WillReturnError(fmt.Errorf("table is full"))
+
+	// Nothing starts once a file failed
+	r := &Restorer{Connection: db, SkipVersionCheck: true, Force: true}
+	err = r.RestoreFiles(restoreFilesContent, restoreFilesManifest)
+	assert.EqualError(t, err, "db.large.sql: line 3: table is full")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, ErrResumeFiles, (&Restorer{FromTable: "large"}).RestoreFiles(restoreFilesContent, restoreFilesManifest))
+}
+
+func TestRestoreFilesZip(t *testing.T) {
+	var buf bytes.Buffer
+	archive := NewZipWriter(&buf)
+	for _, name := range []string{"db.small-schema.sql", "db.small.sql"} {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+		io.WriteString(w, restoreFilesContent[name])
+		assert.NoError(t, w.Close())
+	}
+	assert.NoError(t, archive.Close())
+
+	files, err := NewZipReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	f, err := files.Open("db.small.sql")
+	if assert.NoError(t, err) {
+		b, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, restoreFilesContent["db.small.sql"], string(b))
+		f.Close()
+	}
+	_, err = files.Open("db.large.sql")
+	assert.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Section is a part of a dump that SectionOrder places.
+type Section string
+
+// The sections of a dump. Schema holds the structure of the tables and views,
+// Data their rows, followed by the deferred indexes and foreign keys.
+const (
+	SectionSchema   Section = "schema"
+	SectionData     Section = "data"
+	SectionRoutines Section = "routines"
+	SectionTriggers Section = "triggers"
+	SectionEvents   Section = "events"
+)
+
+// DefaultSectionOrder is used when SectionOrder is empty. Triggers come after
+// the data so restoring the rows does not fire them.
+var DefaultSectionOrder = []Section{SectionSchema, SectionData, SectionRoutines, SectionTriggers, SectionEvents}
+
+// ErrSectionOrder is returned when SectionOrder does not list every section
+// once, or puts the data in front of the schema or the triggers in front of
+// the data.
+var ErrSectionOrder = errors.New("invalid section order")
+
+// Takes a *objectSection
+const objectsTmpl = `
+--
+-- {{ .Title }}
+--
+{{ range .Objects }}
+/*!50003 DROP {{ .Type }} IF EXISTS {{ .QualifiedName }} */;
+/*!50003 SET @saved_sql_mode = @@sql_mode */;
+/*!50003 SET sql_mode = {{ .SQLModeEsc }} */;
+{{- if .TimeZone }}
+/*!50106 SET @saved_time_zone = @@time_zone */;
+/*!50106 SET time_zone = {{ .TimeZoneEsc }} */;
+{{- end }}
+{{ terminate .CreateSQL }}
+{{- if .TimeZone }}
+/*!50106 SET time_zone = @saved_time_zone */;
+{{- end }}
+/*!50003 SET sql_mode = @saved_sql_mode */;
+{{ end -}}
+`
+
+// objectSection is a group of stored programs under one comment
+type objectSection struct {
+	Title   string
+	Objects []*object
+}
+
+// object is a stored procedure, function, trigger or event
+type object struct {
+	Type      string
+	Name      string
+	SQLMode   string
+	TimeZone  string
+	CreateSQL string
+
+	qualifier string
+}
+
+func (o *object) NameEsc() string {
+	return "`" + strings.Replace(o.Name, "`", "``", -1) + "`"
+}
+
+func (o *object) SQLModeEsc() string {
+	return "'" + strings.Replace(o.SQLMode, "'", "''", -1) + "'"
+}
+
+func (o *object) TimeZoneEsc() string {
+	return "'" + strings.Replace(o.TimeZone, "'", "''", -1) + "'"
+}
+
+// sectionOrder returns the validated order of the sections
+func (data *Data) sectionOrder() ([]Section, error) {
+	if len(data.SectionOrder) == 0 {
+		return DefaultSectionOrder, nil
+	}
+	position := map[Section]int{}
+	for i, section := range data.SectionOrder {
+		if _, dup := position[section]; dup {
+			return nil, fmt.Errorf("%w: %s listed twice", ErrSectionOrder, section)
+		}
+		position[section] = i
+	}
+	for _, section := range DefaultSectionOrder {
+		if _, ok := position[section]; !ok {
+			return nil, fmt.Errorf("%w: %s missing", ErrSectionOrder, section)
+		}
+	}
+	if len(position) != len(DefaultSectionOrder) {
+		return nil, fmt.Errorf("%w: unknown section", ErrSectionOrder)
+	}
+	if position[SectionData] < position[SectionSchema] {
+		return nil, fmt.Errorf("%w: data before schema", ErrSectionOrder)
+	}
+	if position[SectionTriggers] < position[SectionData] {
+		return nil, fmt.Errorf("%w: triggers before data", ErrSectionOrder)
+	}
+	return data.SectionOrder, nil
+}
+
+// writeObjects writes the routines, triggers or events of the database to w
+// if they are part of the dump
+func (data *Data) writeObjects(w io.Writer, section Section, tables []*table) error {
+	var sections []*objectSection
+	var err error
+	if !data.dumpsSection(section) {
+		return nil
+	}
+	switch section {
+	case SectionRoutines:
+		err = data.privileged("routines", func() (err error) {
+			sections, err = data.getRoutines()
+			return
+		})
+	case SectionTriggers:
+		err = data.privileged("triggers", func() (err error) {
+			sections, err = data.getTriggers(tables)
+			return
+		})
+	case SectionEvents:
+		err = data.privileged("events", func() (err error) {
+			sections, err = data.getEvents()
+			return
+		})
+	}
+	if err != nil {
+		return err
+	}
+	for _, s := range sections {
+		if err := data.objectsTmpl.Execute(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpsSection reports whether the stored programs of section are dumped
+func (data *Data) dumpsSection(section Section) bool {
+	switch section {
+	case SectionRoutines:
+		return data.Routines
+	case SectionTriggers:
+		return data.Triggers
+	case SectionEvents:
+		return data.Events
+	}
+	return false
+}
+
+// sectionFileName is the file of a multi-file dump the section is written to
+func sectionFileName(section Section) string {
+	return string(section) + ".sql"
+}
+
+func (data *Data) getRoutines() ([]*objectSection, error) {
+	names, err := data.queryNames("SELECT ROUTINE_TYPE, ROUTINE_NAME FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = DATABASE() ORDER BY ROUTINE_TYPE, ROUTINE_NAME")
+	if err != nil {
+		return nil, err
+	}
+	s := &objectSection{Title: "Dumping routines"}
+	for _, name := range names {
+		o, err := data.getObject(name[0], name[1])
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			s.Objects = append(s.Objects, o)
+		}
+	}
+	if len(s.Objects) == 0 {
+		return nil, nil
+	}
+	return []*objectSection{s}, nil
+}
+
+// getTriggers reads the triggers of the dumped tables, grouped by table in the
+// order they fire
+func (data *Data) getTriggers(tables []*table) ([]*objectSection, error) {
+	names, err := data.queryNames("SELECT EVENT_OBJECT_TABLE, TRIGGER_NAME FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = DATABASE() ORDER BY EVENT_OBJECT_TABLE, ACTION_ORDER")
+	if err != nil {
+		return nil, err
+	}
+	dumped := map[string]bool{}
+	for _, table := range tables {
+		if !table.isView {
+			dumped[table.Name] = true
+		}
+	}
+
+	var sections []*objectSection
+	var s *objectSection
+	current := ""
+	for _, name := range names {
+		if !dumped[name[0]] {
+			continue
+		}
+		o, err := data.getObject("TRIGGER", name[1])
+		if err != nil {
+			return nil, err
+		}
+		if o == nil {
+			continue
+		}
+		if s == nil || name[0] != current {
+			current = name[0]
+			s = &objectSection{Title: "Triggers for table `" + current + "`"}
+			sections = append(sections, s)
+		}
+		s.Objects = append(s.Objects, o)
+	}
+	return sections, nil
+}
+
+func (data *Data) getEvents() ([]*objectSection, error) {
+	names, err := data.queryNames("SELECT 'EVENT', EVENT_NAME FROM information_schema.EVENTS WHERE EVENT_SCHEMA = DATABASE() ORDER BY EVENT_NAME")
+	if err != nil {
+		return nil, err
+	}
+	s := &objectSection{Title: "Dumping events"}
+	for _, name := range names {
+		o, err := data.getObject("EVENT", name[1])
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			s.Objects = append(s.Objects, o)
+		}
+	}
+	if len(s.Objects) == 0 {
+		return nil, nil
+	}
+	return []*objectSection{s}, nil
+}
+
+// queryNames reads the two column result of query
+func (data *Data) queryNames(query string) ([][2]string, error) {
+	rows, err := data.metadataQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names [][2]string
+	for rows.Next() {
+		var name [2]string
+		if err := rows.Scan(&name[0], &name[1]); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// getObject reads the definition of a stored program with SHOW CREATE. The
+// server hides the body from users without the privileges to see it, those
+// programs are left out with a warning.
+func (data *Data) getObject(typ, name string) (*object, error) {
+	o := &object{Type: typ, Name: name, qualifier: data.qualifier}
+	rows, err := data.metadataQuery("SHOW CREATE " + typ + " " + o.NameEsc())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s %s not found", strings.ToLower(typ), o.NameEsc())
+	}
+	values := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+	if err := rows.Scan(scans...); err != nil {
+		return nil, err
+	}
+	for i, col := range cols {
+		switch {
+		case col == "sql_mode":
+			o.SQLMode = values[i].String
+		case col == "time_zone":
+			o.TimeZone = values[i].String
+		case strings.HasPrefix(col, "Create ") || col == "SQL Original Statement":
+			o.CreateSQL = values[i].String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if o.CreateSQL == "" {
+		data.warn(fmt.Sprintf("%s %s skipped: definition not visible, SHOW_ROUTINE or the definer is needed", strings.ToLower(typ), o.NameEsc()))
+		return nil, nil
+	}
+	o.CreateSQL = data.qualifyDDL(data.rewriteDDL(o.CreateSQL), typ, o.NameEsc())
+	return o, nil
+}
@@ -0,0 +1,144 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+const createFunction = "CREATE DEFINER=`root`@`%` FUNCTION `f`() RETURNS int DETERMINISTIC BEGIN RETURN 1; END"
+
+// mockRoutines expects the queries of the routines of the database, p can't
+// be read
+func mockRoutines(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`^SELECT ROUTINE_TYPE, ROUTINE_NAME FROM information_schema.ROUTINES`).WillReturnRows(
+		sqlmock.NewRows([]string{"ROUTINE_TYPE", "ROUTINE_NAME"}).AddRow("FUNCTION", "f").AddRow("PROCEDURE", "p"))
+	mock.ExpectQuery("^SHOW CREATE FUNCTION `f`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Function", "sql_mode", "Create Function", "character_set_client", "collation_connection", "Database Collation"}).
+			AddRow("f", "STRICT_TRANS_TABLES", createFunction, "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci"))
+	mock.ExpectQuery("^SHOW CREATE PROCEDURE `p`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Procedure", "sql_mode", "Create Procedure", "character_set_client", "collation_connection", "Database Collation"}).
+			AddRow("p", "", nil, "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci"))
+}
+
+func TestDumpRoutinesTriggersEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int)"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int", "YES", "", nil, ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockRoutines(mock)
+	mock.ExpectQuery(`^SELECT EVENT_OBJECT_TABLE, TRIGGER_NAME FROM information_schema.TRIGGERS`).WillReturnRows(
+		sqlmock.NewRows([]string{"EVENT_OBJECT_TABLE", "TRIGGER_NAME"}).AddRow("Other_Table", "other").AddRow("Test_Table", "audit"))
+	mock.ExpectQuery("^SHOW CREATE TRIGGER `audit`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Trigger", "sql_mode", "SQL Original Statement", "character_set_client", "collation_connection", "Database Collation", "Created"}).
+			AddRow("audit", "", "CREATE TRIGGER `audit` AFTER INSERT ON `Test_Table` FOR EACH ROW INSERT INTO log VALUES (NEW.id)", "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci", nil))
+	mock.ExpectQuery(`^SELECT 'EVENT', EVENT_NAME FROM information_schema.EVENTS`).WillReturnRows(
+		sqlmock.NewRows([]string{"EVENT", "EVENT_NAME"}).AddRow("EVENT", "purge"))
+	mock.ExpectQuery("^SHOW CREATE EVENT `purge`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Event", "sql_mode", "time_zone", "Create Event", "character_set_client", "collation_connection", "Database Collation"}).
+			AddRow("purge", "", "SYSTEM", "CREATE EVENT `purge` ON SCHEDULE EVERY 1 DAY DO DELETE FROM log", "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, Routines: true, Triggers: true, Events: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	assert.Contains(t, out, `
+--
+-- Dumping routines
+--
+
+/*!50003 DROP FUNCTION IF EXISTS `+"`f`"+` */;
+/*!50003 SET @saved_sql_mode = @@sql_mode */;
+/*!50003 SET sql_mode = 'STRICT_TRANS_TABLES' */;
+DELIMITER $$
+`+createFunction+`$$
+DELIMITER ;
+/*!50003 SET sql_mode = @saved_sql_mode */;
+`)
+	assert.NotContains(t, out, "PROCEDURE")
+	assert.Equal(t, []string{"procedure `p` skipped: definition not visible, SHOW_ROUTINE or the definer is needed"}, data.Warnings())
+
+	assert.Contains(t, out, `
+--
+-- Dumping events
+--
+
+/*!50003 DROP EVENT IF EXISTS `+"`purge`"+` */;
+/*!50003 SET @saved_sql_mode = @@sql_mode */;
+/*!50003 SET sql_mode = '' */;
+/*!50106 SET @saved_time_zone = @@time_zone */;
+/*!50106 SET time_zone = 'SYSTEM' */;
+CREATE EVENT `+"`purge`"+` ON SCHEDULE EVERY 1 DAY DO DELETE FROM log;
+/*!50106 SET time_zone = @saved_time_zone */;
+/*!50003 SET sql_mode = @saved_sql_mode */;
+`)
+
+	insert := strings.Index(out, "INSERT INTO `Test_Table`")
+	routines := strings.Index(out, "-- Dumping routines")
+	triggers := strings.Index(out, "-- Triggers for table `Test_Table`")
+	events := strings.Index(out, "-- Dumping events")
+	assert.True(t, insert < routines && routines < triggers && triggers < events, "sections out of order")
+	assert.NotContains(t, out, "other")
+}
+
+func TestDumpSectionOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mockRoutines(mock)
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int)"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int", "YES", "", nil, ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection:   db,
+		Out:          &buf,
+		Routines:     true,
+		SectionOrder: []mysqldump.Section{mysqldump.SectionRoutines, mysqldump.SectionSchema, mysqldump.SectionData, mysqldump.SectionTriggers, mysqldump.SectionEvents},
+	}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	out := buf.String()
+	routines := strings.Index(out, "-- Dumping routines")
+	create := strings.Index(out, "CREATE TABLE `Test_Table`")
+	insert := strings.Index(out, "INSERT INTO `Test_Table`")
+	assert.True(t, routines < create && create < insert, "sections out of order")
+}
+
+func TestDumpInvalidSectionOrder(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	for _, order := range [][]mysqldump.Section{
+		{mysqldump.SectionData, mysqldump.SectionSchema, mysqldump.SectionRoutines, mysqldump.SectionTriggers, mysqldump.SectionEvents},
+		{mysqldump.SectionSchema, mysqldump.SectionTriggers, mysqldump.SectionData, mysqldump.SectionRoutines, mysqldump.SectionEvents},
+		{mysqldump.SectionSchema, mysqldump.SectionData},
+		{mysqldump.SectionSchema, mysqldump.SectionData, mysqldump.SectionData, mysqldump.SectionTriggers, mysqldump.SectionEvents},
+	} {
+		data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}, SectionOrder: order}
+		err := data.Dump()
+		assert.True(t, errors.Is(err, mysqldump.ErrSectionOrder), "%v: %v", order, err)
+	}
+}
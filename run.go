@@ -0,0 +1,149 @@
+package mysqldump
+
+import "sync"
+
+// state is what a Data shares with the goroutines using it: the pause switch,
+// the MaxMemory budget and the lock over the results of the last run
+type state struct {
+	mu      sync.Mutex
+	resume  chan struct{}
+	memory  *memory
+	locks   map[*tableLocks]bool
+	reading map[*table]bool
+}
+
+// stateMu guards the creation of the state of a Data
+var stateMu sync.Mutex
+
+func (data *Data) state() *state {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if data.shared == nil {
+		data.shared = &state{}
+	}
+	return data.shared
+}
+
+// Run is a dump started by Start. It works on a copy of the options of the
+// Data it was started from, so changing them does not affect the run and the
+// same Data can start several runs, one after the other or at the same time.
+type Run struct {
+	data *Data
+	done chan struct{}
+	err  error
+}
+
+// Start begins dumping database, or the current database if it is empty, in
+// the background.
+func (data *Data) Start(database string) *Run {
+	r := &Run{data: data.newRun(), done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		r.err = r.data.dump(database)
+		data.finish(r.data)
+	}()
+	return r
+}
+
+// Wait blocks until the run is done and returns its error.
+func (r *Run) Wait() error {
+	<-r.done
+	return r.err
+}
+
+// Done is closed once the run is done.
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Pause holds the run before its next table or page, see Data.Pause.
+func (r *Run) Pause() {
+	r.data.Pause()
+}
+
+// Resume continues the paused run.
+func (r *Run) Resume() {
+	r.data.Resume()
+}
+
+// Paused reports whether the run is paused.
+func (r *Run) Paused() bool {
+	return r.data.Paused()
+}
+
+// Warnings returns the warnings of the run once it is done.
+func (r *Run) Warnings() []string {
+	<-r.done
+	return r.data.Warnings()
+}
+
+// Report returns the statistics of the run once it is done.
+func (r *Run) Report() []TableStats {
+	<-r.done
+	return r.data.Report()
+}
+
+// Manifest returns the manifest of the run once it is done.
+func (r *Run) Manifest() *Manifest {
+	<-r.done
+	return r.data.Manifest()
+}
+
+// Snapshot returns the session info of the run once it is done.
+func (r *Run) Snapshot() *Snapshot {
+	<-r.done
+	return r.data.Snapshot()
+}
+
+// run performs a dump on a copy of data and keeps its results
+func (data *Data) run(database string) error {
+	run := data.newRun()
+	err := run.dump(database)
+	data.finish(run)
+	return err
+}
+
+// newRun copies the options of data, the state of earlier runs is reset by
+// dump
+func (data *Data) newRun() *Data {
+	s := data.state()
+	s.mu.Lock()
+	run := *data
+	s.mu.Unlock()
+
+	run.IgnoreTables = append([]string(nil), data.IgnoreTables...)
+	run.IncludeTables = append([]string(nil), data.IncludeTables...)
+	run.MaterializeViews = append([]string(nil), data.MaterializeViews...)
+	run.ViewsAsTables = append([]string(nil), data.ViewsAsTables...)
+	run.IncludePatterns = append([]string(nil), data.IncludePatterns...)
+	run.ExcludePatterns = append([]string(nil), data.ExcludePatterns...)
+	run.queryTables = append([]queryTable(nil), data.queryTables...)
+	if data.Masks != nil {
+		run.Masks = make(map[string]Masker, len(data.Masks))
+		for k, m := range data.Masks {
+			run.Masks[k] = m
+		}
+	}
+	if data.Transforms != nil {
+		run.Transforms = make(map[string]map[string]Transform, len(data.Transforms))
+		for k, columns := range data.Transforms {
+			run.Transforms[k] = columns
+		}
+	}
+	run.parent = data
+	run.shared = nil
+	return &run
+}
+
+// finish keeps the results of run as the ones of the last dump of data
+func (data *Data) finish(run *Data) {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data.warnings = run.warnings
+	data.report = run.report
+	data.manifest = run.manifest
+	data.snapshot = run.snapshot
+	data.schema = run.schema
+	data.binlog = run.binlog
+}
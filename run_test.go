@@ -0,0 +1,64 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{
+		Connection: db,
+		Out:        &buf,
+	}
+
+	mockDump(mock)
+	data.Pause()
+	run := data.Start("")
+	assert.True(t, data.Paused())
+
+	// The options of a started run do not change anymore
+	data.IgnoreTables = []string{"Test_Table"}
+	data.Resume()
+
+	assert.NoError(t, run.Wait())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	result := strings.Replace(strings.Split(buf.String(), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+
+	assert.Len(t, run.Report(), 1)
+	assert.Equal(t, run.Report(), data.Report())
+	assert.Equal(t, "test_version", run.Manifest().ServerVersion)
+	assert.Empty(t, run.Warnings())
+}
+
+func TestDumpReuse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	data := &mysqldump.Data{Connection: db}
+
+	var results []string
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		data.Out = &buf
+		mockDump(mock)
+		assert.NoError(t, data.Dump())
+		results = append(results, strings.Split(buf.String(), "-- Dump completed")[0])
+		assert.Len(t, data.Report(), 1)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, results[0], results[1])
+	assert.Zero(t, data.MaxAllowedPacket)
+}
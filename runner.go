@@ -0,0 +1,497 @@
+package mysqldump
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exit codes returned by Runner.Run. Kubernetes Jobs can tell them apart with a
+// podFailurePolicy, e.g. failing the Job right away on ExitPermanent while
+// letting the backoff retry ExitTransient.
+const (
+	ExitOK        = 0
+	ExitPermanent = 1
+	ExitTransient = 75 // EX_TEMPFAIL
+)
+
+/*
+RunnerConfig configures a Runner. Every field can be set from a JSON file and be
+overridden by the environment variable named next to it.
+
+	Driver:          MYSQLDUMP_DRIVER           Name of the registered database/sql driver, mysql by default
+	DSN:             MYSQLDUMP_DSN              Data source name of the database to dump
+	DSNs:            MYSQLDUMP_DSNS             Comma separated candidates instead of DSN, the best replica is picked by a Selector
+	MaxLag:          MYSQLDUMP_MAX_LAG          Replication lag in seconds beyond which a candidate is not picked (0 accepts any lag)
+	Database:        MYSQLDUMP_DATABASE         Database to switch to before dumping
+	OutputDir:       MYSQLDUMP_OUTPUT_DIR       Directory the dump is written to, usually a mounted volume
+	FileFormat:      MYSQLDUMP_FILE_FORMAT      time.Time.Format layout of the file name, the format is appended as extension
+	Format:          MYSQLDUMP_FORMAT           sql (default), tar or zip, sql and tar optionally followed by a codec like sql.gz
+	Preset:          MYSQLDUMP_PRESET           Preset applied before the other options
+	IncludeTables:   MYSQLDUMP_INCLUDE_TABLES   Comma separated list of the only tables to dump
+	IgnoreTables:    MYSQLDUMP_IGNORE_TABLES    Comma separated list of tables to leave out
+	IncludePatterns: MYSQLDUMP_INCLUDE_PATTERNS Comma separated patterns of the only tables to dump along with IncludeTables, globs like tmp_* or regular expressions between slashes
+	ExcludePatterns: MYSQLDUMP_EXCLUDE_PATTERNS Comma separated patterns of tables to leave out
+	LockTables:      MYSQLDUMP_LOCK_TABLES      Lock all tables for the duration of the dump
+	MaxMemory:       MYSQLDUMP_MAX_MEMORY       Bytes of rows buffered at once, keeping the dump within the memory limit of the container (0 disables)
+	Concurrency:     MYSQLDUMP_CONCURRENCY      Tables read at the same time on connections of their own, the snapshots being started under FLUSH TABLES WITH READ LOCK
+	Throttle:        MYSQLDUMP_THROTTLE_RATE    Bytes per second to write at, the time windows with other rates are only read from the file
+
+The other variables of Data.FromEnv, like MYSQLDUMP_CODEC, MYSQLDUMP_ROUTINES or
+MYSQLDUMP_FETCH_SIZE, apply to the Data of every run under the fields above;
+the destination of the dump remains OutputDir or the Uploader.
+*/
+type RunnerConfig struct {
+	Driver          string   `json:"driver"`
+	DSN             string   `json:"dsn"`
+	DSNs            []string `json:"dsns"`
+	MaxLag          int      `json:"maxLag"`
+	Database        string   `json:"database"`
+	OutputDir       string   `json:"outputDir"`
+	FileFormat      string   `json:"fileFormat"`
+	Format          string   `json:"format"`
+	Preset          Preset   `json:"preset"`
+	IncludeTables   []string `json:"includeTables"`
+	IgnoreTables    []string `json:"ignoreTables"`
+	IncludePatterns []string `json:"includePatterns"`
+	ExcludePatterns []string `json:"excludePatterns"`
+	LockTables      bool     `json:"lockTables"`
+	MaxMemory       int64    `json:"maxMemory"`
+	Concurrency     int      `json:"concurrency"`
+	Throttle        Throttle `json:"throttle"`
+}
+
+// errUploadEnded is the error of the writes of a dump after its upload
+// returned
+var errUploadEnded = errors.New("upload ended")
+
+// Uploader stores a finished dump somewhere other than the local file system,
+// e.g. in an S3 bucket. Upload must consume r until EOF.
+type Uploader interface {
+	Upload(ctx context.Context, name string, r io.Reader) error
+}
+
+/*
+Runner performs a single dump the way a container job expects: it is
+configured from a file and the environment, writes the dump to a volume or an
+Uploader, logs JSON lines and returns an exit code.
+
+	Config:           What to dump and where to
+	Uploader:         Receives the dump instead of OutputDir when set
+	Recorder:         Records every run, successful or not, in a backup catalog when set
+	Log:              Destination of the JSON logs, os.Stderr by default
+	Progress:         Called with the progress of the dump every ProgressInterval when set, the tables are then read from information_schema for their row estimates
+	ProgressInterval: Time between the Progress calls, 5s if 0
+*/
+type Runner struct {
+	Config           RunnerConfig
+	Uploader         Uploader
+	Recorder         Recorder
+	Log              io.Writer
+	Progress         func(Heartbeat)
+	ProgressInterval time.Duration
+}
+
+// defaultProgressInterval is the time between the Progress calls of a Runner
+const defaultProgressInterval = 5 * time.Second
+
+// recordTimeout is the time the Recorder of a Runner is given to record a run
+const recordTimeout = 10 * time.Second
+
+// LoadRunnerConfig reads the JSON file at path, if path is not empty, and
+// applies the MYSQLDUMP_* environment variables on top of it.
+func LoadRunnerConfig(path string) (RunnerConfig, error) {
+	var config RunnerConfig
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return config, err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return config, err
+		}
+	}
+
+	if v, ok := os.LookupEnv("MYSQLDUMP_DRIVER"); ok {
+		config.Driver = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_DSN"); ok {
+		config.DSN = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_DSNS"); ok {
+		config.DSNs = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_MAX_LAG"); ok {
+		lag, err := strconv.Atoi(v)
+		if err != nil {
+			return config, errors.New("MYSQLDUMP_MAX_LAG: " + err.Error())
+		}
+		config.MaxLag = lag
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_DATABASE"); ok {
+		config.Database = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_OUTPUT_DIR"); ok {
+		config.OutputDir = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_FILE_FORMAT"); ok {
+		config.FileFormat = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_FORMAT"); ok {
+		config.Format = v
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_PRESET"); ok {
+		config.Preset = Preset(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_INCLUDE_TABLES"); ok {
+		config.IncludeTables = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_IGNORE_TABLES"); ok {
+		config.IgnoreTables = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_INCLUDE_PATTERNS"); ok {
+		config.IncludePatterns = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_EXCLUDE_PATTERNS"); ok {
+		config.ExcludePatterns = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_LOCK_TABLES"); ok {
+		lock, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, errors.New("MYSQLDUMP_LOCK_TABLES: " + err.Error())
+		}
+		config.LockTables = lock
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_MAX_MEMORY"); ok {
+		max, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return config, errors.New("MYSQLDUMP_MAX_MEMORY: " + err.Error())
+		}
+		config.MaxMemory = max
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_CONCURRENCY"); ok {
+		concurrency, err := strconv.Atoi(v)
+		if err != nil {
+			return config, errors.New("MYSQLDUMP_CONCURRENCY: " + err.Error())
+		}
+		config.Concurrency = concurrency
+	}
+	if v, ok := os.LookupEnv("MYSQLDUMP_THROTTLE_RATE"); ok {
+		rate, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return config, errors.New("MYSQLDUMP_THROTTLE_RATE: " + err.Error())
+		}
+		config.Throttle.Rate = rate
+	}
+	// The variables of the Data of the runs fail the configuration, not the run
+	if err := (&Data{}).FromEnv(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// Run performs the dump and returns the exit code the process should end
+// with.
+func (r *Runner) Run(ctx context.Context) int {
+	start := time.Now()
+	config := r.Config
+	if config.Driver == "" {
+		config.Driver = "mysql"
+	}
+	if config.Format == "" {
+		config.Format = "sql"
+	}
+	if config.FileFormat == "" {
+		config.FileFormat = "20060102T150405"
+	}
+
+	if err := config.validate(r.Uploader != nil); err != nil {
+		r.log("error", "invalid configuration", map[string]interface{}{"error": err.Error()})
+		return ExitPermanent
+	}
+
+	name := time.Now().UTC().Format(config.FileFormat) + "." + config.Format
+	r.log("info", "dump started", map[string]interface{}{"file": name})
+
+	record := BackupRecord{
+		Name:     name,
+		Format:   config.Format,
+		Database: config.Database,
+		Start:    start,
+	}
+	err := r.dump(ctx, config, &record)
+	record.Duration = time.Since(start)
+	r.record(record, err)
+	if err != nil {
+		transient := IsTransient(err)
+		var dumpErr *DumpError
+		r.log("error", "dump failed", map[string]interface{}{
+			"file":      name,
+			"error":     err.Error(),
+			"cause":     string(CauseOf(err)),
+			"partial":   errors.As(err, &dumpErr) && dumpErr.Partial,
+			"transient": transient,
+			"duration":  time.Since(start).Seconds(),
+		})
+		if transient {
+			return ExitTransient
+		}
+		return ExitPermanent
+	}
+
+	r.log("info", "dump finished", map[string]interface{}{
+		"file":     name,
+		"bytes":    record.Bytes,
+		"duration": time.Since(start).Seconds(),
+	})
+	return ExitOK
+}
+
+func (config RunnerConfig) validate(upload bool) error {
+	if config.DSN == "" && len(config.DSNs) == 0 {
+		return errors.New("no DSN configured")
+	}
+	if !upload && config.OutputDir == "" {
+		return errors.New("no output directory configured")
+	}
+	if _, _, err := parseFormat(config.Format); err != nil {
+		return err
+	}
+	return config.Throttle.Validate()
+}
+
+// record adds the run to the catalog of the Recorder, a failure to do so is
+// logged but does not fail the run. It is given recordTimeout of its own,
+// the context of a canceled run would fail the record of its failure.
+func (r *Runner) record(record BackupRecord, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	record.Status = StatusSuccess
+	if err != nil {
+		record.Status = StatusFailed
+		record.Error = err.Error()
+	}
+	if err := r.Recorder.Record(ctx, record); err != nil {
+		r.log("warning", "recording the dump failed", map[string]interface{}{
+			"file":  record.Name,
+			"error": err.Error(),
+		})
+	}
+}
+
+// dump writes the dump of DSN, or of the best candidate of DSNs, to the output
+// directory or the uploader and sets the location, size and checksum of record
+func (r *Runner) dump(ctx context.Context, config RunnerConfig, record *BackupRecord) error {
+	if len(config.DSNs) == 0 {
+		db, err := sql.Open(config.Driver, config.DSN)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return r.dumpDB(ctx, db, config, record)
+	}
+
+	selector := &Selector{
+		Driver: config.Driver,
+		DSNs:   config.DSNs,
+		MaxLag: time.Duration(config.MaxLag) * time.Second,
+	}
+	// Nothing written means the dump did not start, the next candidate may do
+	candidate, err := selector.Dump(ctx, func(db *sql.DB) (bool, error) {
+		err := r.dumpDB(ctx, db, config, record)
+		return record.Bytes > 0, err
+	})
+	if err == nil {
+		r.log("info", "dumped from candidate", map[string]interface{}{
+			"candidate": candidate.Index,
+			"replica":   candidate.Replica,
+			"lag":       candidate.Lag.Seconds(),
+		})
+	}
+	return err
+}
+
+// dumpDB writes the dump of db
+func (r *Runner) dumpDB(ctx context.Context, db *sql.DB, config RunnerConfig, record *BackupRecord) error {
+	name := record.Name
+	data := &Data{}
+	if config.Preset != "" {
+		if err := data.ApplyPreset(config.Preset); err != nil {
+			return err
+		}
+	}
+	if err := data.FromEnv(); err != nil {
+		return err
+	}
+	// The run writes one file to OutputDir or the Uploader, not MYSQLDUMP_FILES_DIR
+	data.Files = nil
+	data.Connection = db
+	data.Context = ctx
+	data.IncludeTables = config.IncludeTables
+	data.IgnoreTables = config.IgnoreTables
+	data.IncludePatterns = config.IncludePatterns
+	data.ExcludePatterns = config.ExcludePatterns
+	data.LockTables = data.LockTables || config.LockTables
+	if config.MaxMemory > 0 {
+		data.MaxMemory = config.MaxMemory
+	}
+	if config.Concurrency > 0 {
+		data.Concurrency = config.Concurrency
+	}
+	if r.Progress != nil {
+		data.OnHeartbeat = r.Progress
+		data.HeartbeatInterval = r.ProgressInterval
+		if data.HeartbeatInterval <= 0 {
+			data.HeartbeatInterval = defaultProgressInterval
+		}
+		data.UseInformationSchema = true
+	}
+
+	run := func(w io.Writer) error {
+		w = config.Throttle.Writer(w)
+		if config.Database != "" {
+			return dumpDatabaseFormat(data, w, config.Format, config.Database)
+		}
+		return dumpFormat(data, w, config.Format)
+	}
+
+	hash := sha256.New()
+	defer func() {
+		record.Checksum = hex.EncodeToString(hash.Sum(nil))
+	}()
+
+	if r.Uploader != nil {
+		record.Location = name
+		pr, pw := io.Pipe()
+		counter := &countWriter{w: io.MultiWriter(pw, hash)}
+		done := make(chan error, 1)
+		go func() {
+			err := run(counter)
+			pw.CloseWithError(err)
+			done <- err
+		}()
+		err := r.Uploader.Upload(ctx, name, pr)
+		// The dump stops writing once the upload no longer reads it
+		pr.CloseWithError(errUploadEnded)
+		dumpErr := <-done
+		record.Bytes = counter.n
+		switch {
+		case dumpErr != nil && !errors.Is(dumpErr, errUploadEnded):
+			// The upload fails with the error of the dump it reads
+			return dumpErr
+		case err == nil && dumpErr != nil:
+			err = errors.New("upload returned before the end of the dump")
+		}
+		if err != nil && CauseOf(err) == "" {
+			// The upload failed on its own, part of the dump may be stored
+			err = &DumpError{Cause: CauseSink, Partial: counter.n > 0, Err: err}
+		}
+		return err
+	}
+
+	// Write to a temporary name first so a half written dump is never
+	// mistaken for a complete one
+	p := filepath.Join(config.OutputDir, name)
+	record.Location = p
+	f, err := os.Create(p + ".partial")
+	if err != nil {
+		return err
+	}
+	counter := &countWriter{w: io.MultiWriter(f, hash)}
+	err = run(counter)
+	record.Bytes = counter.n
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (r *Runner) log(level, msg string, fields map[string]interface{}) {
+	w := r.Log
+	if w == nil {
+		w = os.Stderr
+	}
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": level,
+		"msg":   msg,
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	json.NewEncoder(w).Encode(entry)
+}
+
+// mysqlErrorNumber finds the error number in the messages of MySQL drivers,
+// e.g. "Error 1205 (HY000): Lock wait timeout exceeded"
+var mysqlErrorNumber = regexp.MustCompile(`Error (\d{4})\b`)
+
+// transientMySQLErrors are the server errors worth retrying: too many
+// connections, shutdown in progress, lock wait timeout, deadlock, query
+// interrupted, server gone away and lost connection
+var transientMySQLErrors = map[string]bool{
+	"1040": true,
+	"1053": true,
+	"1205": true,
+	"1213": true,
+	"1317": true,
+	"2006": true,
+	"2013": true,
+}
+
+// IsTransient reports whether err is likely to go away when the dump is tried
+// again, like a lost connection or a deadlock, as opposed to permanent
+// failures like bad credentials or a missing database.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	if m := mysqlErrorNumber.FindStringSubmatch(err.Error()); m != nil {
+		return transientMySQLErrors[m[1]]
+	}
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
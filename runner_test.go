@@ -0,0 +1,249 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRunnerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "config.json")
+	assert.NoError(t, ioutil.WriteFile(p, []byte(`{"dsn": "from-file", "outputDir": "/backups", "ignoreTables": ["a"], "includePatterns": ["orders_*"]}`), 0644))
+
+	os.Setenv("MYSQLDUMP_DSN", "from-env")
+	os.Setenv("MYSQLDUMP_LOCK_TABLES", "true")
+	os.Setenv("MYSQLDUMP_EXCLUDE_PATTERNS", "tmp_*,/_old$/")
+	os.Setenv("MYSQLDUMP_CONCURRENCY", "4")
+	defer os.Unsetenv("MYSQLDUMP_DSN")
+	defer os.Unsetenv("MYSQLDUMP_LOCK_TABLES")
+	defer os.Unsetenv("MYSQLDUMP_EXCLUDE_PATTERNS")
+	defer os.Unsetenv("MYSQLDUMP_CONCURRENCY")
+
+	config, err := mysqldump.LoadRunnerConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, mysqldump.RunnerConfig{
+		DSN:             "from-env",
+		OutputDir:       "/backups",
+		IgnoreTables:    []string{"a"},
+		IncludePatterns: []string{"orders_*"},
+		ExcludePatterns: []string{"tmp_*", "/_old$/"},
+		LockTables:      true,
+		Concurrency:     4,
+	}, config)
+}
+
+func TestRunnerOk(t *testing.T) {
+	dsn := fmt.Sprintf("runner-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mockDump(mock)
+
+	var log bytes.Buffer
+	r := &mysqldump.Runner{
+		Config: mysqldump.RunnerConfig{
+			Driver:     "sqlmock",
+			DSN:        dsn,
+			OutputDir:  dir,
+			FileFormat: "dump",
+		},
+		Log: &log,
+	}
+	assert.Equal(t, mysqldump.ExitOK, r.Run(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "dump.sql"))
+	assert.NoError(t, err)
+	result := strings.Replace(strings.Split(string(content), "-- Dump completed")[0], "`", "~", -1)
+	assert.Equal(t, expected, result)
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	assert.Len(t, lines, 2)
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "dump finished", entry["msg"])
+	assert.EqualValues(t, len(content), entry["bytes"])
+}
+
+func TestRunnerDataFromEnv(t *testing.T) {
+	dsn := fmt.Sprintf("runner-env-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Neither option is in RunnerConfig, the files of MYSQLDUMP_FILES_DIR are
+	// not written in place of the one of OutputDir
+	defer setEnv(t, map[string]string{
+		"MYSQLDUMP_LINE_ENDING": "crlf",
+		"MYSQLDUMP_FILES_DIR":   filepath.Join(dir, "files"),
+	})()
+
+	mockDump(mock)
+	r := &mysqldump.Runner{
+		Config: mysqldump.RunnerConfig{Driver: "sqlmock", DSN: dsn, OutputDir: dir, FileFormat: "dump"},
+		Log:    &bytes.Buffer{},
+	}
+	assert.Equal(t, mysqldump.ExitOK, r.Run(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "dump.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\r\n")
+	assert.NotContains(t, strings.Replace(string(content), "\r\n", "", -1), "\n")
+	_, err = os.Stat(filepath.Join(dir, "files"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoadRunnerConfigDataEnv(t *testing.T) {
+	defer setEnv(t, map[string]string{"MYSQLDUMP_FETCH_SIZE": "many"})()
+	_, err := mysqldump.LoadRunnerConfig("")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "MYSQLDUMP_FETCH_SIZE")
+	}
+}
+
+func TestRunnerExitCodes(t *testing.T) {
+	dsn := fmt.Sprintf("runner-fail-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var log bytes.Buffer
+	r := &mysqldump.Runner{
+		Config: mysqldump.RunnerConfig{Driver: "sqlmock", DSN: dsn, OutputDir: dir},
+		Log:    &log,
+	}
+
+	mock.ExpectBegin().WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")})
+	assert.Equal(t, mysqldump.ExitTransient, r.Run(context.Background()))
+
+	mock.ExpectBegin().WillReturnError(errors.New("Error 1045 (28000): Access denied for user"))
+	assert.Equal(t, mysqldump.ExitPermanent, r.Run(context.Background()))
+
+	r.Config.Format = "zip"
+	assert.Equal(t, mysqldump.ExitPermanent, r.Run(context.Background()))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, mysqldump.IsTransient(errors.New("Error 1213 (40001): Deadlock found when trying to get lock")))
+	assert.True(t, mysqldump.IsTransient(fmt.Errorf("dump: %w", driver.ErrBadConn)))
+	assert.False(t, mysqldump.IsTransient(errors.New("Error 1049 (42000): Unknown database 'nope'")))
+	assert.False(t, mysqldump.IsTransient(nil))
+}
+
+// readingUploader reads up to limit bytes of the dump, all of it if
+// negative, and returns err
+type readingUploader struct {
+	limit int64
+	err   error
+	got   bytes.Buffer
+}
+
+func (u *readingUploader) Upload(ctx context.Context, name string, r io.Reader) error {
+	if u.limit < 0 {
+		_, err := io.Copy(&u.got, r)
+		if err != nil {
+			return err
+		}
+		return u.err
+	}
+	io.CopyN(&u.got, r, u.limit)
+	return u.err
+}
+
+func TestRunnerUploader(t *testing.T) {
+	dsn := fmt.Sprintf("runner-upload-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	var records recorder
+	uploader := &readingUploader{limit: -1}
+	r := &mysqldump.Runner{
+		Config:   mysqldump.RunnerConfig{Driver: "sqlmock", DSN: dsn, FileFormat: "dump"},
+		Uploader: uploader,
+		Recorder: &records,
+		Log:      &bytes.Buffer{},
+	}
+	mockDump(mock)
+	assert.Equal(t, mysqldump.ExitOK, r.Run(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	sum := sha256.Sum256(uploader.got.Bytes())
+	assert.Len(t, records, 1)
+	assert.Equal(t, "dump.sql", records[0].Location)
+	assert.Equal(t, int64(uploader.got.Len()), records[0].Bytes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), records[0].Checksum)
+}
+
+func TestRunnerUploaderFailure(t *testing.T) {
+	dsn := fmt.Sprintf("runner-upload-fail-%d", os.Getpid())
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	var records recorder
+	uploader := &readingUploader{limit: 10, err: errors.New("connection reset")}
+	r := &mysqldump.Runner{
+		Config:   mysqldump.RunnerConfig{Driver: "sqlmock", DSN: dsn, FileFormat: "dump"},
+		Uploader: uploader,
+		Recorder: &records,
+		Log:      &bytes.Buffer{},
+	}
+
+	// The upload fails while the dump is still written
+	mockDump(mock)
+	assert.NotEqual(t, mysqldump.ExitOK, r.Run(context.Background()))
+	assert.Len(t, records, 1)
+	assert.Equal(t, mysqldump.StatusFailed, records[0].Status)
+	assert.Contains(t, records[0].Error, "connection reset")
+
+	// The upload returns without an error before the end of the dump
+	records = nil
+	uploader.err = nil
+	uploader.got.Reset()
+	mockDump(mock)
+	assert.NotEqual(t, mysqldump.ExitOK, r.Run(context.Background()))
+	assert.Len(t, records, 1)
+	assert.Equal(t, mysqldump.StatusFailed, records[0].Status)
+	assert.Contains(t, records[0].Error, "upload returned before the end of the dump")
+}
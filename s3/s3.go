@@ -0,0 +1,398 @@
+// Package s3 streams dumps to S3 with a multipart upload, so multi-GB backups
+// never touch the local disk and no length has to be known up front:
+//
+//	client := s3.NewClient("backups")
+//	w, err := client.NewWriter(ctx, "nightly/dump.sql.gz", s3.PartSize(data))
+//	...
+//	data.Out = w
+//	w.CloseWithError(data.Dump())
+//
+// The Client is also a mysqldump.Uploader, the Uploader of a Runner storing
+// its dumps under Prefix.
+//
+// It talks to the REST API of S3 directly, signing the requests with
+// Signature Version 4, so that it takes no dependency on an AWS SDK. Any
+// service speaking the API, like MinIO, works through Endpoint.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamf/go-mysqldump"
+)
+
+const (
+	// MinPartSize is the smallest part S3 accepts, but for the last one.
+	MinPartSize = 5 << 20
+	// MaxPartSize is the largest part S3 accepts.
+	MaxPartSize = 5 << 30
+	// MaxParts is the most parts an upload can have.
+	MaxParts = 10000
+
+	// defaultMaxAllowedPacket is the MaxAllowedPacket of a Data without one
+	defaultMaxAllowedPacket = 4194304
+	// growthParts is the number of parts after which a Writer doubles its
+	// part size
+	growthParts = 1000
+)
+
+// ErrClosed is returned when writing to a Writer that is closed.
+var ErrClosed = errors.New("s3: write to a closed upload")
+
+// ErrNoBucket is returned by a Client without a Bucket.
+var ErrNoBucket = errors.New("s3: no bucket")
+
+/*
+Client uploads objects to a bucket of S3.
+
+	Bucket:          Name of the bucket, required
+	Region:          Region of the bucket, us-east-1 if empty
+	Endpoint:        URL of the service, addressed with path style, the virtual host of the bucket on AWS if empty
+	Prefix:          Prepended to the names of the dumps given to Upload
+	PartSize:        Size of the parts of Upload, raised to MinPartSize and lowered to MaxPartSize
+	AccessKeyID:     Access key of the credentials
+	SecretAccessKey: Secret key of the credentials
+	SessionToken:    Token of temporary credentials, like the ones of a role
+	HTTPClient:      Client sending the requests, http.DefaultClient if nil
+*/
+type Client struct {
+	Bucket          string
+	Region          string
+	Endpoint        string
+	Prefix          string
+	PartSize        int64
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	HTTPClient      *http.Client
+
+	// minPartSize is the smallest part size of the Writers, MinPartSize if
+	// 0, lowered by the tests to upload small parts to a fake S3
+	minPartSize int64
+}
+
+var _ mysqldump.Uploader = (*Client)(nil)
+
+// NewClient returns a Client of bucket with the region and credentials of the
+// AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables, the endpoint of AWS_ENDPOINT_URL_S3 if set.
+func NewClient(bucket string) *Client {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &Client{
+		Bucket:          bucket,
+		Region:          region,
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL_S3"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// PartSize returns the size of the parts to upload the dump of data with: the
+// smallest multiple of its MaxAllowedPacket S3 accepts. The parts are cut at
+// byte boundaries, a statement can span two of them.
+func PartSize(data *mysqldump.Data) int64 {
+	packet := int64(data.MaxAllowedPacket)
+	if packet <= 0 {
+		packet = defaultMaxAllowedPacket
+	}
+	if packet >= MaxPartSize {
+		return MaxPartSize
+	}
+	return (MinPartSize + packet - 1) / packet * packet
+}
+
+// Error is an error returned by S3.
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return "s3: " + http.StatusText(e.StatusCode)
+	}
+	return "s3: " + e.Code + ": " + e.Message
+}
+
+// Upload uploads what it reads from r to the object named Prefix+name until
+// EOF, aborting the upload if r or S3 fails.
+func (c *Client) Upload(ctx context.Context, name string, r io.Reader) error {
+	w, err := c.NewWriter(ctx, c.Prefix+name, c.PartSize)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return w.CloseWithError(err)
+}
+
+// NewWriter starts the multipart upload of the object key with parts of
+// partSize bytes, raised to MinPartSize and lowered to MaxPartSize, and
+// returns the Writer of its content. The part size doubles every 1000 parts
+// for the upload not to run out of them.
+func (c *Client) NewWriter(ctx context.Context, key string, partSize int64) (*Writer, error) {
+	if c.Bucket == "" {
+		return nil, ErrNoBucket
+	}
+	partSize = c.partSize(partSize)
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		ctx:      ctx,
+		client:   c,
+		key:      key,
+		uploadID: result.UploadID,
+		partSize: partSize,
+		buf:      make([]byte, 0, initialBuffer(partSize)),
+	}, nil
+}
+
+// Writer writes an object of S3 through a multipart upload, uploading a part
+// whenever it has buffered enough of it. The object only appears once the
+// Writer is closed without an error.
+type Writer struct {
+	ctx      context.Context
+	client   *Client
+	key      string
+	uploadID string
+	partSize int64
+	buf      []byte
+	parts    []completedPart
+	written  int64
+	err      error
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// Write buffers p, uploading the parts it completes.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for len(p) > 0 {
+		size := w.partSize
+		if len(w.parts) >= growthParts {
+			size <<= uint(len(w.parts) / growthParts)
+			if size > MaxPartSize || size <= 0 {
+				size = MaxPartSize
+			}
+		}
+		free := size - int64(len(w.buf))
+		if free > int64(len(p)) {
+			free = int64(len(p))
+		}
+		w.buf = append(w.buf, p[:free]...)
+		p = p[free:]
+		n += int(free)
+		w.written += free
+		if int64(len(w.buf)) >= size {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Written returns the number of bytes written to w.
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+// flush uploads the buffered part
+func (w *Writer) flush() error {
+	if len(w.parts) >= MaxParts {
+		w.err = fmt.Errorf("s3: more than %d parts", MaxParts)
+		return w.err
+	}
+	number := len(w.parts) + 1
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {w.uploadID}}
+	etag, err := w.client.put(w.ctx, w.key, query, w.buf)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.parts = append(w.parts, completedPart{PartNumber: number, ETag: etag})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close uploads the last part and completes the upload.
+func (w *Writer) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError aborts the upload if err is not nil, leaving no object, and
+// returns err. Otherwise it closes w like Close.
+func (w *Writer) CloseWithError(err error) error {
+	if w.err == ErrClosed {
+		return ErrClosed
+	}
+	if err == nil {
+		err = w.err
+	}
+	if err == nil && (len(w.buf) > 0 || len(w.parts) == 0) {
+		err = w.flush()
+	}
+	if err == nil {
+		var body []byte
+		if body, err = xml.Marshal(completeUpload{Parts: w.parts}); err == nil {
+			err = w.client.do(w.ctx, http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, body, nil)
+		}
+	}
+	if err != nil {
+		// Parts left behind are billed until the upload is aborted, abort it
+		// even if the context is done
+		w.client.do(context.Background(), http.MethodDelete, w.key, url.Values{"uploadId": {w.uploadID}}, nil, nil)
+	}
+	w.err = ErrClosed
+	return err
+}
+
+// put uploads a part and returns its ETag
+func (c *Client) put(ctx context.Context, key string, query url.Values, body []byte) (string, error) {
+	resp, err := c.send(ctx, http.MethodPut, key, query, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := c.read(resp, nil); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// do sends a request and decodes the XML of its response into result if not
+// nil
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte, result interface{}) error {
+	resp, err := c.send(ctx, method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.read(resp, result)
+}
+
+// read reads the response, returning the Error of S3 it holds. S3 can report
+// the failure of a completion in the body of a 200 response.
+func (c *Client) read(resp *http.Response, result interface{}) error {
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 || bytes.Contains(b, []byte("<Error>")) {
+		e := &Error{StatusCode: resp.StatusCode}
+		xml.Unmarshal(b, e)
+		return e
+	}
+	if result != nil {
+		return xml.Unmarshal(b, result)
+	}
+	return nil
+}
+
+// send sends a signed request for the object key
+func (c *Client) send(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u, err := c.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	payload := sha256.Sum256(body)
+	c.sign(req, hex.EncodeToString(payload[:]), time.Now())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// objectURL returns the URL of the object key, in the virtual host of the
+// bucket on AWS or in its path on the Endpoint
+func (c *Client) objectURL(key string) (*url.URL, error) {
+	if c.Endpoint == "" {
+		return &url.URL{Scheme: "https", Host: c.Bucket + ".s3." + c.region() + ".amazonaws.com", Path: "/" + key, RawPath: "/" + escapePath(key)}, nil
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(u.EscapedPath(), "/")
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.Bucket + "/" + key
+	u.RawPath = base + "/" + escapePath(c.Bucket) + "/" + escapePath(key)
+	return u, nil
+}
+
+// initialBuffer returns the capacity the buffer of a part starts with, up to
+// MinPartSize so that a large part size does not take its memory up front
+func initialBuffer(partSize int64) int {
+	if partSize > MinPartSize {
+		return MinPartSize
+	}
+	return int(partSize)
+}
+
+// partSize returns size within the part sizes S3 accepts
+func (c *Client) partSize(size int64) int64 {
+	min := c.minPartSize
+	if min == 0 {
+		min = MinPartSize
+	}
+	if size < min {
+		return min
+	}
+	if size > MaxPartSize {
+		return MaxPartSize
+	}
+	return size
+}
+
+func (c *Client) region() string {
+	if c.Region == "" {
+		return "us-east-1"
+	}
+	return c.Region
+}
@@ -0,0 +1,211 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 keeps the multipart uploads of a bucket in memory
+type fakeS3 struct {
+	mu       sync.Mutex
+	parts    map[int][]byte
+	objects  map[string][]byte
+	aborted  int
+	failPart int
+	paths    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	query := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodPost && query["uploads"] != nil:
+		f.parts = map[int][]byte{}
+		w.Write([]byte("<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>"))
+	case r.Method == http.MethodPut && query.Get("uploadId") == "upload":
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>"))
+			return
+		}
+		f.parts[number] = body
+		w.Header().Set("ETag", `"etag`+strconv.Itoa(number)+`"`)
+	case r.Method == http.MethodPost && query.Get("uploadId") == "upload":
+		var complete completeUpload
+		xml.Unmarshal(body, &complete)
+		var object []byte
+		for i, part := range complete.Parts {
+			if part.PartNumber != i+1 || part.ETag != `"etag`+strconv.Itoa(i+1)+`"` {
+				w.Write([]byte("<Error><Code>InvalidPart</Code><Message>One or more of the specified parts could not be found.</Message></Error>"))
+				return
+			}
+			object = append(object, f.parts[part.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = object
+		w.Write([]byte("<CompleteMultipartUploadResult></CompleteMultipartUploadResult>"))
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "upload":
+		f.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *Client) {
+	f := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, &Client{Bucket: "backups", Endpoint: server.URL, AccessKeyID: "key", SecretAccessKey: "secret", minPartSize: 1}
+}
+
+func TestWriter(t *testing.T) {
+	f, client := newFakeS3(t)
+	w, err := client.NewWriter(context.Background(), "daily/dump 1.sql", 10)
+	assert.NoError(t, err)
+
+	content := strings.Repeat("INSERT INTO `test` VALUES (1);\n", 5)
+	for i := 0; i < len(content); i += 7 {
+		end := i + 7
+		if end > len(content) {
+			end = len(content)
+		}
+		_, err := w.Write([]byte(content[i:end]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, int64(len(content)), w.Written())
+
+	assert.Equal(t, content, string(f.objects["/backups/daily/dump 1.sql"]))
+	assert.Len(t, f.parts, 16)
+	assert.Len(t, f.parts[1], 10)
+	assert.Equal(t, "/backups/daily/dump%201.sql", f.paths[0])
+	assert.Equal(t, 0, f.aborted)
+
+	_, err = w.Write([]byte("more"))
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, w.Close())
+}
+
+func TestWriterEmpty(t *testing.T) {
+	f, client := newFakeS3(t)
+	w, err := client.NewWriter(context.Background(), "empty.sql", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	object, ok := f.objects["/backups/empty.sql"]
+	assert.True(t, ok)
+	assert.Empty(t, object)
+}
+
+func TestWriterAbort(t *testing.T) {
+	f, client := newFakeS3(t)
+	w, err := client.NewWriter(context.Background(), "dump.sql", 10)
+	assert.NoError(t, err)
+	w.Write([]byte("half a dump"))
+
+	dumpErr := errors.New("dump failed")
+	assert.Equal(t, dumpErr, w.CloseWithError(dumpErr))
+	assert.Equal(t, 1, f.aborted)
+	assert.Empty(t, f.objects)
+}
+
+func TestWriterPartError(t *testing.T) {
+	f, client := newFakeS3(t)
+	f.failPart = 2
+	w, err := client.NewWriter(context.Background(), "dump.sql", 4)
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("0123456789"))
+	var s3Err *Error
+	assert.True(t, errors.As(err, &s3Err))
+	assert.Equal(t, http.StatusInternalServerError, s3Err.StatusCode)
+	assert.Equal(t, "s3: InternalError: We encountered an internal error.", err.Error())
+
+	assert.Equal(t, err, w.Close())
+	assert.Equal(t, 1, f.aborted)
+	assert.Empty(t, f.objects)
+}
+
+func TestUpload(t *testing.T) {
+	f, client := newFakeS3(t)
+	client.Prefix = "nightly/"
+	assert.NoError(t, client.Upload(context.Background(), "dump.sql.gz", bytes.NewReader([]byte("dump"))))
+	assert.Equal(t, "dump", string(f.objects["/backups/nightly/dump.sql.gz"]))
+}
+
+func TestClientErrors(t *testing.T) {
+	_, err := (&Client{}).NewWriter(context.Background(), "dump.sql", 0)
+	assert.Equal(t, ErrNoBucket, err)
+
+	_, client := newFakeS3(t)
+	client.AccessKeyID = "other"
+	_, err = client.NewWriter(context.Background(), "dump.sql", 0)
+	assert.Equal(t, &Error{StatusCode: http.StatusForbidden}, err)
+	assert.Equal(t, "s3: Forbidden", err.Error())
+}
+
+func TestObjectURL(t *testing.T) {
+	u, err := (&Client{Bucket: "backups", Region: "eu-west-1"}).objectURL("a b/dump.sql")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://backups.s3.eu-west-1.amazonaws.com/a%20b/dump.sql", u.String())
+
+	u, err = (&Client{Bucket: "backups", Endpoint: "http://minio:9000/"}).objectURL("dump.sql")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://minio:9000/backups/dump.sql", u.String())
+}
+
+func TestWriterPartSize(t *testing.T) {
+	_, client := newFakeS3(t)
+	client.minPartSize = 0
+	for size, want := range map[int64]int64{0: MinPartSize, 10: MinPartSize, 6 << 20: 6 << 20, 6 << 30: MaxPartSize} {
+		assert.Equal(t, want, client.partSize(size))
+	}
+	w, err := client.NewWriter(context.Background(), "dump.sql", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(MinPartSize), w.partSize)
+	assert.Error(t, w.CloseWithError(errors.New("dump failed")))
+}
+
+func TestPartSize(t *testing.T) {
+	assert.EqualValues(t, 8<<20, PartSize(&mysqldump.Data{}))
+	assert.EqualValues(t, 5<<20, PartSize(&mysqldump.Data{MaxAllowedPacket: 1 << 20}))
+	assert.EqualValues(t, 6000000, PartSize(&mysqldump.Data{MaxAllowedPacket: 3000000}))
+	assert.EqualValues(t, 64<<20, PartSize(&mysqldump.Data{MaxAllowedPacket: 64 << 20}))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-central-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	assert.Equal(t, &Client{Bucket: "backups", Region: "eu-central-1", AccessKeyID: "id", SecretAccessKey: "secret"}, NewClient("backups"))
+}
+
+func TestWriterPartSizeLarge(t *testing.T) {
+	_, client := newFakeS3(t)
+	w, err := client.NewWriter(context.Background(), "dump.sql", 6<<30)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(MaxPartSize), w.partSize)
+	assert.Equal(t, MinPartSize, cap(w.buf))
+	assert.Error(t, w.CloseWithError(errors.New("dump failed")))
+}
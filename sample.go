@@ -0,0 +1,74 @@
+package mysqldump
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+// ErrInvalidSample is returned for sampling fractions outside of 0 to 1.
+var ErrInvalidSample = errors.New("sampling fraction must be between 0 and 1")
+
+func (data *Data) checkSample() error {
+	if data.SampleFraction < 0 || data.SampleFraction > 1 {
+		return ErrInvalidSample
+	}
+	for _, fraction := range data.SampleFractions {
+		if fraction < 0 || fraction > 1 {
+			return ErrInvalidSample
+		}
+	}
+	return nil
+}
+
+// sampleFraction returns the fraction of the rows of the table to dump, 1
+// for all of them
+func (table *table) sampleFraction() float64 {
+	if fraction, ok := table.data.SampleFractions[table.Name]; ok {
+		return fraction
+	}
+	if table.data.SampleFraction > 0 {
+		return table.data.SampleFraction
+	}
+	return 1
+}
+
+// sampled reports whether the current row is part of the sample. The choice
+// hashes the seed, the table name and the primary key, or every value without
+// one, so a row is picked the same way whatever the order of the rows, the
+// server or the time of the dump.
+func (table *table) sampled() bool {
+	fraction := table.sampleFraction()
+	if fraction >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(table.data.SampleSeed))
+	h.Write(seed[:])
+	h.Write([]byte(table.Name))
+	key := table.pk
+	if len(key) == 0 {
+		key = make([]int, len(table.values))
+		for i := range key {
+			key[i] = i
+		}
+	}
+	for _, index := range key {
+		h.Write([]byte{0})
+		h.Write([]byte(plainValue(table.values[index])))
+	}
+	// The top 53 bits of the mixed hash are a uniform float64 in [0, 1)
+	return float64(mix(h.Sum64())>>11)/(1<<53) < fraction
+}
+
+// mix is the finalizer of SplitMix64, spreading the similar hashes of
+// consecutive keys over all the bits
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
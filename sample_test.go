@@ -0,0 +1,81 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleIDs reads a table of the ids and returns the ones in the sample
+func sampleIDs(t *testing.T, configure func(*Data), ids []int) []string {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	configure(data)
+
+	rows := sqlmock.NewRowsWithColumnDefinition(c("id", 0))
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(rows)
+
+	table := data.createTable("test", false)
+	sampled := []string{}
+	for table.Next() {
+		sampled = append(sampled, plainValue(table.values[0]))
+	}
+	assert.NoError(t, table.Err)
+	assert.Equal(t, len(sampled), table.row)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	return sampled
+}
+
+func TestSampleFraction(t *testing.T) {
+	ids := make([]int, 1000)
+	reversed := make([]int, len(ids))
+	for i := range ids {
+		ids[i] = i + 1
+		reversed[len(ids)-1-i] = i + 1
+	}
+	sample := func(data *Data) {
+		data.SampleFraction = 0.2
+		data.SampleSeed = 42
+	}
+
+	first := sampleIDs(t, sample, ids)
+	assert.InDelta(t, 200, len(first), 50)
+
+	// The same rows are picked again, whatever their order
+	assert.Equal(t, first, sampleIDs(t, sample, ids))
+	again := sampleIDs(t, sample, reversed)
+	assert.ElementsMatch(t, first, again)
+
+	// Another seed picks another sample
+	other := sampleIDs(t, func(data *Data) {
+		data.SampleFraction = 0.2
+		data.SampleSeed = 7
+	}, ids)
+	assert.NotEqual(t, first, other)
+}
+
+func TestSampleFractions(t *testing.T) {
+	ids := []int{1, 2, 3, 4, 5}
+	assert.Empty(t, sampleIDs(t, func(data *Data) {
+		data.SampleFraction = 0.5
+		data.SampleFractions = map[string]float64{"test": 0}
+	}, ids))
+	assert.Len(t, sampleIDs(t, func(data *Data) {
+		data.SampleFraction = 0.5
+		data.SampleFractions = map[string]float64{"test": 1}
+	}, ids), 5)
+	assert.Len(t, sampleIDs(t, func(data *Data) {
+		data.SampleFractions = map[string]float64{"other": 0}
+	}, ids), 5)
+}
+
+func TestDumpInvalidSample(t *testing.T) {
+	assert.Equal(t, ErrInvalidSample, (&Data{SampleFraction: 1.5}).Dump())
+	assert.Equal(t, ErrInvalidSample, (&Data{SampleFractions: map[string]float64{"test": -0.1}}).Dump())
+}
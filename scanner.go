@@ -0,0 +1,156 @@
+package mysqldump
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+const defaultDelimiter = ";"
+
+// statement is a single SQL statement read from a dump
+type statement struct {
+	// SQL is the statement without its delimiter
+	SQL string
+	// Comments are the line comments in front of the statement, without the
+	// leading -- or #
+	Comments []string
+	// Line is the line the statement starts on, starting at 1
+	Line int
+	// Unterminated is set on a statement cut off by the end of the input
+	Unterminated bool
+}
+
+// statementScanner splits a dump into statements the way the mysql client
+// does: delimiters inside quotes and comments are ignored and DELIMITER
+// lines change the delimiter for the following statements
+type statementScanner struct {
+	r         *bufio.Reader
+	delimiter string
+	line      int
+	comments  []string
+	pending   string
+}
+
+func newStatementScanner(r io.Reader) *statementScanner {
+	return &statementScanner{
+		r:         bufio.NewReaderSize(r, 64*1024),
+		delimiter: defaultDelimiter,
+	}
+}
+
+// Next returns the next statement, or io.EOF once the input is exhausted
+func (s *statementScanner) Next() (*statement, error) {
+	var buf strings.Builder
+	var quote byte
+	start := 0
+	inComment := false
+
+	for {
+		line, err := s.readLine()
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			if strings.TrimSpace(buf.String()) == "" {
+				return nil, io.EOF
+			}
+			return s.emit(buf.String(), start, true), nil
+		}
+
+		// Comments and DELIMITER commands only count in front of statements
+		if quote == 0 && !inComment && strings.TrimSpace(buf.String()) == "" {
+			trimmed := strings.TrimSpace(line)
+			if isLineComment(trimmed) {
+				s.comments = append(s.comments, strings.TrimSpace(strings.TrimLeft(trimmed, "-#")))
+				continue
+			}
+			if len(trimmed) > 10 && strings.EqualFold(trimmed[:10], "DELIMITER ") {
+				s.delimiter = strings.TrimSpace(trimmed[10:])
+				continue
+			}
+			if trimmed == "" {
+				continue
+			}
+			buf.Reset()
+			start = s.line
+		}
+
+		for i := 0; i < len(line); i++ {
+			ch := line[i]
+			switch {
+			case inComment:
+				buf.WriteByte(ch)
+				if ch == '*' && i+1 < len(line) && line[i+1] == '/' {
+					buf.WriteByte('/')
+					i++
+					inComment = false
+				}
+			case quote != 0:
+				buf.WriteByte(ch)
+				if ch == '\\' && quote != '`' && i+1 < len(line) {
+					buf.WriteByte(line[i+1])
+					i++
+				} else if ch == quote {
+					quote = 0
+				}
+			case ch == '\'' || ch == '"' || ch == '`':
+				buf.WriteByte(ch)
+				quote = ch
+			case ch == '/' && i+1 < len(line) && line[i+1] == '*':
+				buf.WriteString("/*")
+				i++
+				inComment = true
+			case ch == '#' || (ch == '-' && isLineComment(line[i:])):
+				// The rest of the line is a comment
+				buf.WriteByte('\n')
+				i = len(line)
+			case strings.HasPrefix(line[i:], s.delimiter):
+				if rest := line[i+len(s.delimiter):]; strings.TrimSpace(rest) != "" {
+					s.pending = rest
+				}
+				return s.emit(buf.String(), start, false), nil
+			default:
+				buf.WriteByte(ch)
+			}
+		}
+	}
+}
+
+// readLine returns what is left of the current line after a delimiter, or
+// the next line of the input
+func (s *statementScanner) readLine() (string, error) {
+	if s.pending != "" {
+		line := s.pending
+		s.pending = ""
+		return line, nil
+	}
+	line, err := s.r.ReadString('\n')
+	if line != "" {
+		s.line++
+	}
+	return line, err
+}
+
+func (s *statementScanner) emit(sql string, line int, unterminated bool) *statement {
+	st := &statement{
+		SQL:          strings.TrimSpace(sql),
+		Comments:     s.comments,
+		Line:         line,
+		Unterminated: unterminated,
+	}
+	s.comments = nil
+	return st
+}
+
+// isLineComment checks for "-- " style comments, which need whitespace or the
+// end of the line after the dashes, and for # comments
+func isLineComment(s string) bool {
+	if strings.HasPrefix(s, "#") {
+		return true
+	}
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\n' || s[2] == '\r'
+}
@@ -0,0 +1,71 @@
+package mysqldump
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanAll(t *testing.T, input string) []*statement {
+	var result []*statement
+	s := newStatementScanner(strings.NewReader(input))
+	for {
+		st, err := s.Next()
+		if err == io.EOF {
+			return result
+		}
+		assert.NoError(t, err)
+		result = append(result, st)
+	}
+}
+
+func sqlOf(statements []*statement) []string {
+	result := make([]string, len(statements))
+	for i, st := range statements {
+		result[i] = st.SQL
+	}
+	return result
+}
+
+func TestScannerStatements(t *testing.T) {
+	statements := scanAll(t, `-- Go SQL Dump
+-- Server version	8.0.34
+
+/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
+INSERT INTO `+"`t;1`"+` VALUES ('a;b','it\'s;',"x;y"),('--;', NULL); SELECT 1;
+# hash comment
+SELECT 2 -- trailing comment;
+;
+`)
+
+	assert.Equal(t, []string{
+		"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */",
+		"INSERT INTO `t;1` VALUES ('a;b','it\\'s;',\"x;y\"),('--;', NULL)",
+		"SELECT 1",
+		"SELECT 2",
+	}, sqlOf(statements)[:4])
+	assert.Equal(t, []string{"Go SQL Dump", "Server version\t8.0.34"}, statements[0].Comments)
+	assert.Equal(t, 4, statements[0].Line)
+	assert.Equal(t, 5, statements[2].Line)
+	assert.Equal(t, []string{"hash comment"}, statements[3].Comments)
+}
+
+func TestScannerDelimiter(t *testing.T) {
+	statements := scanAll(t, "DELIMITER ;;\nCREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW BEGIN SET @a = 1; SET @b = 2; END ;;\nDELIMITER ;\nSELECT 1;\n")
+
+	assert.Equal(t, []string{
+		"CREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW BEGIN SET @a = 1; SET @b = 2; END",
+		"SELECT 1",
+	}, sqlOf(statements))
+}
+
+func TestScannerUnterminated(t *testing.T) {
+	statements := scanAll(t, "SELECT 1;\nINSERT INTO x VALUES (1")
+
+	assert.Len(t, statements, 2)
+	assert.False(t, statements[0].Unterminated)
+	assert.True(t, statements[1].Unterminated)
+	assert.Equal(t, "INSERT INTO x VALUES (1", statements[1].SQL)
+}
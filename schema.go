@@ -0,0 +1,255 @@
+package mysqldump
+
+import (
+	"strings"
+)
+
+// Schema describes the tables and views of a dump, as read from their
+// CREATE statements.
+type Schema struct {
+	Database string        `json:"database,omitempty"`
+	Tables   []SchemaTable `json:"tables"`
+}
+
+// SchemaTable describes a table or view.
+type SchemaTable struct {
+	Name        string             `json:"name"`
+	View        bool               `json:"view,omitempty"`
+	Comment     string             `json:"comment,omitempty"`
+	Columns     []SchemaColumn     `json:"columns,omitempty"`
+	ForeignKeys []SchemaForeignKey `json:"foreignKeys,omitempty"`
+}
+
+// SchemaColumn describes a column of a table.
+type SchemaColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	Default    string `json:"default,omitempty"`
+	PrimaryKey bool   `json:"primaryKey,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// SchemaForeignKey describes a foreign key of a table.
+type SchemaForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"refTable"`
+	RefColumns []string `json:"refColumns"`
+	OnDelete   string   `json:"onDelete,omitempty"`
+	OnUpdate   string   `json:"onUpdate,omitempty"`
+}
+
+// Schema returns the schema of the last dump, or nil unless SchemaDocWriter
+// is set.
+func (data *Data) Schema() *Schema {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.schema
+}
+
+// addSchema records the schema of a dumped table or view
+func (data *Data) addSchema(table *table) {
+	if data.schema == nil {
+		return
+	}
+	if table.schema == nil {
+		table.schema = &SchemaTable{Name: table.Name, View: table.isView}
+	}
+	data.schema.Tables = append(data.schema.Tables, *table.schema)
+}
+
+// parseSchemaTable reads the columns, primary key, foreign keys and comment
+// of the output of SHOW CREATE TABLE, which has one definition per line
+func parseSchemaTable(name, create string, view bool) SchemaTable {
+	t := SchemaTable{Name: name, View: view}
+	if view {
+		return t
+	}
+	lines := strings.Split(create, "\n")
+	for i, line := range lines[1:] {
+		def := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if strings.HasPrefix(def, ")") {
+			t.Comment = tableComment(strings.Join(lines[i+1:], "\n"))
+			break
+		}
+		p := &valueParser{s: def}
+		switch {
+		case strings.HasPrefix(def, "`"):
+			if col, ok := parseSchemaColumn(p); ok {
+				t.Columns = append(t.Columns, col)
+			}
+		case p.keyword("PRIMARY") && p.keyword("KEY"):
+			for _, pk := range p.nameList() {
+				for i := range t.Columns {
+					if t.Columns[i].Name == pk {
+						t.Columns[i].PrimaryKey = true
+					}
+				}
+			}
+		case p.keyword("CONSTRAINT"):
+			if fk, ok := parseSchemaForeignKey(p); ok {
+				t.ForeignKeys = append(t.ForeignKeys, fk)
+			}
+		}
+	}
+	return t
+}
+
+func parseSchemaColumn(p *valueParser) (SchemaColumn, bool) {
+	name, err := p.identifier()
+	if err != nil {
+		return SchemaColumn{}, false
+	}
+	col := SchemaColumn{Name: name, Type: p.token(), Nullable: true}
+	for {
+		if p.keyword("unsigned") {
+			col.Type += " unsigned"
+		} else if p.keyword("zerofill") {
+			col.Type += " zerofill"
+		} else {
+			break
+		}
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return col, true
+		}
+		switch {
+		case p.keyword("NOT"):
+			if p.keyword("NULL") {
+				col.Nullable = false
+			}
+		case p.keyword("DEFAULT"):
+			p.skipSpace()
+			if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+				col.Default, _ = p.quoted()
+			} else {
+				col.Default = p.token()
+			}
+		case p.keyword("COMMENT"):
+			p.skipSpace()
+			if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+				col.Comment, _ = p.quoted()
+			}
+		default:
+			if p.s[p.pos] == '\'' {
+				if _, err := p.quoted(); err != nil {
+					return col, true
+				}
+			} else {
+				p.token()
+			}
+		}
+	}
+}
+
+func parseSchemaForeignKey(p *valueParser) (SchemaForeignKey, bool) {
+	var fk SchemaForeignKey
+	var err error
+	if fk.Name, err = p.identifier(); err != nil {
+		return fk, false
+	}
+	if !p.keyword("FOREIGN") || !p.keyword("KEY") {
+		return fk, false
+	}
+	fk.Columns = p.nameList()
+	if !p.keyword("REFERENCES") {
+		return fk, false
+	}
+	if fk.RefTable, err = p.identifier(); err != nil {
+		return fk, false
+	}
+	if p.consume(".") {
+		if fk.RefTable, err = p.identifier(); err != nil {
+			return fk, false
+		}
+	}
+	fk.RefColumns = p.nameList()
+	for p.keyword("ON") {
+		action := &fk.OnDelete
+		if p.keyword("UPDATE") {
+			action = &fk.OnUpdate
+		} else if !p.keyword("DELETE") {
+			break
+		}
+		switch {
+		case p.keyword("SET"):
+			*action = "SET " + strings.ToUpper(p.token())
+		case p.keyword("NO"):
+			p.token()
+			*action = "NO ACTION"
+		default:
+			*action = strings.ToUpper(p.token())
+		}
+	}
+	return fk, true
+}
+
+// tableComment finds COMMENT='...' in the table options
+func tableComment(options string) string {
+	i := strings.Index(options, " COMMENT='")
+	if i < 0 {
+		return ""
+	}
+	p := &valueParser{s: options, pos: i + len(" COMMENT=")}
+	comment, _ := p.quoted()
+	return comment
+}
+
+// nameList reads a parenthesized list of identifiers, skipping prefix
+// lengths and orders like (`a`(10),`b` DESC)
+func (p *valueParser) nameList() []string {
+	if !p.consume("(") {
+		return nil
+	}
+	var names []string
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return names
+		}
+		names = append(names, name)
+		for depth := 0; p.pos < len(p.s); p.pos++ {
+			ch := p.s[p.pos]
+			if ch == '(' {
+				depth++
+			} else if ch == ')' && depth > 0 {
+				depth--
+			} else if depth == 0 && (ch == ',' || ch == ')') {
+				break
+			}
+		}
+		if !p.consume(",") {
+			p.consume(")")
+			return names
+		}
+	}
+}
+
+// token reads up to the next white space outside of parentheses and quotes
+func (p *valueParser) token() string {
+	p.skipSpace()
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		switch {
+		case ch == '\'' || ch == '"':
+			if _, err := p.quoted(); err != nil {
+				p.pos = len(p.s)
+			}
+			continue
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case (ch == ' ' || ch == '\t') && depth <= 0:
+			return p.s[start:p.pos]
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
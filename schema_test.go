@@ -0,0 +1,98 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const ordersCreateSQL = "CREATE TABLE `orders` (\n" +
+	"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `user_id` int(11) NOT NULL COMMENT 'Who | ordered',\n" +
+	"  `status` enum('new','paid') DEFAULT 'new',\n" +
+	"  `note` varchar(255) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT 'It''s \\'free\\' text',\n" +
+	"  `created` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `user_id` (`user_id`),\n" +
+	"  CONSTRAINT `orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL ON UPDATE NO ACTION\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Placed <orders>'"
+
+func TestParseSchemaTable(t *testing.T) {
+	assert.Equal(t, SchemaTable{
+		Name:    "orders",
+		Comment: "Placed <orders>",
+		Columns: []SchemaColumn{
+			{Name: "id", Type: "int(10) unsigned", PrimaryKey: true},
+			{Name: "user_id", Type: "int(11)", Comment: "Who | ordered"},
+			{Name: "status", Type: "enum('new','paid')", Nullable: true, Default: "new"},
+			{Name: "note", Type: "varchar(255)", Nullable: true, Default: "NULL", Comment: "It's 'free' text"},
+			{Name: "created", Type: "datetime(6)", Default: "CURRENT_TIMESTAMP(6)"},
+		},
+		ForeignKeys: []SchemaForeignKey{{
+			Name:       "orders_user",
+			Columns:    []string{"user_id"},
+			RefTable:   "users",
+			RefColumns: []string{"id"},
+			OnDelete:   "SET NULL",
+			OnUpdate:   "NO ACTION",
+		}},
+	}, parseSchemaTable("orders", ordersCreateSQL, false))
+}
+
+func TestSchemaDoc(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	var doc bytes.Buffer
+	data := &Data{
+		Connection:      db,
+		Out:             &bytes.Buffer{},
+		SchemaDocWriter: &doc,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^USE shop$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.34"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_shop", "Table_type"}).
+		AddRow("orders", "BASE TABLE").
+		AddRow("totals", "VIEW"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `orders`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("orders", ordersCreateSQL))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `orders`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `orders`$").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("^SHOW CREATE TABLE `totals`$").WillReturnRows(sqlmock.NewRows([]string{"View", "Create View"}).AddRow("totals", "CREATE VIEW `totals` AS select 1"))
+	mock.ExpectRollback()
+
+	assert.NoError(t, data.DumpDatabase("shop"))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, "shop", data.Schema().Database)
+	assert.Equal(t, `# Schema of shop
+
+## Table orders
+
+Placed <orders>
+
+| Column | Type | Null | Default | Key | Comment |
+| --- | --- | --- | --- | --- | --- |
+| id | int(10) unsigned | NO |  | PRI |  |
+| user_id | int(11) | NO |  |  | Who \| ordered |
+| status | enum('new','paid') | YES | new |  |  |
+| note | varchar(255) | YES | NULL |  | It's 'free' text |
+| created | datetime(6) | NO | CURRENT_TIMESTAMP(6) |  |  |
+
+Foreign keys:
+
+- orders_user: (user_id) references users (id) ON DELETE SET NULL ON UPDATE NO ACTION
+
+## View totals
+`, doc.String())
+
+	doc.Reset()
+	data.SchemaDocFormat = SchemaDocHTML
+	assert.NoError(t, data.writeSchemaDoc())
+	assert.Contains(t, doc.String(), "<h2>Table orders</h2>\n<p>Placed &lt;orders&gt;</p>\n<table>")
+	assert.Contains(t, doc.String(), "<tr><td>note</td><td>varchar(255)</td><td>YES</td><td>NULL</td><td></td><td>It&#39;s &#39;free&#39; text</td></tr>")
+}
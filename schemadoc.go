@@ -0,0 +1,137 @@
+package mysqldump
+
+import (
+	"errors"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// SchemaDocFormat selects the format of the schema document.
+type SchemaDocFormat int
+
+const (
+	// SchemaDocMarkdown writes the schema as Markdown.
+	SchemaDocMarkdown SchemaDocFormat = iota
+	// SchemaDocHTML writes the schema as a standalone HTML page.
+	SchemaDocHTML
+	// SchemaDocDOT writes the tables and foreign keys as a Graphviz graph.
+	SchemaDocDOT
+	// SchemaDocMermaid writes the tables and foreign keys as a Mermaid entity
+	// relationship diagram.
+	SchemaDocMermaid
+)
+
+// ErrUnknownSchemaDocFormat is returned for schema document formats that
+// don't exist.
+var ErrUnknownSchemaDocFormat = errors.New("unknown schema document format")
+
+// Takes a *Schema
+const schemaMarkdownTmpl = `# Schema{{ with .Database }} of {{ . }}{{ end }}
+{{ range .Tables }}
+## {{ if .View }}View{{ else }}Table{{ end }} {{ .Name }}
+{{ with .Comment }}
+{{ . }}
+{{ end }}
+{{- if .Columns }}
+| Column | Type | Null | Default | Key | Comment |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Columns }}
+| {{ cell .Name }} | {{ cell .Type }} | {{ if .Nullable }}YES{{ else }}NO{{ end }} | {{ cell .Default }} | {{ if .PrimaryKey }}PRI{{ end }} | {{ cell .Comment }} |
+{{- end }}
+{{ end }}
+{{- if .ForeignKeys }}
+Foreign keys:
+{{ range .ForeignKeys }}
+- {{ .Name }}: ({{ join .Columns }}) references {{ .RefTable }} ({{ join .RefColumns }})
+{{- with .OnDelete }} ON DELETE {{ . }}{{ end }}
+{{- with .OnUpdate }} ON UPDATE {{ . }}{{ end }}
+{{- end }}
+{{ end }}
+{{- end }}`
+
+// Takes a *Schema
+const schemaHTMLTmpl = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Schema{{ with .Database }} of {{ . }}{{ end }}</title>
+</head>
+<body>
+<h1>Schema{{ with .Database }} of {{ . }}{{ end }}</h1>
+{{- range .Tables }}
+<h2>{{ if .View }}View{{ else }}Table{{ end }} {{ .Name }}</h2>
+{{- with .Comment }}
+<p>{{ . }}</p>
+{{- end }}
+{{- if .Columns }}
+<table>
+<tr><th>Column</th><th>Type</th><th>Null</th><th>Default</th><th>Key</th><th>Comment</th></tr>
+{{- range .Columns }}
+<tr><td>{{ .Name }}</td><td>{{ .Type }}</td><td>{{ if .Nullable }}YES{{ else }}NO{{ end }}</td><td>{{ .Default }}</td><td>{{ if .PrimaryKey }}PRI{{ end }}</td><td>{{ .Comment }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .ForeignKeys }}
+<ul>
+{{- range .ForeignKeys }}
+<li>{{ .Name }}: ({{ join .Columns }}) references {{ .RefTable }} ({{ join .RefColumns }})
+{{- with .OnDelete }} ON DELETE {{ . }}{{ end }}
+{{- with .OnUpdate }} ON UPDATE {{ . }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`
+
+var (
+	schemaMarkdown = template.Must(template.New("schemaMarkdown").Funcs(template.FuncMap{
+		"cell": markdownCell,
+		"join": joinNames,
+	}).Parse(schemaMarkdownTmpl))
+	schemaHTML = htmltemplate.Must(htmltemplate.New("schemaHTML").Funcs(htmltemplate.FuncMap{
+		"join": joinNames,
+	}).Parse(schemaHTMLTmpl))
+)
+
+// markdownCell keeps a value from breaking the table it is in
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", "").Replace(s)
+}
+
+func joinNames(names []string) string {
+	return strings.Join(names, ", ")
+}
+
+// checkSchemaDocFormat fails on unknown schema document formats before the
+// dump starts
+func (data *Data) checkSchemaDocFormat() error {
+	if data.SchemaDocWriter == nil {
+		return nil
+	}
+	switch data.SchemaDocFormat {
+	case SchemaDocMarkdown, SchemaDocHTML, SchemaDocDOT, SchemaDocMermaid:
+		return nil
+	}
+	return ErrUnknownSchemaDocFormat
+}
+
+// writeSchemaDoc writes the schema to SchemaDocWriter if it is set
+func (data *Data) writeSchemaDoc() error {
+	if data.SchemaDocWriter == nil {
+		return nil
+	}
+	switch data.SchemaDocFormat {
+	case SchemaDocMarkdown:
+		return schemaMarkdown.Execute(data.SchemaDocWriter, data.schema)
+	case SchemaDocHTML:
+		return schemaHTML.Execute(data.SchemaDocWriter, data.schema)
+	case SchemaDocDOT:
+		return data.schema.WriteDOT(data.SchemaDocWriter)
+	case SchemaDocMermaid:
+		return data.schema.WriteMermaid(data.SchemaDocWriter)
+	}
+	return ErrUnknownSchemaDocFormat
+}
@@ -0,0 +1,223 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSelectorTimeout = 5 * time.Second
+
+/*
+Selector picks the server a dump reads from out of several candidates: the
+reachable read only replicas with the lowest lag first, writable servers like
+the primary last.
+
+	Driver:  Name of the registered database/sql driver, mysql by default
+	DSNs:    Data source names of the candidates
+	MaxLag:  Replicas lagging further behind are left out (0 accepts any lag)
+	Timeout: Time each candidate has to answer the health checks, 5 seconds by default
+*/
+type Selector struct {
+	Driver  string
+	DSNs    []string
+	MaxLag  time.Duration
+	Timeout time.Duration
+}
+
+// Candidate is a server checked by a Selector. Err tells why a dump can't be
+// taken from it.
+type Candidate struct {
+	DSN      string
+	Index    int
+	ReadOnly bool
+	Replica  bool
+	Lag      time.Duration
+	Err      error
+}
+
+var (
+	// ErrNoCandidate is returned when no candidate passes the health checks
+	// or a dump could start on none of them.
+	ErrNoCandidate = errors.New("no server to dump from")
+	// ErrReplicationStopped marks replicas whose replication threads are not
+	// running, their data is stale by an unknown amount.
+	ErrReplicationStopped = errors.New("replication is not running")
+	// ErrReplicaLagging marks replicas further behind than MaxLag.
+	ErrReplicaLagging = errors.New("replica lags behind")
+)
+
+// Candidates checks every candidate and returns them best first, the ones
+// that failed the checks last.
+func (s *Selector) Candidates(ctx context.Context) []Candidate {
+	probes := s.probe(ctx)
+	candidates := make([]Candidate, len(probes))
+	for i, p := range probes {
+		if p.db != nil {
+			p.db.Close()
+		}
+		candidates[i] = p.Candidate
+	}
+	return candidates
+}
+
+// Dump calls fn with the best candidate and moves on to the next one as long
+// as fn reports the dump did not start, like when the transaction can't be
+// opened. It returns the candidate the dump was taken from.
+func (s *Selector) Dump(ctx context.Context, fn func(db *sql.DB) (started bool, err error)) (Candidate, error) {
+	probes := s.probe(ctx)
+	defer func() {
+		for _, p := range probes {
+			if p.db != nil {
+				p.db.Close()
+			}
+		}
+	}()
+
+	var errs []string
+	for _, p := range probes {
+		if p.Err != nil {
+			errs = append(errs, fmt.Sprintf("#%d: %v", p.Index, p.Err))
+			continue
+		}
+		started, err := fn(p.db)
+		if err == nil || started {
+			return p.Candidate, err
+		}
+		errs = append(errs, fmt.Sprintf("#%d: %v", p.Index, err))
+	}
+	if len(errs) == 0 {
+		return Candidate{}, ErrNoCandidate
+	}
+	return Candidate{}, fmt.Errorf("%w: %s", ErrNoCandidate, strings.Join(errs, "; "))
+}
+
+type probe struct {
+	Candidate
+	db *sql.DB
+}
+
+// probe checks all candidates at once and sorts them
+func (s *Selector) probe(ctx context.Context) []probe {
+	probes := make([]probe, len(s.DSNs))
+	var wg sync.WaitGroup
+	for i, dsn := range s.DSNs {
+		probes[i].DSN = dsn
+		probes[i].Index = i
+		wg.Add(1)
+		go func(p *probe) {
+			defer wg.Done()
+			s.check(ctx, p)
+		}(&probes[i])
+	}
+	wg.Wait()
+
+	sort.SliceStable(probes, func(i, j int) bool {
+		a, b := probes[i], probes[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if a.ReadOnly != b.ReadOnly {
+			return a.ReadOnly
+		}
+		return a.Lag < b.Lag
+	})
+	return probes
+}
+
+// check opens a candidate and reads its read_only flag and replication lag.
+// The connection is kept open for the dump unless a check fails.
+func (s *Selector) check(ctx context.Context, p *probe) {
+	driver := s.Driver
+	if driver == "" {
+		driver = "mysql"
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultSelectorTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	db, err := sql.Open(driver, p.DSN)
+	if err != nil {
+		p.Err = err
+		return
+	}
+	if p.Err = s.checkDB(ctx, db, &p.Candidate); p.Err != nil {
+		db.Close()
+		return
+	}
+	p.db = db
+}
+
+func (s *Selector) checkDB(ctx context.Context, db *sql.DB, c *Candidate) error {
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	if err := db.QueryRowContext(ctx, "SELECT @@read_only").Scan(&c.ReadOnly); err != nil {
+		return err
+	}
+	if err := readReplicaStatus(ctx, db, c); err != nil {
+		return err
+	}
+	if s.MaxLag > 0 && c.Lag > s.MaxLag {
+		return fmt.Errorf("%w: %s", ErrReplicaLagging, c.Lag)
+	}
+	return nil
+}
+
+// readReplicaStatus reads the lag from SHOW REPLICA STATUS, SHOW SLAVE STATUS
+// before MySQL 8.0.22 and on MariaDB. Servers replicating from nobody have no
+// lag.
+func readReplicaStatus(ctx context.Context, db *sql.DB, c *Candidate) error {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return err
+		}
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		return rows.Err()
+	}
+	values := make([]sql.NullString, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+	if err := rows.Scan(scans...); err != nil {
+		return err
+	}
+
+	c.Replica = true
+	running := true
+	lag := sql.NullString{}
+	for i, col := range cols {
+		switch col {
+		case "Replica_IO_Running", "Slave_IO_Running", "Replica_SQL_Running", "Slave_SQL_Running":
+			running = running && values[i].String == "Yes"
+		case "Seconds_Behind_Source", "Seconds_Behind_Master":
+			lag = values[i]
+		}
+	}
+	if !running || !lag.Valid {
+		return ErrReplicationStopped
+	}
+	var seconds sql.NullInt64
+	if err := seconds.Scan(lag.String); err != nil {
+		return err
+	}
+	c.Lag = time.Duration(seconds.Int64) * time.Second
+	return rows.Err()
+}
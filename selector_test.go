@@ -0,0 +1,139 @@
+package mysqldump_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func replicaStatus(lag interface{}, running string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"Replica_IO_State", "Replica_IO_Running", "Replica_SQL_Running", "Seconds_Behind_Source"}).
+		AddRow("Waiting for source to send event", running, "Yes", lag)
+}
+
+// mockCandidate expects the health checks of a Selector, status nil for a
+// server that is no replica
+func mockCandidate(t *testing.T, dsn string, readOnly int, status *sqlmock.Rows) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	mock.ExpectQuery(`^SELECT @@read_only$`).WillReturnRows(sqlmock.NewRows([]string{"@@read_only"}).AddRow(readOnly))
+	if status == nil {
+		status = sqlmock.NewRows([]string{"Replica_IO_State"})
+	}
+	mock.ExpectQuery(`^SHOW REPLICA STATUS$`).WillReturnRows(status)
+	return db, mock
+}
+
+func TestSelectorCandidates(t *testing.T) {
+	primary, _ := mockCandidate(t, "selector-primary", 0, nil)
+	defer primary.Close()
+	behind, _ := mockCandidate(t, "selector-behind", 1, replicaStatus(30, "Yes"))
+	defer behind.Close()
+	close, _ := mockCandidate(t, "selector-close", 1, replicaStatus(5, "Yes"))
+	defer close.Close()
+	stopped, _ := mockCandidate(t, "selector-stopped", 1, replicaStatus(nil, "No"))
+	defer stopped.Close()
+
+	s := &mysqldump.Selector{
+		Driver: "sqlmock",
+		DSNs:   []string{"selector-primary", "selector-behind", "selector-stopped", "selector-close"},
+	}
+	candidates := s.Candidates(context.Background())
+	assert.Equal(t, []mysqldump.Candidate{
+		{DSN: "selector-close", Index: 3, ReadOnly: true, Replica: true, Lag: 5 * time.Second},
+		{DSN: "selector-behind", Index: 1, ReadOnly: true, Replica: true, Lag: 30 * time.Second},
+		{DSN: "selector-primary", Index: 0},
+		{DSN: "selector-stopped", Index: 2, ReadOnly: true, Replica: true, Err: mysqldump.ErrReplicationStopped},
+	}, candidates)
+}
+
+func TestSelectorDumpFailover(t *testing.T) {
+	behind, _ := mockCandidate(t, "failover-behind", 1, replicaStatus(30, "Yes"))
+	defer behind.Close()
+	lagging, _ := mockCandidate(t, "failover-lagging", 1, replicaStatus(3600, "Yes"))
+	defer lagging.Close()
+	close, _ := mockCandidate(t, "failover-close", 1, replicaStatus(5, "Yes"))
+	defer close.Close()
+
+	s := &mysqldump.Selector{
+		Driver: "sqlmock",
+		DSNs:   []string{"failover-lagging", "failover-behind", "failover-close"},
+		MaxLag: time.Minute,
+	}
+	attempts := 0
+	candidate, err := s.Dump(context.Background(), func(db *sql.DB) (bool, error) {
+		attempts++
+		if attempts == 1 {
+			return false, errors.New("Error 1040: Too many connections")
+		}
+		return true, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "failover-behind", candidate.DSN)
+
+	// A dump that failed half way is not retried elsewhere
+	for _, dsn := range []string{"failover-lagging", "failover-behind", "failover-close"} {
+		_, mock, err := sqlmock.NewWithDSN(dsn + "-again")
+		assert.NoError(t, err)
+		mock.ExpectQuery(`^SELECT @@read_only$`).WillReturnRows(sqlmock.NewRows([]string{"@@read_only"}).AddRow(1))
+		mock.ExpectQuery(`^SHOW REPLICA STATUS$`).WillReturnError(errors.New("Error 1064: You have an error in your SQL syntax"))
+		mock.ExpectQuery(`^SHOW SLAVE STATUS$`).WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_Running", "Slave_SQL_Running", "Seconds_Behind_Master"}).AddRow("Yes", "Yes", 1))
+	}
+	s.DSNs = []string{"failover-lagging-again", "failover-behind-again", "failover-close-again"}
+	attempts = 0
+	_, err = s.Dump(context.Background(), func(db *sql.DB) (bool, error) {
+		attempts++
+		return true, errors.New("Error 2013: Lost connection to MySQL server during query")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestSelectorNoCandidate(t *testing.T) {
+	stopped, _ := mockCandidate(t, "none-stopped", 1, replicaStatus(nil, "No"))
+	defer stopped.Close()
+
+	s := &mysqldump.Selector{Driver: "sqlmock", DSNs: []string{"none-stopped"}}
+	_, err := s.Dump(context.Background(), func(db *sql.DB) (bool, error) { return true, nil })
+	assert.True(t, errors.Is(err, mysqldump.ErrNoCandidate))
+	assert.Contains(t, err.Error(), "#0: replication is not running")
+}
+
+func TestRunnerCandidates(t *testing.T) {
+	best, bestMock := mockCandidate(t, "runner-best", 1, replicaStatus(0, "Yes"))
+	defer best.Close()
+	bestMock.ExpectBegin().WillReturnError(errors.New("Error 1040: Too many connections"))
+	next, nextMock := mockCandidate(t, "runner-next", 1, replicaStatus(2, "Yes"))
+	defer next.Close()
+	mockDump(nextMock)
+
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r := &mysqldump.Runner{
+		Config: mysqldump.RunnerConfig{
+			Driver:     "sqlmock",
+			DSNs:       []string{"runner-next", "runner-best"},
+			OutputDir:  dir,
+			FileFormat: "dump",
+		},
+		Log: ioutil.Discard,
+	}
+	assert.Equal(t, mysqldump.ExitOK, r.Run(context.Background()))
+	assert.NoError(t, bestMock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.NoError(t, nextMock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	_, err = os.Stat(filepath.Join(dir, "dump.sql"))
+	assert.NoError(t, err)
+}
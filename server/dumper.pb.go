@@ -0,0 +1,537 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: dumper.proto
+
+package server
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status_State int32
+
+const (
+	Status_STATE_UNSPECIFIED Status_State = 0
+	Status_STATE_PENDING     Status_State = 1
+	Status_STATE_RUNNING     Status_State = 2
+	Status_STATE_DONE        Status_State = 3
+	Status_STATE_FAILED      Status_State = 4
+	Status_STATE_CANCELED    Status_State = 5
+)
+
+// Enum value maps for Status_State.
+var (
+	Status_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_PENDING",
+		2: "STATE_RUNNING",
+		3: "STATE_DONE",
+		4: "STATE_FAILED",
+		5: "STATE_CANCELED",
+	}
+	Status_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_PENDING":     1,
+		"STATE_RUNNING":     2,
+		"STATE_DONE":        3,
+		"STATE_FAILED":      4,
+		"STATE_CANCELED":    5,
+	}
+)
+
+func (x Status_State) Enum() *Status_State {
+	p := new(Status_State)
+	*p = x
+	return p
+}
+
+func (x Status_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_dumper_proto_enumTypes[0].Descriptor()
+}
+
+func (Status_State) Type() protoreflect.EnumType {
+	return &file_dumper_proto_enumTypes[0]
+}
+
+func (x Status_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status_State.Descriptor instead.
+func (Status_State) EnumDescriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{6, 0}
+}
+
+type StartDumpRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only dump these tables, all of them if empty.
+	IncludeTables []string `protobuf:"bytes,1,rep,name=include_tables,json=includeTables,proto3" json:"include_tables,omitempty"`
+	// Leave these tables out of the dump.
+	IgnoreTables  []string `protobuf:"bytes,2,rep,name=ignore_tables,json=ignoreTables,proto3" json:"ignore_tables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDumpRequest) Reset() {
+	*x = StartDumpRequest{}
+	mi := &file_dumper_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDumpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDumpRequest) ProtoMessage() {}
+
+func (x *StartDumpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDumpRequest.ProtoReflect.Descriptor instead.
+func (*StartDumpRequest) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartDumpRequest) GetIncludeTables() []string {
+	if x != nil {
+		return x.IncludeTables
+	}
+	return nil
+}
+
+func (x *StartDumpRequest) GetIgnoreTables() []string {
+	if x != nil {
+		return x.IgnoreTables
+	}
+	return nil
+}
+
+type StartDumpResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DumpId        string                 `protobuf:"bytes,1,opt,name=dump_id,json=dumpId,proto3" json:"dump_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDumpResponse) Reset() {
+	*x = StartDumpResponse{}
+	mi := &file_dumper_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDumpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDumpResponse) ProtoMessage() {}
+
+func (x *StartDumpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDumpResponse.ProtoReflect.Descriptor instead.
+func (*StartDumpResponse) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartDumpResponse) GetDumpId() string {
+	if x != nil {
+		return x.DumpId
+	}
+	return ""
+}
+
+type StreamChunksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DumpId        string                 `protobuf:"bytes,1,opt,name=dump_id,json=dumpId,proto3" json:"dump_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamChunksRequest) Reset() {
+	*x = StreamChunksRequest{}
+	mi := &file_dumper_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChunksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChunksRequest) ProtoMessage() {}
+
+func (x *StreamChunksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChunksRequest.ProtoReflect.Descriptor instead.
+func (*StreamChunksRequest) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamChunksRequest) GetDumpId() string {
+	if x != nil {
+		return x.DumpId
+	}
+	return ""
+}
+
+type Chunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Offset of data within the dump.
+	Offset        int64  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_dumper_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Chunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DumpId        string                 `protobuf:"bytes,1,opt,name=dump_id,json=dumpId,proto3" json:"dump_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_dumper_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStatusRequest) GetDumpId() string {
+	if x != nil {
+		return x.DumpId
+	}
+	return ""
+}
+
+type CancelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DumpId        string                 `protobuf:"bytes,1,opt,name=dump_id,json=dumpId,proto3" json:"dump_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_dumper_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CancelRequest) GetDumpId() string {
+	if x != nil {
+		return x.DumpId
+	}
+	return ""
+}
+
+type Status struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	DumpId string                 `protobuf:"bytes,1,opt,name=dump_id,json=dumpId,proto3" json:"dump_id,omitempty"`
+	State  Status_State           `protobuf:"varint,2,opt,name=state,proto3,enum=mysqldump.server.v1.Status_State" json:"state,omitempty"`
+	// Bytes of output streamed so far.
+	BytesWritten int64 `protobuf:"varint,3,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	// Error of a failed dump.
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_dumper_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_dumper_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_dumper_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Status) GetDumpId() string {
+	if x != nil {
+		return x.DumpId
+	}
+	return ""
+}
+
+func (x *Status) GetState() Status_State {
+	if x != nil {
+		return x.State
+	}
+	return Status_STATE_UNSPECIFIED
+}
+
+func (x *Status) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *Status) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_dumper_proto protoreflect.FileDescriptor
+
+const file_dumper_proto_rawDesc = "" +
+	"\n" +
+	"\fdumper.proto\x12\x13mysqldump.server.v1\"^\n" +
+	"\x10StartDumpRequest\x12%\n" +
+	"\x0einclude_tables\x18\x01 \x03(\tR\rincludeTables\x12#\n" +
+	"\rignore_tables\x18\x02 \x03(\tR\fignoreTables\",\n" +
+	"\x11StartDumpResponse\x12\x17\n" +
+	"\adump_id\x18\x01 \x01(\tR\x06dumpId\".\n" +
+	"\x13StreamChunksRequest\x12\x17\n" +
+	"\adump_id\x18\x01 \x01(\tR\x06dumpId\"3\n" +
+	"\x05Chunk\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"+\n" +
+	"\x10GetStatusRequest\x12\x17\n" +
+	"\adump_id\x18\x01 \x01(\tR\x06dumpId\"(\n" +
+	"\rCancelRequest\x12\x17\n" +
+	"\adump_id\x18\x01 \x01(\tR\x06dumpId\"\x91\x02\n" +
+	"\x06Status\x12\x17\n" +
+	"\adump_id\x18\x01 \x01(\tR\x06dumpId\x127\n" +
+	"\x05state\x18\x02 \x01(\x0e2!.mysqldump.server.v1.Status.StateR\x05state\x12#\n" +
+	"\rbytes_written\x18\x03 \x01(\x03R\fbytesWritten\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"z\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATE_PENDING\x10\x01\x12\x11\n" +
+	"\rSTATE_RUNNING\x10\x02\x12\x0e\n" +
+	"\n" +
+	"STATE_DONE\x10\x03\x12\x10\n" +
+	"\fSTATE_FAILED\x10\x04\x12\x12\n" +
+	"\x0eSTATE_CANCELED\x10\x052\xd8\x02\n" +
+	"\x06Dumper\x12Z\n" +
+	"\tStartDump\x12%.mysqldump.server.v1.StartDumpRequest\x1a&.mysqldump.server.v1.StartDumpResponse\x12V\n" +
+	"\fStreamChunks\x12(.mysqldump.server.v1.StreamChunksRequest\x1a\x1a.mysqldump.server.v1.Chunk0\x01\x12O\n" +
+	"\tGetStatus\x12%.mysqldump.server.v1.GetStatusRequest\x1a\x1b.mysqldump.server.v1.Status\x12I\n" +
+	"\x06Cancel\x12\".mysqldump.server.v1.CancelRequest\x1a\x1b.mysqldump.server.v1.StatusB,Z*github.com/jamf/go-mysqldump/server;serverb\x06proto3"
+
+var (
+	file_dumper_proto_rawDescOnce sync.Once
+	file_dumper_proto_rawDescData []byte
+)
+
+func file_dumper_proto_rawDescGZIP() []byte {
+	file_dumper_proto_rawDescOnce.Do(func() {
+		file_dumper_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dumper_proto_rawDesc), len(file_dumper_proto_rawDesc)))
+	})
+	return file_dumper_proto_rawDescData
+}
+
+var file_dumper_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_dumper_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_dumper_proto_goTypes = []any{
+	(Status_State)(0),           // 0: mysqldump.server.v1.Status.State
+	(*StartDumpRequest)(nil),    // 1: mysqldump.server.v1.StartDumpRequest
+	(*StartDumpResponse)(nil),   // 2: mysqldump.server.v1.StartDumpResponse
+	(*StreamChunksRequest)(nil), // 3: mysqldump.server.v1.StreamChunksRequest
+	(*Chunk)(nil),               // 4: mysqldump.server.v1.Chunk
+	(*GetStatusRequest)(nil),    // 5: mysqldump.server.v1.GetStatusRequest
+	(*CancelRequest)(nil),       // 6: mysqldump.server.v1.CancelRequest
+	(*Status)(nil),              // 7: mysqldump.server.v1.Status
+}
+var file_dumper_proto_depIdxs = []int32{
+	0, // 0: mysqldump.server.v1.Status.state:type_name -> mysqldump.server.v1.Status.State
+	1, // 1: mysqldump.server.v1.Dumper.StartDump:input_type -> mysqldump.server.v1.StartDumpRequest
+	3, // 2: mysqldump.server.v1.Dumper.StreamChunks:input_type -> mysqldump.server.v1.StreamChunksRequest
+	5, // 3: mysqldump.server.v1.Dumper.GetStatus:input_type -> mysqldump.server.v1.GetStatusRequest
+	6, // 4: mysqldump.server.v1.Dumper.Cancel:input_type -> mysqldump.server.v1.CancelRequest
+	2, // 5: mysqldump.server.v1.Dumper.StartDump:output_type -> mysqldump.server.v1.StartDumpResponse
+	4, // 6: mysqldump.server.v1.Dumper.StreamChunks:output_type -> mysqldump.server.v1.Chunk
+	7, // 7: mysqldump.server.v1.Dumper.GetStatus:output_type -> mysqldump.server.v1.Status
+	7, // 8: mysqldump.server.v1.Dumper.Cancel:output_type -> mysqldump.server.v1.Status
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_dumper_proto_init() }
+func file_dumper_proto_init() {
+	if File_dumper_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dumper_proto_rawDesc), len(file_dumper_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dumper_proto_goTypes,
+		DependencyIndexes: file_dumper_proto_depIdxs,
+		EnumInfos:         file_dumper_proto_enumTypes,
+		MessageInfos:      file_dumper_proto_msgTypes,
+	}.Build()
+	File_dumper_proto = out.File
+	file_dumper_proto_goTypes = nil
+	file_dumper_proto_depIdxs = nil
+}
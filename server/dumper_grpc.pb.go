@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: dumper.proto
+
+package server
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Dumper_StartDump_FullMethodName    = "/mysqldump.server.v1.Dumper/StartDump"
+	Dumper_StreamChunks_FullMethodName = "/mysqldump.server.v1.Dumper/StreamChunks"
+	Dumper_GetStatus_FullMethodName    = "/mysqldump.server.v1.Dumper/GetStatus"
+	Dumper_Cancel_FullMethodName       = "/mysqldump.server.v1.Dumper/Cancel"
+)
+
+// DumperClient is the client API for Dumper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Dumper lets a remote client start a dump, stream its output and follow or
+// cancel it.
+type DumperClient interface {
+	// StartDump registers a new dump. The dump itself runs while its chunks
+	// are streamed.
+	StartDump(ctx context.Context, in *StartDumpRequest, opts ...grpc.CallOption) (*StartDumpResponse, error)
+	// StreamChunks streams the output of a dump until it is complete. A dump
+	// can only be streamed once.
+	StreamChunks(ctx context.Context, in *StreamChunksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	// GetStatus reports the state and progress of a dump.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// Cancel aborts a dump that has not completed yet.
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*Status, error)
+}
+
+type dumperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDumperClient(cc grpc.ClientConnInterface) DumperClient {
+	return &dumperClient{cc}
+}
+
+func (c *dumperClient) StartDump(ctx context.Context, in *StartDumpRequest, opts ...grpc.CallOption) (*StartDumpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartDumpResponse)
+	err := c.cc.Invoke(ctx, Dumper_StartDump_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumperClient) StreamChunks(ctx context.Context, in *StreamChunksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Dumper_ServiceDesc.Streams[0], Dumper_StreamChunks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamChunksRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Dumper_StreamChunksClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *dumperClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Dumper_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumperClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Dumper_Cancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DumperServer is the server API for Dumper service.
+// All implementations must embed UnimplementedDumperServer
+// for forward compatibility.
+//
+// Dumper lets a remote client start a dump, stream its output and follow or
+// cancel it.
+type DumperServer interface {
+	// StartDump registers a new dump. The dump itself runs while its chunks
+	// are streamed.
+	StartDump(context.Context, *StartDumpRequest) (*StartDumpResponse, error)
+	// StreamChunks streams the output of a dump until it is complete. A dump
+	// can only be streamed once.
+	StreamChunks(*StreamChunksRequest, grpc.ServerStreamingServer[Chunk]) error
+	// GetStatus reports the state and progress of a dump.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// Cancel aborts a dump that has not completed yet.
+	Cancel(context.Context, *CancelRequest) (*Status, error)
+	mustEmbedUnimplementedDumperServer()
+}
+
+// UnimplementedDumperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDumperServer struct{}
+
+func (UnimplementedDumperServer) StartDump(context.Context, *StartDumpRequest) (*StartDumpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartDump not implemented")
+}
+func (UnimplementedDumperServer) StreamChunks(*StreamChunksRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamChunks not implemented")
+}
+func (UnimplementedDumperServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedDumperServer) Cancel(context.Context, *CancelRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedDumperServer) mustEmbedUnimplementedDumperServer() {}
+func (UnimplementedDumperServer) testEmbeddedByValue()                {}
+
+// UnsafeDumperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DumperServer will
+// result in compilation errors.
+type UnsafeDumperServer interface {
+	mustEmbedUnimplementedDumperServer()
+}
+
+func RegisterDumperServer(s grpc.ServiceRegistrar, srv DumperServer) {
+	// If the following call pancis, it indicates UnimplementedDumperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Dumper_ServiceDesc, srv)
+}
+
+func _Dumper_StartDump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartDumpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumperServer).StartDump(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dumper_StartDump_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumperServer).StartDump(ctx, req.(*StartDumpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dumper_StreamChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamChunksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DumperServer).StreamChunks(m, &grpc.GenericServerStream[StreamChunksRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Dumper_StreamChunksServer = grpc.ServerStreamingServer[Chunk]
+
+func _Dumper_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumperServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dumper_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumperServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dumper_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumperServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dumper_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumperServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Dumper_ServiceDesc is the grpc.ServiceDesc for Dumper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Dumper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mysqldump.server.v1.Dumper",
+	HandlerType: (*DumperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartDump",
+			Handler:    _Dumper_StartDump_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Dumper_GetStatus_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Dumper_Cancel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamChunks",
+			Handler:       _Dumper_StreamChunks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dumper.proto",
+}
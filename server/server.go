@@ -0,0 +1,286 @@
+/*
+Package server exposes go-mysqldump as a gRPC service so backup orchestration
+systems can start dumps remotely, stream their output and follow or cancel
+them.
+
+	s := grpc.NewServer()
+	server.RegisterDumperServer(s, server.New(db))
+
+The messages and the service are defined in dumper.proto, regenerate the Go
+code with `buf generate` after changing it.
+*/
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jamf/go-mysqldump"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	chunkSize             = 32 * 1024
+	defaultRetention      = 10 * time.Minute
+	defaultPendingTimeout = 10 * time.Minute
+)
+
+/*
+Server implements DumperServer on top of a database connection.
+
+	DB:             Database that will be dumped
+	NewData:        Returns the options for a dump, Connection and Out are set by the server, the IncludeTables of the request only narrow the tables it dumps
+	Retention:      How long the status of a finished dump is kept, 10 minutes by default
+	PendingTimeout: How long a started dump waits to be streamed before it fails, 10 minutes by default
+*/
+type Server struct {
+	UnimplementedDumperServer
+
+	DB             *sql.DB
+	NewData        func(req *StartDumpRequest) *mysqldump.Data
+	Retention      time.Duration
+	PendingTimeout time.Duration
+
+	mu    sync.Mutex
+	dumps map[string]*dump
+}
+
+// New creates a Server dumping db.
+func New(db *sql.DB) *Server {
+	return &Server{DB: db}
+}
+
+type dump struct {
+	id string
+	r  io.ReadCloser
+
+	mu        sync.Mutex
+	state     Status_State
+	written   int64
+	err       error
+	streaming bool
+}
+
+// StartDump registers a new dump, it runs while it is streamed.
+func (s *Server) StartDump(ctx context.Context, req *StartDumpRequest) (*StartDumpResponse, error) {
+	data := &mysqldump.Data{}
+	if s.NewData != nil {
+		data = s.NewData(req)
+	}
+	data.Connection = s.DB
+	if len(req.GetIncludeTables()) > 0 {
+		tables, err := data.AllowedTables(req.GetIncludeTables())
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if len(tables) == 0 {
+			return nil, status.Error(codes.PermissionDenied, "none of the tables may be dumped")
+		}
+		data.IncludeTables = tables
+	}
+	data.IgnoreTables = append(data.IgnoreTables, req.GetIgnoreTables()...)
+
+	id, err := newID()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dumps == nil {
+		s.dumps = make(map[string]*dump)
+	}
+	d := &dump{
+		id:    id,
+		r:     mysqldump.NewReader(data),
+		state: Status_STATE_PENDING,
+	}
+	s.dumps[id] = d
+	s.timeout(d)
+	return &StartDumpResponse{DumpId: id}, nil
+}
+
+// timeout fails a dump nobody streamed or canceled by the PendingTimeout, for
+// it to be forgotten like a finished one
+func (s *Server) timeout(d *dump) {
+	pending := s.PendingTimeout
+	if pending == 0 {
+		pending = defaultPendingTimeout
+	}
+	time.AfterFunc(pending, func() {
+		d.mu.Lock()
+		expired := d.state == Status_STATE_PENDING && !d.streaming
+		if expired {
+			d.state = Status_STATE_FAILED
+			d.err = errors.New("dump " + d.id + " was not streamed within " + pending.String())
+		}
+		d.mu.Unlock()
+
+		if expired {
+			d.r.Close()
+			s.expire(d)
+		}
+	})
+}
+
+// StreamChunks runs the dump and streams its output.
+func (s *Server) StreamChunks(req *StreamChunksRequest, stream Dumper_StreamChunksServer) error {
+	d, err := s.get(req.GetDumpId())
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	if d.streaming || d.state != Status_STATE_PENDING {
+		d.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "dump "+d.id+" has already been streamed")
+	}
+	d.streaming = true
+	d.state = Status_STATE_RUNNING
+	d.mu.Unlock()
+
+	buf := make([]byte, chunkSize)
+	var offset int64
+	for {
+		n, err := d.r.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&Chunk{Offset: offset, Data: buf[:n]}); sendErr != nil {
+				d.r.Close()
+				s.finish(d, sendErr)
+				return sendErr
+			}
+			offset += int64(n)
+			d.mu.Lock()
+			d.written = offset
+			d.mu.Unlock()
+		}
+		if err == io.EOF {
+			s.finish(d, nil)
+			return nil
+		}
+		if err != nil {
+			s.finish(d, err)
+			if d.status().GetState() == Status_STATE_CANCELED {
+				return status.Error(codes.Canceled, "dump "+d.id+" was canceled")
+			}
+			return status.Error(errorCode(err), err.Error())
+		}
+	}
+}
+
+// errorCode picks the code of a failed dump from its cause, Unavailable for
+// the server failures worth retrying
+func errorCode(err error) codes.Code {
+	switch mysqldump.CauseOf(err) {
+	case mysqldump.CauseCanceled:
+		return codes.Canceled
+	case mysqldump.CauseDeadline:
+		return codes.DeadlineExceeded
+	case mysqldump.CauseServer:
+		if mysqldump.IsTransient(err) {
+			return codes.Unavailable
+		}
+	}
+	return codes.Internal
+}
+
+// GetStatus reports the state and progress of a dump.
+func (s *Server) GetStatus(ctx context.Context, req *GetStatusRequest) (*Status, error) {
+	d, err := s.get(req.GetDumpId())
+	if err != nil {
+		return nil, err
+	}
+	return d.status(), nil
+}
+
+// Cancel aborts a dump that has not completed yet.
+func (s *Server) Cancel(ctx context.Context, req *CancelRequest) (*Status, error) {
+	d, err := s.get(req.GetDumpId())
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	finished := d.state == Status_STATE_DONE || d.state == Status_STATE_FAILED || d.state == Status_STATE_CANCELED
+	if !finished {
+		d.state = Status_STATE_CANCELED
+	}
+	streaming := d.streaming
+	d.mu.Unlock()
+
+	if !finished {
+		d.r.Close()
+		if !streaming {
+			s.expire(d)
+		}
+	}
+	return d.status(), nil
+}
+
+func (s *Server) get(id string) (*dump, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.dumps[id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown dump "+id)
+	}
+	return d, nil
+}
+
+// finish records the outcome of a streamed dump, a cancellation wins over the
+// error it caused
+func (s *Server) finish(d *dump, err error) {
+	d.mu.Lock()
+	if d.state != Status_STATE_CANCELED {
+		if err != nil {
+			d.state = Status_STATE_FAILED
+			d.err = err
+		} else {
+			d.state = Status_STATE_DONE
+		}
+	}
+	d.mu.Unlock()
+	s.expire(d)
+}
+
+// expire forgets a finished dump once the retention is over
+func (s *Server) expire(d *dump) {
+	retention := s.Retention
+	if retention == 0 {
+		retention = defaultRetention
+	}
+	time.AfterFunc(retention, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.dumps, d.id)
+	})
+}
+
+func (d *dump) status() *Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := &Status{
+		DumpId:       d.id,
+		State:        d.state,
+		BytesWritten: d.written,
+	}
+	if d.err != nil {
+		st.Error = d.err.Error()
+	}
+	return st
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startServer(t *testing.T, s *Server) DumperClient {
+	lis := bufconn.Listen(1024 * 1024)
+	g := grpc.NewServer()
+	RegisterDumperServer(g, s)
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewDumperClient(conn)
+}
+
+func TestStreamDump(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("ignored", "BASE TABLE"))
+	mock.ExpectRollback()
+
+	client := startServer(t, New(db))
+	ctx := context.Background()
+
+	started, err := client.StartDump(ctx, &StartDumpRequest{IgnoreTables: []string{"ignored"}})
+	assert.NoError(t, err)
+
+	st, err := client.GetStatus(ctx, &GetStatusRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	assert.Equal(t, Status_STATE_PENDING, st.GetState())
+
+	stream, err := client.StreamChunks(ctx, &StreamChunksRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+
+	var out strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		assert.EqualValues(t, out.Len(), chunk.GetOffset())
+		out.Write(chunk.GetData())
+	}
+	assert.Contains(t, out.String(), "-- Server version\ttest_version")
+	assert.Contains(t, out.String(), "-- Dump completed on")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	st, err = client.GetStatus(ctx, &GetStatusRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	assert.Equal(t, Status_STATE_DONE, st.GetState())
+	assert.EqualValues(t, out.Len(), st.GetBytesWritten())
+
+	stream, err = client.StreamChunks(ctx, &StreamChunksRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCancelPending(t *testing.T) {
+	client := startServer(t, New(nil))
+	ctx := context.Background()
+
+	started, err := client.StartDump(ctx, &StartDumpRequest{})
+	assert.NoError(t, err)
+
+	st, err := client.Cancel(ctx, &CancelRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	assert.Equal(t, Status_STATE_CANCELED, st.GetState())
+
+	stream, err := client.StreamChunks(ctx, &StreamChunksRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestUnknownDump(t *testing.T) {
+	client := startServer(t, New(nil))
+
+	_, err := client.GetStatus(context.Background(), &GetStatusRequest{DumpId: "nope"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestStreamDumpServerError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnError(errors.New("Error 2013: Lost connection to MySQL server during query"))
+	mock.ExpectRollback()
+
+	client := startServer(t, New(db))
+	ctx := context.Background()
+
+	started, err := client.StartDump(ctx, &StartDumpRequest{})
+	assert.NoError(t, err)
+	stream, err := client.StreamChunks(ctx, &StreamChunksRequest{DumpId: started.GetDumpId()})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestStartDumpRestrictsTables(t *testing.T) {
+	s := New(nil)
+	s.NewData = func(req *StartDumpRequest) *mysqldump.Data {
+		return &mysqldump.Data{IncludeTables: []string{"a", "b"}}
+	}
+	client := startServer(t, s)
+	ctx := context.Background()
+
+	_, err := client.StartDump(ctx, &StartDumpRequest{IncludeTables: []string{"secret"}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	s.NewData = func(req *StartDumpRequest) *mysqldump.Data {
+		return &mysqldump.Data{IncludePatterns: []string{"public_*"}}
+	}
+	_, err = client.StartDump(ctx, &StartDumpRequest{IncludeTables: []string{"secret"}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.StartDump(ctx, &StartDumpRequest{IncludeTables: []string{"secret", "public_a"}})
+	assert.NoError(t, err)
+}
+
+func TestPendingTimeout(t *testing.T) {
+	s := New(nil)
+	s.PendingTimeout = 10 * time.Millisecond
+	s.Retention = 10 * time.Millisecond
+	client := startServer(t, s)
+	ctx := context.Background()
+
+	started, err := client.StartDump(ctx, &StartDumpRequest{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := client.GetStatus(ctx, &GetStatusRequest{DumpId: started.GetDumpId()})
+		return status.Code(err) == codes.NotFound
+	}, 5*time.Second, 5*time.Millisecond)
+}
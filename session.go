@@ -0,0 +1,66 @@
+package mysqldump
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ProgramName is the program_name connection attribute of ConnectionAttributes.
+const ProgramName = "go-mysqldump"
+
+// ErrInvalidSessionCollation is returned for a SessionCollation that is not
+// the name of a collation.
+var ErrInvalidSessionCollation = errors.New("invalid session collation")
+
+var collationNameRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func (data *Data) checkSessionCollation() error {
+	if data.SessionCollation != "" && !collationNameRe.MatchString(data.SessionCollation) {
+		return ErrInvalidSessionCollation
+	}
+	return nil
+}
+
+// ConnectionAttributes returns the value of the connectionAttributes
+// parameter of the DSN of go-sql-driver/mysql, 1.8 and up, naming the program
+// and the dump in performance_schema.session_connect_attrs. The attributes
+// are sent when connecting, the dump itself can only tag the sessions of
+// connections opened with them with DumpID.
+func ConnectionAttributes(dumpID string) string {
+	attrs := "program_name:" + ProgramName
+	if dumpID != "" {
+		attrs += ",dump_id:" + strings.NewReplacer(",", "_", ":", "_").Replace(dumpID)
+	}
+	return attrs
+}
+
+// sessionStatements returns the statements setting up the sessions of the
+// dump, pinning the collation and tagging them with the DumpID
+func (data *Data) sessionStatements() []string {
+	var statements []string
+	if data.SessionCollation != "" {
+		statements = append(statements, "SET NAMES utf8mb4 COLLATE "+data.SessionCollation)
+	}
+	if data.DumpID != "" {
+		statements = append(statements, "SET @go_mysqldump_program = '"+ProgramName+"', @go_mysqldump_id = '"+sanitize(data.DumpID)+"'")
+	}
+	return statements
+}
+
+// setUpSessions runs the sessionStatements on the connection of the dump and
+// the ones of the workers
+func (data *Data) setUpSessions() error {
+	statements := data.sessionStatements()
+	if len(statements) == 0 {
+		return nil
+	}
+	for _, tx := range append([]transaction{data.tx}, data.workers...) {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^SET NAMES utf8mb4 COLLATE utf8mb4_general_ci$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^SET @go_mysqldump_program = 'go-mysqldump', @go_mysqldump_id = 'nightly\\'s'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"Version()"}).AddRow("8.0.36"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}))
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Out: &bytes.Buffer{}, Connection: db, SessionCollation: "utf8mb4_general_ci", DumpID: "nightly's"}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestDumpInvalidSessionCollation(t *testing.T) {
+	assert.Equal(t, mysqldump.ErrInvalidSessionCollation, (&mysqldump.Data{SessionCollation: "utf8mb4_bin; DROP"}).Dump())
+}
+
+func TestConnectionAttributes(t *testing.T) {
+	assert.Equal(t, "program_name:go-mysqldump", mysqldump.ConnectionAttributes(""))
+	assert.Equal(t, "program_name:go-mysqldump,dump_id:nightly_2026_a", mysqldump.ConnectionAttributes("nightly:2026,a"))
+}
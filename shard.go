@@ -0,0 +1,130 @@
+package mysqldump
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+)
+
+// Hasher hashes the name of a table or database for a Sharder.
+type Hasher func(name string) uint64
+
+// FNVHasher hashes names with 64-bit FNV-1a, the Hasher of a Sharder without
+// one.
+func FNVHasher(name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+// ErrNoShards is returned when there is no shard to route names to.
+var ErrNoShards = errors.New("no shards")
+
+// Sharder routes the tables or tenant databases of an export to Shards
+// shards, like destinations uploaded to in parallel, by the jump consistent
+// hash of their names: every shard gets about as many names, and going from
+// n to n+1 shards only moves about 1/(n+1) of the names, all of them to the
+// new shard. The tables of a database are sharded with a dump per shard, the
+// IncludeTables of each being a shard of Split, each dump reading a snapshot
+// of its own.
+type Sharder struct {
+	Shards int
+	Hash   Hasher
+}
+
+// Shard returns the shard of name, from 0 to Shards-1. There is no shard when
+// Shards is less than 1, it returns -1 then, like Split returns nil.
+func (s Sharder) Shard(name string) int {
+	if s.Shards < 1 {
+		return -1
+	}
+	hash := s.Hash
+	if hash == nil {
+		hash = FNVHasher
+	}
+	return jumpHash(hash(name), s.Shards)
+}
+
+// Split returns the names of every shard, in the order of names.
+func (s Sharder) Split(names []string) [][]string {
+	if s.Shards < 1 {
+		return nil
+	}
+	shards := make([][]string, s.Shards)
+	for _, name := range names {
+		i := s.Shard(name)
+		shards[i] = append(shards[i], name)
+	}
+	return shards
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach, mapping key to
+// one of n buckets
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// DumpDatabaseShards dumps every database to the writer of its shard, the
+// shards being the outs routed to by hash, FNVHasher if nil. The shards are
+// written at the same time, the databases of a shard one after the other
+// like DumpDatabases does. Each database is dumped in a transaction of its
+// own, like with DumpDatabases.
+//
+// The warnings and the report of data cover every database, the table names of
+// the report being qualified with the database. The error is the first one of
+// a shard, the other shards are dumped nonetheless.
+func (data *Data) DumpDatabaseShards(databases []string, outs []io.Writer, hash Hasher) error {
+	if data.Files != nil {
+		return ErrMergeFiles
+	}
+	if len(outs) == 0 {
+		return ErrNoShards
+	}
+	groups := Sharder{Shards: len(outs), Hash: hash}.Split(databases)
+
+	shards := make([]*Data, len(outs))
+	errs := make([]error, len(outs))
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		shard := data.newRun()
+		shard.Out = outs[i]
+		shards[i] = shard
+		wg.Add(1)
+		go func(i int, group []string) {
+			defer wg.Done()
+			errs[i] = shard.DumpDatabases(group, 1)
+		}(i, group)
+	}
+	wg.Wait()
+
+	var warnings []string
+	var report []TableStats
+	for _, shard := range shards {
+		if shard != nil {
+			warnings = append(warnings, shard.warnings...)
+			report = append(report, shard.report...)
+		}
+	}
+	s := data.state()
+	s.mu.Lock()
+	data.warnings = warnings
+	data.report = report
+	s.mu.Unlock()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
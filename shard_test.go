@@ -0,0 +1,97 @@
+package mysqldump
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharderBalance(t *testing.T) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "tenant_" + strconv.Itoa(i)
+	}
+	shards := Sharder{Shards: 8}.Split(names)
+	assert.Len(t, shards, 8)
+	total := 0
+	for _, shard := range shards {
+		assert.InDelta(t, 125, len(shard), 40)
+		total += len(shard)
+	}
+	assert.Equal(t, len(names), total)
+	assert.Equal(t, shards, Sharder{Shards: 8}.Split(names), "shards are stable")
+}
+
+func TestSharderConsistency(t *testing.T) {
+	eight, nine := Sharder{Shards: 8}, Sharder{Shards: 9}
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		name := "tenant_" + strconv.Itoa(i)
+		if before, after := eight.Shard(name), nine.Shard(name); before != after {
+			assert.Equal(t, 8, after, name)
+			moved++
+		}
+	}
+	assert.InDelta(t, 1000/9, moved, 40)
+}
+
+func TestSharderHash(t *testing.T) {
+	s := Sharder{Shards: 4, Hash: func(name string) uint64 { return 0 }}
+	assert.Equal(t, [][]string{{"a", "b"}, nil, nil, nil}, s.Split([]string{"a", "b"}))
+	assert.Nil(t, Sharder{}.Split([]string{"a"}))
+}
+
+func TestSharderNoShards(t *testing.T) {
+	hashed := false
+	for _, shards := range []int{0, -1} {
+		s := Sharder{Shards: shards, Hash: func(name string) uint64 { hashed = true; return 0 }}
+		assert.Equal(t, -1, s.Shard("a"))
+		assert.Nil(t, s.Split([]string{"a"}))
+	}
+	assert.False(t, hashed)
+}
+
+func TestDumpDatabaseShards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	databases := []string{"first", "second", "third"}
+	for _, name := range databases {
+		mock.ExpectBegin()
+		mock.ExpectExec("^USE " + name + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Version()", "")).AddRow("8.0.30"))
+		mock.ExpectQuery("^SHOW CREATE DATABASE `" + name + "`$").WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Database", ""), c("Create Database", "")).
+				AddRow(name, "CREATE DATABASE `"+name+"`"))
+		mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(c("Tables_in_"+name, ""), c("Table_type", "")))
+		mock.ExpectRollback()
+	}
+
+	bufs := []*bytes.Buffer{{}, {}}
+	data := &Data{Connection: db}
+	assert.NoError(t, data.DumpDatabaseShards(databases, []io.Writer{bufs[0], bufs[1]}, nil))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	sharder := Sharder{Shards: 2}
+	for _, name := range databases {
+		for i, buf := range bufs {
+			created := strings.Contains(buf.String(), "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `"+name+"`")
+			assert.Equal(t, sharder.Shard(name) == i, created, name)
+		}
+	}
+}
+
+func TestDumpDatabaseShardsInvalid(t *testing.T) {
+	assert.Equal(t, ErrNoShards, (&Data{}).DumpDatabaseShards([]string{"test"}, nil, nil))
+	data := &Data{Files: NewZipWriter(&bytes.Buffer{})}
+	assert.Equal(t, ErrMergeFiles, data.DumpDatabaseShards([]string{"test"}, []io.Writer{&bytes.Buffer{}}, nil))
+}
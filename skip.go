@@ -0,0 +1,75 @@
+package mysqldump
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// SkipCurrentTable stops reading the tables the running dumps of data are
+// reading, like an unexpectedly huge log table, and lets the dumps go on with
+// the next ones. The rows already read are kept, the statement in progress
+// ends with them, and the table is named in the warnings. A table read in one
+// query has the rest of its result discarded by the driver, with FetchSize
+// only the rest of the page. It reports whether a table was being read.
+func (data *Data) SkipCurrentTable() bool {
+	return data.skipReading(nil)
+}
+
+// SkipCurrentTable skips the table the run is reading, see
+// Data.SkipCurrentTable.
+func (r *Run) SkipCurrentTable() bool {
+	return r.data.root().skipReading(r.data)
+}
+
+// skipReading flags the tables being read, the ones of the run only unless it
+// is nil
+func (data *Data) skipReading(run *Data) bool {
+	s := data.root().state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	skipped := false
+	for table := range s.reading {
+		if run == nil || table.data == run {
+			atomic.StoreInt32(&table.skip, 1)
+			skipped = true
+		}
+	}
+	return skipped
+}
+
+// track registers the table as being read until the returned function is
+// called
+func (data *Data) track(t *table) func() {
+	s := data.root().state()
+	s.mu.Lock()
+	if s.reading == nil {
+		s.reading = make(map[*table]bool)
+	}
+	s.reading[t] = true
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.reading, t)
+		s.mu.Unlock()
+	}
+}
+
+// skipped reports whether SkipCurrentTable stopped reading the table
+func (table *table) skipped() bool {
+	return atomic.LoadInt32(&table.skip) != 0
+}
+
+// stopReading drops the rest of the rows of a skipped table
+func (table *table) stopReading() {
+	if table.rows != nil {
+		table.rows.Close()
+		table.rows = nil
+	}
+	table.closePager()
+}
+
+func (table *table) warnSkipped() {
+	if table.skipped() {
+		table.data.warn("table " + table.NameEsc() + " skipped after " + strconv.Itoa(table.row) + " rows")
+	}
+}
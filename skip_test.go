@@ -0,0 +1,50 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipCurrentTable(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	data.MaxAllowedPacket = 4096
+	assert.NoError(t, data.getTemplates())
+	mockCreateTable(mock, "test")
+	mockTableSelect(mock, "test")
+
+	// Skipped while the first row is read, the second one is left out
+	skipped := false
+	data.Masks = map[string]Masker{"test.name": MaskFunc(func(value string) string {
+		skipped = data.SkipCurrentTable()
+		return value
+	})}
+
+	var buf bytes.Buffer
+	table := data.createTable("test", false)
+	assert.NoError(t, data.writeTableData(&buf, table))
+	assert.True(t, skipped)
+	assert.Equal(t, 1, table.row)
+	assert.Contains(t, buf.String(), "INSERT INTO `test` (`id`, `email`, `name`) VALUES (1,'test@test.de','Test Name 1');")
+	assert.NotContains(t, buf.String(), "Test Name 2")
+	assert.Equal(t, []string{"table `test` skipped after 1 rows"}, data.warnings)
+	assert.Empty(t, data.state().reading)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestSkipCurrentTableIdle(t *testing.T) {
+	data := &Data{}
+	assert.False(t, data.SkipCurrentTable())
+
+	run := data.newRun()
+	other := data.newRun()
+	done := run.track(run.createTable("test", false))
+	assert.False(t, (&Run{data: other}).SkipCurrentTable())
+	assert.True(t, (&Run{data: run}).SkipCurrentTable())
+	done()
+	assert.False(t, data.SkipCurrentTable())
+}
@@ -0,0 +1,119 @@
+package mysqldump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot identifies the server state a dump was taken from, so a backup can
+// be matched with binary logs and server events.
+type Snapshot struct {
+	Time           time.Time         `json:"time"`
+	ConnectionID   int64             `json:"connectionId"`
+	IsolationLevel string            `json:"isolationLevel"`
+	Variables      map[string]string `json:"variables,omitempty"`
+}
+
+// snapshotVariables are the server variables recorded with a Snapshot, the
+// isolation level is read from transaction_isolation or on older servers from
+// tx_isolation
+var snapshotVariables = []string{
+	"binlog_format",
+	"gtid_executed",
+	"gtid_mode",
+	"hostname",
+	"log_bin",
+	"server_id",
+	"server_uuid",
+	"system_time_zone",
+	"time_zone",
+	"transaction_isolation",
+	"tx_isolation",
+}
+
+const snapshotTimeLayout = "2006-01-02 15:04:05.999999"
+
+// Snapshot returns the session info of the last dump, or nil unless
+// SnapshotInfo is set.
+func (data *Data) Snapshot() *Snapshot {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.snapshot
+}
+
+// readSnapshot records the time, connection and variables of the transaction
+// the dump reads from, it is the first statement of the transaction
+func (data *Data) readSnapshot() (*Snapshot, error) {
+	snapshot := &Snapshot{Variables: map[string]string{}}
+
+	if err := data.tx.QueryRow("SELECT UTC_TIMESTAMP(6), CONNECTION_ID()").Scan(snapshotTime{&snapshot.Time}, &snapshot.ConnectionID); err != nil {
+		return nil, err
+	}
+
+	rows, err := data.tx.Query("SHOW VARIABLES WHERE Variable_name IN ('" + strings.Join(snapshotVariables, "', '") + "')")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "transaction_isolation":
+			snapshot.IsolationLevel = value
+		case "tx_isolation":
+			if snapshot.IsolationLevel == "" {
+				snapshot.IsolationLevel = value
+			}
+		default:
+			snapshot.Variables[name] = value
+		}
+	}
+	return snapshot, rows.Err()
+}
+
+// snapshotTime scans the UTC_TIMESTAMP of a snapshot, a time.Time with the
+// parseTime option of the driver and its text otherwise, in the format of
+// MySQL or RFC 3339
+type snapshotTime struct {
+	t *time.Time
+}
+
+func (s snapshotTime) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case time.Time:
+		*s.t = v.UTC()
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cannot scan %T into the snapshot time", src)
+	}
+	t, err := time.Parse(snapshotTimeLayout, text)
+	if err != nil {
+		var rfcErr error
+		if t, rfcErr = time.Parse(time.RFC3339Nano, text); rfcErr != nil {
+			return err
+		}
+	}
+	*s.t = t.UTC()
+	return nil
+}
+
+// VariableNames returns the names of the recorded variables in order
+func (s *Snapshot) VariableNames() []string {
+	names := make([]string, 0, len(s.Variables))
+	for name := range s.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotTime(t *testing.T) {
+	want := time.Date(2021, 3, 4, 5, 6, 7, 123456000, time.UTC)
+	for _, src := range []interface{}{
+		"2021-03-04 05:06:07.123456",
+		[]byte("2021-03-04 05:06:07.123456"),
+		want,
+		want.In(time.FixedZone("CET", 3600)),
+		"2021-03-04T06:06:07.123456+01:00",
+	} {
+		var got time.Time
+		assert.NoError(t, snapshotTime{&got}.Scan(src))
+		assert.True(t, want.Equal(got), "%v", src)
+		assert.Equal(t, time.UTC, got.Location())
+	}
+	var got time.Time
+	assert.Error(t, snapshotTime{&got}.Scan(nil))
+	assert.Error(t, snapshotTime{&got}.Scan("yesterday"))
+}
+
+func TestReadSnapshot(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery(`^SELECT UTC_TIMESTAMP\(6\), CONNECTION_ID\(\)$`).WillReturnRows(
+		sqlmock.NewRows([]string{"UTC_TIMESTAMP(6)", "CONNECTION_ID()"}).AddRow("2021-03-04 05:06:07.123456", 42))
+	mock.ExpectQuery(`^SHOW VARIABLES WHERE Variable_name IN \('binlog_format', `).WillReturnRows(
+		sqlmock.NewRows([]string{"Variable_name", "Value"}).
+			AddRow("gtid_executed", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5").
+			AddRow("server_id", "1").
+			AddRow("transaction_isolation", "REPEATABLE-READ").
+			AddRow("tx_isolation", "READ-COMMITTED"))
+
+	snapshot, err := data.readSnapshot()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Equal(t, &Snapshot{
+		Time:           time.Date(2021, 3, 4, 5, 6, 7, 123456000, time.UTC),
+		ConnectionID:   42,
+		IsolationLevel: "REPEATABLE-READ",
+		Variables: map[string]string{
+			"gtid_executed": "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5",
+			"server_id":     "1",
+		},
+	}, snapshot)
+
+	assert.NoError(t, data.getTemplates())
+	var buf bytes.Buffer
+	assert.NoError(t, data.headerTmpl.Execute(&buf, &metaData{
+		DumpVersion:   Version,
+		ServerVersion: "8.0.34",
+		Snapshot:      snapshot,
+	}))
+	assert.Contains(t, buf.String(), `-- Server version	8.0.34
+-- Snapshot time	2021-03-04 05:06:07.123456 UTC
+-- Connection id	42
+-- Isolation level	REPEATABLE-READ
+-- Variable gtid_executed	3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5
+-- Variable server_id	1
+
+`)
+}
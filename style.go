@@ -0,0 +1,130 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// OutputStyle is the layout of the rows of the INSERT statements.
+type OutputStyle string
+
+const (
+	// StyleCompact writes all the rows of a statement on one line.
+	StyleCompact OutputStyle = ""
+	// StylePretty writes every row of a statement on a line of its own.
+	StylePretty OutputStyle = "pretty"
+	// StyleAligned writes every row on a line of its own with the values
+	// padded to line up in columns within the statement.
+	StyleAligned OutputStyle = "aligned"
+)
+
+// ErrUnknownOutputStyle is returned for output styles that don't exist.
+var ErrUnknownOutputStyle = errors.New("unknown output style")
+
+func (data *Data) checkOutputStyle() error {
+	switch data.OutputStyle {
+	case StyleCompact, StylePretty, StyleAligned:
+		return nil
+	}
+	return ErrUnknownOutputStyle
+}
+
+// valuesSQL is what follows VALUES in front of the first row
+func (data *Data) valuesSQL() string {
+	if data.OutputStyle == StyleCompact {
+		return " VALUES "
+	}
+	return " VALUES\n"
+}
+
+// rowSeparator is what goes between the rows of a statement
+func (data *Data) rowSeparator() string {
+	if data.OutputStyle == StyleCompact {
+		return ","
+	}
+	return ",\n"
+}
+
+// rowValues splits the row b written by RowBuffer into its values
+func (table *table) rowValues(b *bytes.Buffer) []string {
+	row := b.String()
+	values := make([]string, len(table.valueStarts))
+	for i, start := range table.valueStarts {
+		end := len(row) - len(")")
+		if i+1 < len(table.valueStarts) {
+			end = table.valueStarts[i+1] - len(",")
+		}
+		values[i] = row[start:end]
+	}
+	return values
+}
+
+// alignedBatch collects the rows of a statement of StyleAligned, which are
+// only written once the width of every column is known
+type alignedBatch struct {
+	rows   [][]string
+	widths []int
+	last   int
+}
+
+// size returns the bytes of the rows once padded, with values added to them
+func (a *alignedBatch) size(values []string) int {
+	rows, last := len(a.rows), a.last
+	widths := a.widths
+	if values != nil {
+		rows++
+		last += len(values[len(values)-1])
+		widths = make([]int, len(values))
+		copy(widths, a.widths)
+		for i, v := range values {
+			if len(v) > widths[i] {
+				widths[i] = len(v)
+			}
+		}
+	}
+	if rows == 0 {
+		return 0
+	}
+	padded := 0
+	for _, w := range widths[:len(widths)-1] {
+		padded += w
+	}
+	// (, the values but the last padded, ", " between them and ) on every
+	// line, ",\n" between the lines
+	return rows*(2+padded+2*(len(widths)-1)) + last + 2*(rows-1)
+}
+
+func (a *alignedBatch) add(values []string) {
+	if a.widths == nil {
+		a.widths = make([]int, len(values))
+	}
+	for i, v := range values {
+		if len(v) > a.widths[i] {
+			a.widths[i] = len(v)
+		}
+	}
+	a.last += len(values[len(values)-1])
+	a.rows = append(a.rows, values)
+}
+
+// writeTo writes the rows to b and empties the batch
+func (a *alignedBatch) writeTo(b *bytes.Buffer) {
+	for r, values := range a.rows {
+		if r != 0 {
+			b.WriteString(",\n")
+		}
+		b.WriteString("(")
+		for i, v := range values {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(v)
+			if i+1 < len(values) {
+				b.WriteString(strings.Repeat(" ", a.widths[i]-len(v)))
+			}
+		}
+		b.WriteString(")")
+	}
+	a.rows, a.widths, a.last = nil, nil, 0
+}
@@ -0,0 +1,114 @@
+package mysqldump
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPretty(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+	data.MaxAllowedPacket = 4096
+	data.OutputStyle = StylePretty
+
+	s := data.createTable("test", false).Stream()
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES\n(1,'test@test.de','Test Name 1'),\n(2,'test2@test.de','Test Name 2');", <-s)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestStreamAligned(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", "").AddRow("name", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).
+			AddRow(1, "test@test.de", "Test Name 1").
+			AddRow(20, nil, "Test Name 20"))
+	data.MaxAllowedPacket = 4096
+	data.OutputStyle = StyleAligned
+
+	s := data.createTable("test", false).Stream()
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES\n"+
+		"(1 , 'test@test.de', 'Test Name 1'),\n"+
+		"(20, NULL          , 'Test Name 20');", <-s)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestStreamAlignedSmallPackets(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockTableSelect(mock, "test")
+	data.MaxAllowedPacket = 120
+	data.OutputStyle = StyleAligned
+
+	s := data.createTable("test", false).Stream()
+	first, second := <-s, <-s
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES\n(1, 'test@test.de', 'Test Name 1');", first)
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES\n(2, 'test2@test.de', 'Test Name 2');", second)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestAlignedBatchSize(t *testing.T) {
+	a := &alignedBatch{}
+	rows := [][]string{{"1", "'a'", "NULL"}, {"200", "'bcd'", "'e'"}, {"3", "NULL", "NULL"}}
+	for _, row := range rows {
+		expected := a.size(row)
+		a.add(row)
+		assert.Equal(t, expected, a.size(nil))
+	}
+	var b bytes.Buffer
+	size := a.size(nil)
+	a.writeTo(&b)
+	assert.Equal(t, size, b.Len())
+	assert.Equal(t, "(1  , 'a'  , NULL),\n(200, 'bcd', 'e'),\n(3  , NULL , NULL)", b.String())
+}
+
+func TestDumpAlignedDecodes(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mockCreateTable(mock, "test")
+	mockTableSelect(mock, "test")
+	data.MaxAllowedPacket = 4096
+	data.OutputStyle = StyleAligned
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	assert.NoError(t, data.getTemplates())
+	assert.NoError(t, data.writeTableData(&buf, data.createTable("test", false)))
+
+	d := NewDecoder(&buf)
+	var rows *InsertRows
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if r, ok := event.(*InsertRows); ok {
+			rows = r
+		}
+	}
+	if assert.NotNil(t, rows) {
+		assert.Equal(t, []string{"id", "email", "name"}, rows.Columns)
+		assert.Len(t, rows.Rows, 2)
+	}
+}
+
+func TestDumpUnknownOutputStyle(t *testing.T) {
+	data := &Data{OutputStyle: "fancy"}
+	assert.Equal(t, ErrUnknownOutputStyle, data.Dump())
+}
@@ -0,0 +1,153 @@
+package mysqldump
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"time"
+)
+
+// summaryPrefix starts the comment holding the Summary, the last line of
+// every SQL file of a dump
+const summaryPrefix = "-- Dump summary "
+
+var (
+	// ErrNoSummary is returned by ReadSummary for dumps without a summary,
+	// like the ones cut short or written by older versions.
+	ErrNoSummary = errors.New("dump has no summary")
+	// ErrSummaryMismatch is returned by ReadSummary when the dump does not
+	// match the size or checksum of its summary.
+	ErrSummaryMismatch = errors.New("dump does not match its summary")
+)
+
+// Summary is the footer of a dump for tooling, written as a JSON comment.
+// Bytes and SHA256 cover everything in front of the summary line.
+type Summary struct {
+	CompleteTime string `json:"completeTime"`
+	Tables       int    `json:"tables"`
+	Views        int    `json:"views"`
+	Rows         int64  `json:"rows"`
+	Bytes        int64  `json:"bytes"`
+	SHA256       string `json:"sha256"`
+}
+
+// ReadSummary reads a dump to its end and returns its summary, once checked
+// against what was read.
+func ReadSummary(r io.Reader) (*Summary, error) {
+	br := bufio.NewReader(r)
+	h := sha256.New()
+	var n int64
+	var summary *Summary
+	for {
+		line, err := br.ReadBytes('\n')
+		if summary != nil && len(bytes.TrimSpace(line)) != 0 {
+			// Something follows the summary, it describes a part of the dump
+			return nil, ErrSummaryMismatch
+		}
+		if summary == nil && bytes.HasPrefix(line, []byte(summaryPrefix)) {
+			summary = &Summary{}
+			if jerr := json.Unmarshal(bytes.TrimPrefix(line, []byte(summaryPrefix)), summary); jerr != nil {
+				return nil, jerr
+			}
+		} else if summary == nil {
+			h.Write(line)
+			n += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if summary == nil {
+		return nil, ErrNoSummary
+	}
+	if summary.Bytes != n || summary.SHA256 != hex.EncodeToString(h.Sum(nil)) {
+		return summary, ErrSummaryMismatch
+	}
+	return summary, nil
+}
+
+// completeTime returns now in the TimeFormat
+func (data *Data) completeTime() string {
+	layout := data.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return time.Now().UTC().Format(layout)
+}
+
+// summaryWriter counts and hashes the output of a SQL file for its summary,
+// ending its lines with \r\n if crlf is set
+type summaryWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+	crlf bool
+	cr   bool
+}
+
+func newSummaryWriter(w io.Writer) *summaryWriter {
+	return &summaryWriter{w: w, hash: sha256.New()}
+}
+
+func (s *summaryWriter) Write(p []byte) (int, error) {
+	if !s.crlf || len(p) == 0 {
+		return s.write(p)
+	}
+	b := toCRLF(p, s.cr)
+	s.cr = p[len(p)-1] == '\r'
+	if _, err := s.write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *summaryWriter) write(b []byte) (int, error) {
+	n, err := s.w.Write(b)
+	s.hash.Write(b[:n])
+	s.n += int64(n)
+	return n, err
+}
+
+// lineEnd returns what ends the lines written through s
+func (s *summaryWriter) lineEnd() string {
+	if s.crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// writeFooter ends the SQL file written through s with the footer and the
+// summary of the rows of the tables and views in it
+func (data *Data) writeFooter(s *summaryWriter, meta *metaData, stats []TableStats) error {
+	meta.CompleteTime = data.completeTime()
+	if err := data.footerTmpl.Execute(s, meta); err != nil {
+		return err
+	}
+	summary := Summary{
+		CompleteTime: meta.CompleteTime,
+		Bytes:        s.n,
+		SHA256:       hex.EncodeToString(s.hash.Sum(nil)),
+	}
+	for _, table := range stats {
+		if table.View {
+			summary.Views++
+		} else {
+			summary.Tables++
+		}
+		summary.Rows += table.Rows
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.w, summaryPrefix+string(b)+s.lineEnd())
+	return err
+}
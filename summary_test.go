@@ -0,0 +1,84 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	summary, err := mysqldump.ReadSummary(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Tables)
+	assert.Equal(t, 0, summary.Views)
+	assert.Equal(t, int64(2), summary.Rows)
+	assert.Equal(t, int64(strings.LastIndex(buf.String(), "-- Dump summary ")), summary.Bytes)
+
+	completed, err := time.Parse(time.RFC3339, summary.CompleteTime)
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, completed.Location())
+	assert.Contains(t, buf.String(), "-- Dump completed on "+summary.CompleteTime+"\n")
+
+	// A changed dump no longer matches
+	tampered := strings.Replace(buf.String(), "Test Name 1", "Test Name X", 1)
+	_, err = mysqldump.ReadSummary(strings.NewReader(tampered))
+	assert.Equal(t, mysqldump.ErrSummaryMismatch, err)
+
+	// Nor does one cut in front of the summary
+	cut := buf.String()[:summary.Bytes]
+	_, err = mysqldump.ReadSummary(strings.NewReader(cut))
+	assert.Equal(t, mysqldump.ErrNoSummary, err)
+}
+
+func TestDumpTimeFormat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, TimeFormat: "2006-01-02"}
+	assert.NoError(t, data.Dump())
+	assert.Contains(t, buf.String(), "-- Dump completed on "+time.Now().UTC().Format("2006-01-02")+"\n")
+}
+
+func TestDumpFilesSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var out bytes.Buffer
+	tw := mysqldump.NewTarWriter(&out, false)
+	data := &mysqldump.Data{Connection: db, Files: tw}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, tw.Close())
+	_, files := readTar(t, &out)
+
+	summary, err := mysqldump.ReadSummary(strings.NewReader(files["schema.sql"]))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Tables)
+	assert.Zero(t, summary.Rows)
+
+	summary, err = mysqldump.ReadSummary(strings.NewReader(files["data/Test_Table.sql"]))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), summary.Rows)
+}
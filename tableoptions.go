@@ -0,0 +1,128 @@
+package mysqldump
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// TableOption rewrites a table option of CREATE TABLE, like ENCRYPTION or
+// STATS_PERSISTENT, which SHOW CREATE TABLE writes as they are set on the
+// source and which a restore on a server set up differently can fail on. It
+// receives the value as written in the DDL, quotes included, and returns the
+// value to write instead, or "" to leave the option out.
+type TableOption func(table, value string) string
+
+// DropTableOption is a TableOption leaving the option out, like ENCRYPTION
+// for a target without a keyring or TABLESPACE for one without the
+// tablespaces of the source.
+func DropTableOption(table, value string) string {
+	return ""
+}
+
+// SetTableOption returns a TableOption writing value instead of the one of
+// the source, like "1" for STATS_PERSISTENT.
+func SetTableOption(value string) TableOption {
+	return func(table, _ string) string {
+		return value
+	}
+}
+
+// ErrInvalidTableOption is returned for TableOptions whose names are not the
+// words of a table option.
+var ErrInvalidTableOption = errors.New("invalid table option name")
+
+var (
+	tableOptionNameRe = regexp.MustCompile(`^[A-Za-z_]+(?: [A-Za-z_]+)*$`)
+	partitionByRe     = regexp.MustCompile(`(?i)(?:/\*!\d+\s*)?\bPARTITION\s+BY\b`)
+)
+
+func (data *Data) checkTableOptions() error {
+	for name := range data.TableOptions {
+		if !tableOptionNameRe.MatchString(name) {
+			return ErrInvalidTableOption
+		}
+	}
+	return nil
+}
+
+// tableOptionRe matches a table option and its value, along with the
+// versioned comment around it like /*!50100 TABLESPACE `ts` */
+func tableOptionRe(name string) *regexp.Regexp {
+	words := strings.Split(regexp.QuoteMeta(name), " ")
+	return regexp.MustCompile(`(?i)(\s*/\*!\d+\s*)?(\s*)\b(` + strings.Join(words, `\s+`) + `)(\s*=\s*|\s+)` +
+		"('(?:[^'\\\\]|\\\\.|'')*'|`(?:[^`]|``)*`|\\w+)(\\s*\\*/)?")
+}
+
+// rewriteTableOptions applies TableOptions to the options following the
+// column definitions of a CREATE TABLE. The partitions are left alone, like
+// the options the DDL lacks.
+func (table *table) rewriteTableOptions(create string) string {
+	if len(table.data.TableOptions) == 0 {
+		return create
+	}
+	end := columnsEnd(create)
+	if end < 0 {
+		return create
+	}
+	options, partitions := create[end+1:], ""
+	if loc := partitionByRe.FindStringIndex(options); loc != nil && !inQuotes(options, loc[0]) {
+		options, partitions = options[:loc[0]], options[loc[0]:]
+	}
+	for name, rewrite := range table.data.TableOptions {
+		options = replaceTableOption(options, tableOptionRe(name), func(value string) string {
+			return rewrite(table.Name, value)
+		})
+	}
+	return create[:end+1] + options + partitions
+}
+
+// replaceTableOption replaces the matches of re outside of quotes with the
+// value returned by rewrite, dropping them for "" along with their versioned
+// comment if they are alone in it
+func replaceTableOption(options string, re *regexp.Regexp, rewrite func(value string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(options, -1) {
+		if inQuotes(options, m[6]) {
+			continue
+		}
+		value := rewrite(options[m[10]:m[11]])
+		b.WriteString(options[last:m[0]])
+		comment := m[2] >= 0 && m[12] >= 0
+		switch {
+		case value == "" && comment:
+		case value == "":
+			if m[2] >= 0 {
+				b.WriteString(options[m[2]:m[3]])
+			}
+		default:
+			b.WriteString(options[m[0]:m[10]])
+			b.WriteString(value)
+			if m[12] >= 0 {
+				b.WriteString(options[m[12]:m[13]])
+			}
+		}
+		last = m[1]
+	}
+	b.WriteString(options[last:])
+	return b.String()
+}
+
+// inQuotes reports whether the byte at i of s is within a quoted string or
+// identifier
+func inQuotes(s string, i int) bool {
+	var quote byte
+	for j := 0; j < i; j++ {
+		c := s[j]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+		case quote != 0 && c == '\\' && quote != '`':
+			j++
+		case quote != 0 && c == quote:
+			quote = 0
+		}
+	}
+	return quote != 0
+}
@@ -0,0 +1,71 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const tableOptionsDDL = "CREATE TABLE `t` (\n" +
+	"  `id` int NOT NULL,\n" +
+	"  `c` varchar(10) DEFAULT NULL COMMENT 'ENCRYPTION=''Y''',\n" +
+	"  PRIMARY KEY (`id`)\n" +
+	") /*!50100 TABLESPACE `ts1` */ ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 STATS_PERSISTENT=0 STATS_AUTO_RECALC=1 ENCRYPTION='Y' COMMENT='STATS_PERSISTENT=0'\n" +
+	"/*!50100 PARTITION BY HASH (`id`)\n(PARTITION p0 ENGINE = InnoDB) */"
+
+func rewriteOptions(options map[string]TableOption) string {
+	table := (&Data{TableOptions: options}).createTable("t", false)
+	return table.rewriteTableOptions(tableOptionsDDL)
+}
+
+func TestRewriteTableOptions(t *testing.T) {
+	// Written as they are without TableOptions
+	assert.Equal(t, tableOptionsDDL, rewriteOptions(nil))
+
+	assert.Equal(t, "CREATE TABLE `t` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `c` varchar(10) DEFAULT NULL COMMENT 'ENCRYPTION=''Y''',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=MyISAM DEFAULT CHARSET=utf8mb4 STATS_PERSISTENT=1 STATS_AUTO_RECALC=1 COMMENT='STATS_PERSISTENT=0'\n"+
+		"/*!50100 PARTITION BY HASH (`id`)\n(PARTITION p0 ENGINE = InnoDB) */", rewriteOptions(map[string]TableOption{
+		"ENCRYPTION":       DropTableOption,
+		"tablespace":       DropTableOption,
+		"STATS_PERSISTENT": SetTableOption("1"),
+		"ENGINE":           SetTableOption("MyISAM"),
+		"KEY_BLOCK_SIZE":   DropTableOption,
+	}))
+}
+
+func TestRewriteTableOptionsValue(t *testing.T) {
+	var seen []string
+	rewriteOptions(map[string]TableOption{
+		"TABLESPACE": func(table, value string) string {
+			seen = append(seen, table, value)
+			return "`innodb_file_per_table`"
+		},
+	})
+	assert.Equal(t, []string{"t", "`ts1`"}, seen)
+
+	assert.Contains(t, rewriteOptions(map[string]TableOption{"TABLESPACE": SetTableOption("`other`")}),
+		") /*!50100 TABLESPACE `other` */ ENGINE=InnoDB")
+}
+
+func TestDumpTableOptions(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `t`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("t", tableOptionsDDL))
+	data.TableOptions = map[string]TableOption{"ENCRYPTION": DropTableOption}
+
+	create, err := data.createTable("t", false).CreateSQL()
+	assert.NoError(t, err)
+	assert.NotContains(t, create, "ENCRYPTION='Y'")
+	assert.Contains(t, create, "/*!50100 TABLESPACE `ts1` */ ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 STATS_PERSISTENT=0 STATS_AUTO_RECALC=1 COMMENT=")
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	data.TableOptions = map[string]TableOption{"ENCRYPTION=": DropTableOption}
+	assert.Equal(t, ErrInvalidTableOption, data.checkTableOptions())
+}
@@ -0,0 +1,120 @@
+package mysqldump
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// TarWriter is a WriterFactory that stores every file of the dump as an entry
+// of a single tar stream, optionally compressed.
+//
+// Entries are staged in temporary files until they are closed because the tar
+// header needs their size up front.
+type TarWriter struct {
+	mu  sync.Mutex
+	tw  *tar.Writer
+	zw  io.WriteCloser
+	now time.Time
+}
+
+// NewTarWriter creates a TarWriter writing the archive to w. Close has to be
+// called once the dump is done to write the end of the archive.
+func NewTarWriter(w io.Writer, compress bool) *TarWriter {
+	t := &TarWriter{now: time.Now()}
+	if compress {
+		t.zw = gzip.NewWriter(w)
+		w = t.zw
+	}
+	t.tw = tar.NewWriter(w)
+	return t
+}
+
+// NewTarWriterCodec creates a TarWriter encoding the archive with codec, or
+// writing it as is if codec is nil.
+func NewTarWriterCodec(w io.Writer, codec Codec) (*TarWriter, error) {
+	t := &TarWriter{now: time.Now()}
+	if codec != nil {
+		zw, err := codec.Wrap(w)
+		if err != nil {
+			return nil, err
+		}
+		t.zw = zw
+		w = zw
+	}
+	t.tw = tar.NewWriter(w)
+	return t, nil
+}
+
+// Create starts a new entry of the archive. The entry is added when the
+// returned writer is closed.
+func (t *TarWriter) Create(name string) (io.WriteCloser, error) {
+	f, err := ioutil.TempFile("", "mysqldump-tar-")
+	if err != nil {
+		return nil, err
+	}
+	return &tarEntry{archive: t, name: name, f: f}, nil
+}
+
+// Close writes the end of the archive and flushes the compression. It does not
+// close the underlying writer.
+func (t *TarWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.zw != nil {
+		return t.zw.Close()
+	}
+	return nil
+}
+
+func (t *TarWriter) add(name string, f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    fi.Size(),
+		ModTime: t.now,
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(t.tw, f)
+	return err
+}
+
+type tarEntry struct {
+	archive *TarWriter
+	name    string
+	f       *os.File
+	closed  bool
+}
+
+func (e *tarEntry) Write(p []byte) (int, error) {
+	return e.f.Write(p)
+}
+
+// Close adds the entry to the archive. It is safe to call more than once.
+func (e *tarEntry) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	defer os.Remove(e.f.Name())
+	defer e.f.Close()
+	return e.archive.add(e.name, e.f)
+}
@@ -0,0 +1,74 @@
+package mysqldump_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func readTar(t *testing.T, r io.Reader) (names []string, files map[string]string) {
+	files = make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return
+		}
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+		files[hdr.Name] = string(content)
+	}
+}
+
+func TestDumpTarOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	assert.NoError(t, mysqldump.DumpTar(db, &buf, true))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	gz, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	names, files := readTar(t, gz)
+
+	assert.Equal(t, []string{"schema.sql", "data/Test_Table.sql", "manifest.json", "SHA256SUMS"}, names)
+
+	assert.Contains(t, files["schema.sql"], "CREATE TABLE 'Test_Table'")
+	assert.NotContains(t, files["schema.sql"], "INSERT INTO")
+	assert.Contains(t, files["data/Test_Table.sql"], "INSERT INTO `Test_Table` (`id`, `email`, `name`) VALUES (1,NULL,'Test Name 1'),(2,'test2@test.de','Test Name 2');")
+	assert.NotContains(t, files["data/Test_Table.sql"], "CREATE TABLE")
+
+	var manifest mysqldump.Manifest
+	assert.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	if assert.Len(t, manifest.Tables, 1) && assert.NotNil(t, manifest.Tables[0].DataStart) && assert.NotNil(t, manifest.Tables[0].DataEnd) {
+		assert.False(t, manifest.Tables[0].DataEnd.Time.Before(manifest.Tables[0].DataStart.Time))
+		manifest.Tables[0].DataStart, manifest.Tables[0].DataEnd = nil, nil
+	}
+	assert.Equal(t, []mysqldump.ManifestTable{{Name: "Test_Table", Rows: 2}}, manifest.Tables)
+
+	var sums []string
+	for _, name := range names[:3] {
+		sum := sha256.Sum256([]byte(files[name]))
+		sums = append(sums, fmt.Sprintf("%s  %s", hex.EncodeToString(sum[:]), name))
+	}
+	assert.Equal(t, strings.Join(sums, "\n")+"\n", files["SHA256SUMS"])
+}
@@ -0,0 +1,147 @@
+package mysqldump
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrInvalidWindow is returned for throttle windows whose times are not
+// formatted as 15:04.
+var ErrInvalidWindow = errors.New("throttle window times must look like 15:04")
+
+// ThrottleWindow sets the rate from Start to End each day, like 01:00 to
+// 05:00. A window ending before it starts spans midnight.
+type ThrottleWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Rate  int64  `json:"rate"` // bytes per second, 0 is full speed
+}
+
+/*
+Throttle limits how fast a dump is written, e.g. full speed during the
+maintenance window and 10MB/s otherwise:
+
+	Throttle{Rate: 10 << 20, Windows: []ThrottleWindow{{Start: "01:00", End: "05:00"}}}
+
+	Rate:     Bytes per second outside of the windows, 0 is full speed
+	Windows:  Times of the day with a different rate, the first matching one applies
+	Location: Time zone of the windows, local time if nil
+*/
+type Throttle struct {
+	Rate     int64            `json:"rate"`
+	Windows  []ThrottleWindow `json:"windows"`
+	Location *time.Location   `json:"-"`
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// Validate checks the times of the windows.
+func (t *Throttle) Validate() error {
+	for _, w := range t.Windows {
+		if _, err := parseTimeOfDay(w.Start); err != nil {
+			return err
+		}
+		if _, err := parseTimeOfDay(w.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RateAt returns the bytes per second allowed at the given time, 0 if
+// unlimited.
+func (t *Throttle) RateAt(at time.Time) int64 {
+	if t.Location != nil {
+		at = at.In(t.Location)
+	}
+	minute := at.Hour()*60 + at.Minute()
+	for _, w := range t.Windows {
+		start, err := parseTimeOfDay(w.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseTimeOfDay(w.End)
+		if err != nil {
+			continue
+		}
+		if start <= end && minute >= start && minute < end ||
+			start > end && (minute >= start || minute < end) {
+			return w.Rate
+		}
+	}
+	return t.Rate
+}
+
+// Writer returns w limited to the rate of the throttle.
+func (t *Throttle) Writer(w io.Writer) io.Writer {
+	if t == nil || t.Rate == 0 && len(t.Windows) == 0 {
+		return w
+	}
+	tw := &throttledWriter{w: w, t: t, now: t.now, sleep: t.sleep}
+	if tw.now == nil {
+		tw.now = time.Now
+	}
+	if tw.sleep == nil {
+		tw.sleep = time.Sleep
+	}
+	return tw
+}
+
+// parseTimeOfDay returns the minutes since midnight of a 15:04 time
+func parseTimeOfDay(s string) (int, error) {
+	tod, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, ErrInvalidWindow
+	}
+	return tod.Hour()*60 + tod.Minute(), nil
+}
+
+type throttledWriter struct {
+	w     io.Writer
+	t     *Throttle
+	now   func() time.Time
+	sleep func(time.Duration)
+	last  time.Time
+}
+
+// Write passes p on in pieces of a tenth of a second at the current rate,
+// sleeping as long as the pieces should take
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		now := tw.now()
+		rate := tw.t.RateAt(now)
+		if rate == 0 {
+			tw.last = time.Time{}
+			m, err := tw.w.Write(p)
+			return n + m, err
+		}
+
+		size := int(rate / 10)
+		if size < 1 {
+			size = 1
+		}
+		if size > len(p) {
+			size = len(p)
+		}
+		m, err := tw.w.Write(p[:size])
+		n += m
+		if err != nil {
+			return n, err
+		}
+		p = p[size:]
+
+		if tw.last.IsZero() {
+			tw.last = now
+		}
+		tw.last = tw.last.Add(time.Duration(int64(m) * int64(time.Second) / rate))
+		if wait := tw.last.Sub(tw.now()); wait > 0 {
+			tw.sleep(wait)
+		} else {
+			tw.last = tw.now()
+		}
+	}
+	return n, nil
+}
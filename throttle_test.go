@@ -0,0 +1,65 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleRateAt(t *testing.T) {
+	throttle := &Throttle{
+		Rate: 10 << 20,
+		Windows: []ThrottleWindow{
+			{Start: "01:00", End: "05:00"},
+			{Start: "22:00", End: "00:30", Rate: 1 << 20},
+		},
+		Location: time.UTC,
+	}
+	assert.NoError(t, throttle.Validate())
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 3, 4, hour, minute, 0, 0, time.UTC)
+	}
+	assert.Equal(t, int64(0), throttle.RateAt(at(1, 0)))
+	assert.Equal(t, int64(0), throttle.RateAt(at(4, 59)))
+	assert.Equal(t, int64(10<<20), throttle.RateAt(at(5, 0)))
+	assert.Equal(t, int64(1<<20), throttle.RateAt(at(23, 0)))
+	assert.Equal(t, int64(1<<20), throttle.RateAt(at(0, 15)))
+	assert.Equal(t, int64(10<<20), throttle.RateAt(at(0, 30)))
+
+	throttle.Windows = append(throttle.Windows, ThrottleWindow{Start: "1am", End: "05:00"})
+	assert.Equal(t, ErrInvalidWindow, throttle.Validate())
+}
+
+func TestThrottleWriter(t *testing.T) {
+	clock := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	throttle := &Throttle{
+		Rate:    100,
+		Windows: []ThrottleWindow{{Start: "12:00", End: "12:00"}},
+		now:     func() time.Time { return clock },
+		sleep: func(d time.Duration) {
+			slept += d
+			clock = clock.Add(d)
+		},
+	}
+
+	var buf bytes.Buffer
+	w := throttle.Writer(&buf)
+	n, err := w.Write(bytes.Repeat([]byte("x"), 250))
+	assert.NoError(t, err)
+	assert.Equal(t, 250, n)
+	assert.Equal(t, 250, buf.Len())
+	assert.Equal(t, 2500*time.Millisecond, slept)
+
+	// Full speed inside the window
+	throttle.Windows[0].End = "13:00"
+	slept = 0
+	_, err = w.Write(bytes.Repeat([]byte("x"), 250))
+	assert.NoError(t, err)
+	assert.Zero(t, slept)
+
+	assert.Equal(t, &buf, (&Throttle{}).Writer(&buf))
+}
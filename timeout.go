@@ -0,0 +1,74 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryTimeout is the error of a dump whose metadata query or SELECT was
+// not answered within MetadataTimeout or QueryTimeout.
+var ErrQueryTimeout = errors.New("query timed out")
+
+// queryRows are the rows of a timedQuery, its context is canceled once they
+// are closed
+type queryRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (rows *queryRows) Close() error {
+	err := rows.Rows.Close()
+	rows.cancel()
+	return err
+}
+
+// timedQuery runs query on a context of the Context of data, also canceled
+// when the server has not answered it within timeout, like while it waits for
+// the lock of a table. The bound ends with the answer: the rows are then read
+// in the time they take, however large the table.
+func (data *Data) timedQuery(timeout time.Duration, query string, run func(ctx context.Context) (*sql.Rows, error)) (*queryRows, error) {
+	ctx, cancel := context.WithCancel(data.context())
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, cancel)
+	}
+	rows, err := run(ctx)
+	if timer != nil && !timer.Stop() {
+		cancel()
+		if err == nil {
+			rows.Close()
+		}
+		return nil, fmt.Errorf("%w: %s not answered within %s", ErrQueryTimeout, query, timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &queryRows{Rows: rows, cancel: cancel}, nil
+}
+
+// metadataQuery runs a query reading the structure of the database within
+// MetadataTimeout
+func (data *Data) metadataQuery(query string) (*queryRows, error) {
+	return data.timedQuery(data.MetadataTimeout, query, func(ctx context.Context) (*sql.Rows, error) {
+		return data.tx.QueryContext(ctx, query)
+	})
+}
+
+// selectRows runs a SELECT of the rows of the table within QueryTimeout
+func (table *table) selectRows(query string) (*queryRows, error) {
+	return table.data.timedQuery(table.data.QueryTimeout, query, func(ctx context.Context) (*sql.Rows, error) {
+		return table.txn().QueryContext(ctx, query)
+	})
+}
+
+// metadataQuery runs a query reading the structure of the table within
+// MetadataTimeout on the transaction the table is read through
+func (table *table) metadataQuery(query string) (*queryRows, error) {
+	return table.data.timedQuery(table.data.MetadataTimeout, query, func(ctx context.Context) (*sql.Rows, error) {
+		return table.txn().QueryContext(ctx, query)
+	})
+}
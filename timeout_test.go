@@ -0,0 +1,98 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataTimeout(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.MetadataTimeout = 10 * time.Millisecond
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `test`$").WillDelayFor(time.Second).WillReturnRows(
+		sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("test", "CREATE TABLE `test` (`id` int)"))
+
+	start := time.Now()
+	_, err = data.createTable("test", false).CreateSQL()
+	assert.True(t, errors.Is(err, ErrQueryTimeout), "%v", err)
+	assert.EqualError(t, err, "query timed out: SHOW CREATE TABLE `test` not answered within 10ms")
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestQueryTimeoutReadsAnsweredRows(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.MetadataTimeout = time.Second
+	data.QueryTimeout = 20 * time.Millisecond
+
+	mockTableSelect(mock, "test")
+	table := data.createTable("test", false)
+	assert.True(t, table.Next())
+	// The bound ends with the answer, reading the rows takes what it takes
+	time.Sleep(40 * time.Millisecond)
+	assert.True(t, table.Next())
+	assert.False(t, table.Next())
+	assert.NoError(t, table.Err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestQueryTimeout(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.QueryTimeout = 10 * time.Millisecond
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	table := data.createTable("test", false)
+	assert.False(t, table.Next())
+	assert.True(t, errors.Is(table.Err, ErrQueryTimeout), "%v", table.Err)
+}
+
+func TestMetadataQueryContext(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	data.Context = ctx
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillDelayFor(3 * time.Second).WillReturnRows(
+		sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("test", "BASE TABLE"))
+
+	// Canceling the Context of the dump aborts a query waiting without a
+	// MetadataTimeout
+	start := time.Now()
+	_, err = data.metadataQuery("SHOW FULL TABLES")
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestQueryRowsClose(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+	data.MetadataTimeout = time.Second
+
+	mock.ExpectQuery("^SHOW FULL TABLES$").WillReturnRows(
+		sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("test", "BASE TABLE"))
+	rows, err := data.metadataQuery("SHOW FULL TABLES")
+	assert.NoError(t, err)
+	canceled := false
+	cancel := rows.cancel
+	rows.cancel = func() {
+		canceled = true
+		cancel()
+	}
+	assert.NoError(t, rows.Close())
+	assert.True(t, canceled)
+}
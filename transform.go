@@ -0,0 +1,95 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Transform replaces the value of a column before it is formatted. It gets
+// nil for NULL, a string, an int64, a float64 or, for the binary columns, a
+// []byte, and returns a value of one of those types or a bool or int. Unlike
+// a Masker it sees NULL and the type of the value, so it can blank a column
+// or keep a number a number.
+type Transform func(value interface{}) interface{}
+
+// initTransforms looks up the transform of every column, for the table first
+// and for * for any table
+func (table *table) initTransforms() {
+	if len(table.data.Transforms) == 0 {
+		return
+	}
+	table.transforms = make([]Transform, len(table.cols))
+	for i, col := range table.cols {
+		if f, ok := table.data.Transforms[table.Name][col]; ok {
+			table.transforms[i] = f
+		} else if f, ok := table.data.Transforms["*"][col]; ok {
+			table.transforms[i] = f
+		}
+	}
+}
+
+// transformed returns the scanned value of column as its transform leaves it
+func (table *table) transformed(column int, value interface{}) interface{} {
+	if column >= len(table.transforms) || table.transforms[column] == nil {
+		return value
+	}
+	return scannedValue(table.transforms[column](transformValue(value)))
+}
+
+// transformValue returns a scanned value as the value a Transform gets
+func transformValue(value interface{}) interface{} {
+	switch s := value.(type) {
+	case *sql.NullString:
+		if s.Valid {
+			return s.String
+		}
+	case *sql.NullInt64:
+		if s.Valid {
+			return s.Int64
+		}
+	case *sql.NullFloat64:
+		if s.Valid {
+			return s.Float64
+		}
+	case *sql.RawBytes:
+		if *s != nil {
+			return append([]byte{}, *s...)
+		}
+	default:
+		if value != nil {
+			return fmt.Sprintf("%s", value)
+		}
+	}
+	return nil
+}
+
+// scannedValue returns the value of a Transform as the scanned value it is
+// formatted from
+func scannedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return &sql.NullString{String: v, Valid: true}
+	case int64:
+		return &sql.NullInt64{Int64: v, Valid: true}
+	case int:
+		return &sql.NullInt64{Int64: int64(v), Valid: true}
+	case bool:
+		n := int64(0)
+		if v {
+			n = 1
+		}
+		return &sql.NullInt64{Int64: n, Valid: true}
+	case float64:
+		return &sql.NullFloat64{Float64: v, Valid: true}
+	case []byte:
+		// Nil raw bytes are NULL
+		b := sql.RawBytes(v)
+		if b == nil {
+			b = sql.RawBytes{}
+		}
+		return &b
+	}
+	return &sql.NullString{String: fmt.Sprint(value), Valid: true}
+}
@@ -0,0 +1,63 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamTransforms(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW COLUMNS FROM `test`$").WillReturnRows(
+		sqlmock.NewRows([]string{"Field", "Extra"}).AddRow("id", "").AddRow("email", "").AddRow("name", ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `test`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).
+			AddRow(1, "test@test.de", "Test Name 1").
+			AddRow(2, nil, "Test Name 2"))
+	data.MaxAllowedPacket = 4096
+
+	var seen []interface{}
+	data.Transforms = map[string]map[string]Transform{
+		"test": {
+			"id": func(value interface{}) interface{} {
+				seen = append(seen, value)
+				return value.(int64) + 100
+			},
+			"email": func(value interface{}) interface{} {
+				seen = append(seen, value)
+				return nil
+			},
+		},
+		"*": {
+			"email": func(value interface{}) interface{} { return "unused" },
+			"name": func(value interface{}) interface{} {
+				return strings.ToUpper(value.(string))
+			},
+		},
+	}
+
+	s := data.createTable("test", false).Stream()
+	assert.EqualValues(t, "INSERT INTO `test` (`id`, `email`, `name`) VALUES (101,NULL,'TEST NAME 1'),(102,NULL,'TEST NAME 2');", <-s)
+	assert.Equal(t, []interface{}{int64(1), "test@test.de", int64(2), nil}, seen)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+}
+
+func TestScannedValue(t *testing.T) {
+	for value, expected := range map[interface{}]interface{}{
+		"a":     "a",
+		7:       int64(7),
+		true:    int64(1),
+		2.5:     2.5,
+		"":      "",
+		int8(3): "3",
+	} {
+		assert.Equal(t, expected, transformValue(scannedValue(value)))
+	}
+	assert.Nil(t, scannedValue(nil))
+	assert.Equal(t, []byte{1, 2}, transformValue(scannedValue([]byte{1, 2})))
+}
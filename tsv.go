@@ -0,0 +1,93 @@
+package mysqldump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const loadFileName = "load.sql"
+
+// tsvWriter writes the fields of the rows of SELECT ... INTO OUTFILE with its
+// defaults: fields terminated by tab, escaped by backslash, NULL as \N
+type tsvWriter struct {
+	w       *bufio.Writer
+	escaper *strings.Replacer
+	eol     string
+}
+
+func (data *Data) newTSVWriter(w io.Writer) *tsvWriter {
+	t := &tsvWriter{
+		w:       bufio.NewWriter(w),
+		escaper: strings.NewReplacer(`\`, `\\`, "\t", "\\\t", "\n", "\\\n", "\x00", `\0`),
+		eol:     "\n",
+	}
+	if data.LineEnding == LineEndingCRLF {
+		// Like OUTFILE the first character of the line terminator is escaped
+		t.escaper = strings.NewReplacer(`\`, `\\`, "\t", "\\\t", "\r", "\\\r", "\x00", `\0`)
+		t.eol = "\r\n"
+	}
+	return t
+}
+
+// write writes a record, nil fields being NULL
+func (t *tsvWriter) write(fields []*string) error {
+	for i, field := range fields {
+		if i > 0 {
+			t.w.WriteString("\t")
+		}
+		if field == nil {
+			t.w.WriteString(`\N`)
+		} else {
+			t.escaper.WriteString(t.w, *field)
+		}
+	}
+	_, err := t.w.WriteString(t.eol)
+	return err
+}
+
+func (t *tsvWriter) flush() error {
+	return t.w.Flush()
+}
+
+// tsvFileName returns the name of the text file of the table, as written
+// before the Codec
+func (data *Data) tsvFileName(meta *metaData, table *table) string {
+	return textFileName(data.dataFileName(meta, table), ".txt")
+}
+
+// writeTSVFile writes the rows of table to its own text file
+func (data *Data) writeTSVFile(meta *metaData, table *table) error {
+	f, err := data.createFile(data.tsvFileName(meta, table))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := data.writeTableRecords(data.newTSVWriter(f), table, false); err != nil {
+		return err
+	}
+	table.bytes = f.written
+	return f.Close()
+}
+
+// writeLoadStatements writes the LOAD DATA statement of the text file of
+// every table. The files are read by the client from where it runs, relative
+// to the root of the dump.
+func (data *Data) writeLoadStatements(w io.Writer, meta *metaData, tables []*table) error {
+	lines := `\n`
+	if data.LineEnding == LineEndingCRLF {
+		lines = `\r\n`
+	}
+	for _, table := range tables {
+		if table.isView || table.mergeSkipped || len(table.cols) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "LOAD DATA LOCAL INFILE '%s' INTO TABLE %s CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '%s' (%s);\n",
+			sanitize(data.tsvFileName(meta, table)), table.NameEsc(), lines, table.columnsList()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
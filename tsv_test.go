@@ -0,0 +1,37 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSVWriterEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	w := (&Data{}).newTSVWriter(&buf)
+	s := "a\tb\\c\x00d\re"
+	assert.NoError(t, w.write([]*string{nil, &s}))
+	assert.NoError(t, w.flush())
+	assert.Equal(t, "\\N\ta\\\tb\\\\c\\0d\re\n", buf.String())
+
+	buf.Reset()
+	w = (&Data{LineEnding: LineEndingCRLF}).newTSVWriter(&buf)
+	s = "one\r\ntwo"
+	assert.NoError(t, w.write([]*string{&s}))
+	assert.NoError(t, w.flush())
+	assert.Equal(t, "one\\\r\ntwo\r\n", buf.String())
+}
+
+func TestWriteLoadStatements(t *testing.T) {
+	data := &Data{LineEnding: LineEndingCRLF}
+	tables := []*table{
+		{Name: "it's", cols: []string{"id"}},
+		{Name: "v", isView: true, cols: []string{"id"}},
+		{Name: "generated"},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, data.writeLoadStatements(&buf, &metaData{}, tables))
+	assert.Equal(t, "LOAD DATA LOCAL INFILE 'data/it_s~"+nameHash("it's")+".txt' INTO TABLE `it's` CHARACTER SET utf8mb4 "+
+		"FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\r\\n' (`id`);\n", buf.String())
+}
@@ -0,0 +1,121 @@
+package mysqldump
+
+import (
+	"io"
+	"strings"
+)
+
+// Takes a []*account
+const usersTmpl = `
+--
+-- Dumping users and grants
+--
+{{ range . }}
+{{ terminate .CreateSQL }}
+{{- range .Grants }}
+{{ terminate . }}
+{{- end }}
+{{ end -}}
+`
+
+// account is a user of the server along with its privileges
+type account struct {
+	User      string
+	Host      string
+	CreateSQL string
+	Grants    []string
+}
+
+// NameEsc is the account in the user@host form of the account statements
+func (a *account) NameEsc() string {
+	return quoteAccountPart(a.User) + "@" + quoteAccountPart(a.Host)
+}
+
+func quoteAccountPart(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// isReservedAccount reports whether the account is one of those created
+// with the server for its own use, which exist on every target already
+func isReservedAccount(user string) bool {
+	return strings.HasPrefix(user, "mysql.") || user == "mariadb.sys"
+}
+
+// writeUsers writes the accounts of the server and their grants to w if the
+// dump includes them
+func (data *Data) writeUsers(w io.Writer, meta *metaData) error {
+	if !data.IncludeGrants {
+		return nil
+	}
+	var accounts []*account
+	if err := data.privileged("users and grants", func() (err error) {
+		accounts, err = data.getAccounts(parseServerVersion(meta.ServerVersion))
+		return
+	}); err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+	return data.usersTmpl.Execute(w, accounts)
+}
+
+// getAccounts reads the accounts of the server with SHOW CREATE USER and
+// their privileges with SHOW GRANTS. The accounts are created with IF NOT
+// EXISTS so restoring into a server that has some of them keeps their
+// passwords and only adds the grants.
+func (data *Data) getAccounts(version serverVersion) ([]*account, error) {
+	// Without it the password hashes of caching_sha2_password are written
+	// as raw bytes that don't survive the character set of the dump
+	if !version.MariaDB && !version.less(serverVersion{Major: 8, Patch: 17}) {
+		if _, err := data.tx.Exec("SET SESSION print_identified_with_as_hex = ON"); err != nil {
+			return nil, err
+		}
+	}
+
+	// The roles of MariaDB are rows of mysql.user too
+	query := "SELECT User, Host FROM mysql.user ORDER BY User, Host"
+	if version.MariaDB {
+		query = "SELECT User, Host FROM mysql.user WHERE is_role = 'N' ORDER BY User, Host"
+	}
+	names, err := data.queryNames(query)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []*account
+	for _, name := range names {
+		if isReservedAccount(name[0]) {
+			continue
+		}
+		a := &account{User: name[0], Host: name[1]}
+		if err := data.tx.QueryRow("SHOW CREATE USER " + a.NameEsc()).Scan(&a.CreateSQL); err != nil {
+			return nil, err
+		}
+		a.CreateSQL = strings.Replace(a.CreateSQL, "CREATE USER ", "CREATE USER IF NOT EXISTS ", 1)
+		if a.Grants, err = data.queryGrants(a); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+// queryGrants reads the GRANT statements of an account
+func (data *Data) queryGrants(a *account) ([]string, error) {
+	rows, err := data.tx.Query("SHOW GRANTS FOR " + a.NameEsc())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []string
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, rows.Err()
+}
@@ -0,0 +1,93 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTable expects the queries of a dump of Test_Table on the server version
+func mockTable(mock sqlmock.Sqlmock, version string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow(version))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(sqlmock.NewRows([]string{"Tables_in_Testdb", "Table_type"}).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int)"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).AddRow("id", "int", "YES", "", nil, ""))
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+func TestDumpIncludeGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockTable(mock, "8.0.34")
+	mock.ExpectExec(`^SET SESSION print_identified_with_as_hex = ON$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT User, Host FROM mysql.user ORDER BY User, Host$`).WillReturnRows(
+		sqlmock.NewRows([]string{"User", "Host"}).AddRow("app", "%").AddRow("mysql.sys", "localhost").AddRow("o'neil", "10.0.0.%"))
+	mock.ExpectQuery(`^SHOW CREATE USER 'app'@'%'$`).WillReturnRows(
+		sqlmock.NewRows([]string{"CREATE USER for app@%"}).AddRow("CREATE USER `app`@`%` IDENTIFIED WITH 'caching_sha2_password' AS 0x24412430303524 REQUIRE NONE"))
+	mock.ExpectQuery(`^SHOW GRANTS FOR 'app'@'%'$`).WillReturnRows(
+		sqlmock.NewRows([]string{"Grants for app@%"}).AddRow("GRANT USAGE ON *.* TO `app`@`%`").AddRow("GRANT SELECT, INSERT ON `Testdb`.* TO `app`@`%`"))
+	mock.ExpectQuery(`^SHOW CREATE USER 'o''neil'@'10.0.0.%'$`).WillReturnRows(
+		sqlmock.NewRows([]string{"CREATE USER for o'neil@10.0.0.%"}).AddRow("CREATE USER `o'neil`@`10.0.0.%` IDENTIFIED WITH 'mysql_native_password' REQUIRE NONE"))
+	mock.ExpectQuery(`^SHOW GRANTS FOR 'o''neil'@'10.0.0.%'$`).WillReturnRows(
+		sqlmock.NewRows([]string{"Grants for o'neil@10.0.0.%"}).AddRow("GRANT PROCESS ON *.* TO `o'neil`@`10.0.0.%`"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, IncludeGrants: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.Contains(t, buf.String(), `
+--
+-- Dumping users and grants
+--
+
+CREATE USER IF NOT EXISTS `+"`app`@`%`"+` IDENTIFIED WITH 'caching_sha2_password' AS 0x24412430303524 REQUIRE NONE;
+GRANT USAGE ON *.* TO `+"`app`@`%`"+`;
+GRANT SELECT, INSERT ON `+"`Testdb`.* TO `app`@`%`"+`;
+
+CREATE USER IF NOT EXISTS `+"`o'neil`@`10.0.0.%`"+` IDENTIFIED WITH 'mysql_native_password' REQUIRE NONE;
+GRANT PROCESS ON *.* TO `+"`o'neil`@`10.0.0.%`"+`;
+`)
+	assert.NotContains(t, buf.String(), "mysql.sys")
+}
+
+func TestDumpIncludeGrantsMariaDB(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockTable(mock, "10.6.12-MariaDB")
+	mock.ExpectQuery(`^SELECT User, Host FROM mysql.user WHERE is_role = 'N' ORDER BY User, Host$`).WillReturnRows(
+		sqlmock.NewRows([]string{"User", "Host"}).AddRow("mariadb.sys", "localhost"))
+	mock.ExpectRollback()
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, IncludeGrants: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.NotContains(t, buf.String(), "Dumping users and grants")
+}
+
+func TestDumpIncludeGrantsDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	denied := errors.New("Error 1142 (42000): SELECT command denied to user 'dump'@'localhost' for table 'user'")
+	mockTable(mock, "5.7.44")
+	mock.ExpectQuery(`^SELECT User, Host FROM mysql.user`).WillReturnError(denied)
+	mock.ExpectRollback()
+
+	data := &mysqldump.Data{Connection: db, Out: &bytes.Buffer{}, IncludeGrants: true, Grants: mysqldump.GrantsAuto}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []string{"users and grants skipped: " + denied.Error()}, data.Warnings())
+}
@@ -0,0 +1,167 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidOutput is wrapped by the error of a dump whose output fails the
+// checks of ValidateOutput.
+var ErrInvalidOutput = errors.New("invalid dump output")
+
+// validStatements are the first keywords of the statements a dump writes
+var validStatements = map[string]bool{
+	"ALTER":   true,
+	"CREATE":  true,
+	"DROP":    true,
+	"INSERT":  true,
+	"LOCK":    true,
+	"REPLACE": true,
+	"SET":     true,
+	"UNLOCK":  true,
+	"USE":     true,
+}
+
+// validator splits the output written to it into statements and checks them
+// in a goroutine of its own. Once a statement fails, so do the following
+// writes.
+type validator struct {
+	pw        *io.PipeWriter
+	done      chan struct{}
+	once      sync.Once
+	err       error
+	name      string
+	maxPacket int
+}
+
+// newValidator starts checking the output of the SQL file name, empty for
+// Out
+func (data *Data) newValidator(name string) *validator {
+	pr, pw := io.Pipe()
+	v := &validator{
+		pw:        pw,
+		done:      make(chan struct{}),
+		name:      name,
+		maxPacket: data.MaxAllowedPacket,
+	}
+	go func() {
+		defer close(v.done)
+		v.err = v.check(pr)
+		// Fail the writes still to come, or let them through once done
+		pr.CloseWithError(v.err)
+	}()
+	return v
+}
+
+func (v *validator) Write(p []byte) (int, error) {
+	return v.pw.Write(p)
+}
+
+// Close waits for the checks of everything written and returns their
+// error. It is safe to call more than once.
+func (v *validator) Close() error {
+	v.once.Do(func() {
+		v.pw.Close()
+	})
+	<-v.done
+	return v.err
+}
+
+// check reads statements from r until the first invalid one
+func (v *validator) check(r io.Reader) error {
+	scanner := newStatementScanner(r)
+	for {
+		st, err := scanner.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if st.SQL == "" {
+			continue
+		}
+		if msg := checkStatement(st, scanner.delimiter, v.maxPacket); msg != "" {
+			where := ""
+			if v.name != "" {
+				where = v.name + " "
+			}
+			return fmt.Errorf("%w: %sline %d: %s", ErrInvalidOutput, where, st.Line, msg)
+		}
+	}
+}
+
+// checkStatement returns what is wrong with the statement, if anything
+func checkStatement(st *statement, delimiter string, maxPacket int) string {
+	if st.Unterminated {
+		return "statement is not terminated by " + delimiter
+	}
+	if len(st.SQL)+1 > maxPacket {
+		return fmt.Sprintf("statement of %d bytes exceeds %d", len(st.SQL)+1, maxPacket)
+	}
+	keyword := firstKeyword(st.SQL)
+	if !validStatements[keyword] {
+		return fmt.Sprintf("unexpected statement %.40q", st.SQL)
+	}
+	if m := insertRe.FindStringIndex(st.SQL); m != nil {
+		if _, err := parseInsert(st.SQL[m[1]:]); err != nil {
+			return "malformed INSERT: " + err.Error()
+		}
+		return ""
+	}
+	if !balanced(st.SQL) {
+		return "unbalanced parentheses"
+	}
+	return ""
+}
+
+// firstKeyword returns the first word of a statement in upper case, looking
+// into a leading version comment
+func firstKeyword(sql string) string {
+	s := strings.TrimSpace(sql)
+	if strings.HasPrefix(s, "/*!") {
+		s = strings.TrimLeft(s[3:], "0123456789")
+	}
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool { return r > 0x7f || !isIdentChar(byte(r)) })
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToUpper(s[:end])
+}
+
+// balanced reports whether the parentheses of sql outside of quotes and
+// comments match
+func balanced(sql string) bool {
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch ch := sql[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < len(sql) && sql[i] != ch; i++ {
+				if sql[i] == '\\' && ch != '`' {
+					i++
+				}
+			}
+		case ch == '#' || ch == '-' && strings.HasPrefix(sql[i:], "-- "):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*") && !strings.HasPrefix(sql[i:], "/*!"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
@@ -0,0 +1,102 @@
+package mysqldump_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpValidateOutputOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, ValidateOutput: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Contains(t, buf.String(), "INSERT INTO `Test_Table`")
+}
+
+func TestDumpValidateOutputFilesOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var out bytes.Buffer
+	tw := mysqldump.NewTarWriter(&out, false)
+	data := &mysqldump.Data{Connection: db, Files: tw, ValidateOutput: true}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	names, _ := readTar(t, &out)
+	assert.Contains(t, names, "data/Test_Table.sql")
+}
+
+// mockMalformedDump expects a dump of a table whose CREATE TABLE misses a
+// parenthesis
+func mockMalformedDump(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT version\(\)$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Version()", "")).AddRow("test_version"))
+	mock.ExpectQuery(`^SHOW FULL TABLES$`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Tables_in_Testdb", ""), c("Table_type", "")).AddRow("Test_Table", "BASE TABLE"))
+	mock.ExpectQuery("^SHOW CREATE TABLE `Test_Table`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("Table", ""), c("Create Table", "")).
+			AddRow("Test_Table", "CREATE TABLE `Test_Table` (`id` int(11) NOT NULL, PRIMARY KEY (`id`)ENGINE=InnoDB"))
+	mock.ExpectQuery("^SHOW COLUMNS FROM `Test_Table`$").WillReturnRows(mockColumnRows())
+	mock.ExpectQuery("^SELECT (.+) FROM `Test_Table`$").WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(c("id", 0), c("email", ""), c("name", "")).AddRow(1, nil, "Test Name 1"))
+	mock.ExpectRollback()
+}
+
+func TestDumpValidateOutputMalformed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockMalformedDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf, ValidateOutput: true}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, mysqldump.ErrInvalidOutput), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "unbalanced parentheses")
+	assert.Equal(t, mysqldump.CauseInvalidOutput, mysqldump.CauseOf(err))
+}
+
+func TestDumpValidateOutputMalformedFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockMalformedDump(mock)
+
+	var out bytes.Buffer
+	tw := mysqldump.NewTarWriter(&out, false)
+	data := &mysqldump.Data{Connection: db, Files: tw, ValidateOutput: true}
+	err = data.Dump()
+	assert.True(t, errors.Is(err, mysqldump.ErrInvalidOutput), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "schema.sql line")
+}
+
+func TestDumpWithoutValidateOutput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockMalformedDump(mock)
+
+	var buf bytes.Buffer
+	data := &mysqldump.Data{Connection: db, Out: &buf}
+	assert.NoError(t, data.Dump())
+}
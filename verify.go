@@ -0,0 +1,92 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrVerificationFailed is wrapped by the error of a restore whose result does
+// not match the manifest of the dump.
+var ErrVerificationFailed = errors.New("restored data does not match the manifest")
+
+// VerifyReport is the outcome of comparing a database with a manifest.
+type VerifyReport struct {
+	Passed bool
+	Tables []VerifyTable
+}
+
+// VerifyTable compares a single table. Checksums are only compared when the
+// manifest recorded one.
+type VerifyTable struct {
+	Name             string
+	ExpectedRows     int64
+	Rows             int64
+	ExpectedChecksum string
+	Checksum         string
+	Passed           bool
+}
+
+// VerifyError is returned by a restore that failed verification.
+type VerifyError struct {
+	Report *VerifyReport
+}
+
+func (e *VerifyError) Error() string {
+	var failed []string
+	for _, table := range e.Report.Tables {
+		if !table.Passed {
+			failed = append(failed, table.Name)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrVerificationFailed, strings.Join(failed, ", "))
+}
+
+func (e *VerifyError) Unwrap() error {
+	return ErrVerificationFailed
+}
+
+// Verify counts the rows of every table of the manifest in db, computes
+// CHECKSUM TABLE where the manifest has a checksum and reports the
+// differences. Views are left out.
+func Verify(db *sql.DB, manifest *Manifest) (*VerifyReport, error) {
+	return verify(context.Background(), db, manifest)
+}
+
+// rowQueryer is implemented by *sql.DB and *sql.Conn
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func verify(ctx context.Context, db rowQueryer, manifest *Manifest) (*VerifyReport, error) {
+	report := &VerifyReport{Passed: true}
+	for _, expected := range manifest.Tables {
+		if expected.View {
+			continue
+		}
+		name := "`" + expected.Name + "`"
+		result := VerifyTable{
+			Name:             expected.Name,
+			ExpectedRows:     expected.Rows,
+			ExpectedChecksum: expected.Checksum,
+		}
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+name).Scan(&result.Rows); err != nil {
+			return nil, err
+		}
+		if expected.Checksum != "" {
+			var table, checksum sql.NullString
+			if err := db.QueryRowContext(ctx, "CHECKSUM TABLE "+name).Scan(&table, &checksum); err != nil {
+				return nil, err
+			}
+			result.Checksum = checksum.String
+		}
+		result.Passed = result.Rows == result.ExpectedRows && result.Checksum == result.ExpectedChecksum
+		if !result.Passed {
+			report.Passed = false
+		}
+		report.Tables = append(report.Tables, result)
+	}
+	return report, nil
+}
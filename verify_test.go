@@ -0,0 +1,60 @@
+package mysqldump
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	manifest, err := ReadManifest(strings.NewReader(`{"tables": [
+		{"name": "a", "rows": 2, "checksum": "1234"},
+		{"name": "b", "rows": 5},
+		{"name": "v", "view": true}
+	]}`))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `a`$").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(2))
+	mock.ExpectQuery("^CHECKSUM TABLE `a`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Checksum"}).AddRow("test.a", "1234"))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `b`$").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(4))
+
+	report, err := Verify(db, manifest)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, []VerifyTable{
+		{Name: "a", ExpectedRows: 2, Rows: 2, ExpectedChecksum: "1234", Checksum: "1234", Passed: true},
+		{Name: "b", ExpectedRows: 5, Rows: 4},
+	}, report.Tables)
+
+	err = &VerifyError{Report: report}
+	assert.True(t, errors.Is(err, ErrVerificationFailed))
+	assert.Equal(t, "restored data does not match the manifest: b", err.Error())
+}
+
+func TestChecksumRecorded(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^CHECKSUM TABLE `test`$").WillReturnRows(sqlmock.NewRows([]string{"Table", "Checksum"}).AddRow("db.test", "42"))
+
+	data.Checksums = true
+	data.manifest = &Manifest{}
+	table := data.createTable("test", false)
+	table.row = 3
+
+	assert.NoError(t, data.recordTable(table))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+	assert.Equal(t, []ManifestTable{{Name: "test", Rows: 3, Checksum: "42"}}, data.manifest.Tables)
+}
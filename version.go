@@ -0,0 +1,55 @@
+package mysqldump
+
+import (
+	"strconv"
+	"strings"
+)
+
+// serverVersion is the parsed result of SELECT version()
+type serverVersion struct {
+	Major, Minor, Patch int
+	MariaDB             bool
+}
+
+// parseServerVersion understands MySQL versions like 8.0.34-log and MariaDB
+// versions, including the 5.5.5- prefix some clients see, like
+// 5.5.5-10.6.12-MariaDB-1:10.6.12+maria~ubu2004
+func parseServerVersion(s string) serverVersion {
+	v := serverVersion{MariaDB: strings.Contains(strings.ToLower(s), "mariadb")}
+	if v.MariaDB {
+		s = strings.TrimPrefix(s, "5.5.5-")
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.SplitN(s, ".", 3)
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		*nums[i], _ = strconv.Atoi(part)
+	}
+	return v
+}
+
+// less compares the numeric parts of the versions
+func (v serverVersion) less(o serverVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// atLeast reports whether v is major.minor or newer
+func (v serverVersion) atLeast(major, minor int) bool {
+	return !v.less(serverVersion{Major: major, Minor: minor})
+}
+
+func (v serverVersion) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if v.MariaDB {
+		s += "-MariaDB"
+	}
+	return s
+}
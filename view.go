@@ -0,0 +1,77 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Takes a *table
+const invalidViewTmpl = `
+--
+-- View structure for view {{ .NameEsc }}
+--
+-- The view is invalid and was commented out: {{ .Invalid }}
+--
+
+{{ .CommentedSQL }}
+`
+
+// isInvalidView reports whether err is ER_VIEW_INVALID, returned for views
+// that reference tables, columns or functions that no longer exist
+func isInvalidView(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1356") || strings.Contains(msg, "references invalid table")
+}
+
+// viewTemplate returns the template to write a view with. Invalid views are
+// commented out with a warning unless StrictViews is set.
+func (data *Data) viewTemplate(table *table) (*template.Template, error) {
+	if _, err := table.CreateSQL(); err != nil {
+		if data.StrictViews || !isInvalidView(err) {
+			return nil, err
+		}
+		table.Invalid = err
+		table.createSQL = table.viewDefinition()
+		data.warn(fmt.Sprintf("view %s is invalid and was commented out: %v", table.NameEsc(), err))
+		return data.invalidViewTmpl, nil
+	}
+	return data.viewTmpl, nil
+}
+
+// viewDefinition reads the definition of an invalid view from
+// information_schema, which still has it when SHOW CREATE TABLE fails
+func (table *table) viewDefinition() string {
+	var definition sql.NullString
+	err := table.txn().QueryRow("SELECT VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table.Name).Scan(&definition)
+	if err != nil || !definition.Valid {
+		return ""
+	}
+	return "CREATE VIEW " + table.QualifiedName() + " AS " + definition.String
+}
+
+// CommentedSQL returns the definition of an invalid view as SQL comments
+func (table *table) CommentedSQL() string {
+	if table.createSQL == "" {
+		return "-- The definition is not available"
+	}
+	return "-- " + strings.Replace(table.createSQL, "\n", "\n-- ", -1) + ";"
+}
+
+// warn records a problem that did not stop the dump
+func (data *Data) warn(warning string) {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data.warnings = append(data.warnings, warning)
+}
+
+// Warnings returns the problems the last dump worked around, like invalid
+// views that were commented out.
+func (data *Data) Warnings() []string {
+	s := data.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return data.warnings
+}
@@ -0,0 +1,88 @@
+package mysqldump
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var errInvalidView = errors.New("Error 1356 (HY000): View 'Testdb.v' references invalid table(s) or column(s) or function(s) or definer/invoker of view lack rights to use them")
+
+func TestWriteInvalidView(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `v`$").WillReturnError(errInvalidView)
+	mock.ExpectQuery("^SELECT VIEW_DEFINITION FROM information_schema.VIEWS").WithArgs("v").
+		WillReturnRows(sqlmock.NewRows([]string{"VIEW_DEFINITION"}).AddRow("select `gone`.`id` AS `id`\nfrom `gone`"))
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	assert.NoError(t, data.getTemplates())
+
+	assert.NoError(t, data.writeTable(data.createTable("v", true)))
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	expected := `
+--
+-- View structure for view ~v~
+--
+-- The view is invalid and was commented out: ` + errInvalidView.Error() + `
+--
+
+-- CREATE VIEW ~v~ AS select ~gone~.~id~ AS ~id~
+-- from ~gone~;
+`
+	assert.Equal(t, expected, strings.Replace(buf.String(), "`", "~", -1))
+	assert.Equal(t, []string{"view `v` is invalid and was commented out: " + errInvalidView.Error()}, data.Warnings())
+}
+
+func TestWriteInvalidViewWithoutDefinition(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `v`$").WillReturnError(errInvalidView)
+	mock.ExpectQuery("^SELECT VIEW_DEFINITION FROM information_schema.VIEWS").WillReturnError(errors.New("denied"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, data.getTemplates())
+	assert.NoError(t, data.writeTableSchema(&buf, data.createTable("v", true)))
+	assert.True(t, strings.HasSuffix(buf.String(), "\n-- The definition is not available\n"))
+}
+
+func TestWriteInvalidViewStrict(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `v`$").WillReturnError(errInvalidView)
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	data.StrictViews = true
+	assert.NoError(t, data.getTemplates())
+
+	assert.Equal(t, errInvalidView, data.writeTable(data.createTable("v", true)))
+	assert.Empty(t, buf.String())
+	assert.Empty(t, data.Warnings())
+}
+
+func TestWriteViewOtherError(t *testing.T) {
+	data, mock, err := getMockData()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer data.Close()
+
+	mock.ExpectQuery("^SHOW CREATE TABLE `v`$").WillReturnError(errors.New("connection lost"))
+
+	var buf bytes.Buffer
+	data.Out = &buf
+	assert.NoError(t, data.getTemplates())
+
+	assert.EqualError(t, data.writeTable(data.createTable("v", true)), "connection lost")
+}
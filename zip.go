@@ -0,0 +1,80 @@
+package mysqldump
+
+import (
+	"archive/zip"
+	"io"
+	"sync"
+	"time"
+)
+
+// ZipWriter is a WriterFactory that stores every file of the dump as an entry
+// of a zip archive, for consumers that can't open a tar.
+//
+// Entries are written to the archive as they are written, without staging:
+// one entry is open at a time and Create waits for the previous one to be
+// closed.
+type ZipWriter struct {
+	// Store returns whether the entry name is stored as is rather than
+	// deflated, like the files already encoded by a Codec. Every entry is
+	// deflated if Store is nil.
+	Store func(name string) bool
+
+	mu  sync.Mutex
+	zw  *zip.Writer
+	now time.Time
+}
+
+// NewZipWriter creates a ZipWriter writing the archive to w. Close has to be
+// called once the dump is done to write the central directory.
+func NewZipWriter(w io.Writer) *ZipWriter {
+	return &ZipWriter{zw: zip.NewWriter(w), now: time.Now()}
+}
+
+// Create starts a new entry of the archive, which lasts until the returned
+// writer is closed.
+func (z *ZipWriter) Create(name string) (io.WriteCloser, error) {
+	z.mu.Lock()
+	method := zip.Deflate
+	if z.Store != nil && z.Store(name) {
+		method = zip.Store
+	}
+	w, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: z.now,
+	})
+	if err != nil {
+		z.mu.Unlock()
+		return nil, err
+	}
+	return &zipEntry{archive: z, w: w}, nil
+}
+
+// Close writes the central directory of the archive. It does not close the
+// underlying writer.
+func (z *ZipWriter) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.zw.Close()
+}
+
+type zipEntry struct {
+	archive *ZipWriter
+	w       io.Writer
+	closed  bool
+}
+
+func (e *zipEntry) Write(p []byte) (int, error) {
+	return e.w.Write(p)
+}
+
+// Close ends the entry so the next one can start. It is safe to call more
+// than once.
+func (e *zipEntry) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	e.archive.mu.Unlock()
+	return nil
+}
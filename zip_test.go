@@ -0,0 +1,91 @@
+package mysqldump_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jamf/go-mysqldump"
+	"github.com/stretchr/testify/assert"
+)
+
+func readZip(t *testing.T, b []byte) (entries []*zip.File, files map[string]string) {
+	files = make(map[string]string)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		r.Close()
+		entries = append(entries, f)
+		files[f.Name] = string(content)
+	}
+	return
+}
+
+func dumpZip(t *testing.T, db *sql.DB, archive *mysqldump.ZipWriter) {
+	data := &mysqldump.Data{Connection: db, Files: archive, MaxAllowedPacket: 4194304}
+	assert.NoError(t, data.Dump())
+	assert.NoError(t, archive.Close())
+}
+
+func TestDumpZipOk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	var buf bytes.Buffer
+	archive := mysqldump.NewZipWriter(&buf)
+	archive.Store = func(name string) bool { return strings.HasPrefix(name, "data/") }
+	dumpZip(t, db, archive)
+	assert.NoError(t, mock.ExpectationsWereMet(), "there were unfulfilled expections")
+
+	entries, files := readZip(t, buf.Bytes())
+	var names []string
+	for _, f := range entries {
+		names = append(names, f.Name)
+		if f.Name == "data/Test_Table.sql" {
+			assert.Equal(t, zip.Store, f.Method)
+		} else {
+			assert.Equal(t, zip.Deflate, f.Method)
+		}
+	}
+	assert.Equal(t, []string{"schema.sql", "data/Test_Table.sql", "manifest.json", "SHA256SUMS"}, names)
+	assert.Contains(t, files["schema.sql"], "CREATE TABLE 'Test_Table'")
+	assert.Contains(t, files["data/Test_Table.sql"], "INSERT INTO `Test_Table` (`id`, `email`, `name`) VALUES (1,NULL,'Test Name 1'),(2,'test2@test.de','Test Name 2');")
+	assert.Contains(t, files["SHA256SUMS"], "  data/Test_Table.sql\n")
+}
+
+func TestHandlerZip(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "an error was not expected when opening a stub database connection")
+	defer db.Close()
+
+	mockDump(mock)
+
+	rec := httptest.NewRecorder()
+	h := &mysqldump.Handler{DB: db}
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?format=zip", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `.zip"`)
+	_, files := readZip(t, rec.Body.Bytes())
+	assert.Contains(t, files["data/Test_Table.sql"], "INSERT INTO `Test_Table`")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dump?format=zip.gz", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
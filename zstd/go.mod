@@ -0,0 +1,18 @@
+module github.com/jamf/go-mysqldump/zstd
+
+go 1.22
+
+replace github.com/jamf/go-mysqldump => ../
+
+require (
+	github.com/jamf/go-mysqldump v0.0.0-00010101000000-000000000000
+	github.com/klauspost/compress v1.17.11
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
@@ -0,0 +1,57 @@
+// Package zstd registers the zst codec, compressing dumps with Zstandard. It
+// gives a better ratio than gzip at a much higher throughput, which adds up for
+// multi-GB backups. Importing the package registers the codec at the default
+// level, for the formats of the Handler like sql.zst and tar.zst:
+//
+//	import _ "github.com/jamf/go-mysqldump/zstd"
+//
+// Set it as the Codec of Compress or Files to compress a dump with it, at
+// another level if need be:
+//
+//	data.Codec = zstd.NewCodec(7)
+//	data.Compress = true
+//
+// It lives in a module of its own so that the dependency on
+// github.com/klauspost/compress is only taken by the programs using it. Other
+// algorithms plug in the same way through mysqldump.RegisterCodec, like lz4 in
+// the lz4 module.
+package zstd
+
+import (
+	"io"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	mysqldump.RegisterCodec(NewCodec(0))
+}
+
+// NewCodec returns the zst codec compressing at a level of the zstd command,
+// from 1 to 22, or its default level of 3 if 0. The levels are approximated
+// by the four speeds of the encoder.
+func NewCodec(level int) mysqldump.Codec {
+	if level == 0 {
+		level = 3
+	}
+	return codec{level: zstd.EncoderLevelFromZstd(level)}
+}
+
+type codec struct {
+	level zstd.EncoderLevel
+}
+
+func (codec) Name() string { return "zst" }
+
+func (c codec) Wrap(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level))
+}
+
+func (codec) Unwrap(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
@@ -0,0 +1,40 @@
+package zstd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/jamf/go-mysqldump"
+	"github.com/jamf/go-mysqldump/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistered(t *testing.T) {
+	codec, err := mysqldump.LookupCodec("zst")
+	assert.NoError(t, err)
+	assert.Equal(t, "zst", codec.Name())
+	assert.Contains(t, mysqldump.Codecs(), "zst")
+}
+
+func TestRoundTrip(t *testing.T) {
+	sql := strings.Repeat("INSERT INTO `test` VALUES (1,'test@test.de');\n", 1000)
+	for _, level := range []int{0, 1, 19} {
+		codec := zstd.NewCodec(level)
+		var buf bytes.Buffer
+		w, err := codec.Wrap(&buf)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(sql))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.Less(t, buf.Len(), len(sql)/10, "level %d", level)
+
+		r, err := codec.Unwrap(&buf)
+		assert.NoError(t, err)
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+		assert.Equal(t, sql, string(b))
+	}
+}